@@ -35,7 +35,7 @@ func main() {
 	logger.Info(fmt.Sprintf("Starting MangaHub API Server on %s:%d", cfg.HTTP.Host, cfg.HTTP.Port))
 
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.NewWithConfig(cfg.Database)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize database: %v", err))
 		os.Exit(1)
@@ -73,7 +73,48 @@ func main() {
 
 	// Initialize API handler and register routes
 	handler := api.NewHandler(db, logger)
+	handler.SetAuth(cfg)
+	handler.SetRetention(cfg)
+	handler.SetStorage(cfg)
+	if err := handler.SetCatalog(cfg); err != nil {
+		logger.Error("failed to set up catalog providers: %v", err)
+	}
 	handler.RegisterRoutes(engine)
+	handler.WarnWithoutAdmin()
+
+	// Purge trashed library entries and manga once they outlive the retention period
+	if cfg.Database.TrashRetentionDays > 0 {
+		retention := time.Duration(cfg.Database.TrashRetentionDays) * 24 * time.Hour
+		go handler.RunTrashPurge(retention, time.Hour)
+	}
+
+	// Prune old chat messages and notifications and rotate the server log
+	pruneInterval := time.Duration(cfg.Retention.PruneInterval) * time.Minute
+	if pruneInterval <= 0 {
+		pruneInterval = time.Hour
+	}
+	go handler.RunPruning(pruneInterval)
+
+	// Keep the manga rankings cache current
+	rankingInterval := time.Duration(cfg.Rankings.RefreshInterval) * time.Minute
+	if rankingInterval <= 0 {
+		rankingInterval = 10 * time.Minute
+	}
+	go handler.RunRankingRefresh(rankingInterval)
+
+	// Recompute the similar manga behind recommendations
+	recommendationInterval := time.Duration(cfg.Recommendations.RefreshInterval) * time.Minute
+	if recommendationInterval <= 0 {
+		recommendationInterval = time.Hour
+	}
+	go handler.RunRecommendationRefresh(recommendationInterval)
+
+	// Poll the metadata providers for new chapters of subscribed manga
+	watchInterval := time.Duration(cfg.Catalog.WatchInterval) * time.Minute
+	if watchInterval <= 0 {
+		watchInterval = 30 * time.Minute
+	}
+	go handler.RunReleaseWatcher(watchInterval)
 
 	// Health check endpoint with server configuration
 	engine.GET("/health", func(c *gin.Context) {
@@ -8,10 +8,14 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"mangahub/internal/auth"
 	"mangahub/internal/grpc/service"
+	"mangahub/internal/user"
 	"mangahub/pkg/config"
 	"mangahub/pkg/database"
+	"mangahub/pkg/store"
 	"mangahub/pkg/utils"
 	pb "mangahub/proto"
 
@@ -54,7 +58,7 @@ func main() {
 	logger.Info(fmt.Sprintf("Starting MangaHub gRPC Server on %s:%d", cfg.GRPC.Host, cfg.GRPC.Port))
 
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.NewWithConfig(cfg.Database)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize database: %v", err))
 		os.Exit(1)
@@ -73,9 +77,16 @@ func main() {
 		os.Exit(1)
 	}
 
-	// Create gRPC server with logging interceptor
+	// Create gRPC server with logging and auth interceptors. Tokens are
+	// the API server's, signed with the same secret, and the sessions they
+	// belong to and personal access tokens are checked in the shared
+	// database.
+	stores := store.NewSQLiteStores(db)
+	tokens := auth.NewAccessTokenService(stores.AccessTokens, stores.Users)
+	sessions := auth.NewSessionService(auth.NewAuthService(cfg.App.JWTSecret), stores.Sessions, tokens, stores.Users)
+	authInterceptor := service.NewAuthInterceptor(sessions, user.NewService(db))
 	grpcServer := grpc.NewServer(
-		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 			resp, err := handler(ctx, req)
 			if err != nil {
 				logger.Error("Method: %s, Error: %v", info.FullMethod, err)
@@ -84,13 +95,23 @@ func main() {
 				logger.Info("Method: %s, Response: %s", info.FullMethod, string(respJSON))
 			}
 			return resp, err
-		}),
+		}, authInterceptor),
 	)
 
 	// Register services
 	mangaService := service.NewMangaService(db, logger)
 	pb.RegisterMangaServiceServer(grpcServer, mangaService)
 
+	// Keep the manga rankings cache current
+	rankingInterval := time.Duration(cfg.Rankings.RefreshInterval) * time.Minute
+	if rankingInterval <= 0 {
+		rankingInterval = 10 * time.Minute
+	}
+	go mangaService.RunRankingRefresh(rankingInterval)
+
+	// The similar manga behind recommendations are recomputed by the API
+	// server; this server only reads them from the shared database
+
 	// Start server in goroutine
 	go func() {
 		logger.Info(fmt.Sprintf("gRPC Server listening on %s", lis.Addr()))
@@ -33,7 +33,7 @@ func main() {
 	logger.Info("Starting MangaHub TCP Server on %s:%d", cfg.TCP.Host, cfg.TCP.Port)
 
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.NewWithConfig(cfg.Database)
 	if err != nil {
 		logger.Error("failed to initialize database: %v", err)
 		os.Exit(1)
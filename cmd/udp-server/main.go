@@ -30,7 +30,7 @@ func main() {
 	logger.Info(fmt.Sprintf("Starting MangaHub UDP Server on %s:%d", cfg.UDP.Host, cfg.UDP.Port))
 
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.NewWithConfig(cfg.Database)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize database: %v", err))
 		os.Exit(1)
@@ -9,9 +9,11 @@ import (
 	"syscall"
 	"time"
 
+	"mangahub/internal/auth"
 	"mangahub/internal/websocket"
 	"mangahub/pkg/config"
 	"mangahub/pkg/database"
+	"mangahub/pkg/store"
 	"mangahub/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -35,7 +37,7 @@ func main() {
 	logger.Info(fmt.Sprintf("Starting MangaHub WebSocket Server on %s:%d", cfg.WebSocket.Host, cfg.WebSocket.Port))
 
 	// Initialize database
-	db, err := database.New(cfg.Database.Path)
+	db, err := database.NewWithConfig(cfg.Database)
 	if err != nil {
 		logger.Error(fmt.Sprintf("failed to initialize database: %v", err))
 		os.Exit(1)
@@ -51,6 +53,13 @@ func main() {
 	hub := websocket.NewHub()
 	go hub.Run()
 
+	// Tokens are the API server's, signed with the same secret, and the
+	// sessions and personal access tokens behind them are checked in the
+	// shared database
+	stores := store.NewSQLiteStores(db)
+	tokens := auth.NewAccessTokenService(stores.AccessTokens, stores.Users)
+	sessions := auth.NewSessionService(auth.NewAuthService(cfg.App.JWTSecret), stores.Sessions, tokens, stores.Users)
+
 	// Setup Gin
 	gin.SetMode(gin.ReleaseMode)
 	engine := gin.New()
@@ -60,7 +69,7 @@ func main() {
 	// WebSocket endpoint
 	engine.GET("/ws/:room", func(c *gin.Context) {
 		room := c.Param("room")
-		websocket.HandleConnection(c, hub, room)
+		websocket.HandleConnection(c, hub, room, sessions)
 	})
 
 	// Health check
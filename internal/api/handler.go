@@ -1,15 +1,27 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"mangahub/internal/auth"
+	"mangahub/internal/catalog"
+	"mangahub/internal/libimport"
 	"mangahub/internal/manga"
+	"mangahub/internal/recommend"
 	"mangahub/internal/user"
+	"mangahub/pkg/config"
+	"mangahub/pkg/covers"
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/retention"
+	"mangahub/pkg/store"
 	"mangahub/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -19,20 +31,49 @@ import (
 type Handler struct {
 	db             *database.Database
 	authService    *auth.AuthService
+	sessions       *auth.SessionService
+	tokens         *auth.AccessTokenService
 	userService    *user.Service
 	libraryService *user.LibraryService
 	mangaService   *manga.Service
+	recommender    *recommend.Service
+	catalog        *catalog.Service
+	watcher        *catalog.Watcher
+	importer       *libimport.Importer
+	covers         *covers.Store
+	stores         *store.Stores
 	logger         *utils.Logger
+
+	// retention and trashRetentionDays are reported by GetDatabaseStats
+	retention          config.RetentionConfig
+	trashRetentionDays int
 }
 
-// NewHandler creates a new API handler
+// NewHandler creates a new API handler backed by the SQLite database
 func NewHandler(db *database.Database, logger *utils.Logger) *Handler {
+	return NewHandlerWithStores(db, store.NewSQLiteStores(db), logger)
+}
+
+// NewHandlerWithStores creates a new API handler on top of the given stores.
+// db may be nil, in which case the database management endpoints respond
+// with 503 Service Unavailable.
+func NewHandlerWithStores(db *database.Database, stores *store.Stores, logger *utils.Logger) *Handler {
+	catalogService := catalog.NewService(stores.Manga, stores.Titles, stores.ExternalIDs, nil, nil)
+	authService := auth.NewAuthService("your-secret-key")
+	tokens := auth.NewAccessTokenService(stores.AccessTokens, stores.Users)
 	return &Handler{
 		db:             db,
-		authService:    auth.NewAuthService("your-secret-key"),
-		userService:    user.NewService(db),
-		libraryService: user.NewLibraryService(db),
-		mangaService:   manga.NewService(db),
+		authService:    authService,
+		sessions:       auth.NewSessionService(authService, stores.Sessions, tokens, stores.Users),
+		tokens:         tokens,
+		userService:    user.NewServiceWithStore(stores.Users),
+		libraryService: user.NewLibraryServiceWithStore(stores.Library),
+		mangaService:   manga.NewServiceWithStores(stores.Manga, stores.Chapters, stores.Genres, stores.Titles),
+		recommender:    recommend.NewServiceWithStores(stores.Similarity, stores.Manga, stores.Library),
+		catalog:        catalogService,
+		importer:       libimport.NewImporter(stores.Manga, stores.ExternalIDs, stores.Library, catalogService),
+		covers:         covers.NewStore(config.DefaultConfig().Storage.DataDir, 0),
+		stores:         stores,
 		logger:         logger,
 	}
 }
@@ -44,53 +85,90 @@ func (h *Handler) RegisterRoutes(engine *gin.Engine) {
 	{
 		auth.POST("/register", h.Register)
 		auth.POST("/login", h.Login)
+		auth.POST("/refresh", h.Refresh)
+		auth.POST("/logout", h.Logout)
 	}
 
 	// Public manga routes
 	mangaGroup := engine.Group("/manga")
 	{
 		mangaGroup.GET("", h.ListManga)
+		mangaGroup.GET("/rankings", h.GetRankings)
+		mangaGroup.GET("/trending", h.GetRankings)
+		mangaGroup.GET("/autocomplete", h.AutocompleteManga)
 		mangaGroup.GET("/:id", h.GetManga)
+		mangaGroup.GET("/:id/chapters", h.ListChapters)
+		mangaGroup.GET("/:id/titles", h.ListTitles)
+		mangaGroup.GET("/:id/similar", h.GetSimilarManga)
+		mangaGroup.GET("/:id/cover", h.GetCover)
 		mangaGroup.POST("/search", h.SearchManga)
 	}
+	engine.GET("/genres", h.ListGenres)
 
-	// Protected routes
+	// Protected routes. Personal access tokens reach the routes their
+	// scopes cover; the account routes take a login.
 	protected := engine.Group("")
 	protected.Use(h.AuthMiddleware())
 	{
+		read := h.RequireScope(models.ScopeLibraryRead)
+		write := h.RequireScope(models.ScopeLibraryWrite)
+		login := h.RequireLogin()
+
 		// User routes
 		user := protected.Group("/users")
 		{
 			user.GET("/profile", h.GetProfile)
-			user.PUT("/profile", h.UpdateProfile)
+			user.PUT("/profile", login, h.UpdateProfile)
+			user.PUT("/password", login, h.ChangePassword)
+			user.GET("/sessions", login, h.ListSessions)
+			user.DELETE("/sessions/:id", login, h.RevokeSession)
+			user.POST("/tokens", login, h.CreateAccessToken)
+			user.GET("/tokens", login, h.ListAccessTokens)
+			user.DELETE("/tokens/:id", login, h.RevokeAccessToken)
+			user.GET("/progress/events", read, h.GetProgressEvents)
+			user.GET("/recommendations", read, h.GetRecommendations)
 		}
 
 		// Library routes
 		library := protected.Group("/users/library")
 		{
-			library.GET("", h.GetLibrary)
-			library.POST("", h.AddToLibrary)
-			library.DELETE("/:mangaId", h.RemoveFromLibrary)
-			library.PUT("/:mangaId/progress", h.UpdateProgress)
+			library.GET("", read, h.GetLibrary)
+			library.POST("", write, h.AddToLibrary)
+			library.POST("/import", write, h.ImportLibrary)
+			library.GET("/trash", read, h.GetLibraryTrash)
+			library.POST("/trash/:mangaId/restore", write, h.RestoreLibraryEntry)
+			library.DELETE("/:mangaId", write, h.RemoveFromLibrary)
+			library.PUT("/:mangaId/progress", write, h.UpdateProgress)
 		}
 
 		// Server management routes
 		server := protected.Group("/server")
+		server.Use(h.RequirePermission(models.PermManageServer))
 		{
 			server.GET("/logs", h.GetServerLogs)
 			server.GET("/database/check", h.GetDatabaseCheck)
 			server.POST("/database/optimize", h.OptimizeDatabase)
 			server.GET("/database/stats", h.GetDatabaseStats)
 			server.POST("/database/repair", h.RepairDatabase)
+			server.POST("/database/fix", h.FixDatabaseSchema)
 		}
 
-		// Admin routes (placeholder)
+		// Catalog admin routes
 		admin := protected.Group("/admin")
 		admin.Use(h.AdminMiddleware())
 		{
 			admin.POST("/manga", h.CreateManga)
 			admin.PUT("/manga/:id", h.UpdateManga)
 			admin.DELETE("/manga/:id", h.DeleteManga)
+			admin.GET("/manga/trash", h.GetMangaTrash)
+			admin.POST("/manga/:id/restore", h.RestoreManga)
+			admin.POST("/manga/:id/chapters", h.CreateChapter)
+			admin.PUT("/manga/:id/chapters/:chapterId", h.UpdateChapter)
+			admin.DELETE("/manga/:id/chapters/:chapterId", h.DeleteChapter)
+			admin.POST("/manga/:id/titles", h.AddTitle)
+			admin.PUT("/manga/:id/titles", h.ReplaceTitles)
+			admin.DELETE("/manga/:id/titles/:titleId", h.DeleteTitle)
+			admin.POST("/manga/:id/cover", h.UploadCover)
 		}
 	}
 }
@@ -168,19 +246,199 @@ func (h *Handler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate token
-	token, expiresAt, err := h.authService.GenerateToken(user.ID, user.Username, user.Email)
+	// Start a session
+	device := req.Device
+	if device == "" {
+		device = c.Request.UserAgent()
+	}
+	resp, err := h.sessions.Start(user, device, c.ClientIP())
 	if err != nil {
+		h.logger.Error("failed to start session: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate token"})
 		return
 	}
 
-	c.JSON(http.StatusOK, models.LoginResponse{
-		UserID:    user.ID,
-		Username:  user.Username,
-		Token:     token,
-		ExpiresAt: expiresAt,
-	})
+	c.JSON(http.StatusOK, resp)
+}
+
+// Refresh trades a refresh token for a new access token and refresh token.
+// A refresh token that was already traded in revokes its session.
+func (h *Handler) Refresh(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.BindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	resp, err := h.sessions.Refresh(req.RefreshToken, c.ClientIP())
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrRefreshReused):
+			h.logger.Warn("Refresh token reused from %s; session revoked", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, auth.ErrSessionEnded):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to refresh session: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to refresh session"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Logout revokes the session of a refresh token, so neither it nor the
+// session's access tokens work any more
+func (h *Handler) Logout(c *gin.Context) {
+	var req models.RefreshRequest
+	if err := c.BindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	if err := h.sessions.Logout(req.RefreshToken); err != nil {
+		if errors.Is(err, auth.ErrSessionEnded) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.Error("failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logged out"})
+}
+
+// ListSessions lists the devices the user is logged in on, marking the one
+// making the request as current
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.sessions.List(c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		h.logger.Error("failed to list sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions, "total": len(sessions)})
+}
+
+// RevokeSession logs one of the user's devices out
+func (h *Handler) RevokeSession(c *gin.Context) {
+	if err := h.sessions.Revoke(c.GetString("user_id"), c.Param("id")); err != nil {
+		if errors.Is(err, store.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session not found"})
+			return
+		}
+		h.logger.Error("failed to revoke session: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "session revoked", "current": c.Param("id") == c.GetString("session_id")})
+}
+
+// CreateAccessToken issues a personal access token for scripts and bots.
+// The token is only shown in this response.
+func (h *Handler) CreateAccessToken(c *gin.Context) {
+	var req models.CreateAccessTokenRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	user, err := h.userService.GetByID(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	token, err := h.tokens.Create(user, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, auth.ErrAdminScope):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, auth.ErrInvalidScope), errors.Is(err, auth.ErrTokenName), errors.Is(err, auth.ErrTokenExpiry):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to create access token: %v", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create access token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// ListAccessTokens lists the user's live personal access tokens
+func (h *Handler) ListAccessTokens(c *gin.Context) {
+	tokens, err := h.tokens.List(c.GetString("user_id"))
+	if err != nil {
+		h.logger.Error("failed to list access tokens: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list access tokens"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": tokens, "total": len(tokens)})
+}
+
+// RevokeAccessToken revokes one of the user's personal access tokens
+func (h *Handler) RevokeAccessToken(c *gin.Context) {
+	if err := h.tokens.Revoke(c.GetString("user_id"), c.Param("id")); err != nil {
+		if errors.Is(err, store.ErrAccessTokenNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "access token not found"})
+			return
+		}
+		h.logger.Error("failed to revoke access token: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke access token"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "access token revoked"})
+}
+
+// ChangePassword changes the user's password after checking the current
+// one, and logs every other device out
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req models.ChangePasswordRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	userID := c.GetString("user_id")
+	user, err := h.userService.GetByID(userID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if err := h.authService.VerifyPassword(user.PasswordHash, req.CurrentPassword); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "current password is incorrect"})
+		return
+	}
+	if err := utils.ValidatePassword(req.NewPassword); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hashedPassword, err := h.authService.HashPassword(req.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+	if err := h.userService.UpdatePassword(userID, hashedPassword); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update password"})
+		return
+	}
+
+	revoked, err := h.sessions.RevokeOthers(userID, c.GetString("session_id"))
+	if err != nil {
+		h.logger.Error("failed to revoke sessions: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "password changed, but failed to log out other sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "password changed successfully", "sessions_revoked": revoked})
 }
 
 // GetProfile retrieves user profile
@@ -200,7 +458,9 @@ func (h *Handler) GetProfile(c *gin.Context) {
 	c.JSON(http.StatusOK, user)
 }
 
-// UpdateProfile updates user profile
+// UpdateProfile updates the username, email and preferred title language
+// of the user. Fields left out of the request keep their value; an empty
+// title_language clears the preference.
 func (h *Handler) UpdateProfile(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
@@ -208,14 +468,32 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	var req models.User
+	var req struct {
+		Username      *string `json:"username"`
+		Email         *string `json:"email"`
+		TitleLanguage *string `json:"title_language"`
+	}
 	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
-	req.ID = userID.(string)
-	if err := h.userService.Update(&req); err != nil {
+	user, err := h.userService.GetByID(userID.(string))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+	if req.Username != nil && *req.Username != "" {
+		user.Username = *req.Username
+	}
+	if req.Email != nil && *req.Email != "" {
+		user.Email = *req.Email
+	}
+	if req.TitleLanguage != nil {
+		user.TitleLanguage = models.NormalizeLanguage(*req.TitleLanguage)
+	}
+
+	if err := h.userService.Update(user); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update profile"})
 		return
 	}
@@ -240,6 +518,9 @@ func (h *Handler) ListManga(c *gin.Context) {
 	}
 
 	mangaList, err := h.mangaService.List(limit, offset)
+	if err == nil {
+		err = h.mangaService.Localize(mangaList, h.titleLanguage(c))
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list manga"})
 		return
@@ -254,13 +535,44 @@ func (h *Handler) GetManga(c *gin.Context) {
 
 	manga, err := h.mangaService.GetByID(id)
 	if err != nil {
+		if h.redirectMerged(c) {
+			return
+		}
 		c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
 		return
 	}
+	manga.Localize(manga.Titles, h.titleLanguage(c))
 
 	c.JSON(http.StatusOK, manga)
 }
 
+// redirectMerged answers a request for a manga that was merged into another
+// with a 301 to the same path under the kept manga's ID, and reports
+// whether it did
+func (h *Handler) redirectMerged(c *gin.Context) bool {
+	id := c.Param("id")
+	to, err := h.stores.Merges.Redirect(id)
+	if err != nil {
+		return false
+	}
+	location := strings.Replace(c.Request.URL.Path, "/manga/"+id, "/manga/"+to, 1)
+	if c.Request.URL.RawQuery != "" {
+		location += "?" + c.Request.URL.RawQuery
+	}
+	c.Redirect(http.StatusMovedPermanently, location)
+	return true
+}
+
+// resolveMangaID returns the manga a merged-away ID redirects to, and
+// any other ID as it is, so library writes naming a dropped manga land on
+// the one that was kept
+func (h *Handler) resolveMangaID(id string) string {
+	if to, err := h.stores.Merges.Redirect(id); err == nil {
+		return to
+	}
+	return id
+}
+
 // SearchManga searches for manga
 func (h *Handler) SearchManga(c *gin.Context) {
 	var filter models.MangaFilter
@@ -269,171 +581,1082 @@ func (h *Handler) SearchManga(c *gin.Context) {
 		return
 	}
 
-	results, err := h.mangaService.Search(&filter)
+	results, err := h.mangaService.Search(&filter)
+	if err != nil {
+		if errors.Is(err, manga.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search manga"})
+		return
+	}
+	if err := h.mangaService.Localize(results.Manga, h.titleLanguage(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search manga"})
+		return
+	}
+
+	c.JSON(http.StatusOK, results)
+}
+
+// AutocompleteManga suggests titles starting with the q query parameter,
+// at most limit (default 10, at most 20) of them
+func (h *Handler) AutocompleteManga(c *gin.Context) {
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.mangaService.Autocomplete(c.Query("q"), limit)
+	if err != nil {
+		if errors.Is(err, manga.ErrInvalidFilter) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to autocomplete manga"})
+		return
+	}
+	if suggestions == nil {
+		suggestions = []models.Suggestion{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suggestions": suggestions, "total": len(suggestions)})
+}
+
+// GetRankings returns the cached manga rankings. Query parameters: window
+// (week, month or all; default week), genre and limit (default 10, at most
+// 100). /manga/trending serves the same rankings.
+func (h *Handler) GetRankings(c *gin.Context) {
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
+
+	rankings, err := h.mangaService.Rankings(c.Query("window"), c.Query("genre"), limit)
+	if err != nil {
+		if errors.Is(err, manga.ErrInvalidRanking) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rank manga"})
+		return
+	}
+	localized := make([]*models.Manga, len(rankings.Rankings))
+	for i := range rankings.Rankings {
+		localized[i] = &rankings.Rankings[i].Manga
+	}
+	if err := h.mangaService.LocalizeAll(localized, h.titleLanguage(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rank manga"})
+		return
+	}
+
+	c.JSON(http.StatusOK, rankings)
+}
+
+// GetSimilarManga returns the manga most similar to a manga by genre and
+// co-reading, at most limit (default 10, at most 50) of them
+func (h *Handler) GetSimilarManga(c *gin.Context) {
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
+
+	similar, err := h.recommender.Similar(c.Param("id"), limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrMangaNotFound):
+			if h.redirectMerged(c) {
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+		case errors.Is(err, recommend.ErrInvalidRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find similar manga"})
+		}
+		return
+	}
+	localized := make([]*models.Manga, len(similar.Similar))
+	for i := range similar.Similar {
+		localized[i] = &similar.Similar[i].Manga
+	}
+	if err := h.mangaService.LocalizeAll(localized, h.titleLanguage(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find similar manga"})
+		return
+	}
+
+	c.JSON(http.StatusOK, similar)
+}
+
+// CreateManga creates a new manga (admin)
+func (h *Handler) CreateManga(c *gin.Context) {
+	var manga models.Manga
+	if err := c.BindJSON(&manga); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	if err := h.mangaService.Create(&manga); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create manga"})
+		return
+	}
+	if err := h.catalog.RecordEdit(&manga); err != nil {
+		h.logger.Error("failed to record catalog edit of %s: %v", manga.ID, err)
+	}
+
+	c.JSON(http.StatusCreated, manga)
+}
+
+// UpdateManga updates a manga (admin)
+func (h *Handler) UpdateManga(c *gin.Context) {
+	id := c.Param("id")
+
+	var manga models.Manga
+	if err := c.BindJSON(&manga); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	manga.ID = id
+	if err := h.mangaService.Update(&manga); err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update manga"})
+		return
+	}
+	if err := h.catalog.RecordEdit(&manga); err != nil {
+		h.logger.Error("failed to record catalog edit of %s: %v", manga.ID, err)
+	}
+
+	c.JSON(http.StatusOK, manga)
+}
+
+// DeleteManga moves a manga to the trash (admin)
+func (h *Handler) DeleteManga(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.mangaService.Delete(id); err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete manga"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "manga moved to trash"})
+}
+
+// GetMangaTrash lists trashed manga (admin)
+func (h *Handler) GetMangaTrash(c *gin.Context) {
+	limit, offset := pageParams(c)
+
+	mangaList, err := h.mangaService.Trash(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get manga trash"})
+		return
+	}
+	if mangaList == nil {
+		mangaList = []models.Manga{}
+	}
+
+	c.JSON(http.StatusOK, mangaList)
+}
+
+// RestoreManga moves a manga out of the trash (admin)
+func (h *Handler) RestoreManga(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.mangaService.Restore(id); err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore manga"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "manga restored"})
+}
+
+// ListGenres lists the genre and tag taxonomy with manga counts. The kind
+// query parameter limits it to demographic, genre or tag entries.
+func (h *Handler) ListGenres(c *gin.Context) {
+	kind := c.Query("kind")
+	switch kind {
+	case "", models.GenreKindDemographic, models.GenreKindGenre, models.GenreKindTag:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be demographic, genre or tag"})
+		return
+	}
+
+	genres, err := h.mangaService.Genres(kind)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list genres"})
+		return
+	}
+	if genres == nil {
+		genres = []models.Genre{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"genres": genres, "total": len(genres)})
+}
+
+// ListChapters lists the chapters of a manga. Query parameters: lang,
+// after (only chapters numbered above it), order (asc or desc), limit and
+// offset.
+func (h *Handler) ListChapters(c *gin.Context) {
+	filter := models.ChapterFilter{
+		MangaID:  c.Param("id"),
+		Language: c.Query("lang"),
+		Order:    c.Query("order"),
+	}
+	filter.Limit, filter.Offset = pageParams(c)
+	if c.Query("limit") == "" {
+		filter.Limit = 100
+	}
+	if after := c.Query("after"); after != "" {
+		v, err := strconv.ParseFloat(after, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid after parameter"})
+			return
+		}
+		filter.After = v
+	}
+
+	chapters, err := h.mangaService.ListChapters(filter)
+	if err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) {
+			if h.redirectMerged(c) {
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list chapters"})
+		return
+	}
+	if chapters == nil {
+		chapters = []models.Chapter{}
+	}
+
+	c.JSON(http.StatusOK, models.ChapterList{
+		MangaID:  filter.MangaID,
+		Chapters: chapters,
+		Limit:    filter.Limit,
+		Offset:   filter.Offset,
+	})
+}
+
+// ListTitles lists the alternate and localized titles of a manga
+func (h *Handler) ListTitles(c *gin.Context) {
+	titles, err := h.mangaService.Titles(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) && h.redirectMerged(c) {
+			return
+		}
+		h.titleError(c, err, "failed to list titles")
+		return
+	}
+	if titles == nil {
+		titles = []models.MangaTitle{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"titles": titles, "total": len(titles)})
+}
+
+// AddTitle adds an alternate title to a manga (admin)
+func (h *Handler) AddTitle(c *gin.Context) {
+	var title models.MangaTitle
+	if err := c.BindJSON(&title); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	title.MangaID = c.Param("id")
+	if err := h.mangaService.AddTitle(&title); err != nil {
+		h.titleError(c, err, "failed to add title")
+		return
+	}
+
+	c.JSON(http.StatusCreated, title)
+}
+
+// ReplaceTitles replaces every alternate title of a manga (admin). The body
+// is {"titles": [...]}; an empty list removes them all.
+func (h *Handler) ReplaceTitles(c *gin.Context) {
+	var req struct {
+		Titles []models.MangaTitle `json:"titles"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	id := c.Param("id")
+	if err := h.mangaService.ReplaceTitles(id, req.Titles); err != nil {
+		h.titleError(c, err, "failed to replace titles")
+		return
+	}
+	h.ListTitles(c)
+}
+
+// DeleteTitle removes an alternate title of a manga (admin)
+func (h *Handler) DeleteTitle(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("titleId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid title id"})
+		return
+	}
+
+	if err := h.mangaService.DeleteTitle(c.Param("id"), id); err != nil {
+		h.titleError(c, err, "failed to delete title")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "title deleted"})
+}
+
+// titleError maps title errors to responses
+func (h *Handler) titleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrMangaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+	case errors.Is(err, store.ErrTitleNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "title not found"})
+	case errors.Is(err, store.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "title already exists for this language"})
+	case errors.Is(err, manga.ErrInvalidTitle):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// GetCover serves a manga's stored cover. The size query parameter picks
+// original (default), medium or thumb.
+func (h *Handler) GetCover(c *gin.Context) {
+	size := c.DefaultQuery("size", covers.SizeOriginal)
+
+	f, info, err := h.covers.Open(c.Param("id"), size)
+	if err != nil {
+		switch {
+		case errors.Is(err, covers.ErrInvalidSize):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, covers.ErrNotFound):
+			if h.redirectMerged(c) {
+				return
+			}
+			c.JSON(http.StatusNotFound, gin.H{"error": "cover not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get cover"})
+		}
+		return
+	}
+	defer f.Close()
+
+	// Covers change only on upload; ServeContent answers conditional
+	// requests against the ETag and modification time
+	c.Header("Content-Type", "image/jpeg")
+	c.Header("Cache-Control", "public, max-age=86400")
+	c.Header("ETag", fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()))
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), f)
+}
+
+// UploadCover stores a new cover for a manga, from the cover field of a
+// multipart form or else the raw request body, and points the manga's
+// cover_url at it (admin)
+func (h *Handler) UploadCover(c *gin.Context) {
+	m, err := h.mangaService.GetByID(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get manga"})
+		return
+	}
+
+	body := c.Request.Body
+	if file, err := c.FormFile("cover"); err == nil {
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cover upload"})
+			return
+		}
+		defer f.Close()
+		body = f
+	}
+
+	if err := h.covers.Save(m.ID, body); err != nil {
+		switch {
+		case errors.Is(err, covers.ErrInvalidImage):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cover must be a JPEG, PNG or GIF image"})
+		case errors.Is(err, covers.ErrTooLarge):
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error()})
+		default:
+			h.logger.Error("failed to store cover of %s: %v", m.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store cover"})
+		}
+		return
+	}
+
+	m.CoverURL = covers.URL(m.ID)
+	if err := h.mangaService.Update(m); err != nil {
+		if errors.Is(err, store.ErrMangaNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update manga"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":       "cover uploaded",
+		"cover_url":     m.CoverURL,
+		"thumbnail_url": m.CoverURL + "?size=" + covers.SizeThumb,
+	})
+}
+
+// titleLanguage returns the language manga titles are shown in: the
+// title_lang query parameter, else the preference of the user whose bearer
+// token came with the request. Public routes do not require a token, so a
+// missing or invalid one just means no preference.
+func (h *Handler) titleLanguage(c *gin.Context) string {
+	if lang := c.Query("title_lang"); lang != "" {
+		return models.NormalizeLanguage(lang)
+	}
+	token := c.GetHeader("Authorization")
+	if len(token) <= 7 {
+		return ""
+	}
+	claims, err := h.sessions.Authenticate(token[7:])
+	if err != nil {
+		return ""
+	}
+	user, err := h.userService.GetByID(claims.UserID)
+	if err != nil {
+		return ""
+	}
+	return user.TitleLanguage
+}
+
+// CreateChapter adds a chapter to a manga (admin)
+func (h *Handler) CreateChapter(c *gin.Context) {
+	var chapter models.Chapter
+	if err := c.BindJSON(&chapter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	chapter.MangaID = c.Param("id")
+	if err := h.mangaService.CreateChapter(&chapter); err != nil {
+		h.chapterError(c, err, "failed to create chapter")
+		return
+	}
+
+	c.JSON(http.StatusCreated, chapter)
+}
+
+// UpdateChapter updates a chapter (admin)
+func (h *Handler) UpdateChapter(c *gin.Context) {
+	existing, ok := h.lookupChapter(c)
+	if !ok {
+		return
+	}
+
+	var chapter models.Chapter
+	if err := c.BindJSON(&chapter); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	chapter.ID = existing.ID
+	chapter.MangaID = existing.MangaID
+	chapter.CreatedAt = existing.CreatedAt
+	if err := h.mangaService.UpdateChapter(&chapter); err != nil {
+		h.chapterError(c, err, "failed to update chapter")
+		return
+	}
+
+	c.JSON(http.StatusOK, chapter)
+}
+
+// DeleteChapter deletes a chapter (admin)
+func (h *Handler) DeleteChapter(c *gin.Context) {
+	chapter, ok := h.lookupChapter(c)
+	if !ok {
+		return
+	}
+
+	if err := h.mangaService.DeleteChapter(chapter.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete chapter"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "chapter deleted"})
+}
+
+// lookupChapter loads the :chapterId chapter of the :id manga, writing a
+// 404 response when it does not exist
+func (h *Handler) lookupChapter(c *gin.Context) (*models.Chapter, bool) {
+	id, err := strconv.ParseInt(c.Param("chapterId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid chapter id"})
+		return nil, false
+	}
+
+	chapter, err := h.mangaService.GetChapter(id)
+	if err != nil || chapter.MangaID != c.Param("id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "chapter not found"})
+		return nil, false
+	}
+	return chapter, true
+}
+
+// chapterError maps chapter write errors to responses
+func (h *Handler) chapterError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, store.ErrMangaNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "manga not found"})
+	case errors.Is(err, store.ErrAlreadyExists):
+		c.JSON(http.StatusConflict, gin.H{"error": "chapter number already exists for this language"})
+	case errors.Is(err, manga.ErrInvalidChapter):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// GetLibrary retrieves user's library
+func (h *Handler) GetLibrary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit := 20
+	offset := 0
+
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil {
+			limit = v
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if v, err := strconv.Atoi(o); err == nil {
+			offset = v
+		}
+	}
+
+	var progressList []models.Progress
+	var err error
+
+	if status := c.Query("status"); status != "" {
+		progressList, err = h.libraryService.GetLibraryByStatus(userID.(string), status, limit, offset)
+	} else {
+		progressList, err = h.libraryService.GetLibrary(userID.(string), limit, offset)
+	}
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get library"})
+		return
+	}
+
+	c.JSON(http.StatusOK, progressList)
+}
+
+// AddToLibrary adds manga to user's library
+func (h *Handler) AddToLibrary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var req struct {
+		MangaID string `json:"manga_id"`
+		Status  string `json:"status"`
+		Rating  int    `json:"rating"`
+		Notes   string `json:"notes"`
+	}
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	req.MangaID = h.resolveMangaID(req.MangaID)
+	if err := h.libraryService.AddToLibrary(userID.(string), req.MangaID, req.Status, req.Rating, req.Notes, eventOrigin(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add to library"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "manga added to library"})
+}
+
+// maxImportSize bounds an uploaded list export
+const maxImportSize = 16 << 20
+
+// ImportLibrary merges a MyAnimeList or AniList list export, sent as the
+// request body or an "export" form file, into user's library. ?format=mal
+// or anilist is required; with ?dry_run=true nothing is written.
+func (h *Handler) ImportLibrary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	format := c.Query("format")
+	dryRun, _ := strconv.ParseBool(c.Query("dry_run"))
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+	var reader io.Reader = c.Request.Body
+	if c.ContentType() == "multipart/form-data" {
+		file, err := c.FormFile("export")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "missing export form file"})
+			return
+		}
+		f, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid export upload"})
+			return
+		}
+		defer f.Close()
+		reader = f
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("export must be at most %d MB", maxImportSize>>20)})
+		return
+	}
+
+	entries, err := libimport.Parse(format, data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := h.importer.Import(userID.(string), entries, dryRun, eventOrigin(c))
+	if err != nil {
+		// The entries before the failing one are written, so the client
+		// gets their report along with the error
+		h.logger.Error("failed to import library of %s: %v", userID, err)
+		report.Error = fmt.Sprintf("failed to import library: stopped at %q", entries[len(report.Results)].Title)
+		c.JSON(http.StatusInternalServerError, report)
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// RemoveFromLibrary removes manga from user's library
+func (h *Handler) RemoveFromLibrary(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	mangaID := h.resolveMangaID(c.Param("mangaId"))
+
+	if err := h.libraryService.RemoveFromLibrary(userID.(string), mangaID, eventOrigin(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove from library"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "manga moved to trash"})
+}
+
+// GetLibraryTrash lists user's trashed library entries
+func (h *Handler) GetLibraryTrash(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	limit, offset := pageParams(c)
+
+	trash, err := h.libraryService.GetTrash(userID.(string), limit, offset)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to search manga"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get library trash"})
 		return
 	}
+	if trash == nil {
+		trash = []models.Progress{}
+	}
 
-	c.JSON(http.StatusOK, results)
+	c.JSON(http.StatusOK, trash)
 }
 
-// CreateManga creates a new manga (admin)
-func (h *Handler) CreateManga(c *gin.Context) {
-	var manga models.Manga
-	if err := c.BindJSON(&manga); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+// RestoreLibraryEntry moves a trashed entry back into user's library
+func (h *Handler) RestoreLibraryEntry(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	if err := h.mangaService.Create(&manga); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create manga"})
+	mangaID := h.resolveMangaID(c.Param("mangaId"))
+
+	if err := h.libraryService.RestoreFromTrash(userID.(string), mangaID, eventOrigin(c)); err != nil {
+		if errors.Is(err, store.ErrEntryNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "manga not in trash"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore library entry"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, manga)
+	c.JSON(http.StatusOK, gin.H{"message": "manga restored to library"})
 }
 
-// UpdateManga updates a manga (admin)
-func (h *Handler) UpdateManga(c *gin.Context) {
-	id := c.Param("id")
+// UpdateProgress updates reading progress
+func (h *Handler) UpdateProgress(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
 
-	var manga models.Manga
-	if err := c.BindJSON(&manga); err != nil {
+	mangaID := h.resolveMangaID(c.Param("mangaId"))
+
+	var req models.Progress
+	if err := c.BindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
 		return
 	}
 
-	manga.ID = id
-	if err := h.mangaService.Update(&manga); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update manga"})
+	req.UserID = userID.(string)
+	req.MangaID = mangaID
+
+	if err := h.libraryService.UpdateLibraryEntry(&req, eventOrigin(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update progress"})
 		return
 	}
 
-	c.JSON(http.StatusOK, manga)
+	c.JSON(http.StatusOK, gin.H{"message": "progress updated successfully"})
 }
 
-// DeleteManga deletes a manga (admin)
-func (h *Handler) DeleteManga(c *gin.Context) {
-	id := c.Param("id")
+// GetRecommendations recommends manga to the user from the similar manga
+// of their library and their favorite genres, at most limit (default 10,
+// at most 50) of them
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	userID, exists := c.Get("user_id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	limit, ok := limitParam(c)
+	if !ok {
+		return
+	}
 
-	if err := h.mangaService.Delete(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete manga"})
+	recs, err := h.recommender.ForUser(userID.(string), limit)
+	if err != nil {
+		if errors.Is(err, recommend.ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to recommend manga"})
+		return
+	}
+	localized := make([]*models.Manga, len(recs.Recommendations))
+	for i := range recs.Recommendations {
+		localized[i] = &recs.Recommendations[i].Manga
+	}
+	if err := h.mangaService.LocalizeAll(localized, h.titleLanguage(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to recommend manga"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "manga deleted successfully"})
+	c.JSON(http.StatusOK, recs)
 }
 
-// GetLibrary retrieves user's library
-func (h *Handler) GetLibrary(c *gin.Context) {
+// GetProgressEvents returns the user's reading event log, newest first.
+// since and until accept RFC 3339 timestamps or YYYY-MM-DD dates.
+func (h *Handler) GetProgressEvents(c *gin.Context) {
 	userID, exists := c.Get("user_id")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	limit := 20
-	offset := 0
+	filter := models.ProgressEventFilter{
+		UserID:  userID.(string),
+		MangaID: c.Query("manga_id"),
+		Limit:   50,
+	}
 
 	if l := c.Query("limit"); l != "" {
 		if v, err := strconv.Atoi(l); err == nil {
-			limit = v
+			filter.Limit = v
 		}
 	}
 	if o := c.Query("offset"); o != "" {
 		if v, err := strconv.Atoi(o); err == nil {
-			offset = v
+			filter.Offset = v
 		}
 	}
 
-	var progressList []models.Progress
 	var err error
-
-	if status := c.Query("status"); status != "" {
-		progressList, err = h.libraryService.GetLibraryByStatus(userID.(string), status, limit, offset)
-	} else {
-		progressList, err = h.libraryService.GetLibrary(userID.(string), limit, offset)
+	if filter.Since, err = parseTimeParam(c.Query("since")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid since: " + err.Error()})
+		return
+	}
+	if filter.Until, err = parseTimeParam(c.Query("until")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid until: " + err.Error()})
+		return
 	}
 
+	events, err := h.libraryService.GetProgressEvents(filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get library"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get progress events"})
 		return
 	}
+	if events == nil {
+		events = []models.ProgressEvent{}
+	}
 
-	c.JSON(http.StatusOK, progressList)
+	c.JSON(http.StatusOK, events)
 }
 
-// AddToLibrary adds manga to user's library
-func (h *Handler) AddToLibrary(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
+// RunTrashPurge permanently deletes library entries and manga that have been
+// in the trash longer than retention, once immediately and then every interval.
+// It never returns.
+func (h *Handler) RunTrashPurge(retention, interval time.Duration) {
+	for {
+		before := time.Now().Add(-retention)
+		if n, err := h.libraryService.PurgeTrash(before); err != nil {
+			h.logger.Error("failed to purge library trash: %v", err)
+		} else if n > 0 {
+			h.logger.Info("Purged %d library entries from trash", n)
+		}
+		if n, err := h.mangaService.PurgeTrash(before); err != nil {
+			h.logger.Error("failed to purge manga trash: %v", err)
+		} else if n > 0 {
+			h.logger.Info("Purged %d manga from trash", n)
+		}
+		time.Sleep(interval)
 	}
+}
 
-	var req struct {
-		MangaID string `json:"manga_id"`
-		Status  string `json:"status"`
-		Rating  int    `json:"rating"`
-		Notes   string `json:"notes"`
+// SetStorage points cover storage at the configured data directory, or
+// the database's directory when none is set
+func (h *Handler) SetStorage(cfg *config.Config) {
+	dataDir := cfg.Storage.DataDir
+	if dataDir == "" {
+		dataDir = filepath.Dir(cfg.Database.Path)
 	}
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-		return
+	h.covers = covers.NewStore(dataDir, int64(cfg.Storage.MaxCoverSizeMB)<<20)
+}
+
+// SetCatalog sets up the configured metadata providers and the chapter
+// release watcher polling them, which pushes releases to the UDP server,
+// and points library imports at the new catalog service. SetStorage must
+// come first, as catalog covers go into the cover store.
+func (h *Handler) SetCatalog(cfg *config.Config) error {
+	defaults := config.DefaultConfig().Catalog
+	catalogCfg := cfg.Catalog
+	if len(catalogCfg.Providers) == 0 {
+		catalogCfg.Providers = defaults.Providers
+	}
+	if catalogCfg.LocalDir == "" {
+		catalogCfg.LocalDir = defaults.LocalDir
+	}
+	if catalogCfg.Language == "" {
+		catalogCfg.Language = defaults.Language
+	}
+	providers, err := catalog.NewProviders(catalogCfg)
+	if err != nil {
+		return err
 	}
 
-	if err := h.libraryService.AddToLibrary(userID.(string), req.MangaID, req.Status, req.Rating, req.Notes); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to add to library"})
-		return
+	h.catalog = catalog.NewService(h.stores.Manga, h.stores.Titles, h.stores.ExternalIDs, h.covers,
+		catalog.DefaultPriority.WithOverrides(catalogCfg.Priority), providers...)
+	h.importer = libimport.NewImporter(h.stores.Manga, h.stores.ExternalIDs, h.stores.Library, h.catalog)
+
+	interval := time.Duration(catalogCfg.WatchInterval) * time.Minute
+	if interval <= 0 {
+		interval = time.Duration(defaults.WatchInterval) * time.Minute
 	}
+	maxBackoff := time.Duration(catalogCfg.MaxBackoff) * time.Minute
+	if maxBackoff <= 0 {
+		maxBackoff = time.Duration(defaults.MaxBackoff) * time.Minute
+	}
+	pusher := catalog.NewUDPPusher(fmt.Sprintf("%s:%d", cfg.UDP.Host, cfg.UDP.Port))
+	h.watcher = catalog.NewWatcher(h.catalog, h.stores.Chapters, h.stores.Notifications, pusher, interval, maxBackoff)
+	return nil
+}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "manga added to library"})
+// SetAuth signs and verifies tokens with the configured JWT secret, which
+// the gRPC server shares so it accepts the same tokens, and sets the token
+// lifetimes
+func (h *Handler) SetAuth(cfg *config.Config) {
+	h.authService = auth.NewAuthService(cfg.App.JWTSecret)
+	h.authService.SetAccessTTL(time.Duration(cfg.Auth.AccessTokenTTL) * time.Minute)
+	h.sessions = auth.NewSessionService(h.authService, h.stores.Sessions, h.tokens, h.stores.Users)
+	h.sessions.SetRefreshTTL(time.Duration(cfg.Auth.RefreshTokenTTL) * 24 * time.Hour)
 }
 
-// RemoveFromLibrary removes manga from user's library
-func (h *Handler) RemoveFromLibrary(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+// WarnWithoutAdmin logs how to create the first admin while there is none,
+// as until then nobody can edit the catalog or maintain the database
+func (h *Handler) WarnWithoutAdmin() {
+	admins, err := h.userService.ListByRole(models.RoleAdmin)
+	if err != nil {
+		h.logger.Error("failed to look up admins: %v", err)
 		return
 	}
+	if len(admins) == 0 {
+		h.logger.Warn("No admin account yet. Create one with: mangahub admin users bootstrap --username <username>")
+	}
+}
 
-	mangaID := c.Param("mangaId")
+// SetRetention records the retention settings the server enforces
+func (h *Handler) SetRetention(cfg *config.Config) {
+	h.retention = cfg.Retention
+	h.trashRetentionDays = cfg.Database.TrashRetentionDays
+}
 
-	if err := h.libraryService.RemoveFromLibrary(userID.(string), mangaID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove from library"})
-		return
-	}
+// RunPruning enforces the table retention policies and rotates the server
+// log, once immediately and then every interval. It never returns.
+func (h *Handler) RunPruning(interval time.Duration) {
+	for {
+		if h.db != nil {
+			results, err := retention.Prune(h.db, retention.Policies(h.retention), time.Now(), false)
+			for _, r := range results {
+				if r.Rows > 0 {
+					h.logger.Info("Pruned %d rows older than %d days from %s", r.Rows, r.Days, r.Table)
+				}
+			}
+			if err != nil {
+				h.logger.Error("failed to prune: %v", err)
+			}
+		}
 
-	c.JSON(http.StatusOK, gin.H{"message": "manga removed from library"})
+		if n, err := h.sessions.PurgeEnded(time.Now()); err != nil {
+			h.logger.Error("failed to purge ended sessions: %v", err)
+		} else if n > 0 {
+			h.logger.Info("Purged %d ended sessions", n)
+		}
+		if n, err := h.tokens.PurgeEnded(time.Now()); err != nil {
+			h.logger.Error("failed to purge ended access tokens: %v", err)
+		} else if n > 0 {
+			h.logger.Info("Purged %d ended access tokens", n)
+		}
+
+		if logPath, err := h.getLogFilePath(); err == nil {
+			maxSize := int64(h.retention.LogMaxSizeMB) << 20
+			if rotation, err := retention.RotateLog(logPath, maxSize, h.retention.LogMaxBackups, false); err != nil {
+				h.logger.Error("failed to rotate server log: %v", err)
+			} else if rotation.Rotated {
+				h.logger.Info("Rotated server log %s at %d bytes", rotation.Path, rotation.Size)
+			}
+		}
+		time.Sleep(interval)
+	}
 }
 
-// UpdateProgress updates reading progress
-func (h *Handler) UpdateProgress(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
+// RunRankingRefresh recomputes the cached manga rankings once immediately
+// and then every interval. It never returns.
+func (h *Handler) RunRankingRefresh(interval time.Duration) {
+	for {
+		if err := h.mangaService.RefreshRankings(); err != nil {
+			h.logger.Error("failed to refresh rankings: %v", err)
+		}
+		time.Sleep(interval)
 	}
+}
 
-	mangaID := c.Param("mangaId")
+// RunRecommendationRefresh recomputes the similar manga behind
+// recommendations once immediately and then every interval. It never
+// returns.
+func (h *Handler) RunRecommendationRefresh(interval time.Duration) {
+	for {
+		if err := h.recommender.Refresh(); err != nil {
+			h.logger.Error("failed to refresh recommendations: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
 
-	var req models.Progress
-	if err := c.BindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+// RunReleaseWatcher polls the metadata providers for new chapters of
+// subscribed manga once immediately and then every interval. It never
+// returns; without SetCatalog it does nothing.
+func (h *Handler) RunReleaseWatcher(interval time.Duration) {
+	if h.watcher == nil {
 		return
 	}
+	for {
+		report, err := h.watcher.Poll()
+		if err != nil {
+			h.logger.Error("failed to poll for chapter releases: %v", err)
+		}
+		if report != nil {
+			for _, r := range report.Releases {
+				h.logger.Info("Chapter %g of %s is out, notified %d subscribers", r.Chapter, r.MangaID, r.Notified)
+				if r.PushError != "" {
+					h.logger.Error("failed to push release of %s: %s", r.MangaID, r.PushError)
+				}
+			}
+			for source, msg := range report.Failed {
+				h.logger.Error("chapter release poll of %s failed: %s", source, msg)
+			}
+		}
+		time.Sleep(interval)
+	}
+}
 
-	req.UserID = userID.(string)
-	req.MangaID = mangaID
+// limitParam reads the optional limit query parameter, writing a 400
+// response when it is not a number; 0 means the default
+func limitParam(c *gin.Context) (int, bool) {
+	l := c.Query("limit")
+	if l == "" {
+		return 0, true
+	}
+	v, err := strconv.Atoi(l)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a number"})
+		return 0, false
+	}
+	return v, true
+}
 
-	if err := h.libraryService.UpdateLibraryEntry(&req); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update progress"})
-		return
+// pageParams reads the limit and offset query parameters, defaulting to 20 and 0
+func pageParams(c *gin.Context) (int, int) {
+	limit, offset := 20, 0
+	if l := c.Query("limit"); l != "" {
+		if v, err := strconv.Atoi(l); err == nil {
+			limit = v
+		}
+	}
+	if o := c.Query("offset"); o != "" {
+		if v, err := strconv.Atoi(o); err == nil {
+			offset = v
+		}
 	}
+	return limit, offset
+}
 
-	c.JSON(http.StatusOK, gin.H{"message": "progress updated successfully"})
+// eventOrigin attributes a library write to the HTTP API and the
+// client-supplied X-Device-ID header
+func eventOrigin(c *gin.Context) models.EventOrigin {
+	return models.EventOrigin{Source: models.EventSourceHTTP, DeviceID: c.GetHeader("X-Device-ID")}
+}
+
+// parseTimeParam parses an optional RFC 3339 timestamp or YYYY-MM-DD date
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", value)
 }
 
 // AuthMiddleware checks JWT token
@@ -451,7 +1674,7 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			token = token[7:]
 		}
 
-		claims, err := h.authService.VerifyToken(token)
+		claims, err := h.sessions.Authenticate(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
@@ -461,14 +1684,94 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 		c.Set("user_id", claims.UserID)
 		c.Set("username", claims.Username)
 		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_id", claims.TokenID)
+		c.Set("scopes", claims.Scopes)
+		c.Next()
+	}
+}
+
+// RequireScope lets a request through unless it came with a personal
+// access token that lacks scope, which is answered with 403 Forbidden. It
+// runs after AuthMiddleware.
+func (h *Handler) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasScope(c, scope) {
+			c.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("access token lacks the %s scope", scope)})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireLogin turns personal access tokens away from the routes that
+// manage the account itself, such as its password, sessions and tokens. It
+// runs after AuthMiddleware.
+func (h *Handler) RequireLogin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("token_id") != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "personal access tokens cannot manage the account; log in instead"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
-// AdminMiddleware checks admin privileges (placeholder)
+// hasScope reports whether the request's token allows scope; login tokens
+// allow every scope
+func hasScope(c *gin.Context, scope string) bool {
+	if c.GetString("token_id") == "" {
+		return true
+	}
+	for _, s := range c.GetStringSlice("scopes") {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AdminMiddleware lets moderators and admins through to the catalog admin
+// routes
 func (h *Handler) AdminMiddleware() gin.HandlerFunc {
+	return h.RequirePermission(models.PermEditCatalog)
+}
+
+// RequirePermission lets a request through when the user's role grants
+// perm, and answers 403 Forbidden otherwise. It runs after AuthMiddleware.
+// The token's role has to allow it and so does the user's current role, so
+// a revoked role stops working before the token expires, while a newly
+// granted one takes effect at the next token refresh. Personal access
+// tokens also need the admin scope.
+func (h *Handler) RequirePermission(perm models.Permission) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// TODO: Implement admin role checking
+		if !hasScope(c, models.ScopeAdmin) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "access token lacks the admin scope"})
+			c.Abort()
+			return
+		}
+		if !models.RoleAllows(c.GetString("role"), perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			c.Abort()
+			return
+		}
+
+		if _, err := h.userService.Authorize(c.GetString("user_id"), perm); err != nil {
+			switch {
+			case errors.Is(err, user.ErrForbidden):
+				c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
+			case errors.Is(err, store.ErrUserNotFound):
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			default:
+				h.logger.Error("failed to authorize user: %v", err)
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permissions"})
+			}
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
@@ -525,6 +1828,10 @@ func (h *Handler) readLogFile(logPath, level string, maxLines int) ([]string, er
 
 // GetDatabaseCheck performs database integrity checks
 func (h *Handler) GetDatabaseCheck(c *gin.Context) {
+	if !h.requireDatabase(c) {
+		return
+	}
+
 	// Run integrity check
 	integrityOK, integrityIssues, err := h.checkDatabaseIntegrity()
 	if err != nil {
@@ -539,9 +1846,24 @@ func (h *Handler) GetDatabaseCheck(c *gin.Context) {
 		return
 	}
 
+	// Compare the live schema with the one the migrations produce
+	schemaIssues, err := h.db.CheckSchema()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("schema check failed: %v", err)})
+		return
+	}
+	schemaOK := !hasSchemaErrors(schemaIssues)
+
+	migrator := database.NewMigrator(h.db)
+	version, err := migrator.CurrentVersion()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read schema version: %v", err)})
+		return
+	}
+
 	// Determine overall status
 	status := "healthy"
-	if !integrityOK || len(missingTables) > 0 {
+	if !integrityOK || len(missingTables) > 0 || !schemaOK {
 		status = "unhealthy"
 	}
 
@@ -555,9 +1877,58 @@ func (h *Handler) GetDatabaseCheck(c *gin.Context) {
 			"verified": tables,
 			"missing":  missingTables,
 		},
+		"schema": gin.H{
+			"ok":      schemaOK,
+			"version": version,
+			"latest":  migrator.LatestVersion(),
+			"issues":  schemaIssues,
+		},
 	})
 }
 
+// FixDatabaseSchema applies pending migrations and repairs schema drift
+func (h *Handler) FixDatabaseSchema(c *gin.Context) {
+	if !h.requireDatabase(c) {
+		return
+	}
+
+	steps, remaining, err := h.db.FixSchema()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("schema fix failed: %v", err), "steps": steps})
+		return
+	}
+
+	status := "ok"
+	if hasSchemaErrors(remaining) {
+		status = "partial"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":    status,
+		"steps":     steps,
+		"remaining": remaining,
+	})
+}
+
+// requireDatabase responds with 503 when the handler has no SQLite database
+func (h *Handler) requireDatabase(c *gin.Context) bool {
+	if h.db == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "database management is not available"})
+		return false
+	}
+	return true
+}
+
+// hasSchemaErrors reports whether any issue is error severity
+func hasSchemaErrors(issues []database.SchemaIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == "error" {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Handler) checkDatabaseIntegrity() (bool, []string, error) {
 	rows, err := h.db.DB.Query(`PRAGMA integrity_check;`)
 	if err != nil {
@@ -604,6 +1975,10 @@ func (h *Handler) verifyDatabaseTables() ([]string, []string, error) {
 
 // OptimizeDatabase runs database optimization commands
 func (h *Handler) OptimizeDatabase(c *gin.Context) {
+	if !h.requireDatabase(c) {
+		return
+	}
+
 	var steps []string
 	var errors []string
 
@@ -645,9 +2020,13 @@ func (h *Handler) OptimizeDatabase(c *gin.Context) {
 
 // GetDatabaseStats returns database statistics
 func (h *Handler) GetDatabaseStats(c *gin.Context) {
+	if !h.requireDatabase(c) {
+		return
+	}
+
 	// Get table counts
 	tables := map[string]int{}
-	tableNames := []string{"users", "manga", "user_progress", "chat_messages", "notifications"}
+	tableNames := []string{"users", "manga", "user_progress", "chat_messages", "notifications", "progress_events"}
 
 	for _, name := range tableNames {
 		var count int
@@ -670,15 +2049,26 @@ func (h *Handler) GetDatabaseStats(c *gin.Context) {
 		}
 	}
 
+	retentionDays := map[string]int{}
+	for _, p := range retention.Policies(h.retention) {
+		retentionDays[p.Table] = p.Days
+	}
+
 	c.JSON(http.StatusOK, gin.H{
-		"file_size_bytes": fileSize,
-		"file_size_mb":    float64(fileSize) / 1024.0 / 1024.0,
-		"tables":          tables,
+		"file_size_bytes":      fileSize,
+		"file_size_mb":         float64(fileSize) / 1024.0 / 1024.0,
+		"tables":               tables,
+		"retention_days":       retentionDays,
+		"trash_retention_days": h.trashRetentionDays,
 	})
 }
 
 // RepairDatabase performs database repair operations
 func (h *Handler) RepairDatabase(c *gin.Context) {
+	if !h.requireDatabase(c) {
+		return
+	}
+
 	var steps []string
 	var errors []string
 
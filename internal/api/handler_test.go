@@ -0,0 +1,195 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+	"mangahub/pkg/utils"
+)
+
+// testServer serves the API routes on top of in-memory stores seeded with
+// one manga
+type testServer struct {
+	t      *testing.T
+	engine *gin.Engine
+	stores *store.Stores
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	stores := store.NewMemoryStores()
+	manga := &models.Manga{ID: "one-piece", Title: "One Piece", Author: "Eiichiro Oda", Status: "ongoing", TotalChapters: 1100}
+	if err := stores.Manga.Create(manga); err != nil {
+		t.Fatalf("seed manga: %v", err)
+	}
+
+	engine := gin.New()
+	NewHandlerWithStores(nil, stores, utils.NewLogger()).RegisterRoutes(engine)
+	return &testServer{t: t, engine: engine, stores: stores}
+}
+
+// do sends a JSON request, with token as the bearer token when set, and
+// decodes the response body into out when it is non-nil
+func (s *testServer) do(method, path, token string, body interface{}, out interface{}) int {
+	s.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			s.t.Fatalf("encode %s %s: %v", method, path, err)
+		}
+	}
+	req := httptest.NewRequest(method, path, &payload)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.engine.ServeHTTP(rec, req)
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			s.t.Fatalf("decode %s %s (%d): %v: %s", method, path, rec.Code, err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+// login registers a user and returns an access token for them
+func (s *testServer) login(username string) string {
+	s.t.Helper()
+	creds := models.RegisterRequest{Username: username, Email: username + "@example.com", Password: "Secret123!"}
+	if code := s.do(http.MethodPost, "/auth/register", "", creds, nil); code != http.StatusCreated {
+		s.t.Fatalf("register %s: status %d", username, code)
+	}
+	var resp models.LoginResponse
+	login := models.LoginRequest{Username: username, Password: creds.Password}
+	if code := s.do(http.MethodPost, "/auth/login", "", login, &resp); code != http.StatusOK {
+		s.t.Fatalf("login %s: status %d", username, code)
+	}
+	return resp.Token
+}
+
+func TestLibraryRoutes(t *testing.T) {
+	s := newTestServer(t)
+
+	if code := s.do(http.MethodGet, "/users/library", "", nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("library without a token: status %d, want 401", code)
+	}
+
+	token := s.login("alice")
+	add := gin.H{"manga_id": "one-piece", "status": "reading"}
+	if code := s.do(http.MethodPost, "/users/library", token, add, nil); code != http.StatusCreated {
+		t.Fatalf("add to library: status %d", code)
+	}
+	progress := gin.H{"current_chapter": 42}
+	if code := s.do(http.MethodPut, "/users/library/one-piece/progress", token, progress, nil); code != http.StatusOK {
+		t.Fatalf("update progress: status %d", code)
+	}
+
+	var library []models.Progress
+	if code := s.do(http.MethodGet, "/users/library", token, nil, &library); code != http.StatusOK {
+		t.Fatalf("get library: status %d", code)
+	}
+	if len(library) != 1 || library[0].MangaID != "one-piece" || library[0].CurrentChapter != 42 {
+		t.Errorf("library = %+v", library)
+	}
+
+	user, err := s.stores.Users.GetByUsername("alice")
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	events, err := s.stores.Library.Events(models.ProgressEventFilter{UserID: user.ID, Limit: 10})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Errorf("recorded %d progress events, want 2", len(events))
+	}
+}
+
+func TestRolesAndScopes(t *testing.T) {
+	s := newTestServer(t)
+	token := s.login("bob")
+
+	manga := gin.H{"id": "naruto", "title": "Naruto", "author": "Masashi Kishimoto", "status": "completed"}
+	if code := s.do(http.MethodPost, "/admin/manga", token, manga, nil); code != http.StatusForbidden {
+		t.Errorf("plain user creating manga: status %d, want 403", code)
+	}
+
+	var created models.CreateAccessTokenResponse
+	req := models.CreateAccessTokenRequest{Name: "reader", Scopes: []string{models.ScopeLibraryRead}}
+	if code := s.do(http.MethodPost, "/users/tokens", token, req, &created); code != http.StatusCreated {
+		t.Fatalf("create access token: status %d", code)
+	}
+	pat := created.Token
+
+	if code := s.do(http.MethodGet, "/users/library", pat, nil, nil); code != http.StatusOK {
+		t.Errorf("read-scoped token reading the library: status %d, want 200", code)
+	}
+	add := gin.H{"manga_id": "one-piece", "status": "reading"}
+	if code := s.do(http.MethodPost, "/users/library", pat, add, nil); code != http.StatusForbidden {
+		t.Errorf("read-scoped token writing the library: status %d, want 403", code)
+	}
+	if code := s.do(http.MethodGet, "/users/tokens", pat, nil, nil); code != http.StatusForbidden {
+		t.Errorf("access token managing tokens: status %d, want 403", code)
+	}
+
+	if code := s.do(http.MethodDelete, "/users/tokens/"+created.ID, token, nil, nil); code != http.StatusOK {
+		t.Fatalf("revoke access token: status %d", code)
+	}
+	if code := s.do(http.MethodGet, "/users/library", pat, nil, nil); code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want 401", code)
+	}
+}
+
+func TestAdminMangaNotFound(t *testing.T) {
+	s := newTestServer(t)
+	s.login("carol")
+	user, err := s.stores.Users.GetByUsername("carol")
+	if err != nil {
+		t.Fatalf("get user: %v", err)
+	}
+	if err := s.stores.Users.SetRole(user.ID, models.RoleAdmin); err != nil {
+		t.Fatalf("grant admin: %v", err)
+	}
+	// The role is carried in the token, so log in again to pick it up
+	var resp models.LoginResponse
+	login := models.LoginRequest{Username: "carol", Password: "Secret123!"}
+	if code := s.do(http.MethodPost, "/auth/login", "", login, &resp); code != http.StatusOK {
+		t.Fatalf("login: status %d", code)
+	}
+	token := resp.Token
+
+	edit := gin.H{"title": "Ghost", "author": "Nobody", "status": "ongoing"}
+	if code := s.do(http.MethodPut, "/admin/manga/missing", token, edit, nil); code != http.StatusNotFound {
+		t.Errorf("update unknown manga: status %d, want 404", code)
+	}
+	if code := s.do(http.MethodDelete, "/admin/manga/missing", token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("delete unknown manga: status %d, want 404", code)
+	}
+
+	if code := s.do(http.MethodDelete, "/admin/manga/one-piece", token, nil, nil); code != http.StatusOK {
+		t.Fatalf("delete manga: status %d", code)
+	}
+	if code := s.do(http.MethodDelete, "/admin/manga/one-piece", token, nil, nil); code != http.StatusNotFound {
+		t.Errorf("delete trashed manga: status %d, want 404", code)
+	}
+	if code := s.do(http.MethodPut, "/admin/manga/one-piece", token, edit, nil); code != http.StatusNotFound {
+		t.Errorf("update trashed manga: status %d, want 404", code)
+	}
+	sources, err := s.stores.ExternalIDs.FieldSources("one-piece")
+	if err != nil {
+		t.Fatalf("get field sources: %v", err)
+	}
+	if len(sources) != 0 {
+		t.Errorf("field sources = %v; an update that did not apply must not record an edit", sources)
+	}
+}
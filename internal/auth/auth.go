@@ -2,17 +2,25 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
 	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"golang.org/x/crypto/bcrypt"
+
+	"mangahub/pkg/models"
 )
 
+// DefaultAccessTTL is how long access tokens last unless set otherwise
+const DefaultAccessTTL = 15 * time.Minute
+
 // AuthService handles authentication operations
 type AuthService struct {
 	jwtSecret string
+	accessTTL time.Duration
 }
 
 // NewAuthService creates a new auth service
@@ -22,6 +30,14 @@ func NewAuthService(jwtSecret string) *AuthService {
 	}
 	return &AuthService{
 		jwtSecret: jwtSecret,
+		accessTTL: DefaultAccessTTL,
+	}
+}
+
+// SetAccessTTL sets how long access tokens last
+func (as *AuthService) SetAccessTTL(ttl time.Duration) {
+	if ttl > 0 {
+		as.accessTTL = ttl
 	}
 }
 
@@ -30,9 +46,39 @@ type Claims struct {
 	UserID   string `json:"user_id"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	// Role is the user's role when the token was issued; tokens issued
+	// before roles existed carry none and count as a plain user
+	Role string `json:"role,omitempty"`
+	// SessionID is the login session the token was issued to; the token
+	// stops working when the session is revoked
+	SessionID string `json:"sid,omitempty"`
+	// TokenID is the personal access token the claims came from, which
+	// only allows Scopes. Personal access tokens are not JWTs, so neither
+	// is ever encoded.
+	TokenID string   `json:"-"`
+	Scopes  []string `json:"-"`
 	jwt.RegisteredClaims
 }
 
+// Personal reports whether the claims came from a personal access token
+func (c *Claims) Personal() bool {
+	return c.TokenID != ""
+}
+
+// HasScope reports whether the claims allow scope. Login tokens allow every
+// scope; personal access tokens only those they were given.
+func (c *Claims) HasScope(scope string) bool {
+	if !c.Personal() {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
 // GenerateUserID generates a new user ID
 func (as *AuthService) GenerateUserID() string {
 	b := make([]byte, 8)
@@ -56,13 +102,15 @@ func (as *AuthService) VerifyPassword(hash, password string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-// GenerateToken generates a JWT token
-func (as *AuthService) GenerateToken(userID, username, email string) (string, time.Time, error) {
-	expirationTime := time.Now().Add(24 * time.Hour)
+// GenerateToken generates a short-lived JWT access token for a user's session
+func (as *AuthService) GenerateToken(user *models.User, sessionID string) (string, time.Time, error) {
+	expirationTime := time.Now().Add(as.accessTTL)
 	claims := &Claims{
-		UserID:   userID,
-		Username: username,
-		Email:    email,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -95,3 +143,21 @@ func (as *AuthService) VerifyToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+// GenerateRefreshToken generates a random refresh token and the hash it is
+// stored under
+func (as *AuthService) GenerateRefreshToken() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+// HashToken returns the hash a refresh token or personal access token is
+// stored under
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,237 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// DefaultRefreshTTL is how long a session lasts without being refreshed
+// unless set otherwise
+const DefaultRefreshTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrSessionEnded is returned for tokens of a session that was revoked
+	// or expired, and for refresh tokens that are unknown
+	ErrSessionEnded = errors.New("session expired or revoked")
+	// ErrRefreshReused is returned when a refresh token that was already
+	// traded in comes back. Someone kept a copy of it, so the session is
+	// revoked.
+	ErrRefreshReused = errors.New("refresh token reused; session revoked")
+)
+
+// SessionService issues access and refresh tokens for login sessions and
+// checks that the session behind an access token is still live
+type SessionService struct {
+	auth       *AuthService
+	sessions   store.SessionStore
+	tokens     *AccessTokenService
+	users      store.UserStore
+	refreshTTL time.Duration
+}
+
+// NewSessionService creates a session service. Authenticate also accepts
+// the personal access tokens of tokens, unless it is nil.
+func NewSessionService(authService *AuthService, sessions store.SessionStore, tokens *AccessTokenService, users store.UserStore) *SessionService {
+	return &SessionService{
+		auth:       authService,
+		sessions:   sessions,
+		tokens:     tokens,
+		users:      users,
+		refreshTTL: DefaultRefreshTTL,
+	}
+}
+
+// SetRefreshTTL sets how long a session lasts without being refreshed
+func (s *SessionService) SetRefreshTTL(ttl time.Duration) {
+	if ttl > 0 {
+		s.refreshTTL = ttl
+	}
+}
+
+// Start opens a session for a user who just logged in and issues its
+// first tokens
+func (s *SessionService) Start(user *models.User, device, ip string) (*models.LoginResponse, error) {
+	refreshToken, hash, err := s.auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:          generateSessionID(),
+		UserID:      user.ID,
+		Device:      device,
+		IP:          ip,
+		CreatedAt:   now,
+		LastUsedAt:  now,
+		ExpiresAt:   now.Add(s.refreshTTL),
+		RefreshHash: hash,
+	}
+	if err := s.sessions.Create(session); err != nil {
+		return nil, err
+	}
+	return s.issue(user, session.ID, refreshToken, session.ExpiresAt)
+}
+
+// Refresh trades a refresh token for a new access token and a new refresh
+// token, which replaces it. The user is read again, so a role granted since
+// shows up in the new access token.
+func (s *SessionService) Refresh(refreshToken, ip string) (*models.LoginResponse, error) {
+	hash := HashToken(refreshToken)
+	session, err := s.sessions.GetByRefreshHash(hash)
+	if errors.Is(err, store.ErrSessionNotFound) {
+		return nil, ErrSessionEnded
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return nil, ErrSessionEnded
+	}
+	if session.RefreshHash != hash {
+		if err := s.sessions.Revoke(session.ID, now); err != nil && !errors.Is(err, store.ErrSessionNotFound) {
+			return nil, err
+		}
+		return nil, ErrRefreshReused
+	}
+
+	user, err := s.users.GetByID(session.UserID)
+	if errors.Is(err, store.ErrUserNotFound) {
+		return nil, ErrSessionEnded
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	newToken, newHash, err := s.auth.GenerateRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+	expiresAt := now.Add(s.refreshTTL)
+	if err := s.sessions.Rotate(session.ID, hash, newHash, ip, now, expiresAt); err != nil {
+		if errors.Is(err, store.ErrSessionNotFound) {
+			return nil, ErrSessionEnded
+		}
+		return nil, err
+	}
+	return s.issue(user, session.ID, newToken, expiresAt)
+}
+
+// issue generates the access token of a session
+func (s *SessionService) issue(user *models.User, sessionID, refreshToken string, refreshExpiresAt time.Time) (*models.LoginResponse, error) {
+	token, expiresAt, err := s.auth.GenerateToken(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResponse{
+		UserID:           user.ID,
+		Username:         user.Username,
+		Role:             user.Role,
+		Token:            token,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+		SessionID:        sessionID,
+	}, nil
+}
+
+// Authenticate verifies an access token and that its session is still
+// live, or checks a personal access token
+func (s *SessionService) Authenticate(token string) (*Claims, error) {
+	if strings.HasPrefix(token, models.AccessTokenPrefix) {
+		if s.tokens == nil {
+			return nil, ErrAccessTokenEnded
+		}
+		return s.tokens.Authenticate(token)
+	}
+
+	claims, err := s.auth.VerifyToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.SessionID == "" {
+		return nil, ErrSessionEnded
+	}
+	session, err := s.sessions.Get(claims.SessionID)
+	if errors.Is(err, store.ErrSessionNotFound) {
+		return nil, ErrSessionEnded
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check session: %w", err)
+	}
+	if session.UserID != claims.UserID || session.RevokedAt != nil || !time.Now().Before(session.ExpiresAt) {
+		return nil, ErrSessionEnded
+	}
+	return claims, nil
+}
+
+// Logout revokes the session of a refresh token
+func (s *SessionService) Logout(refreshToken string) error {
+	session, err := s.sessions.GetByRefreshHash(HashToken(refreshToken))
+	if errors.Is(err, store.ErrSessionNotFound) {
+		return ErrSessionEnded
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.sessions.Revoke(session.ID, time.Now()); err != nil {
+		if errors.Is(err, store.ErrSessionNotFound) {
+			return ErrSessionEnded
+		}
+		return err
+	}
+	return nil
+}
+
+// List lists a user's live sessions, marking currentID as the current one
+func (s *SessionService) List(userID, currentID string) ([]models.Session, error) {
+	sessions, err := s.sessions.List(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// Revoke revokes one of a user's sessions. Sessions of other users are
+// reported as store.ErrSessionNotFound.
+func (s *SessionService) Revoke(userID, sessionID string) error {
+	session, err := s.sessions.Get(sessionID)
+	if err != nil {
+		return err
+	}
+	if session.UserID != userID {
+		return store.ErrSessionNotFound
+	}
+	return s.sessions.Revoke(sessionID, time.Now())
+}
+
+// RevokeOthers revokes every session of a user but keepID and returns how
+// many it revoked
+func (s *SessionService) RevokeOthers(userID, keepID string) (int, error) {
+	return s.sessions.RevokeAll(userID, keepID, time.Now())
+}
+
+// PurgeEnded deletes the sessions that expired or were revoked before the
+// cutoff
+func (s *SessionService) PurgeEnded(before time.Time) (int64, error) {
+	return s.sessions.PurgeEnded(before)
+}
+
+// generateSessionID generates a new session ID
+func generateSessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "sess_" + fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return "sess_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,187 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+const (
+	// DefaultAccessTokenDays is how long a personal access token lasts
+	// unless asked otherwise
+	DefaultAccessTokenDays = 90
+	// MaxAccessTokenDays is the longest a personal access token can last
+	MaxAccessTokenDays = 365
+)
+
+var (
+	// ErrAccessTokenEnded is returned for personal access tokens that are
+	// unknown, expired or revoked
+	ErrAccessTokenEnded = errors.New("access token expired or revoked")
+	// ErrAdminScope is returned when a plain user asks for the admin scope
+	ErrAdminScope = errors.New("the admin scope takes a moderator or admin role")
+	// ErrInvalidScope is returned for unknown or missing scopes
+	ErrInvalidScope = errors.New("invalid scope")
+	// ErrTokenName is returned for a missing or overlong token name
+	ErrTokenName = errors.New("name is required and at most 64 characters")
+	// ErrTokenExpiry is returned for an expiry out of range
+	ErrTokenExpiry = fmt.Errorf("expires_in_days must be between 1 and %d", MaxAccessTokenDays)
+)
+
+// AccessTokenService issues, checks and revokes personal access tokens
+type AccessTokenService struct {
+	tokens store.AccessTokenStore
+	users  store.UserStore
+}
+
+// NewAccessTokenService creates a personal access token service
+func NewAccessTokenService(tokens store.AccessTokenStore, users store.UserStore) *AccessTokenService {
+	return &AccessTokenService{tokens: tokens, users: users}
+}
+
+// Create issues a personal access token for user. The token itself is
+// only returned here.
+func (s *AccessTokenService) Create(user *models.User, req models.CreateAccessTokenRequest) (*models.CreateAccessTokenResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 64 {
+		return nil, ErrTokenName
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if containsScope(scopes, models.ScopeAdmin) && !models.RoleAllows(user.Role, models.PermEditCatalog) {
+		return nil, ErrAdminScope
+	}
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = DefaultAccessTokenDays
+	}
+	if days < 0 || days > MaxAccessTokenDays {
+		return nil, ErrTokenExpiry
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("failed to generate access token: %w", err)
+	}
+	secret := models.AccessTokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+
+	now := time.Now()
+	token := models.AccessToken{
+		ID:        generateAccessTokenID(),
+		UserID:    user.ID,
+		Name:      name,
+		Scopes:    scopes,
+		Hint:      secret[:len(models.AccessTokenPrefix)+6],
+		CreatedAt: now,
+		ExpiresAt: now.AddDate(0, 0, days),
+		TokenHash: HashToken(secret),
+	}
+	if err := s.tokens.Create(&token); err != nil {
+		return nil, err
+	}
+	return &models.CreateAccessTokenResponse{AccessToken: token, Token: secret}, nil
+}
+
+// List lists a user's live personal access tokens
+func (s *AccessTokenService) List(userID string) ([]models.AccessToken, error) {
+	return s.tokens.List(userID, time.Now())
+}
+
+// Revoke revokes one of a user's personal access tokens
+func (s *AccessTokenService) Revoke(userID, id string) error {
+	return s.tokens.Revoke(userID, id, time.Now())
+}
+
+// Authenticate checks a personal access token and returns claims for its
+// user with the token's scopes. The role is the user's current one, so
+// role changes apply to tokens at once.
+func (s *AccessTokenService) Authenticate(secret string) (*Claims, error) {
+	token, err := s.tokens.GetByHash(HashToken(secret))
+	if errors.Is(err, store.ErrAccessTokenNotFound) {
+		return nil, ErrAccessTokenEnded
+	}
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if token.RevokedAt != nil || !now.Before(token.ExpiresAt) {
+		return nil, ErrAccessTokenEnded
+	}
+
+	user, err := s.users.GetByID(token.UserID)
+	if errors.Is(err, store.ErrUserNotFound) {
+		return nil, ErrAccessTokenEnded
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Last use is kept to the minute, sparing a write on every request
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= time.Minute {
+		if err := s.tokens.Touch(token.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Claims{
+		UserID:   user.ID,
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		TokenID:  token.ID,
+		Scopes:   token.Scopes,
+	}, nil
+}
+
+// PurgeEnded deletes the tokens that expired or were revoked before the
+// cutoff
+func (s *AccessTokenService) PurgeEnded(before time.Time) (int64, error) {
+	return s.tokens.PurgeEnded(before)
+}
+
+// normalizeScopes checks and deduplicates requested scopes, keeping the
+// order of models.Scopes
+func normalizeScopes(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return nil, fmt.Errorf("%w: at least one of %s is required", ErrInvalidScope, strings.Join(models.Scopes, ", "))
+	}
+	for _, scope := range requested {
+		if !models.ValidScope(scope) {
+			return nil, fmt.Errorf("%w '%s'. Valid scopes: %s", ErrInvalidScope, scope, strings.Join(models.Scopes, ", "))
+		}
+	}
+	var scopes []string
+	for _, scope := range models.Scopes {
+		if containsScope(requested, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func containsScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// generateAccessTokenID generates a new personal access token ID
+func generateAccessTokenID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "pat_" + fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return "pat_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,219 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// Duplicates lists the catalog manga that look like the same series, as
+// loading the same series from two sources under different IDs leaves
+// them: manga whose main or alternate titles match once normalized and
+// whose authors match, in any word order, or are unknown on one side.
+// Groups are ordered by title and their manga by ID.
+func (s *Service) Duplicates() ([]models.DuplicateGroup, error) {
+	all, err := s.manga.List(-1, 0)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	// Manga sharing a title are joined into one group when their authors
+	// agree; parent[i] leads back to the first manga of i's group
+	parent := make([]int, len(all))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	groupTitles := make(map[int]string)
+
+	byTitle := make(map[string][]int)
+	var keys []string
+	for i, m := range all {
+		titles, err := s.titles.List(m.ID)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, title := range append([]string{m.Title}, titleStrings(titles)...) {
+			key := models.NormalizeTitle(title)
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			if _, ok := byTitle[key]; !ok {
+				keys = append(keys, key)
+			}
+			byTitle[key] = append(byTitle[key], i)
+		}
+	}
+
+	for _, key := range keys {
+		indexes := byTitle[key]
+		for a := 0; a < len(indexes); a++ {
+			for b := a + 1; b < len(indexes); b++ {
+				i, j := indexes[a], indexes[b]
+				if !sameAuthor(all[i].Author, all[j].Author) {
+					continue
+				}
+				ri, rj := find(i), find(j)
+				if ri == rj {
+					continue
+				}
+				if rj < ri {
+					ri, rj = rj, ri
+				}
+				parent[rj] = ri
+				if _, ok := groupTitles[ri]; !ok {
+					groupTitles[ri] = key
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]models.Manga)
+	for i, m := range all {
+		root := find(i)
+		members[root] = append(members[root], m)
+	}
+	groups := []models.DuplicateGroup{}
+	for root, manga := range members {
+		if len(manga) < 2 {
+			continue
+		}
+		title := groupTitles[root]
+		if title == "" {
+			title = models.NormalizeTitle(manga[0].Title)
+		}
+		groups = append(groups, models.DuplicateGroup{Title: title, Manga: manga})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Title < groups[j].Title })
+	return groups, nil
+}
+
+// titleStrings returns the text of each title
+func titleStrings(titles []models.MangaTitle) []string {
+	out := make([]string, len(titles))
+	for i, t := range titles {
+		out[i] = t.Title
+	}
+	return out
+}
+
+// sameAuthor reports whether two author fields name the same people, so
+// "Oda, Eiichiro" matches "Eiichiro Oda". An unknown author matches any.
+func sameAuthor(a, b string) bool {
+	a, b = authorKey(a), authorKey(b)
+	return a == "" || b == "" || a == b
+}
+
+// authorKey normalizes an author field like a title and sorts its words
+func authorKey(author string) string {
+	words := strings.Fields(models.NormalizeTitle(author))
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+// Merge folds the duplicate manga drop into keep with the merge store and
+// brings keep up to date: fields keep lacks are filled in from drop, drop's
+// cover is moved over when keep has none, and the sources keep is now
+// linked to are merged again in priority order. Both manga must be outside
+// the trash; store.ErrMangaNotFound is returned otherwise.
+func (s *Service) Merge(merges store.MergeStore, keepID, dropID string) (*models.MergeResult, error) {
+	keep, err := s.manga.GetByID(keepID)
+	if err != nil {
+		return nil, err
+	}
+	drop, err := s.manga.GetByID(dropID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := merges.Merge(keepID, dropID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := *keep
+	result.Filled, result.Note = s.fillFrom(&updated, drop)
+	if len(result.Filled) > 0 {
+		if err := s.manga.Update(&updated); err != nil {
+			return result, err
+		}
+	}
+	if s.covers != nil {
+		if err := s.covers.Delete(dropID); err != nil && result.Note == "" {
+			result.Note = "old cover not removed: " + err.Error()
+		}
+	}
+
+	merged, err := s.manga.GetByID(keepID)
+	if err != nil {
+		return result, err
+	}
+	if _, err := s.merge(merged, Result{}); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// fillFrom fills in the fields keep lacks from drop and returns the fields
+// it filled, with a note when drop's cover could not be moved
+func (s *Service) fillFrom(keep, drop *models.Manga) ([]string, string) {
+	var filled []string
+	if keep.Author == "" && drop.Author != "" {
+		keep.Author = drop.Author
+		filled = append(filled, models.FieldAuthor)
+	}
+	if keep.Description == "" && drop.Description != "" {
+		keep.Description = drop.Description
+		filled = append(filled, models.FieldDescription)
+	}
+	if len(keep.Genres) == 0 && len(drop.Genres) > 0 {
+		keep.Genres = drop.Genres
+		filled = append(filled, models.FieldGenres)
+	}
+	if keep.Year == 0 && drop.Year != 0 {
+		keep.Year = drop.Year
+		filled = append(filled, models.FieldYear)
+	}
+
+	var note string
+	if keep.CoverURL == "" && drop.CoverURL != "" {
+		if drop.CoverURL == covers.URL(drop.ID) {
+			note = s.moveCover(keep, drop.ID)
+		} else {
+			keep.CoverURL, note = s.storeCover(keep.ID, drop.CoverURL)
+		}
+		if keep.CoverURL != "" {
+			filled = append(filled, models.FieldCoverURL)
+		}
+	}
+	return filled, note
+}
+
+// moveCover stores drop's stored cover as keep's
+func (s *Service) moveCover(keep *models.Manga, dropID string) string {
+	if s.covers == nil {
+		return "cover not moved: no cover store"
+	}
+	f, _, err := s.covers.Open(dropID, covers.SizeOriginal)
+	if err != nil {
+		return "cover not moved: " + err.Error()
+	}
+	defer f.Close()
+	if err := s.covers.Save(keep.ID, f); err != nil {
+		return "cover not moved: " + err.Error()
+	}
+	keep.CoverURL = covers.URL(keep.ID)
+	return ""
+}
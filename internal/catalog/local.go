@@ -0,0 +1,151 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"mangahub/pkg/models"
+)
+
+// LocalProvider reads catalog entries from the JSON files in a directory,
+// in the format scripts/load_data.go loads (data/manga_manual.json and the
+// `mangahub manga dex --output` exports). Every file holding a JSON array
+// of manga is read, in file name order; other files are ignored, and the
+// first entry with an ID wins. External IDs are the IDs in the files, which
+// are the catalog IDs the loader gave the manga.
+type LocalProvider struct {
+	dir string
+}
+
+// localManga is one manga in a local JSON file
+type localManga struct {
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Titles      []models.MangaTitle `json:"titles,omitempty"`
+	Author      string              `json:"author"`
+	Genres      []string            `json:"genres"`
+	Status      string              `json:"status"`
+	Chapters    int                 `json:"chapters"`
+	Description string              `json:"description"`
+	Year        int                 `json:"year"`
+	CoverURL    string              `json:"cover_url,omitempty"`
+}
+
+// NewLocalProvider creates a provider reading the JSON files in dir
+func NewLocalProvider(dir string) *LocalProvider {
+	return &LocalProvider{dir: dir}
+}
+
+// Source returns models.SourceLocal
+func (p *LocalProvider) Source() string {
+	return models.SourceLocal
+}
+
+// Search returns up to limit entries whose title or alternate titles
+// contain the query once normalized, in title order. An empty query
+// returns every entry.
+func (p *LocalProvider) Search(query string, limit int) ([]models.CatalogEntry, error) {
+	entries, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+
+	query = models.NormalizeTitle(query)
+	var matches []models.CatalogEntry
+	for _, e := range entries {
+		if query == "" || localMatch(e, query) {
+			matches = append(matches, e)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Title < matches[j].Title })
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, nil
+}
+
+// Fetch returns the entry with the given ID
+func (p *LocalProvider) Fetch(externalID string) (*models.CatalogEntry, error) {
+	entries, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if e.ExternalID == externalID {
+			return &e, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// Chapters returns no chapters; the local files only hold chapter counts
+func (p *LocalProvider) Chapters(externalID string) ([]models.Chapter, error) {
+	if _, err := p.Fetch(externalID); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+// load reads every entry in the directory. The files are small and edited
+// by hand, so they are read again on every call.
+func (p *LocalProvider) load() ([]models.CatalogEntry, error) {
+	if _, err := os.Stat(p.dir); err != nil {
+		return nil, fmt.Errorf("failed to read local catalog: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(p.dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read local catalog: %w", err)
+	}
+	sort.Strings(files)
+
+	var entries []models.CatalogEntry
+	seen := make(map[string]bool)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		var list []localManga
+		if err := json.Unmarshal(data, &list); err != nil {
+			continue
+		}
+		for _, m := range list {
+			if m.ID == "" || strings.TrimSpace(m.Title) == "" || seen[m.ID] {
+				continue
+			}
+			seen[m.ID] = true
+			entries = append(entries, models.CatalogEntry{
+				Source:      models.SourceLocal,
+				ExternalID:  m.ID,
+				Title:       strings.TrimSpace(m.Title),
+				Titles:      m.Titles,
+				Author:      m.Author,
+				Description: m.Description,
+				Status:      m.Status,
+				Genres:      m.Genres,
+				Chapters:    m.Chapters,
+				Year:        m.Year,
+				CoverURL:    m.CoverURL,
+			})
+		}
+	}
+	return entries, nil
+}
+
+// localMatch reports whether an entry's title or alternate titles contain
+// a normalized query
+func localMatch(e models.CatalogEntry, query string) bool {
+	if strings.Contains(models.NormalizeTitle(e.Title), query) {
+		return true
+	}
+	for _, t := range e.Titles {
+		if strings.Contains(models.NormalizeTitle(t.Title), query) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,115 @@
+package catalog
+
+import (
+	"errors"
+
+	"mangahub/internal/mangadex"
+	"mangahub/pkg/models"
+)
+
+// MangaDexProvider reads catalog entries from the MangaDex API. External
+// IDs are MangaDex manga UUIDs.
+type MangaDexProvider struct {
+	client   *mangadex.Client
+	language string
+}
+
+// NewMangaDexProvider creates a provider for the MangaDex API at baseURL,
+// the public API when empty, with covers served from coverURL, the public
+// cover host when empty, listing chapters in the given language ("en" when
+// empty)
+func NewMangaDexProvider(baseURL, coverURL, language string) *MangaDexProvider {
+	if language == "" {
+		language = "en"
+	}
+	client := mangadex.NewClient(baseURL)
+	client.SetCoverURL(coverURL)
+	return &MangaDexProvider{client: client, language: language}
+}
+
+// Source returns models.SourceMangaDex
+func (p *MangaDexProvider) Source() string {
+	return models.SourceMangaDex
+}
+
+// Search returns up to limit MangaDex manga whose title matches the query
+func (p *MangaDexProvider) Search(query string, limit int) ([]models.CatalogEntry, error) {
+	results, err := p.client.Search(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]models.CatalogEntry, 0, len(results))
+	for _, m := range results {
+		entries = append(entries, MangaDexEntry(m))
+	}
+	return entries, nil
+}
+
+// Fetch returns the MangaDex manga with the given ID
+func (p *MangaDexProvider) Fetch(externalID string) (*models.CatalogEntry, error) {
+	m, err := p.client.Fetch(externalID)
+	if errors.Is(err, mangadex.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := MangaDexEntry(*m)
+	return &entry, nil
+}
+
+// Chapters lists the chapters MangaDex has of a manga in the provider's
+// language
+func (p *MangaDexProvider) Chapters(externalID string) ([]models.Chapter, error) {
+	list, err := p.client.Chapters(externalID, p.language)
+	if errors.Is(err, mangadex.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	chapters := make([]models.Chapter, 0, len(list))
+	for _, ch := range list {
+		chapter := models.Chapter{
+			Number:   ch.Number,
+			Volume:   ch.Volume,
+			Title:    ch.Title,
+			Language: ch.Language,
+			Pages:    ch.Pages,
+		}
+		if !ch.PublishedAt.IsZero() {
+			releasedAt := ch.PublishedAt
+			chapter.ReleasedAt = &releasedAt
+		}
+		chapters = append(chapters, chapter)
+	}
+	return chapters, nil
+}
+
+// MangaDexEntry turns a MangaDex manga into a catalog entry
+func MangaDexEntry(m mangadex.Manga) models.CatalogEntry {
+	var links map[string]string
+	if m.MALID != "" || m.AniListID != "" {
+		links = make(map[string]string)
+		if m.MALID != "" {
+			links[models.SourceMAL] = m.MALID
+		}
+		if m.AniListID != "" {
+			links[models.SourceAniList] = m.AniListID
+		}
+	}
+	return models.CatalogEntry{
+		Source:      models.SourceMangaDex,
+		ExternalID:  m.ID,
+		Title:       m.Title,
+		Titles:      m.Titles,
+		Author:      m.Author,
+		Description: m.Description,
+		Status:      m.Status,
+		Genres:      m.Genres,
+		Chapters:    m.Chapters,
+		Year:        m.Year,
+		CoverURL:    m.CoverURL,
+		Links:       links,
+	}
+}
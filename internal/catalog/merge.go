@@ -0,0 +1,149 @@
+package catalog
+
+import (
+	"sort"
+	"strings"
+
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+)
+
+// Priority orders the sources of each catalog field, highest first. Sources
+// missing from a field's list rank below the listed ones, in name order.
+type Priority map[string][]string
+
+// DefaultPriority trusts hand-kept data for descriptive fields and MangaDex
+// for what changes as a series runs. Covers prefer MangaDex art over the
+// local files, which rarely carry one.
+var DefaultPriority = Priority{
+	models.FieldTitle:       {models.SourceAdmin, models.SourceLocal, models.SourceMangaDex},
+	models.FieldTitles:      {models.SourceAdmin, models.SourceLocal, models.SourceMangaDex},
+	models.FieldAuthor:      {models.SourceAdmin, models.SourceLocal, models.SourceMangaDex},
+	models.FieldDescription: {models.SourceAdmin, models.SourceLocal, models.SourceMangaDex},
+	models.FieldGenres:      {models.SourceAdmin, models.SourceLocal, models.SourceMangaDex},
+	models.FieldYear:        {models.SourceAdmin, models.SourceLocal, models.SourceMangaDex},
+	models.FieldCoverURL:    {models.SourceAdmin, models.SourceMangaDex, models.SourceLocal},
+	models.FieldStatus:      {models.SourceMangaDex, models.SourceAdmin, models.SourceLocal},
+	models.FieldChapters:    {models.SourceMangaDex, models.SourceAdmin, models.SourceLocal},
+}
+
+// WithOverrides returns a copy of the priority with the fields in
+// overrides ordered as given
+func (p Priority) WithOverrides(overrides map[string][]string) Priority {
+	merged := make(Priority, len(p)+len(overrides))
+	for field, sources := range p {
+		merged[field] = sources
+	}
+	for field, sources := range overrides {
+		merged[field] = sources
+	}
+	return merged
+}
+
+// rank is the position of a source in a field's order; lower wins
+func (p Priority) rank(field, source string) int {
+	for i, s := range p[field] {
+		if s == source {
+			return i
+		}
+	}
+	return len(p[field])
+}
+
+// best returns the highest-priority entry with a value for the field, nil
+// when no entry has one
+func (p Priority) best(field string, entries []models.CatalogEntry) *models.CatalogEntry {
+	var best *models.CatalogEntry
+	for i := range entries {
+		e := &entries[i]
+		if !e.Has(field) {
+			continue
+		}
+		if best == nil || p.rank(field, e.Source) < p.rank(field, best.Source) ||
+			(p.rank(field, e.Source) == p.rank(field, best.Source) && e.Source < best.Source) {
+			best = e
+		}
+	}
+	return best
+}
+
+// Merge applies the entries of a manga's sources to it. Each field takes
+// the value of the highest-priority entry that has one; fields no entry
+// has keep their value and source. Alternate titles are the union of the
+// current titles and every entry's, so merging never removes one, and a
+// cover stored in the cover store is never replaced by a remote one.
+//
+// It returns the merged alternate titles and the source of every field,
+// leaving titles and sources as they are.
+func (p Priority) Merge(manga *models.Manga, titles []models.MangaTitle, entries []models.CatalogEntry, sources map[string]string) ([]models.MangaTitle, map[string]string) {
+	merged := make(map[string]string, len(sources))
+	for field, source := range sources {
+		merged[field] = source
+	}
+
+	for _, field := range models.CatalogFields {
+		if field == models.FieldTitles {
+			continue
+		}
+		if field == models.FieldCoverURL && manga.CoverURL == covers.URL(manga.ID) {
+			continue
+		}
+		e := p.best(field, entries)
+		if e == nil {
+			continue
+		}
+		switch field {
+		case models.FieldTitle:
+			manga.Title = strings.TrimSpace(e.Title)
+		case models.FieldAuthor:
+			manga.Author = e.Author
+		case models.FieldDescription:
+			manga.Description = e.Description
+		case models.FieldStatus:
+			manga.Status = e.Status
+		case models.FieldGenres:
+			manga.Genres = append([]string(nil), e.Genres...)
+		case models.FieldChapters:
+			manga.TotalChapters = e.Chapters
+		case models.FieldYear:
+			manga.Year = e.Year
+		case models.FieldCoverURL:
+			manga.CoverURL = e.CoverURL
+		}
+		merged[field] = e.Source
+	}
+
+	// Titles are added source by source, so the primary title of a
+	// language comes from the highest-priority source that has one
+	ordered := append([]models.CatalogEntry(nil), entries...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return p.rank(models.FieldTitles, ordered[i].Source) < p.rank(models.FieldTitles, ordered[j].Source)
+	})
+	out := append([]models.MangaTitle(nil), titles...)
+	seen := make(map[string]bool)
+	primary := make(map[string]bool)
+	for _, t := range titles {
+		seen[titleKey(t)] = true
+		primary[models.NormalizeLanguage(t.Language)] = primary[models.NormalizeLanguage(t.Language)] || t.IsPrimary
+	}
+	for _, e := range ordered {
+		for _, t := range e.Titles {
+			lang := models.NormalizeLanguage(t.Language)
+			if lang == "" || strings.TrimSpace(t.Title) == "" || seen[titleKey(t)] {
+				continue
+			}
+			seen[titleKey(t)] = true
+			out = append(out, models.MangaTitle{Language: lang, Title: strings.TrimSpace(t.Title), IsPrimary: t.IsPrimary && !primary[lang]})
+			primary[lang] = primary[lang] || t.IsPrimary
+		}
+	}
+	if e := p.best(models.FieldTitles, entries); e != nil {
+		merged[models.FieldTitles] = e.Source
+	}
+	return out, merged
+}
+
+// titleKey identifies a title by language and text
+func titleKey(t models.MangaTitle) string {
+	return models.NormalizeLanguage(t.Language) + "\x00" + strings.TrimSpace(t.Title)
+}
@@ -0,0 +1,51 @@
+// Package catalog keeps the manga catalog in step with its metadata
+// sources. Providers such as MangaDex and the local JSON files supply
+// entries; the catalog keeps the latest entry of every source linked to a
+// manga and merges them field by field in source priority order, recording
+// which source supplied each field.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+
+	"mangahub/pkg/config"
+	"mangahub/pkg/models"
+)
+
+// ErrNotFound is returned when a provider has no entry with the given
+// external ID
+var ErrNotFound = errors.New("catalog entry not found")
+
+// MetadataProvider is a source of catalog metadata
+type MetadataProvider interface {
+	// Source is the name entries and external IDs of the provider are
+	// recorded under
+	Source() string
+	// Search returns up to limit entries whose title matches the query
+	Search(query string, limit int) ([]models.CatalogEntry, error)
+	// Fetch returns the entry with the given external ID, and ErrNotFound
+	// when the provider has none
+	Fetch(externalID string) (*models.CatalogEntry, error)
+	// Chapters lists the chapters of the entry with the given external ID
+	// in chapter order, without their MangaID. Providers that only know
+	// chapter counts return none.
+	Chapters(externalID string) ([]models.Chapter, error)
+}
+
+// NewProviders creates the providers enabled in the catalog configuration,
+// in the configured order
+func NewProviders(cfg config.CatalogConfig) ([]MetadataProvider, error) {
+	var providers []MetadataProvider
+	for _, name := range cfg.Providers {
+		switch name {
+		case models.SourceMangaDex:
+			providers = append(providers, NewMangaDexProvider(cfg.MangaDexURL, cfg.MangaDexCoverURL, cfg.Language))
+		case models.SourceLocal:
+			providers = append(providers, NewLocalProvider(cfg.LocalDir))
+		default:
+			return nil, fmt.Errorf("unknown metadata provider %q (use mangadex or local)", name)
+		}
+	}
+	return providers, nil
+}
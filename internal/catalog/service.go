@@ -0,0 +1,564 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// Actions taken on a catalog entry or manga
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionSkipped = "skipped"
+)
+
+// Result is what an import or refresh did with one entry or manga
+type Result struct {
+	Source     string `json:"source,omitempty"`
+	ExternalID string `json:"external_id,omitempty"`
+	Title      string `json:"title"`
+	MangaID    string `json:"manga_id,omitempty"`
+	Action     string `json:"action"`
+	// Note says why an entry was skipped, or what went wrong on the side
+	// such as a failed cover download or an unreachable provider
+	Note string `json:"note,omitempty"`
+}
+
+// Report is the outcome of an import or refresh, one result per entry or
+// manga in the order given
+type Report struct {
+	Results []Result `json:"results"`
+	Created int      `json:"created"`
+	Updated int      `json:"updated"`
+	Skipped int      `json:"skipped"`
+}
+
+// add counts a result and appends it
+func (r *Report) add(result Result) {
+	switch result.Action {
+	case ActionCreated:
+		r.Created++
+	case ActionUpdated:
+		r.Updated++
+	default:
+		r.Skipped++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// Service imports entries into the catalog and refreshes catalog manga
+// from their providers. Every write goes through the merge, so a manga
+// always holds the highest-priority value of each field among its sources.
+type Service struct {
+	manga       store.MangaStore
+	titles      store.TitleStore
+	externalIDs store.ExternalIDStore
+	covers      *covers.Store
+	priority    Priority
+	providers   []MetadataProvider
+
+	// LinkDuplicates links an imported entry to the catalog manga it
+	// duplicates by title and merges it into that manga instead of
+	// skipping it
+	LinkDuplicates bool
+}
+
+// NewService creates a catalog service writing to the given stores and
+// refreshing from the given providers. coverStore may be nil, in which case
+// remote cover URLs are stored as they are; a nil priority is
+// DefaultPriority.
+func NewService(manga store.MangaStore, titles store.TitleStore, externalIDs store.ExternalIDStore,
+	coverStore *covers.Store, priority Priority, providers ...MetadataProvider) *Service {
+	if priority == nil {
+		priority = DefaultPriority
+	}
+	return &Service{
+		manga:       manga,
+		titles:      titles,
+		externalIDs: externalIDs,
+		covers:      coverStore,
+		priority:    priority,
+		providers:   providers,
+	}
+}
+
+// Import upserts entries into the catalog and reports what happened to
+// each. An entry is matched by its source and external ID first; an
+// unlinked entry whose title matches a catalog manga is reported as a
+// duplicate and left alone unless LinkDuplicates is set; the others are
+// added under a slug of their title. It stops at the first store error.
+func (s *Service) Import(entries []models.CatalogEntry) (*Report, error) {
+	report := &Report{Results: []Result{}}
+	for _, e := range entries {
+		result, err := s.importOne(e)
+		if err != nil {
+			return report, fmt.Errorf("failed to import %s: %w", e.ExternalID, err)
+		}
+		report.add(result)
+	}
+	return report, nil
+}
+
+func (s *Service) importOne(e models.CatalogEntry) (Result, error) {
+	result := Result{Source: e.Source, ExternalID: e.ExternalID, Title: e.Title, Action: ActionSkipped}
+	if e.Source == "" || e.ExternalID == "" || strings.TrimSpace(e.Title) == "" {
+		result.Note = "no ID or title"
+		return result, nil
+	}
+
+	mangaID, err := s.externalIDs.Find(e.Source, e.ExternalID)
+	switch {
+	case err == nil:
+		result.MangaID = mangaID
+		return s.save(mangaID, e, result)
+	case !errors.Is(err, store.ErrExternalIDNotFound):
+		return result, err
+	}
+
+	duplicate, err := s.findDuplicate(e)
+	if err != nil {
+		return result, err
+	}
+	if duplicate != "" {
+		result.MangaID = duplicate
+		if !s.LinkDuplicates {
+			result.Note = "duplicate by title"
+			return result, nil
+		}
+		return s.save(duplicate, e, result)
+	}
+	return s.create(e, result)
+}
+
+// save keeps an entry as its source's view of a manga and merges the
+// manga again
+func (s *Service) save(mangaID string, e models.CatalogEntry, result Result) (Result, error) {
+	existing, err := s.manga.GetByID(mangaID)
+	if errors.Is(err, store.ErrMangaNotFound) {
+		result.Note = "linked manga is in the trash"
+		return result, nil
+	}
+	if err != nil {
+		return result, err
+	}
+	if err := s.saveEntry(mangaID, &e); err != nil {
+		return result, err
+	}
+	return s.merge(existing, result)
+}
+
+// saveEntry keeps an entry as its source's view of a manga and links the
+// manga's IDs at other sources the entry reports, unless they already link
+// to a manga
+func (s *Service) saveEntry(mangaID string, e *models.CatalogEntry) error {
+	if err := s.externalIDs.SaveEntry(mangaID, e); err != nil {
+		return err
+	}
+	for source, externalID := range e.Links {
+		_, err := s.externalIDs.Find(source, externalID)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, store.ErrExternalIDNotFound) {
+			return err
+		}
+		if err := s.externalIDs.Link(&models.ExternalID{Source: source, ExternalID: externalID, MangaID: mangaID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// create adds an entry to the catalog under the slug of its title, or the
+// slug with the start of its external ID appended when the slug is taken
+func (s *Service) create(e models.CatalogEntry, result Result) (Result, error) {
+	slug := strings.ReplaceAll(models.NormalizeTitle(e.Title), " ", "-")
+	ids := []string{slug, slug + "-" + strings.SplitN(e.ExternalID, "-", 2)[0]}
+	if slug == "" {
+		ids = []string{e.ExternalID}
+	}
+
+	for _, id := range ids {
+		manga := &models.Manga{ID: id}
+		titles, sources := s.priority.Merge(manga, nil, []models.CatalogEntry{e}, nil)
+		remoteCover := manga.CoverURL
+		err := s.manga.Create(manga)
+		if errors.Is(err, store.ErrAlreadyExists) {
+			continue
+		}
+		if err != nil {
+			return result, err
+		}
+
+		// The cover is stored once the ID is known to be this manga's
+		if manga.CoverURL, result.Note = s.storeCover(id, remoteCover); manga.CoverURL != remoteCover {
+			if err := s.manga.Update(manga); err != nil {
+				return result, err
+			}
+		}
+		if len(titles) > 0 {
+			if err := s.titles.Replace(id, titles); err != nil {
+				return result, err
+			}
+		}
+		if err := s.saveEntry(id, &e); err != nil {
+			return result, err
+		}
+		if err := s.externalIDs.SetFieldSources(id, sources); err != nil {
+			return result, err
+		}
+		result.MangaID = id
+		result.Action = ActionCreated
+		return result, nil
+	}
+
+	result.Note = "catalog ID " + ids[0] + " is taken"
+	return result, nil
+}
+
+// merge merges the saved entries of a manga into it and writes what
+// changed, reporting the manga as updated or unchanged
+func (s *Service) merge(existing *models.Manga, result Result) (Result, error) {
+	entries, err := s.externalIDs.Entries(existing.ID)
+	if err != nil {
+		return result, err
+	}
+	titles, err := s.titles.List(existing.ID)
+	if err != nil {
+		return result, err
+	}
+	sources, err := s.externalIDs.FieldSources(existing.ID)
+	if err != nil {
+		return result, err
+	}
+
+	updated := *existing
+	mergedTitles, mergedSources := s.priority.Merge(&updated, titles, entries, sources)
+	if updated.CoverURL != existing.CoverURL {
+		var note string
+		if updated.CoverURL, note = s.storeCover(existing.ID, updated.CoverURL); note != "" {
+			result.Note = note
+		}
+	}
+
+	changed := !sameManga(existing, &updated)
+	if changed {
+		if err := s.manga.Update(&updated); err != nil {
+			return result, err
+		}
+	}
+	if !sameTitles(titles, mergedTitles) {
+		if err := s.titles.Replace(existing.ID, mergedTitles); err != nil {
+			return result, err
+		}
+		changed = true
+	}
+	if !sameSources(sources, mergedSources) {
+		if err := s.externalIDs.SetFieldSources(existing.ID, mergedSources); err != nil {
+			return result, err
+		}
+	}
+
+	if changed {
+		result.Action = ActionUpdated
+	} else if result.Note == "" {
+		result.Note = "unchanged"
+	}
+	return result, nil
+}
+
+// Refresh fetches a manga again from every provider it is linked to and
+// merges the entries into it. Provider failures are noted on the result and
+// the other sources still apply.
+func (s *Service) Refresh(mangaID string) (Result, error) {
+	existing, err := s.manga.GetByID(mangaID)
+	if err != nil {
+		return Result{MangaID: mangaID, Action: ActionSkipped}, err
+	}
+	result := Result{Title: existing.Title, MangaID: mangaID, Action: ActionSkipped}
+
+	links, err := s.externalIDs.List(mangaID)
+	if err != nil {
+		return result, err
+	}
+
+	var notes []string
+	for _, p := range s.providers {
+		ids, linked := linkedIDs(p, mangaID, links)
+		for _, id := range ids {
+			entry, err := p.Fetch(id)
+			if errors.Is(err, ErrNotFound) {
+				if linked {
+					notes = append(notes, "gone from "+p.Source())
+				}
+				continue
+			}
+			if err != nil {
+				notes = append(notes, p.Source()+": "+err.Error())
+				continue
+			}
+			if err := s.saveEntry(mangaID, entry); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	result, err = s.merge(existing, result)
+	if len(notes) > 0 {
+		if result.Note != "" && result.Note != "unchanged" {
+			notes = append(notes, result.Note)
+		}
+		result.Note = strings.Join(notes, "; ")
+	}
+	return result, err
+}
+
+// linkedIDs returns the external IDs a manga has at a provider and whether
+// it is linked there. Manga not linked to the local provider are looked up
+// there by their own ID, which is how the loader keyed them.
+func linkedIDs(p MetadataProvider, mangaID string, links []models.ExternalID) ([]string, bool) {
+	var ids []string
+	for _, link := range links {
+		if link.Source == p.Source() {
+			ids = append(ids, link.ExternalID)
+		}
+	}
+	if len(ids) == 0 && p.Source() == models.SourceLocal {
+		return []string{mangaID}, false
+	}
+	return ids, len(ids) > 0
+}
+
+// RefreshAll refreshes the given manga, every manga in the catalog when
+// none are given, and reports what happened to each. Manga that are not
+// in the catalog are reported as skipped; it stops at the first store
+// error.
+func (s *Service) RefreshAll(mangaIDs []string) (*Report, error) {
+	if len(mangaIDs) == 0 {
+		all, err := s.manga.List(-1, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range all {
+			mangaIDs = append(mangaIDs, m.ID)
+		}
+		sort.Strings(mangaIDs)
+	}
+
+	report := &Report{Results: []Result{}}
+	for _, id := range mangaIDs {
+		result, err := s.Refresh(id)
+		if errors.Is(err, store.ErrMangaNotFound) {
+			result.Note = "not in the catalog"
+			report.add(result)
+			continue
+		}
+		if err != nil {
+			return report, fmt.Errorf("failed to refresh %s: %w", id, err)
+		}
+		report.add(result)
+	}
+	return report, nil
+}
+
+// RecordEdit keeps an admin's edit of a manga as the admin source's entry
+// and marks the fields it set as supplied by the admin, so later merges
+// rank them by the admin source's priority. Alternate titles are edited on
+// their own and only recorded when the manga carries them.
+func (s *Service) RecordEdit(manga *models.Manga) error {
+	entry := models.CatalogEntry{
+		Source:      models.SourceAdmin,
+		ExternalID:  manga.ID,
+		Title:       manga.Title,
+		Titles:      manga.Titles,
+		Author:      manga.Author,
+		Description: manga.Description,
+		Status:      manga.Status,
+		Genres:      manga.Genres,
+		Chapters:    manga.TotalChapters,
+		Year:        manga.Year,
+		CoverURL:    manga.CoverURL,
+	}
+	if err := s.externalIDs.SaveEntry(manga.ID, &entry); err != nil {
+		return err
+	}
+
+	sources, err := s.externalIDs.FieldSources(manga.ID)
+	if err != nil {
+		return err
+	}
+	for _, field := range models.CatalogFields {
+		switch {
+		case entry.Has(field):
+			sources[field] = models.SourceAdmin
+		case field != models.FieldTitles:
+			delete(sources, field)
+		}
+	}
+	return s.externalIDs.SetFieldSources(manga.ID, sources)
+}
+
+// findDuplicate returns the catalog manga whose title or alternate titles
+// match one of the entry's titles once normalized, "" when there is none.
+// Manga already linked to another ID at the entry's source are different
+// series that share a title.
+func (s *Service) findDuplicate(e models.CatalogEntry) (string, error) {
+	titles := []string{e.Title}
+	for _, t := range e.Titles {
+		titles = append(titles, t.Title)
+	}
+	return s.findByTitle(titles, func(links []models.ExternalID) bool {
+		for _, link := range links {
+			if link.Source == e.Source {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// FindByTitle returns the catalog manga whose main or alternate title is
+// one of the given titles once normalized, or "" when there is none
+func (s *Service) FindByTitle(titles ...string) (string, error) {
+	return s.findByTitle(titles, nil)
+}
+
+// findByTitle searches the catalog for each title in turn and returns the
+// first manga matching any of them that accept, when given, takes
+func (s *Service) findByTitle(titles []string, accept func(links []models.ExternalID) bool) (string, error) {
+	wanted := make(map[string]bool, len(titles))
+	var queries []string
+	for _, title := range titles {
+		normalized := models.NormalizeTitle(title)
+		if normalized == "" || wanted[normalized] {
+			continue
+		}
+		wanted[normalized] = true
+		queries = append(queries, title)
+	}
+
+	seen := make(map[string]bool)
+	for _, query := range queries {
+		candidates, err := s.manga.Search(&models.MangaFilter{Query: query, SortBy: "relevance", Limit: 10})
+		if err != nil {
+			return "", err
+		}
+		for _, c := range candidates {
+			if seen[c.ID] {
+				continue
+			}
+			seen[c.ID] = true
+			if !wanted[models.NormalizeTitle(c.Title)] {
+				titles, err := s.titles.List(c.ID)
+				if err != nil {
+					return "", err
+				}
+				if !anyTitle(titles, wanted) {
+					continue
+				}
+			}
+			if accept == nil {
+				return c.ID, nil
+			}
+
+			links, err := s.externalIDs.List(c.ID)
+			if err != nil {
+				return "", err
+			}
+			if accept(links) {
+				return c.ID, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// storeCover downloads a remote cover into the cover store and returns the
+// catalog cover URL, the remote URL when there is no store or the download
+// failed, and a note on failures
+func (s *Service) storeCover(mangaID, coverURL string) (string, string) {
+	if !strings.HasPrefix(coverURL, "http") || s.covers == nil {
+		return coverURL, ""
+	}
+	if err := s.covers.Download(mangaID, coverURL); err != nil {
+		return coverURL, "cover not stored: " + err.Error()
+	}
+	return covers.URL(mangaID), ""
+}
+
+// sameManga reports whether a merge leaves the catalog fields as they are.
+// Genres compare by normalized name, as the taxonomy may spell them
+// differently.
+func sameManga(a, b *models.Manga) bool {
+	if a.Title != b.Title || a.Author != b.Author || a.Description != b.Description ||
+		a.Status != b.Status || a.Year != b.Year || a.TotalChapters != b.TotalChapters ||
+		a.CoverURL != b.CoverURL || len(a.Genres) != len(b.Genres) {
+		return false
+	}
+	genres := make(map[string]bool, len(a.Genres))
+	for _, g := range a.Genres {
+		genres[models.NormalizeTitle(g)] = true
+	}
+	for _, g := range b.Genres {
+		if !genres[models.NormalizeTitle(g)] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameTitles reports whether two title lists hold the same titles per
+// language
+func sameTitles(a, b []models.MangaTitle) bool {
+	key := func(titles []models.MangaTitle) []string {
+		keys := make([]string, 0, len(titles))
+		seen := make(map[string]bool)
+		for _, t := range titles {
+			k := titleKey(t)
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	ka, kb := key(a), key(b)
+	if len(ka) != len(kb) {
+		return false
+	}
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sameSources reports whether two field source maps are equal
+func sameSources(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for field, source := range a {
+		if b[field] != source {
+			return false
+		}
+	}
+	return true
+}
+
+func anyTitle(titles []models.MangaTitle, wanted map[string]bool) bool {
+	for _, t := range titles {
+		if wanted[models.NormalizeTitle(t.Title)] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,364 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"mangahub/pkg/client"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// Pusher delivers a notification as it happens, on top of the one stored
+// for each subscriber
+type Pusher interface {
+	Push(payload models.NotificationPayload) error
+}
+
+// UDPPusher pushes notifications to the UDP notification server, which
+// broadcasts them to its registered clients
+type UDPPusher struct {
+	addr string
+}
+
+// NewUDPPusher creates a pusher for the UDP server at addr (host:port)
+func NewUDPPusher(addr string) *UDPPusher {
+	return &UDPPusher{addr: addr}
+}
+
+// Push sends a notification to the UDP server
+func (p *UDPPusher) Push(payload models.NotificationPayload) error {
+	c := client.NewUDPClient(p.addr)
+	if err := c.Connect(); err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err := c.SendNotification(payload); err != nil {
+		return err
+	}
+	// The server registers whoever sends it a notification, and this
+	// connection is about to go away
+	return c.Unregister()
+}
+
+// Release is a new chapter of a manga found by the watcher
+type Release struct {
+	MangaID  string  `json:"manga_id"`
+	Title    string  `json:"title"`
+	Previous float64 `json:"previous"`
+	Chapter  float64 `json:"chapter"`
+	// Notified is the number of subscribers a notification was stored for
+	Notified int `json:"notified"`
+	// PushError says why the notification could not be pushed
+	PushError string `json:"push_error,omitempty"`
+}
+
+// PollReport is the outcome of one poll
+type PollReport struct {
+	Checked  int       `json:"checked"`
+	Releases []Release `json:"releases"`
+	// Failed maps the providers that failed during the poll to their error
+	Failed map[string]string `json:"failed,omitempty"`
+	// Waiting maps the providers left alone while they back off to when
+	// they are polled again
+	Waiting map[string]time.Time `json:"waiting,omitempty"`
+}
+
+// sourceState tracks the failures of a provider in a row
+type sourceState struct {
+	failures int
+	retryAt  time.Time
+}
+
+// Watcher polls the metadata providers for new chapters of the manga users
+// are subscribed to. Each poll pulls those manga again like Refresh, adds
+// the chapters the providers list to the chapter store, and notifies the
+// subscribers who want chapter releases when the latest chapter moved on.
+// A provider that fails is left alone for a backoff that doubles with
+// every failure in a row, up to a maximum.
+type Watcher struct {
+	catalog       *Service
+	chapters      store.ChapterStore
+	notifications store.NotificationStore
+	pusher        Pusher
+	backoff       time.Duration
+	maxBackoff    time.Duration
+
+	mu      sync.Mutex
+	sources map[string]*sourceState
+
+	// now is the clock backoffs are measured on
+	now func() time.Time
+}
+
+// NewWatcher creates a watcher polling the providers of the catalog
+// service. pusher may be nil, in which case notifications are only stored.
+func NewWatcher(service *Service, chapters store.ChapterStore, notifications store.NotificationStore,
+	pusher Pusher, backoff, maxBackoff time.Duration) *Watcher {
+	if maxBackoff < backoff {
+		maxBackoff = backoff
+	}
+	return &Watcher{
+		catalog:       service,
+		chapters:      chapters,
+		notifications: notifications,
+		pusher:        pusher,
+		backoff:       backoff,
+		maxBackoff:    maxBackoff,
+		sources:       make(map[string]*sourceState),
+		now:           time.Now,
+	}
+}
+
+// Poll checks every subscribed manga once. Provider failures back that
+// provider off and the poll goes on; it stops at the first store error.
+func (w *Watcher) Poll() (*PollReport, error) {
+	report := &PollReport{Releases: []Release{}}
+	ids, err := w.notifications.SubscribedManga()
+	if err != nil {
+		return report, err
+	}
+
+	// Providers backing off sit the whole poll out
+	var ready []MetadataProvider
+	for _, p := range w.catalog.providers {
+		if retryAt, waiting := w.waiting(p.Source()); waiting {
+			if report.Waiting == nil {
+				report.Waiting = make(map[string]time.Time)
+			}
+			report.Waiting[p.Source()] = retryAt
+			continue
+		}
+		ready = append(ready, p)
+	}
+
+	for _, id := range ids {
+		release, err := w.check(id, ready, report)
+		if err != nil {
+			return report, fmt.Errorf("failed to check %s: %w", id, err)
+		}
+		if release != nil {
+			report.Releases = append(report.Releases, *release)
+		}
+	}
+	for _, p := range ready {
+		if _, failed := report.Failed[p.Source()]; !failed {
+			w.succeeded(p.Source())
+		}
+	}
+	return report, nil
+}
+
+// check pulls a manga from the ready providers and notifies its
+// subscribers when its latest chapter moved on. The first time a manga
+// gets chapters nothing is sent, as they are not new releases.
+func (w *Watcher) check(mangaID string, providers []MetadataProvider, report *PollReport) (*Release, error) {
+	existing, err := w.catalog.manga.GetByID(mangaID)
+	if errors.Is(err, store.ErrMangaNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	report.Checked++
+
+	previous, err := w.latest(existing)
+	if err != nil {
+		return nil, err
+	}
+	links, err := w.catalog.externalIDs.List(mangaID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range providers {
+		if _, failed := report.Failed[p.Source()]; failed {
+			continue
+		}
+		ids, _ := linkedIDs(p, mangaID, links)
+		for _, externalID := range ids {
+			entry, chapters, err := pull(p, externalID)
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				if report.Failed == nil {
+					report.Failed = make(map[string]string)
+				}
+				report.Failed[p.Source()] = err.Error()
+				w.failed(p.Source())
+				break
+			}
+			if err := w.catalog.saveEntry(mangaID, entry); err != nil {
+				return nil, err
+			}
+			if err := w.addChapters(mangaID, chapters); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if _, err := w.catalog.merge(existing, Result{}); err != nil {
+		return nil, err
+	}
+	updated, err := w.catalog.manga.GetByID(mangaID)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := w.latest(updated)
+	if err != nil {
+		return nil, err
+	}
+	if previous <= 0 || latest <= previous {
+		return nil, nil
+	}
+	return w.notify(updated, previous, latest)
+}
+
+// pull fetches a manga and its chapter list from a provider
+func pull(p MetadataProvider, externalID string) (*models.CatalogEntry, []models.Chapter, error) {
+	entry, err := p.Fetch(externalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	chapters, err := p.Chapters(externalID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, chapters, nil
+}
+
+// latest returns the number of the latest chapter of a manga: its chapter
+// count, or the highest chapter listed when that is higher
+func (w *Watcher) latest(manga *models.Manga) (float64, error) {
+	latest := float64(manga.TotalChapters)
+	last, err := w.chapters.List(models.ChapterFilter{MangaID: manga.ID, Order: "desc", Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	if len(last) > 0 && last[0].Number > latest {
+		latest = last[0].Number
+	}
+	return latest, nil
+}
+
+// addChapters adds the chapters a manga does not have yet in their language
+func (w *Watcher) addChapters(mangaID string, chapters []models.Chapter) error {
+	if len(chapters) == 0 {
+		return nil
+	}
+	existing, err := w.chapters.List(models.ChapterFilter{MangaID: mangaID})
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(existing))
+	for _, ch := range existing {
+		have[chapterKey(ch)] = true
+	}
+
+	for _, ch := range chapters {
+		ch.MangaID = mangaID
+		if ch.Language == "" {
+			ch.Language = models.DefaultChapterLanguage
+		}
+		if have[chapterKey(ch)] {
+			continue
+		}
+		if err := w.chapters.Create(&ch); err != nil && !errors.Is(err, store.ErrAlreadyExists) {
+			return err
+		}
+		have[chapterKey(ch)] = true
+	}
+	return nil
+}
+
+// chapterKey identifies a chapter of a manga by number and language
+func chapterKey(ch models.Chapter) string {
+	return strconv.FormatFloat(ch.Number, 'f', -1, 64) + "/" + ch.Language
+}
+
+// notify stores a chapter_release notification for every subscriber who
+// wants them and pushes one to the UDP server
+func (w *Watcher) notify(manga *models.Manga, previous, latest float64) (*Release, error) {
+	release := &Release{MangaID: manga.ID, Title: manga.Title, Previous: previous, Chapter: latest}
+	userIDs, err := w.notifications.ReleaseSubscribers(manga.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		return release, nil
+	}
+
+	number := strconv.FormatFloat(latest, 'f', -1, 64)
+	message := fmt.Sprintf("Chapter %s of %s is out", number, manga.Title)
+	for _, userID := range userIDs {
+		err := w.notifications.CreateNotification(&models.Notification{
+			UserID:  userID,
+			Type:    models.NotificationChapterRelease,
+			MangaID: manga.ID,
+			Message: message,
+			Data:    map[string]interface{}{"chapter": latest, "previous": previous},
+		})
+		if err != nil {
+			return nil, err
+		}
+		release.Notified++
+	}
+
+	if w.pusher != nil {
+		err := w.pusher.Push(models.NotificationPayload{
+			Type:      models.NotificationChapterRelease,
+			MangaID:   manga.ID,
+			Message:   message,
+			Timestamp: w.now().Unix(),
+		})
+		if err != nil {
+			release.PushError = err.Error()
+		}
+	}
+	return release, nil
+}
+
+// waiting reports whether a provider is backing off and until when
+func (w *Watcher) waiting(source string) (time.Time, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	state, ok := w.sources[source]
+	if !ok || !w.now().Before(state.retryAt) {
+		return time.Time{}, false
+	}
+	return state.retryAt, true
+}
+
+// failed backs a provider off for backoff doubled with each failure in a
+// row, capped at maxBackoff
+func (w *Watcher) failed(source string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	state, ok := w.sources[source]
+	if !ok {
+		state = &sourceState{}
+		w.sources[source] = state
+	}
+	state.failures++
+
+	delay := w.backoff
+	for i := 1; i < state.failures && delay < w.maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > w.maxBackoff {
+		delay = w.maxBackoff
+	}
+	state.retryAt = w.now().Add(delay)
+}
+
+// succeeded clears the failures of a provider
+func (w *Watcher) succeeded(source string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.sources, source)
+}
@@ -0,0 +1,247 @@
+package catalog
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// fakeProvider serves one manga as MangaDex would, with a chapter count
+// and an outage switch
+type fakeProvider struct {
+	entry   models.CatalogEntry
+	down    bool
+	fetches int
+}
+
+func (p *fakeProvider) Source() string { return models.SourceMangaDex }
+
+func (p *fakeProvider) Search(query string, limit int) ([]models.CatalogEntry, error) {
+	return []models.CatalogEntry{p.entry}, nil
+}
+
+func (p *fakeProvider) Fetch(externalID string) (*models.CatalogEntry, error) {
+	p.fetches++
+	if p.down {
+		return nil, errors.New("mangadex is down")
+	}
+	if externalID != p.entry.ExternalID {
+		return nil, ErrNotFound
+	}
+	entry := p.entry
+	return &entry, nil
+}
+
+func (p *fakeProvider) Chapters(externalID string) ([]models.Chapter, error) {
+	return nil, nil
+}
+
+// fakePusher records the notifications pushed to it
+type fakePusher struct {
+	pushed []models.NotificationPayload
+}
+
+func (p *fakePusher) Push(payload models.NotificationPayload) error {
+	p.pushed = append(p.pushed, payload)
+	return nil
+}
+
+// recordingNotifications keeps the notifications stored through it, which
+// the notification store has no way to list
+type recordingNotifications struct {
+	store.NotificationStore
+	created []models.Notification
+}
+
+func (r *recordingNotifications) CreateNotification(n *models.Notification) error {
+	if err := r.NotificationStore.CreateNotification(n); err != nil {
+		return err
+	}
+	r.created = append(r.created, *n)
+	return nil
+}
+
+// fakeClock is a clock that only moves when told to
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// watcherFixture is a watcher over in-memory stores holding Berserk,
+// linked to the fake provider, with no chapters yet
+type watcherFixture struct {
+	watcher       *Watcher
+	provider      *fakeProvider
+	pusher        *fakePusher
+	notifications *recordingNotifications
+	clock         *fakeClock
+	stores        *store.Stores
+}
+
+func newWatcherFixture(t *testing.T, backoff, maxBackoff time.Duration) *watcherFixture {
+	t.Helper()
+	stores := store.NewMemoryStores()
+	if err := stores.Manga.Create(&models.Manga{ID: "berserk", Title: "Berserk", Status: "ongoing"}); err != nil {
+		t.Fatalf("seed manga: %v", err)
+	}
+	err := stores.ExternalIDs.Link(&models.ExternalID{Source: models.SourceMangaDex, ExternalID: "md-berserk", MangaID: "berserk"})
+	if err != nil {
+		t.Fatalf("link manga: %v", err)
+	}
+
+	provider := &fakeProvider{entry: models.CatalogEntry{Source: models.SourceMangaDex, ExternalID: "md-berserk",
+		Title: "Berserk", Status: "ongoing", Chapters: 374}}
+	pusher := &fakePusher{}
+	notifications := &recordingNotifications{NotificationStore: stores.Notifications}
+	clock := &fakeClock{now: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	service := NewService(stores.Manga, stores.Titles, stores.ExternalIDs, nil, nil, provider)
+	watcher := NewWatcher(service, stores.Chapters, notifications, pusher, backoff, maxBackoff)
+	watcher.now = clock.Now
+	return &watcherFixture{watcher: watcher, provider: provider, pusher: pusher,
+		notifications: notifications, clock: clock, stores: stores}
+}
+
+func (f *watcherFixture) poll(t *testing.T) *PollReport {
+	t.Helper()
+	report, err := f.watcher.Poll()
+	if err != nil {
+		t.Fatalf("poll: %v", err)
+	}
+	return report
+}
+
+// subscribe subscribes a user to Berserk, saving their chapter release
+// preference unless it is nil
+func (f *watcherFixture) subscribe(t *testing.T, userID string, releases *bool) {
+	t.Helper()
+	if err := f.stores.Notifications.Subscribe(userID, "berserk"); err != nil {
+		t.Fatalf("subscribe %s: %v", userID, err)
+	}
+	if releases == nil {
+		return
+	}
+	prefs := &models.NotificationPreferences{UserID: userID, ChapterReleases: *releases}
+	if err := f.stores.Notifications.SavePreferences(prefs); err != nil {
+		t.Fatalf("save preferences of %s: %v", userID, err)
+	}
+}
+
+func TestWatcherFirstPollDoesNotNotify(t *testing.T) {
+	f := newWatcherFixture(t, time.Minute, time.Hour)
+	f.subscribe(t, "alice", nil)
+
+	report := f.poll(t)
+	if report.Checked != 1 || len(report.Releases) != 0 {
+		t.Errorf("first poll = %+v, want one manga checked and no releases", report)
+	}
+	if len(f.notifications.created) != 0 || len(f.pusher.pushed) != 0 {
+		t.Errorf("first poll stored %d and pushed %d notifications, want none",
+			len(f.notifications.created), len(f.pusher.pushed))
+	}
+	manga, err := f.stores.Manga.GetByID("berserk")
+	if err != nil {
+		t.Fatalf("get manga: %v", err)
+	}
+	if manga.TotalChapters != 374 {
+		t.Errorf("total chapters = %d after the first poll, want 374", manga.TotalChapters)
+	}
+
+	if report := f.poll(t); len(report.Releases) != 0 {
+		t.Errorf("poll without a new chapter = %+v, want no releases", report)
+	}
+}
+
+func TestWatcherNotifiesOptedInSubscribers(t *testing.T) {
+	f := newWatcherFixture(t, time.Minute, time.Hour)
+	on, off := true, false
+	f.subscribe(t, "alice", nil)
+	f.subscribe(t, "bob", &off)
+	f.subscribe(t, "carol", &on)
+	f.poll(t)
+
+	f.provider.entry.Chapters = 375
+	report := f.poll(t)
+	if len(report.Releases) != 1 {
+		t.Fatalf("releases = %+v, want one", report.Releases)
+	}
+	release := report.Releases[0]
+	if release.MangaID != "berserk" || release.Previous != 374 || release.Chapter != 375 ||
+		release.Notified != 2 || release.PushError != "" {
+		t.Errorf("release = %+v", release)
+	}
+
+	var notified []string
+	for _, n := range f.notifications.created {
+		notified = append(notified, n.UserID)
+		if n.Type != models.NotificationChapterRelease || n.MangaID != "berserk" || n.Message != "Chapter 375 of Berserk is out" {
+			t.Errorf("notification = %+v", n)
+		}
+	}
+	if len(notified) != 2 || notified[0] != "alice" || notified[1] != "carol" {
+		t.Errorf("notified %v, want alice and carol but not bob, who opted out", notified)
+	}
+}
+
+func TestWatcherPushesRelease(t *testing.T) {
+	f := newWatcherFixture(t, time.Minute, time.Hour)
+	f.subscribe(t, "alice", nil)
+	f.poll(t)
+
+	f.provider.entry.Chapters = 375
+	f.clock.advance(time.Hour)
+	f.poll(t)
+
+	want := models.NotificationPayload{
+		Type:      models.NotificationChapterRelease,
+		MangaID:   "berserk",
+		Message:   "Chapter 375 of Berserk is out",
+		Timestamp: f.clock.now.Unix(),
+	}
+	if len(f.pusher.pushed) != 1 || f.pusher.pushed[0] != want {
+		t.Errorf("pushed %+v, want %+v", f.pusher.pushed, want)
+	}
+}
+
+func TestWatcherBacksOff(t *testing.T) {
+	f := newWatcherFixture(t, time.Minute, 5*time.Minute)
+	f.subscribe(t, "alice", nil)
+	f.provider.down = true
+
+	// The backoff doubles with every failure in a row up to the maximum
+	for i, delay := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		report := f.poll(t)
+		if _, failed := report.Failed[models.SourceMangaDex]; !failed {
+			t.Fatalf("poll %d: failed = %v, want mangadex", i+1, report.Failed)
+		}
+
+		fetches := f.provider.fetches
+		f.clock.advance(delay - time.Second)
+		report = f.poll(t)
+		if retryAt := report.Waiting[models.SourceMangaDex]; !retryAt.Equal(f.clock.now.Add(time.Second)) {
+			t.Errorf("after failure %d: waiting until %v, want %v later", i+1, report.Waiting, delay)
+		}
+		if f.provider.fetches != fetches {
+			t.Errorf("after failure %d: the provider was polled while backing off", i+1)
+		}
+		f.clock.advance(time.Second)
+	}
+
+	// A successful poll resets the backoff
+	f.provider.down = false
+	if report := f.poll(t); len(report.Failed) != 0 || len(report.Waiting) != 0 {
+		t.Fatalf("poll after recovery = %+v", report)
+	}
+	f.provider.down = true
+	f.poll(t)
+	report := f.poll(t)
+	if retryAt := report.Waiting[models.SourceMangaDex]; !retryAt.Equal(f.clock.now.Add(time.Minute)) {
+		t.Errorf("after recovering and failing again: waiting until %v, want a minute later", report.Waiting)
+	}
+}
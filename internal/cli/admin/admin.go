@@ -0,0 +1,36 @@
+package admin
+
+import "github.com/spf13/cobra"
+
+const defaultDBPath = "./data/mangahub.db"
+
+// AdminCmd is the main admin command (parent/root for admin subcommands).
+var AdminCmd = &cobra.Command{
+	Use:   "admin",
+	Short: "Catalog maintenance and user roles on the local database",
+	Long: `Maintenance tasks for catalog administrators, run directly against the local
+SQLite database. They act as the logged-in user, whose role in that database
+has to allow them; see 'mangahub admin users --help'.`,
+}
+
+// mangaCmd groups the admin commands for catalog manga
+var mangaCmd = &cobra.Command{
+	Use:   "manga",
+	Short: "Find and merge duplicate catalog manga",
+	Long: `Loading the same series from two sources, such as manga_manual.json and a
+MangaDex export, leaves it in the catalog twice under different IDs, with
+library entries and subscriptions split between them. List the likely
+duplicates with 'duplicates' and fold each into the manga to keep with 'merge'.`,
+}
+
+func init() {
+	AdminCmd.AddCommand(mangaCmd)
+}
+
+// truncateString truncates a string to max length with ellipsis
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
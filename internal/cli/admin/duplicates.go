@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/catalog"
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// duplicatesCmd handles `mangahub admin manga duplicates`.
+var duplicatesCmd = &cobra.Command{
+	Use:   "duplicates",
+	Short: "List likely duplicate manga",
+	Long: `List catalog manga that look like the same series: their main or alternate
+titles match once case, punctuation and spacing are ignored, and their authors
+match in any word order or are unknown on one side.
+
+Each group shows the sources every manga is linked to and how many library
+entries and subscriptions point at it, to help pick the one to keep.
+
+Examples:
+  mangahub admin manga duplicates
+  mangahub admin manga merge one-piece a1c7c817-4e59-43b7-9365-09675a149a6f`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		stores := store.NewSQLiteStores(db)
+		service := catalog.NewService(stores.Manga, stores.Titles, stores.ExternalIDs, nil, nil)
+		groups, err := service.Duplicates()
+		if err != nil {
+			return fmt.Errorf("failed to find duplicates: %w", err)
+		}
+		if len(groups) == 0 {
+			fmt.Println("✓ No likely duplicates in the catalog.")
+			return nil
+		}
+
+		fmt.Println("┌──────────────────────────────────────┬──────────────────────────────┬────────────────────┬──────────────────┬─────────┬──────┐")
+		fmt.Printf("│ %-36s │ %-28s │ %-18s │ %-16s │ %-7s │ %-4s │\n", "ID", "TITLE", "AUTHOR", "LINKS", "READERS", "SUBS")
+		for _, group := range groups {
+			fmt.Println("├──────────────────────────────────────┼──────────────────────────────┼────────────────────┼──────────────────┼─────────┼──────┤")
+			for _, m := range group.Manga {
+				links, err := stores.ExternalIDs.List(m.ID)
+				if err != nil {
+					return err
+				}
+				readers, subs, err := countReferences(db, m.ID)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("│ %-36s │ %-28s │ %-18s │ %-16s │ %7d │ %4d │\n",
+					truncateString(m.ID, 36), truncateString(m.Title, 28), truncateString(m.Author, 18),
+					truncateString(formatLinks(links), 16), readers, subs)
+			}
+		}
+		fmt.Println("└──────────────────────────────────────┴──────────────────────────────┴────────────────────┴──────────────────┴─────────┴──────┘")
+		fmt.Printf("\n🔍 %d groups of likely duplicates\n", len(groups))
+		fmt.Println("Merge one into another with: mangahub admin manga merge <keep> <drop>")
+		return nil
+	},
+}
+
+func init() {
+	mangaCmd.AddCommand(duplicatesCmd)
+	duplicatesCmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+}
+
+// countReferences counts the library entries and subscriptions of a manga
+func countReferences(db *database.Database, mangaID string) (int, int, error) {
+	var readers, subs int
+	err := db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM user_progress WHERE manga_id = ?1),
+			(SELECT COUNT(*) FROM notification_subscriptions WHERE manga_id = ?1)`, mangaID).Scan(&readers, &subs)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count references to %s: %w", mangaID, err)
+	}
+	return readers, subs, nil
+}
+
+// formatLinks lists the sources a manga is linked to, "-" for none
+func formatLinks(links []models.ExternalID) string {
+	if len(links) == 0 {
+		return "-"
+	}
+	sources := make([]string, len(links))
+	for i, link := range links {
+		sources[i] = link.Source
+	}
+	return strings.Join(sources, ", ")
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/catalog"
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// mergeCmd handles `mangahub admin manga merge`.
+var mergeCmd = &cobra.Command{
+	Use:   "merge <keep> <drop>",
+	Short: "Merge a duplicate manga into another",
+	Long: `Fold the duplicate manga <drop> into <keep> and delete it.
+
+In one transaction, the library entries, notification subscriptions, chat room
+messages, notifications and progress history of <drop> are moved over to
+<keep>, along with the chapters, alternate titles and source links <keep> does
+not have yet. A user with entries for both keeps one entry: the furthest
+chapter, completed if either was, and the rating and notes of <keep>'s entry
+when set. Fields <keep> lacks, such as its author or cover, are filled in from
+<drop>, and the sources it is now linked to are merged again.
+
+<drop>'s ID keeps redirecting to <keep>: the API answers requests for it with
+a 301 and applies library changes made under it to <keep>.
+
+Examples:
+  mangahub admin manga merge one-piece a1c7c817-4e59-43b7-9365-09675a149a6f
+  mangahub admin manga merge one-piece onepiece --force`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		force, _ := cmd.Flags().GetBool("force")
+		keepID, dropID := args[0], args[1]
+		if keepID == dropID {
+			return fmt.Errorf("cannot merge %s into itself", keepID)
+		}
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := localdb.Authorize(db, models.PermMergeManga); err != nil {
+			return err
+		}
+
+		stores := store.NewSQLiteStores(db)
+		for _, id := range args {
+			m, err := stores.Manga.GetByID(id)
+			if errors.Is(err, store.ErrMangaNotFound) {
+				return fmt.Errorf("manga %s not found (trashed manga must be restored first)", id)
+			}
+			if err != nil {
+				return err
+			}
+			readers, subs, err := countReferences(db, id)
+			if err != nil {
+				return err
+			}
+			role := "Keep"
+			if id == dropID {
+				role = "Drop"
+			}
+			author := m.Author
+			if author == "" {
+				author = "unknown author"
+			}
+			fmt.Printf("%s: %s — %s by %s (%d library entries, %d subscriptions)\n",
+				role, m.ID, m.Title, author, readers, subs)
+		}
+
+		if !force {
+			fmt.Printf("\nThis will move everything pointing at %s over to %s and delete %s.\n", dropID, keepID, dropID)
+			fmt.Print("\nType 'yes' to confirm: ")
+
+			reader := bufio.NewReader(os.Stdin)
+			input, _ := reader.ReadString('\n')
+			input = strings.TrimSpace(strings.ToLower(input))
+
+			if input != "yes" && input != "y" {
+				fmt.Println("Merge cancelled.")
+				return nil
+			}
+		}
+
+		if dataDir == "" {
+			dataDir = filepath.Dir(dbPath)
+		}
+		service := catalog.NewService(stores.Manga, stores.Titles, stores.ExternalIDs, covers.NewStore(dataDir, 0), nil)
+		result, err := service.Merge(stores.Merges, keepID, dropID)
+		if err != nil {
+			return fmt.Errorf("failed to merge %s into %s: %w", dropID, keepID, err)
+		}
+
+		fmt.Printf("\n✓ Merged %s into %s\n", dropID, keepID)
+		fmt.Printf("  Library entries:  %d moved, %d merged\n", result.LibraryEntries, result.MergedEntries)
+		fmt.Printf("  Subscriptions:    %d\n", result.Subscriptions)
+		fmt.Printf("  Chat messages:    %d\n", result.ChatMessages)
+		fmt.Printf("  Notifications:    %d\n", result.Notifications)
+		fmt.Printf("  Progress events:  %d\n", result.ProgressEvents)
+		fmt.Printf("  Chapters added:   %d\n", result.Chapters)
+		fmt.Printf("  Titles added:     %d\n", result.Titles)
+		fmt.Printf("  Source links:     %d\n", result.ExternalIDs)
+		if len(result.Filled) > 0 {
+			fmt.Printf("  Filled in:        %s\n", strings.Join(result.Filled, ", "))
+		}
+		if result.Redirects > 0 {
+			fmt.Printf("  Redirects moved:  %d\n", result.Redirects)
+		}
+		if result.Note != "" {
+			fmt.Printf("⚠ %s\n", result.Note)
+		}
+		fmt.Printf("\n%s now redirects to %s.\n", dropID, keepID)
+		return nil
+	},
+}
+
+func init() {
+	mangaCmd.AddCommand(mergeCmd)
+	mergeCmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+	mergeCmd.Flags().String("data-dir", "", "Directory covers are stored under (default: the database's directory)")
+	mergeCmd.Flags().BoolP("force", "f", false, "Merge without asking for confirmation")
+}
@@ -0,0 +1,263 @@
+package admin
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/cli/localdb"
+	"mangahub/internal/user"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+	"mangahub/pkg/utils"
+)
+
+// usersCmd groups the admin commands for user roles
+var usersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage user roles",
+	Long: `Every account starts as a user, who can only manage their own library.
+
+Roles:
+  moderator  also creates, edits, trashes and restores catalog manga, their
+             chapters, titles and covers, and refreshes or imports the catalog
+  admin      also merges duplicate manga, runs server database maintenance,
+             reads the server logs and grants roles
+
+Granting and revoking takes an admin login. The first admin is created with
+'bootstrap', which only works while the database has no admin.`,
+}
+
+// listUsersCmd handles `mangahub admin users list`.
+var listUsersCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List moderators and admins",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		users := user.NewService(db)
+		var staff []models.User
+		for _, role := range []string{models.RoleAdmin, models.RoleModerator} {
+			withRole, err := users.ListByRole(role)
+			if err != nil {
+				return fmt.Errorf("failed to list users: %w", err)
+			}
+			staff = append(staff, withRole...)
+		}
+		if len(staff) == 0 {
+			fmt.Println("No moderators or admins yet.")
+			fmt.Println("\nCreate the first admin with:")
+			fmt.Println("  mangahub admin users bootstrap --username <username>")
+			return nil
+		}
+
+		fmt.Println("┌──────────────────────┬───────────┬──────────────────────────────┬──────────────────────┐")
+		fmt.Printf("│ %-20s │ %-9s │ %-28s │ %-20s │\n", "USERNAME", "ROLE", "EMAIL", "USER ID")
+		fmt.Println("├──────────────────────┼───────────┼──────────────────────────────┼──────────────────────┤")
+		for _, u := range staff {
+			fmt.Printf("│ %-20s │ %-9s │ %-28s │ %-20s │\n",
+				truncateString(u.Username, 20), u.Role, truncateString(u.Email, 28), truncateString(u.ID, 20))
+		}
+		fmt.Println("└──────────────────────┴───────────┴──────────────────────────────┴──────────────────────┘")
+		return nil
+	},
+}
+
+// grantCmd handles `mangahub admin users grant`.
+var grantCmd = &cobra.Command{
+	Use:   "grant <username> <role>",
+	Short: "Give a user a role",
+	Long: `Give a user the moderator or admin role. The role is carried in the user's
+access token, so it takes effect when the token is next refreshed, within
+auth.access_token_ttl minutes, or when they log in again.
+
+Examples:
+  mangahub admin users grant alice moderator
+  mangahub admin users grant bob admin`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setRole(cmd, args[0], strings.ToLower(args[1]))
+	},
+}
+
+// revokeCmd handles `mangahub admin users revoke`.
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <username>",
+	Short: "Take a user's role away",
+	Long: `Make a moderator or admin a plain user again. This applies at once, even to
+logins made before it; the last admin cannot be revoked.
+
+Example:
+  mangahub admin users revoke alice`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return setRole(cmd, args[0], models.RoleUser)
+	},
+}
+
+// setRole gives username role, as the logged-in admin
+func setRole(cmd *cobra.Command, username, role string) error {
+	dbPath, _ := cmd.Flags().GetString("db")
+
+	if !models.ValidRole(role) {
+		return fmt.Errorf("invalid role '%s'. Valid options: %s", role, strings.Join(models.Roles, ", "))
+	}
+
+	db, err := localdb.Open(dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := localdb.Authorize(db, models.PermManageUsers); err != nil {
+		return err
+	}
+
+	users := user.NewService(db)
+	u, err := users.GetByUsername(username)
+	if errors.Is(err, store.ErrUserNotFound) {
+		return fmt.Errorf("user '%s' not found", username)
+	}
+	if err != nil {
+		return err
+	}
+	if u.Role == role {
+		fmt.Printf("%s is already a %s.\n", u.Username, role)
+		return nil
+	}
+
+	if err := users.SetRole(u.ID, role); err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+	fmt.Printf("✓ %s is now a %s (was %s)\n", u.Username, role, u.Role)
+	return nil
+}
+
+// bootstrapCmd handles `mangahub admin users bootstrap`.
+var bootstrapCmd = &cobra.Command{
+	Use:   "bootstrap",
+	Short: "Create the first admin",
+	Long: `Create the first admin of a new installation. An existing account is made an
+admin; otherwise a new account is created with --email and a password you are
+prompted for. This only works while the database has no admin, and needs no
+login; after that, admins grant roles with 'grant'.
+
+Examples:
+  # Make an account registered through the API server the first admin
+  mangahub admin users bootstrap --username alice
+
+  # Create a new admin account
+  mangahub admin users bootstrap --username admin --email admin@example.com`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+		username, _ := cmd.Flags().GetString("username")
+		email, _ := cmd.Flags().GetString("email")
+
+		if username == "" {
+			return fmt.Errorf("please provide --username")
+		}
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		users := user.NewService(db)
+		admins, err := users.ListByRole(models.RoleAdmin)
+		if err != nil {
+			return fmt.Errorf("failed to look up admins: %w", err)
+		}
+		if len(admins) > 0 {
+			return fmt.Errorf("%s is already an admin; ask an admin to run 'mangahub admin users grant %s admin'",
+				admins[0].Username, username)
+		}
+
+		existing, err := users.GetByUsername(username)
+		if err == nil {
+			if err := users.SetRole(existing.ID, models.RoleAdmin); err != nil {
+				return fmt.Errorf("failed to set role: %w", err)
+			}
+			fmt.Printf("✓ %s is now the first admin\n", existing.Username)
+			fmt.Println("\nLog in again for the role to take effect:")
+			fmt.Printf("  mangahub auth login --username %s\n", existing.Username)
+			return nil
+		}
+		if !errors.Is(err, store.ErrUserNotFound) {
+			return err
+		}
+
+		created, err := newAdmin(username, email)
+		if err != nil {
+			return err
+		}
+		if err := users.Create(created); err != nil {
+			return err
+		}
+		fmt.Printf("✓ Created admin %s (%s)\n", created.Username, created.ID)
+		fmt.Println("\nLog in with:")
+		fmt.Printf("  mangahub auth login --username %s\n", created.Username)
+		return nil
+	},
+}
+
+// newAdmin prompts for the password of a new admin account
+func newAdmin(username, email string) (*models.User, error) {
+	if email == "" {
+		return nil, fmt.Errorf("user '%s' not found; provide --email to create it", username)
+	}
+	if err := utils.ValidateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := utils.ValidateEmail(email); err != nil {
+		return nil, err
+	}
+
+	prompt := utils.NewPrompt()
+	password, err := prompt.Password("Password: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	if err := utils.ValidatePassword(password); err != nil {
+		return nil, err
+	}
+	confirmPassword, err := prompt.Password("Confirm Password: ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read password: %w", err)
+	}
+	if password != confirmPassword {
+		return nil, fmt.Errorf("passwords do not match")
+	}
+
+	authService := auth.NewAuthService("")
+	hash, err := authService.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return &models.User{
+		ID:           authService.GenerateUserID(),
+		Username:     username,
+		Email:        email,
+		PasswordHash: hash,
+		Role:         models.RoleAdmin,
+	}, nil
+}
+
+func init() {
+	AdminCmd.AddCommand(usersCmd)
+	usersCmd.AddCommand(listUsersCmd, grantCmd, revokeCmd, bootstrapCmd)
+
+	for _, cmd := range []*cobra.Command{listUsersCmd, grantCmd, revokeCmd, bootstrapCmd} {
+		cmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+	}
+	bootstrapCmd.Flags().StringP("username", "u", "", "Username of the first admin (required)")
+	bootstrapCmd.Flags().StringP("email", "e", "", "Email address, to create a new account")
+}
@@ -5,21 +5,24 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"mangahub/pkg/client"
+	"mangahub/pkg/session"
 	"mangahub/pkg/utils"
 )
 
 var changePasswordCmd = &cobra.Command{
 	Use:   "change-password",
 	Short: "Change your password",
-	Long: `Change your MangaHub account password.
+	Long: `Change your MangaHub account password via the API server.
 
-You must be logged in to change your password.
+You must be logged in to change your password. Every other device logged in
+to your account is logged out.
 
 Example:
   mangahub auth change-password`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Check if user is logged in
-		session, err := loadSession()
+		sess, err := session.Load()
 		if err != nil {
 			fmt.Println("You are not logged in.")
 			fmt.Println("\nPlease login first:")
@@ -35,30 +38,14 @@ Example:
 			return fmt.Errorf("failed to read password: %w", err)
 		}
 
-		// Verify current password
-		userSvc, err := getUserService()
-		if err != nil {
-			return fmt.Errorf("database error: %w", err)
-		}
-
-		user, err := userSvc.GetByID(session.UserID)
-		if err != nil {
-			return fmt.Errorf("failed to get user: %w", err)
-		}
-
-		authSvc := getAuthService()
-		if err := authSvc.VerifyPassword(currentPassword, user.PasswordHash); err != nil {
-			return fmt.Errorf("current password is incorrect")
-		}
-
 		// Prompt for new password
 		newPassword, err := prompt.Password("New password: ")
 		if err != nil {
 			return fmt.Errorf("failed to read password: %w", err)
 		}
 
-		if len(newPassword) < 6 {
-			return fmt.Errorf("password must be at least 6 characters")
+		if err := utils.ValidatePassword(newPassword); err != nil {
+			return err
 		}
 
 		// Confirm new password
@@ -71,20 +58,19 @@ Example:
 			return fmt.Errorf("passwords do not match")
 		}
 
-		// Hash new password
-		hashedPassword, err := authSvc.HashPassword(newPassword)
-		if err != nil {
-			return fmt.Errorf("failed to hash password: %w", err)
-		}
-
-		// Update password in database
-		err = userSvc.UpdatePassword(session.UserID, hashedPassword)
+		// The API server checks the current password and logs the other
+		// sessions out
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		revoked, err := httpClient.ChangePassword(currentPassword, newPassword)
 		if err != nil {
-			return fmt.Errorf("failed to update password: %w", err)
+			return fmt.Errorf("failed to change password: %w", err)
 		}
 
 		fmt.Println("\n✓ Password changed successfully!")
 		fmt.Println("\nYour session remains active. You don't need to login again.")
+		if revoked > 0 {
+			fmt.Printf("Logged out %d other session(s).\n", revoked)
+		}
 
 		return nil
 	},
@@ -2,13 +2,8 @@ package auth
 
 import (
 	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
-
-	"mangahub/internal/auth"
-	"mangahub/internal/user"
-	"mangahub/pkg/database"
 )
 
 // Session stores the current user session
@@ -28,21 +23,6 @@ func getAPIURL() string {
 	return "http://10.238.53.72:8080" // Server IP
 }
 
-// getDBPath returns the path to the database file
-func getDBPath() string {
-	paths := []string{
-		"data/mangahub.db",
-		"./data/mangahub.db",
-		filepath.Join(os.Getenv("HOME"), ".mangahub", "mangahub.db"),
-	}
-	for _, p := range paths {
-		if _, err := os.Stat(p); err == nil {
-			return p
-		}
-	}
-	return "data/mangahub.db"
-}
-
 // getSessionPath returns the path to the session file
 func getSessionPath() string {
 	homeDir, err := os.UserHomeDir()
@@ -84,18 +64,3 @@ func clearSession() error {
 	sessionPath := getSessionPath()
 	return os.Remove(sessionPath)
 }
-
-// getUserService creates and returns a user service connected to the database
-func getUserService() (*user.Service, error) {
-	dbPath := getDBPath()
-	db, err := database.New(dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
-	}
-	return user.NewService(db), nil
-}
-
-// getAuthService creates and returns an auth service
-func getAuthService() *auth.AuthService {
-	return auth.NewAuthService("")
-}
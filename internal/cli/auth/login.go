@@ -52,11 +52,13 @@ Examples:
 
 		// Save session
 		sess := &session.Session{
-			UserID:    loginResp.UserID,
-			Username:  loginResp.Username,
-			Email:     "", // API doesn't return email in login response
-			Token:     loginResp.Token,
-			ExpiresAt: loginResp.ExpiresAt.Format("2006-01-02 15:04:05 MST"),
+			UserID:       loginResp.UserID,
+			Username:     loginResp.Username,
+			Email:        "", // API doesn't return email in login response
+			Token:        loginResp.Token,
+			ExpiresAt:    loginResp.ExpiresAt.Format(session.TimeFormat),
+			RefreshToken: loginResp.RefreshToken,
+			SessionID:    loginResp.SessionID,
 		}
 		if err := session.Save(sess); err != nil {
 			fmt.Printf("Warning: could not save session: %v\n", err)
@@ -65,7 +67,7 @@ Examples:
 		fmt.Println("✓ Login successful")
 		fmt.Println()
 		fmt.Printf("User: %s\n", loginResp.Username)
-		fmt.Printf("Token expires: %s\n", loginResp.ExpiresAt.Format("2006-01-02 15:04:05 MST"))
+		fmt.Printf("Session expires: %s (renewed while in use)\n", loginResp.RefreshExpiresAt.Format(session.TimeFormat))
 
 		return nil
 	},
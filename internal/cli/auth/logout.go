@@ -5,13 +5,15 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"mangahub/pkg/client"
 	"mangahub/pkg/session"
 )
 
 var logoutCmd = &cobra.Command{
 	Use:   "logout",
 	Short: "Logout from MangaHub",
-	Long: `Logout from MangaHub and clear the local session.
+	Long: `Logout from MangaHub: the session is revoked on the API server, so its
+tokens stop working everywhere, and the local session is cleared.
 
 Example:
   mangahub auth logout`,
@@ -23,6 +25,12 @@ Example:
 		}
 
 		fmt.Printf("Logging out user %s...\n", sess.Username)
+		if sess.RefreshToken != "" {
+			httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+			if err := httpClient.Logout(); err != nil {
+				fmt.Printf("Warning: could not revoke the session on the server: %v\n", err)
+			}
+		}
 		if err := session.Clear(); err != nil {
 			return fmt.Errorf("failed to clear session: %w", err)
 		}
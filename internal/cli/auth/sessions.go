@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/client"
+	"mangahub/pkg/models"
+	"mangahub/pkg/session"
+)
+
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "List the devices you are logged in on",
+	Long: `List your active sessions via the API server: every device logged in to your
+account, with the address and time it was last used from.
+
+Examples:
+  mangahub auth sessions
+  mangahub auth revoke <session-id>`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.Load()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		sessions, err := httpClient.ListSessions()
+		if err != nil {
+			return fmt.Errorf("failed to list sessions: %w", err)
+		}
+
+		fmt.Printf("🔑 %s's Sessions\n\n", sess.Username)
+		printSessionsTable(sessions)
+		fmt.Printf("\nTotal: %d active session(s); * marks this device\n", len(sessions))
+		fmt.Println("\nLog a device out with:")
+		fmt.Println("  mangahub auth revoke <session-id>")
+
+		return nil
+	},
+}
+
+var revokeCmd = &cobra.Command{
+	Use:   "revoke <session-id>",
+	Short: "Log one of your devices out",
+	Long: `Revoke one of your sessions via the API server. Its refresh token stops
+working at once, and so does every access token issued to it. Revoking the
+session of this device logs you out here too.
+
+Example:
+  mangahub auth revoke sess_3f9a1c2b7d4e5f60`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.Load()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		if err := httpClient.RevokeSession(args[0]); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+
+		fmt.Printf("✓ Revoked session %s\n", args[0])
+		if args[0] == sess.SessionID {
+			if err := session.Clear(); err != nil {
+				return fmt.Errorf("failed to clear session: %w", err)
+			}
+			fmt.Println("That was this device's session; you are now logged out.")
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(sessionsCmd)
+	AuthCmd.AddCommand(revokeCmd)
+}
+
+// printSessionsTable prints sessions in a formatted table
+func printSessionsTable(sessions []models.Session) {
+	fmt.Println("┌───┬───────────────────────┬──────────────────────────┬─────────────────┬──────────────────┬──────────────────┐")
+	fmt.Printf("│   │ %-21s │ %-24s │ %-15s │ %-16s │ %-16s │\n", "ID", "DEVICE", "IP", "LAST USED", "CREATED")
+	fmt.Println("├───┼───────────────────────┼──────────────────────────┼─────────────────┼──────────────────┼──────────────────┤")
+	for _, s := range sessions {
+		marker := " "
+		if s.Current {
+			marker = "*"
+		}
+		device := s.Device
+		if device == "" {
+			device = "-"
+		}
+		fmt.Printf("│ %s │ %-21s │ %-24s │ %-15s │ %-16s │ %-16s │\n",
+			marker, truncateString(s.ID, 21), truncateString(device, 24), truncateString(s.IP, 15),
+			s.LastUsedAt.Local().Format("2006-01-02 15:04"), s.CreatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Println("└───┴───────────────────────┴──────────────────────────┴─────────────────┴──────────────────┴──────────────────┘")
+}
+
+// truncateString truncates a string to the specified length
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
@@ -26,7 +26,7 @@ Example:
 		}
 
 		// Create HTTP client with token to validate with API server
-		httpClient := client.NewHTTPClient(getAPIURL(), sess.Token)
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
 
 		// Try to get profile from API to validate token
 		user, err := httpClient.GetProfile()
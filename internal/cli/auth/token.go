@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/client"
+	"mangahub/pkg/models"
+	"mangahub/pkg/session"
+)
+
+// tokenCmd groups the personal access token commands
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage personal access tokens",
+	Long: `Personal access tokens let scripts and bots use the API, gRPC and chat
+servers without your password. Send one as a bearer token, the same way as a
+login token; each token only reaches what its scopes cover.
+
+Scopes:
+  library:read   read your library, progress history and recommendations
+  library:write  add, import, update, remove and restore library entries
+  chat:write     send chat messages
+  admin          what your moderator or admin role allows
+
+Tokens cannot change your profile, password, sessions or tokens.`,
+}
+
+// tokenCreateCmd handles `mangahub auth token create`.
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a personal access token",
+	Long: `Create a personal access token via the API server. The token is shown once;
+store it somewhere safe.
+
+Examples:
+  mangahub auth token create --name backup-script --scope library:read
+  mangahub auth token create --name bot --scope library:read,chat:write --expires 30`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetStringSlice("scope")
+		expires, _ := cmd.Flags().GetInt("expires")
+
+		if name == "" {
+			return fmt.Errorf("please provide --name")
+		}
+		if len(scopes) == 0 {
+			return fmt.Errorf("please provide --scope. Valid scopes: %s", strings.Join(models.Scopes, ", "))
+		}
+
+		sess, err := session.Load()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		token, err := httpClient.CreateAccessToken(name, scopes, expires)
+		if err != nil {
+			return fmt.Errorf("failed to create access token: %w", err)
+		}
+
+		fmt.Printf("✓ Created access token '%s' (%s)\n", token.Name, token.ID)
+		fmt.Println()
+		fmt.Printf("Token:   %s\n", token.Token)
+		fmt.Printf("Scopes:  %s\n", strings.Join(token.Scopes, ", "))
+		fmt.Printf("Expires: %s\n", token.ExpiresAt.Local().Format("2006-01-02 15:04"))
+		fmt.Println("\n⚠ This is the only time the token is shown. Store it somewhere safe.")
+
+		return nil
+	},
+}
+
+// tokenListCmd handles `mangahub auth token list`.
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List your personal access tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.Load()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		tokens, err := httpClient.ListAccessTokens()
+		if err != nil {
+			return fmt.Errorf("failed to list access tokens: %w", err)
+		}
+
+		fmt.Printf("🔑 %s's Access Tokens\n\n", sess.Username)
+		if len(tokens) == 0 {
+			fmt.Println("No access tokens yet.")
+			fmt.Println("\nCreate one with:")
+			fmt.Println("  mangahub auth token create --name <name> --scope <scope>")
+			return nil
+		}
+
+		printTokensTable(tokens)
+		fmt.Printf("\nTotal: %d active token(s)\n", len(tokens))
+
+		return nil
+	},
+}
+
+// tokenRevokeCmd handles `mangahub auth token revoke`.
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke <token-id>",
+	Short: "Revoke a personal access token",
+	Long: `Revoke a personal access token via the API server. It stops working at once
+on every server.
+
+Example:
+  mangahub auth token revoke pat_3f9a1c2b7d4e5f60`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		sess, err := session.Load()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		if err := httpClient.RevokeAccessToken(args[0]); err != nil {
+			return fmt.Errorf("failed to revoke access token: %w", err)
+		}
+
+		fmt.Printf("✓ Revoked access token %s\n", args[0])
+		return nil
+	},
+}
+
+func init() {
+	AuthCmd.AddCommand(tokenCmd)
+	tokenCmd.AddCommand(tokenCreateCmd, tokenListCmd, tokenRevokeCmd)
+
+	tokenCreateCmd.Flags().StringP("name", "n", "", "What the token is for (required)")
+	tokenCreateCmd.Flags().StringSliceP("scope", "s", nil, "Scopes, repeated or comma-separated (required)")
+	tokenCreateCmd.Flags().Int("expires", 0, "Days until the token expires (default 90, at most 365)")
+}
+
+// printTokensTable prints personal access tokens in a formatted table
+func printTokensTable(tokens []models.AccessToken) {
+	fmt.Println("┌──────────────────────┬──────────────────┬────────────┬──────────────────────────────┬──────────────────┬────────────┐")
+	fmt.Printf("│ %-20s │ %-16s │ %-10s │ %-28s │ %-16s │ %-10s │\n", "ID", "NAME", "TOKEN", "SCOPES", "LAST USED", "EXPIRES")
+	fmt.Println("├──────────────────────┼──────────────────┼────────────┼──────────────────────────────┼──────────────────┼────────────┤")
+	for _, t := range tokens {
+		lastUsed := "never"
+		if t.LastUsedAt != nil {
+			lastUsed = t.LastUsedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("│ %-20s │ %-16s │ %-10s │ %-28s │ %-16s │ %-10s │\n",
+			truncateString(t.ID, 20), truncateString(t.Name, 16), truncateString(t.Hint, 10),
+			truncateString(strings.Join(t.Scopes, ","), 28), lastUsed, t.ExpiresAt.Local().Format("2006-01-02"))
+	}
+	fmt.Println("└──────────────────────┴──────────────────┴────────────┴──────────────────────────────┴──────────────────┴────────────┘")
+}
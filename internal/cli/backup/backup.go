@@ -0,0 +1,264 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	defaultDBPath     = "./data/mangahub.db"
+	defaultConfigPath = "config.yaml"
+
+	manifestName    = "manifest.json"
+	databaseEntry   = "mangahub.db"
+	configEntry     = "config.yaml"
+	sessionsDir     = "sessions"
+	manifestFormat  = 1
+	maxArchiveEntry = 4 << 30
+)
+
+// BackupCmd is the main backup command (parent/root for backup subcommands).
+var BackupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Create and restore system backups",
+	Long: `Create and restore backups of the local MangaHub installation: the SQLite
+database, config.yaml and the saved profile sessions.`,
+}
+
+// manifest describes the contents of a backup archive
+type manifest struct {
+	Format        int            `json:"format"`
+	CreatedAt     time.Time      `json:"created_at"`
+	AppVersion    string         `json:"app_version"`
+	SchemaVersion int            `json:"schema_version"`
+	Files         []manifestFile `json:"files"`
+}
+
+// manifestFile is one archived file and its checksum
+type manifestFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// find returns the manifest entry for an archive name
+func (m *manifest) find(name string) (manifestFile, bool) {
+	for _, f := range m.Files {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return manifestFile{}, false
+}
+
+// fileChecksum returns the size and SHA-256 of a file
+func fileChecksum(p string) (int64, string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// archiveFile is a file on disk and the name it gets inside the archive
+type archiveFile struct {
+	name string
+	path string
+}
+
+// writeArchive writes the manifest followed by the files into a .tar.gz
+func writeArchive(dest string, m *manifest, files []archiveFile) error {
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+	defer f.Close()
+
+	gw := gzip.NewWriter(f)
+	tw := tar.NewWriter(gw)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: manifestName, Mode: 0o600, Size: int64(len(data)), ModTime: m.CreatedAt}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	for _, af := range files {
+		if err := addToArchive(tw, af); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return f.Sync()
+}
+
+func addToArchive(tw *tar.Writer, af archiveFile) error {
+	fh, err := os.Open(af.path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", af.path, err)
+	}
+	defer fh.Close()
+
+	info, err := fh.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", af.path, err)
+	}
+	hdr := &tar.Header{Name: af.name, Mode: 0o600, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return fmt.Errorf("failed to write header for %s: %w", af.name, err)
+	}
+	if _, err := io.Copy(tw, fh); err != nil {
+		return fmt.Errorf("failed to copy %s into archive: %w", af.path, err)
+	}
+	return nil
+}
+
+// extractArchive unpacks a backup into dir and checks every file against the
+// manifest. It fails if a file is missing, unexpected, or does not match its
+// recorded size and checksum.
+func extractArchive(src, dir string) (*manifest, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer f.Close()
+
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	var m *manifest
+	seen := make(map[string]bool)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, fmt.Errorf("unexpected archive entry %q", hdr.Name)
+		}
+		if hdr.Size > maxArchiveEntry {
+			return nil, fmt.Errorf("archive entry %q is too large", hdr.Name)
+		}
+
+		if hdr.Name == manifestName {
+			m = &manifest{}
+			if err := json.NewDecoder(io.LimitReader(tr, 1<<20)).Decode(m); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			if m.Format != manifestFormat {
+				return nil, fmt.Errorf("unsupported backup format %d", m.Format)
+			}
+			continue
+		}
+		if m == nil {
+			return nil, fmt.Errorf("archive does not start with %s", manifestName)
+		}
+
+		name := path.Clean(hdr.Name)
+		if name != hdr.Name || path.IsAbs(name) || strings.HasPrefix(name, "../") || name == ".." {
+			return nil, fmt.Errorf("unsafe archive entry %q", hdr.Name)
+		}
+		want, ok := m.find(name)
+		if !ok {
+			return nil, fmt.Errorf("archive entry %q is not in the manifest", name)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("archive entry %q appears twice", name)
+		}
+		seen[name] = true
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return nil, err
+		}
+		out, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err != nil {
+			return nil, err
+		}
+		h := sha256.New()
+		n, err := io.Copy(io.MultiWriter(out, h), tr)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to extract %s: %w", name, err)
+		}
+		if n != want.Size || hex.EncodeToString(h.Sum(nil)) != want.SHA256 {
+			return nil, fmt.Errorf("checksum mismatch for %s", name)
+		}
+	}
+
+	if m == nil {
+		return nil, fmt.Errorf("archive has no %s", manifestName)
+	}
+	for _, mf := range m.Files {
+		if !seen[mf.Name] {
+			return nil, fmt.Errorf("archive is missing %s", mf.Name)
+		}
+	}
+	if !seen[databaseEntry] {
+		return nil, fmt.Errorf("archive has no database")
+	}
+	return m, nil
+}
+
+// copyFile copies src to dest and syncs it to disk
+func copyFile(src, dest string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
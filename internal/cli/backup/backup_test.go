@@ -0,0 +1,421 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// newTestDatabase creates a migrated database at path holding one manga
+// with the given title
+func newTestDatabase(t *testing.T, path, title string) {
+	t.Helper()
+	db, err := database.New(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(); err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+	err = store.NewSQLiteStores(db).Manga.Create(&models.Manga{ID: "marker", Title: title, Status: "ongoing"})
+	if err != nil {
+		t.Fatalf("create manga: %v", err)
+	}
+}
+
+// mangaTitle returns the title of the manga newTestDatabase created at path
+func mangaTitle(t *testing.T, path string) string {
+	t.Helper()
+	db, err := database.New(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	manga, err := store.NewSQLiteStores(db).Manga.GetByID("marker")
+	if err != nil {
+		t.Fatalf("get manga in %s: %v", path, err)
+	}
+	return manga.Title
+}
+
+// runBackup runs `mangahub backup` with args and a home directory of its own
+func runBackup(t *testing.T, args ...string) error {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	BackupCmd.SetArgs(args)
+	return BackupCmd.Execute()
+}
+
+// createBackup backs up a database holding "Berserk" and returns the archive
+func createBackup(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	src := filepath.Join(dir, "mangahub.db")
+	newTestDatabase(t, src, "Berserk")
+	config := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(config, []byte("app:\n  name: MangaHub\n"), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	archive := filepath.Join(dir, "backup.tar.gz")
+	if err := runBackup(t, "create", "--db", src, "--config", config, "--output", archive, "--no-sessions"); err != nil {
+		t.Fatalf("backup create: %v", err)
+	}
+	return archive
+}
+
+// archiveEntry is one file of a hand-built archive
+type archiveEntry struct {
+	name     string
+	data     []byte
+	typeflag byte
+}
+
+// readArchive returns the entries of the archive at path
+func readArchive(t *testing.T, path string) []archiveEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	tr := tar.NewReader(gr)
+
+	var entries []archiveEntry
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return entries
+		}
+		if err != nil {
+			t.Fatalf("read archive: %v", err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("read %s: %v", hdr.Name, err)
+		}
+		entries = append(entries, archiveEntry{name: hdr.Name, data: data, typeflag: hdr.Typeflag})
+	}
+}
+
+// writeTestArchive writes entries to a new archive in dir, in order
+func writeTestArchive(t *testing.T, dir string, entries []archiveEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, e := range entries {
+		typeflag := e.typeflag
+		if typeflag == 0 {
+			typeflag = tar.TypeReg
+		}
+		hdr := &tar.Header{Name: e.name, Mode: 0o600, Size: int64(len(e.data)), Typeflag: typeflag}
+		if typeflag != tar.TypeReg {
+			hdr.Size = 0
+			hdr.Linkname = "/etc/passwd"
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("write header for %s: %v", e.name, err)
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			t.Fatalf("write %s: %v", e.name, err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatalf("close archive: %v", err)
+	}
+
+	path := filepath.Join(dir, "crafted.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatalf("write archive: %v", err)
+	}
+	return path
+}
+
+// manifestEntry returns the manifest entry describing files, each listed
+// with the size and checksum of its data
+func manifestEntry(t *testing.T, files ...archiveEntry) archiveEntry {
+	t.Helper()
+	m := manifest{Format: manifestFormat, CreatedAt: time.Now().UTC(), SchemaVersion: 1}
+	for _, f := range files {
+		sum := sha256.Sum256(f.data)
+		m.Files = append(m.Files, manifestFile{Name: f.name, Size: int64(len(f.data)), SHA256: hex.EncodeToString(sum[:])})
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal manifest: %v", err)
+	}
+	return archiveEntry{name: manifestName, data: data}
+}
+
+func TestBackupCreateAndRestore(t *testing.T) {
+	archive := createBackup(t)
+
+	entries := readArchive(t, archive)
+	if len(entries) != 3 || entries[0].name != manifestName || entries[1].name != databaseEntry || entries[2].name != configEntry {
+		t.Fatalf("archive holds %d entries, want the manifest, the database and the config in order", len(entries))
+	}
+	var m manifest
+	if err := json.Unmarshal(entries[0].data, &m); err != nil {
+		t.Fatalf("decode manifest: %v", err)
+	}
+	if m.Format != manifestFormat || m.SchemaVersion != database.NewMigrator(nil).LatestVersion() || len(m.Files) != 2 {
+		t.Errorf("manifest = %+v", m)
+	}
+	for i, f := range m.Files {
+		sum := sha256.Sum256(entries[i+1].data)
+		if f.Name != entries[i+1].name || f.Size != int64(len(entries[i+1].data)) || f.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Errorf("manifest entry %+v does not describe %s", f, entries[i+1].name)
+		}
+	}
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "data", "mangahub.db")
+	config := filepath.Join(dir, "config.yaml")
+	if err := runBackup(t, "restore", archive, "--db", dest, "--config", config, "--with-config", "--force"); err != nil {
+		t.Fatalf("backup restore: %v", err)
+	}
+	if title := mangaTitle(t, dest); title != "Berserk" {
+		t.Errorf("restored manga title = %q, want Berserk", title)
+	}
+	if data, err := os.ReadFile(config); err != nil || string(data) != "app:\n  name: MangaHub\n" {
+		t.Errorf("restored config = %q, %v", data, err)
+	}
+	leftovers, _ := filepath.Glob(filepath.Join(dir, "data", ".mangahub-restore-*"))
+	if len(leftovers) != 0 {
+		t.Errorf("staging directories left behind: %v", leftovers)
+	}
+}
+
+func TestRestoreRejectsTamperedArchive(t *testing.T) {
+	entries := readArchive(t, createBackup(t))
+	// Flip a byte of the database without touching its size
+	db := entries[1].data
+	db[len(db)/2] ^= 0xff
+
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "mangahub.db")
+	err := runBackup(t, "restore", writeTestArchive(t, dir, entries), "--db", dest, "--force")
+	if err == nil || !strings.Contains(err.Error(), "checksum mismatch for "+databaseEntry) {
+		t.Fatalf("restore of a tampered archive = %v, want a checksum mismatch", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("database written from a tampered archive: %v", err)
+	}
+}
+
+func TestExtractArchiveRejects(t *testing.T) {
+	db := archiveEntry{name: databaseEntry, data: []byte("database")}
+	tests := []struct {
+		name    string
+		entries []archiveEntry
+		want    string
+	}{
+		{
+			name: "size mismatch",
+			entries: func() []archiveEntry {
+				m := manifestEntry(t, archiveEntry{name: databaseEntry, data: []byte("database plus")})
+				return []archiveEntry{m, db}
+			}(),
+			want: "checksum mismatch for " + databaseEntry,
+		},
+		{
+			name: "content mismatch",
+			entries: func() []archiveEntry {
+				m := manifestEntry(t, archiveEntry{name: databaseEntry, data: []byte("DATABASE")})
+				return []archiveEntry{m, db}
+			}(),
+			want: "checksum mismatch for " + databaseEntry,
+		},
+		{
+			name: "parent directory",
+			entries: func() []archiveEntry {
+				evil := archiveEntry{name: "../evil", data: []byte("evil")}
+				return []archiveEntry{manifestEntry(t, db, evil), db, evil}
+			}(),
+			want: `unsafe archive entry "../evil"`,
+		},
+		{
+			name: "unclean path",
+			entries: func() []archiveEntry {
+				evil := archiveEntry{name: "sessions/../../evil", data: []byte("evil")}
+				return []archiveEntry{manifestEntry(t, db, evil), db, evil}
+			}(),
+			want: "unsafe archive entry",
+		},
+		{
+			name: "absolute path",
+			entries: func() []archiveEntry {
+				evil := archiveEntry{name: "/tmp/evil", data: []byte("evil")}
+				return []archiveEntry{manifestEntry(t, db, evil), db, evil}
+			}(),
+			want: `unsafe archive entry "/tmp/evil"`,
+		},
+		{
+			name:    "symlink",
+			entries: []archiveEntry{manifestEntry(t, db), {name: databaseEntry, typeflag: tar.TypeSymlink}},
+			want:    "unexpected archive entry",
+		},
+		{
+			name: "entry not in the manifest",
+			entries: []archiveEntry{manifestEntry(t, db), db,
+				{name: configEntry, data: []byte("app: {}")}},
+			want: `archive entry "config.yaml" is not in the manifest`,
+		},
+		{
+			name:    "entry twice",
+			entries: []archiveEntry{manifestEntry(t, db), db, db},
+			want:    "appears twice",
+		},
+		{
+			name:    "missing file",
+			entries: []archiveEntry{manifestEntry(t, db, archiveEntry{name: configEntry}), db},
+			want:    "archive is missing " + configEntry,
+		},
+		{
+			name:    "no manifest first",
+			entries: []archiveEntry{db, manifestEntry(t, db)},
+			want:    "does not start with " + manifestName,
+		},
+		{
+			name: "no database",
+			entries: func() []archiveEntry {
+				config := archiveEntry{name: configEntry, data: []byte("app: {}")}
+				return []archiveEntry{manifestEntry(t, config), config}
+			}(),
+			want: "archive has no database",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			staging := filepath.Join(dir, "staging")
+			if err := os.Mkdir(staging, 0o700); err != nil {
+				t.Fatalf("create staging: %v", err)
+			}
+			_, err := extractArchive(writeTestArchive(t, dir, tt.entries), staging)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("extract = %v, want an error containing %q", err, tt.want)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "evil")); !os.IsNotExist(err) {
+				t.Errorf("file written outside the staging directory")
+			}
+		})
+	}
+}
+
+func TestExtractArchiveKeepsExistingFiles(t *testing.T) {
+	db := archiveEntry{name: databaseEntry, data: []byte("database")}
+	dir := t.TempDir()
+	archive := writeTestArchive(t, dir, []archiveEntry{manifestEntry(t, db), db})
+
+	staging := filepath.Join(dir, "staging")
+	if err := os.Mkdir(staging, 0o700); err != nil {
+		t.Fatalf("create staging: %v", err)
+	}
+	existing := filepath.Join(staging, databaseEntry)
+	if err := os.WriteFile(existing, []byte("already here"), 0o600); err != nil {
+		t.Fatalf("write existing file: %v", err)
+	}
+
+	if _, err := extractArchive(archive, staging); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("extract over an existing file = %v, want it refused", err)
+	}
+	if data, _ := os.ReadFile(existing); string(data) != "already here" {
+		t.Errorf("existing file overwritten with %q", data)
+	}
+}
+
+func TestVerifyRestoredDatabase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "mangahub.db")
+	newTestDatabase(t, path, "Berserk")
+	latest := database.NewMigrator(nil).LatestVersion()
+
+	if err := verifyRestoredDatabase(path, &manifest{SchemaVersion: latest}); err != nil {
+		t.Fatalf("verify a good database: %v", err)
+	}
+	err := verifyRestoredDatabase(path, &manifest{SchemaVersion: latest - 1})
+	if err == nil || !strings.Contains(err.Error(), "the manifest says") {
+		t.Errorf("verify against another schema version = %v, want it refused", err)
+	}
+
+	db, err := database.New(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	_, err = db.Exec(`INSERT INTO schema_migrations (version, name, checksum) VALUES (?, 'from_the_future', 'x')`, latest+1)
+	db.Close()
+	if err != nil {
+		t.Fatalf("record a newer migration: %v", err)
+	}
+	if err := verifyRestoredDatabase(path, &manifest{SchemaVersion: latest + 1}); !errors.Is(err, database.ErrSchemaTooNew) {
+		t.Errorf("verify a newer schema = %v, want %v", err, database.ErrSchemaTooNew)
+	}
+}
+
+func TestSwapDatabase(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "mangahub.db")
+	newTestDatabase(t, dest, "Current")
+	if err := os.Chmod(dest, 0o600); err != nil {
+		t.Fatalf("chmod: %v", err)
+	}
+	staging := filepath.Join(dir, "staging")
+	if err := os.Mkdir(staging, 0o700); err != nil {
+		t.Fatalf("create staging: %v", err)
+	}
+	staged := filepath.Join(staging, databaseEntry)
+	newTestDatabase(t, staged, "Restored")
+
+	kept, err := swapDatabase(staged, dest)
+	if err != nil {
+		t.Fatalf("swap: %v", err)
+	}
+	if !strings.HasPrefix(kept, dest+".pre-restore-") {
+		t.Errorf("kept the current database as %q", kept)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(dest + suffix); !os.IsNotExist(err) {
+			t.Errorf("%s file of the replaced database left behind: %v", suffix, err)
+		}
+	}
+	if title := mangaTitle(t, kept); title != "Current" {
+		t.Errorf("kept database has %q, want the current one", title)
+	}
+	if title := mangaTitle(t, dest); title != "Restored" {
+		t.Errorf("database after the swap has %q, want the restored one", title)
+	}
+	if _, err := os.Stat(staged); !os.IsNotExist(err) {
+		t.Errorf("staged database still there: %v", err)
+	}
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatalf("stat restored database: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("restored database mode = %v, want the 0600 of the one it replaced", info.Mode().Perm())
+	}
+}
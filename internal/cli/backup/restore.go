@@ -1,3 +1,260 @@
 package backup
 
-// backup restore command
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+	"mangahub/pkg/session"
+
+	"github.com/spf13/cobra"
+)
+
+// restoreCmd handles `mangahub backup restore`.
+var restoreCmd = &cobra.Command{
+	Use:   "restore <archive>",
+	Short: "Restore a backup archive",
+	Long: `Restore the database from a backup created with 'mangahub backup create'.
+
+The archive is unpacked next to the database and every file is checked
+against the manifest checksums. The restored database must pass
+PRAGMA integrity_check and its schema must not be newer than this binary
+supports; an older schema is migrated by the servers on their next start.
+
+The current database is kept as <db>.pre-restore-<timestamp> and the
+restored file is renamed into place in one atomic step. Stop the servers
+before restoring and start them again afterwards. Replacing an existing
+database takes an admin login, checked against the role in that database.
+
+Examples:
+  mangahub backup restore mangahub-backup-20250101-120000.tar.gz
+  mangahub backup restore backup.tar.gz --db ./data/mangahub.db --force
+  mangahub backup restore backup.tar.gz --with-config --with-sessions`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func init() {
+	BackupCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().String("db", defaultDBPath, "Path of the SQLite database to replace")
+	restoreCmd.Flags().String("config", defaultConfigPath, "Where to write config.yaml with --with-config")
+	restoreCmd.Flags().Bool("with-config", false, "Also restore config.yaml")
+	restoreCmd.Flags().Bool("with-sessions", false, "Also restore saved profile sessions")
+	restoreCmd.Flags().BoolP("force", "f", false, "Skip confirmation prompt")
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	archive := args[0]
+	dbPath, _ := cmd.Flags().GetString("db")
+	configPath, _ := cmd.Flags().GetString("config")
+	withConfig, _ := cmd.Flags().GetBool("with-config")
+	withSessions, _ := cmd.Flags().GetBool("with-sessions")
+	force, _ := cmd.Flags().GetBool("force")
+
+	if err := authorizeRestore(dbPath); err != nil {
+		return err
+	}
+
+	dbDir := filepath.Dir(dbPath)
+	if err := os.MkdirAll(dbDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	// Stage inside the database directory so the final rename stays on
+	// one filesystem and is atomic
+	staging, err := os.MkdirTemp(dbDir, ".mangahub-restore-")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	fmt.Printf("Verifying %s...\n", archive)
+	m, err := extractArchive(archive, staging)
+	if err != nil {
+		return fmt.Errorf("backup verification failed: %w", err)
+	}
+	fmt.Printf("✓ %d files match the manifest checksums\n", len(m.Files))
+
+	staged := filepath.Join(staging, databaseEntry)
+	if err := verifyRestoredDatabase(staged, m); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Database passes integrity check (schema version %d)\n", m.SchemaVersion)
+
+	if !force {
+		fmt.Printf("\nThis will replace %s with the backup from %s.\n", dbPath, m.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+		fmt.Print("\nType 'yes' to confirm: ")
+
+		reader := bufio.NewReader(os.Stdin)
+		input, _ := reader.ReadString('\n')
+		input = strings.TrimSpace(strings.ToLower(input))
+
+		if input != "yes" && input != "y" {
+			fmt.Println("Restore cancelled.")
+			return nil
+		}
+	}
+
+	kept, err := swapDatabase(staged, dbPath)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\n✓ Restored database to %s\n", dbPath)
+	if kept != "" {
+		fmt.Printf("  Previous database kept as %s\n", kept)
+	}
+
+	if withConfig {
+		if _, ok := m.find(configEntry); !ok {
+			fmt.Println("⚠ Backup has no config.yaml, skipping")
+		} else if err := replaceFile(filepath.Join(staging, configEntry), configPath, 0o644); err != nil {
+			return fmt.Errorf("failed to restore config: %w", err)
+		} else {
+			fmt.Printf("✓ Restored config to %s\n", configPath)
+		}
+	}
+
+	if withSessions {
+		n, err := restoreSessions(staging, m)
+		if err != nil {
+			return fmt.Errorf("failed to restore sessions: %w", err)
+		}
+		fmt.Printf("✓ Restored %d profile sessions\n", n)
+	}
+
+	fmt.Println("\nRestart the servers to pick up the restored database.")
+	return nil
+}
+
+// authorizeRestore checks that the logged-in user may replace the database
+// at path. With no database there yet, there is nothing to protect and no
+// role to check.
+func authorizeRestore(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	db, err := database.New(path)
+	if err != nil {
+		return fmt.Errorf("failed to open current database %s: %w", path, err)
+	}
+	defer db.Close()
+	return localdb.Authorize(db, models.PermManageServer)
+}
+
+// verifyRestoredDatabase checks the unpacked database before it replaces the
+// live one
+func verifyRestoredDatabase(path string, m *manifest) error {
+	db, err := database.New(path)
+	if err != nil {
+		return fmt.Errorf("failed to open restored database: %w", err)
+	}
+	defer db.Close()
+
+	problems, err := db.IntegrityCheck()
+	if err != nil {
+		return fmt.Errorf("failed to check restored database: %w", err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("restored database failed integrity check: %s", problems[0])
+	}
+
+	migrator := database.NewMigrator(db)
+	if err := migrator.Verify(); err != nil {
+		return fmt.Errorf("cannot restore backup: %w", err)
+	}
+	version, err := migrator.CurrentVersion()
+	if err != nil {
+		return err
+	}
+	if version != m.SchemaVersion {
+		return fmt.Errorf("restored database is at schema version %d but the manifest says %d", version, m.SchemaVersion)
+	}
+	return nil
+}
+
+// swapDatabase moves the staged database over dest. The current database is
+// first copied aside and checkpointed so no WAL content is left to be
+// replayed onto the restored file. It returns the path of the copy.
+func swapDatabase(staged, dest string) (string, error) {
+	var kept string
+	if err := os.Chmod(staged, 0o644); err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(dest); err == nil {
+		if err := os.Chmod(staged, info.Mode().Perm()); err != nil {
+			return "", err
+		}
+		kept = fmt.Sprintf("%s.pre-restore-%s", dest, time.Now().Format("20060102-150405"))
+
+		current, err := database.New(dest)
+		if err != nil {
+			return "", fmt.Errorf("failed to open current database: %w", err)
+		}
+		err = current.BackupTo(kept)
+		if err == nil {
+			_, err = current.Exec(`PRAGMA wal_checkpoint(TRUNCATE)`)
+		}
+		current.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to keep current database: %w", err)
+		}
+	}
+
+	if err := os.Rename(staged, dest); err != nil {
+		return "", fmt.Errorf("failed to swap in restored database: %w", err)
+	}
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(dest + suffix); err != nil && !os.IsNotExist(err) {
+			return kept, fmt.Errorf("failed to remove stale %s file: %w", suffix, err)
+		}
+	}
+	syncDir(filepath.Dir(dest))
+	return kept, nil
+}
+
+// replaceFile atomically replaces dest with a copy of src
+func replaceFile(src, dest string, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0o700); err != nil {
+		return err
+	}
+	tmp := dest + ".restore-tmp"
+	if err := copyFile(src, tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dest)
+}
+
+// restoreSessions copies the archived sessions into the session directory
+func restoreSessions(staging string, m *manifest) (int, error) {
+	dir := filepath.Dir(session.GetPathForProfile("default"))
+	n := 0
+	for _, f := range m.Files {
+		if !strings.HasPrefix(f.Name, sessionsDir+"/") {
+			continue
+		}
+		name := strings.TrimPrefix(f.Name, sessionsDir+"/")
+		if name != filepath.Base(name) {
+			continue
+		}
+		if err := replaceFile(filepath.Join(staging, filepath.FromSlash(f.Name)), filepath.Join(dir, name), 0o600); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// syncDir flushes a directory entry change to disk where supported
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
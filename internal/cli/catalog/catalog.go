@@ -0,0 +1,61 @@
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/config"
+)
+
+const (
+	defaultDBPath     = "./data/mangahub.db"
+	defaultConfigPath = "config.yaml"
+)
+
+// CatalogCmd is the main catalog command (parent/root for catalog subcommands).
+var CatalogCmd = &cobra.Command{
+	Use:   "catalog",
+	Short: "Refresh the manga catalog from its metadata sources",
+	Long: `Keep the local SQLite catalog in step with its metadata providers, MangaDex
+and the JSON files in the data directory. Each manga keeps the latest entry of
+every source it is linked to; the entries are merged field by field in the
+source priority order set under catalog.priority in config.yaml.`,
+}
+
+// loadConfig reads the config file, falling back to the defaults when it
+// cannot be read and filling in the catalog defaults it leaves out
+func loadConfig(path string) *config.Config {
+	defaults := config.DefaultConfig()
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		fmt.Printf("⚠ %v, using defaults\n", err)
+		return defaults
+	}
+
+	catalog := &cfg.Catalog
+	if len(catalog.Providers) == 0 {
+		catalog.Providers = defaults.Catalog.Providers
+	}
+	if catalog.LocalDir == "" {
+		catalog.LocalDir = defaults.Catalog.LocalDir
+	}
+	if catalog.Language == "" {
+		catalog.Language = defaults.Catalog.Language
+	}
+	if catalog.WatchInterval <= 0 {
+		catalog.WatchInterval = defaults.Catalog.WatchInterval
+	}
+	if catalog.MaxBackoff <= 0 {
+		catalog.MaxBackoff = defaults.Catalog.MaxBackoff
+	}
+	return cfg
+}
+
+// truncateString truncates a string to max length with ellipsis
+func truncateString(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen-3] + "..."
+}
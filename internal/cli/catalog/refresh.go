@@ -0,0 +1,130 @@
+package catalog
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/catalog"
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// refreshCmd handles `mangahub catalog refresh`.
+var refreshCmd = &cobra.Command{
+	Use:   "refresh [manga-id...]",
+	Short: "Re-pull manga from their metadata providers",
+	Long: `Fetch catalog manga again from every provider they are linked to and merge
+the fresh entries into them. Without IDs, every manga in the catalog is
+refreshed.
+
+Manga are linked to MangaDex when they were imported from it; every manga
+is looked up in the local JSON files by its own ID. Fields take the value of
+the highest-priority source that has one, and the source of each field is
+recorded (see 'mangahub catalog sources'). Alternate titles are only ever
+added, and a stored cover is never replaced by a remote one.
+
+Examples:
+  # Refresh the whole catalog from the configured providers
+  mangahub catalog refresh
+
+  # Refresh two manga from MangaDex only
+  mangahub catalog refresh one-piece naruto --source mangadex
+
+  # Refresh from a local directory and store new covers
+  mangahub catalog refresh --local-dir ./data --covers`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+		configPath, _ := cmd.Flags().GetString("config")
+		sources, _ := cmd.Flags().GetStringSlice("source")
+		withCovers, _ := cmd.Flags().GetBool("covers")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+
+		cfg := loadConfig(configPath).Catalog
+		if len(sources) > 0 {
+			cfg.Providers = sources
+		}
+		if cmd.Flags().Changed("mangadex-url") {
+			cfg.MangaDexURL, _ = cmd.Flags().GetString("mangadex-url")
+		}
+		if cmd.Flags().Changed("mangadex-cover-url") {
+			cfg.MangaDexCoverURL, _ = cmd.Flags().GetString("mangadex-cover-url")
+		}
+		if cmd.Flags().Changed("local-dir") {
+			cfg.LocalDir, _ = cmd.Flags().GetString("local-dir")
+		}
+		providers, err := catalog.NewProviders(cfg)
+		if err != nil {
+			return err
+		}
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := localdb.Authorize(db, models.PermEditCatalog); err != nil {
+			return err
+		}
+
+		var coverStore *covers.Store
+		if withCovers {
+			if dataDir == "" {
+				dataDir = filepath.Dir(dbPath)
+			}
+			coverStore = covers.NewStore(dataDir, 0)
+		}
+
+		target := "every manga"
+		if len(args) > 0 {
+			target = fmt.Sprintf("%d manga", len(args))
+		}
+		fmt.Printf("🔄 Refreshing %s from %s...\n\n", target, strings.Join(cfg.Providers, ", "))
+
+		service := catalog.NewService(store.NewSQLiteMangaStore(db), store.NewSQLiteTitleStore(db),
+			store.NewSQLiteExternalIDStore(db), coverStore, catalog.DefaultPriority.WithOverrides(cfg.Priority), providers...)
+		report, err := service.RefreshAll(args)
+		if report != nil && len(report.Results) > 0 {
+			printRefreshTable(report.Results)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n✓ Updated %d · Unchanged or skipped %d\n", report.Updated, report.Skipped)
+		return nil
+	},
+}
+
+func init() {
+	CatalogCmd.AddCommand(refreshCmd)
+	refreshCmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+	refreshCmd.Flags().String("config", defaultConfigPath, "Path to the config file with the catalog settings")
+	refreshCmd.Flags().StringSlice("source", nil, "Only refresh from these providers (mangadex, local)")
+	refreshCmd.Flags().String("mangadex-url", "", "MangaDex API base URL (default: catalog.mangadex_url)")
+	refreshCmd.Flags().String("mangadex-cover-url", "", "MangaDex cover host URL (default: catalog.mangadex_cover_url)")
+	refreshCmd.Flags().String("local-dir", "", "Directory of the local JSON files (default: catalog.local_dir)")
+	refreshCmd.Flags().Bool("covers", false, "Download new covers into the cover store")
+	refreshCmd.Flags().String("data-dir", "", "Directory covers are stored under (default: the database's directory)")
+}
+
+// printRefreshTable prints what a refresh did with each manga
+func printRefreshTable(results []catalog.Result) {
+	icons := map[string]string{
+		catalog.ActionUpdated: "↻",
+		catalog.ActionSkipped: "·",
+	}
+
+	fmt.Println("┌───────────┬──────────────────────────────┬──────────────────────┬──────────────────────────────┐")
+	fmt.Printf("│ %-9s │ %-28s │ %-20s │ %-28s │\n", "ACTION", "TITLE", "MANGA ID", "NOTE")
+	fmt.Println("├───────────┼──────────────────────────────┼──────────────────────┼──────────────────────────────┤")
+	for _, r := range results {
+		fmt.Printf("│ %s %-7s │ %-28s │ %-20s │ %-28s │\n",
+			icons[r.Action], r.Action, truncateString(r.Title, 28), truncateString(r.MangaID, 20), truncateString(r.Note, 28))
+	}
+	fmt.Println("└───────────┴──────────────────────────────┴──────────────────────┴──────────────────────────────┘")
+}
@@ -0,0 +1,88 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// sourcesCmd handles `mangahub catalog sources`.
+var sourcesCmd = &cobra.Command{
+	Use:   "sources <manga-id>",
+	Short: "Show where a manga's catalog data came from",
+	Long: `Show the sources a manga is linked to, when each was last fetched, and
+which source supplied each of its fields in the last merge.
+
+Examples:
+  mangahub catalog sources one-piece
+  mangahub catalog sources naruto --db ./data/mangahub.db`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+		mangaID := args[0]
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		manga, err := store.NewSQLiteMangaStore(db).GetByID(mangaID)
+		if errors.Is(err, store.ErrMangaNotFound) {
+			return fmt.Errorf("manga %q not found", mangaID)
+		}
+		if err != nil {
+			return err
+		}
+		externalIDs := store.NewSQLiteExternalIDStore(db)
+		links, err := externalIDs.List(mangaID)
+		if err != nil {
+			return err
+		}
+		fields, err := externalIDs.FieldSources(mangaID)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("📚 %s (%s)\n\n", manga.Title, manga.ID)
+
+		fmt.Println("┌────────────┬──────────────────────────────────────┬─────────────────────┐")
+		fmt.Printf("│ %-10s │ %-36s │ %-19s │\n", "SOURCE", "EXTERNAL ID", "FETCHED")
+		fmt.Println("├────────────┼──────────────────────────────────────┼─────────────────────┤")
+		if len(links) == 0 {
+			fmt.Printf("│ %-10s │ %-36s │ %-19s │\n", "-", "not linked", "-")
+		}
+		for _, link := range links {
+			fetched := "never"
+			if link.FetchedAt != nil {
+				fetched = link.FetchedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("│ %-10s │ %-36s │ %-19s │\n", link.Source, truncateString(link.ExternalID, 36), fetched)
+		}
+		fmt.Println("└────────────┴──────────────────────────────────────┴─────────────────────┘")
+		fmt.Println()
+
+		fmt.Println("┌──────────────┬────────────┐")
+		fmt.Printf("│ %-12s │ %-10s │\n", "FIELD", "SOURCE")
+		fmt.Println("├──────────────┼────────────┤")
+		for _, field := range models.CatalogFields {
+			source := fields[field]
+			if source == "" {
+				source = "unknown"
+			}
+			fmt.Printf("│ %-12s │ %-10s │\n", field, source)
+		}
+		fmt.Println("└──────────────┴────────────┘")
+		return nil
+	},
+}
+
+func init() {
+	CatalogCmd.AddCommand(sourcesCmd)
+	sourcesCmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+}
@@ -0,0 +1,157 @@
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/catalog"
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// watchCmd handles `mangahub catalog watch`.
+var watchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Check subscribed manga for new chapters",
+	Long: `Poll the metadata providers for new chapters of the manga users are
+subscribed to, as the API server does in the background every
+catalog.watch_interval minutes.
+
+Each subscribed manga is pulled again like 'mangahub catalog refresh', and the
+chapters the providers list are added to it. When its latest chapter moved on,
+a chapter_release notification is stored for every subscriber with chapter
+releases enabled and one is pushed to the UDP notification server. Manga
+getting chapters for the first time do not notify. A provider that fails is
+skipped for a backoff that doubles with every failure in a row, up to
+catalog.max_backoff minutes.
+
+Examples:
+  # Poll once and push releases to the configured UDP server
+  mangahub catalog watch
+
+  # Poll a local fake MangaDex every minute without pushing
+  mangahub catalog watch --mangadex-url http://localhost:8099 --interval 1 --no-push`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dbPath, _ := cmd.Flags().GetString("db")
+		configPath, _ := cmd.Flags().GetString("config")
+		sources, _ := cmd.Flags().GetStringSlice("source")
+		udpAddr, _ := cmd.Flags().GetString("udp")
+		noPush, _ := cmd.Flags().GetBool("no-push")
+		interval, _ := cmd.Flags().GetInt("interval")
+
+		cfg := loadConfig(configPath)
+		catalogCfg := cfg.Catalog
+		if len(sources) > 0 {
+			catalogCfg.Providers = sources
+		}
+		if cmd.Flags().Changed("mangadex-url") {
+			catalogCfg.MangaDexURL, _ = cmd.Flags().GetString("mangadex-url")
+		}
+		if cmd.Flags().Changed("local-dir") {
+			catalogCfg.LocalDir, _ = cmd.Flags().GetString("local-dir")
+		}
+		providers, err := catalog.NewProviders(catalogCfg)
+		if err != nil {
+			return err
+		}
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := localdb.Authorize(db, models.PermEditCatalog); err != nil {
+			return err
+		}
+
+		var pusher catalog.Pusher
+		if !noPush {
+			if udpAddr == "" {
+				udpAddr = fmt.Sprintf("%s:%d", cfg.UDP.Host, cfg.UDP.Port)
+			}
+			pusher = catalog.NewUDPPusher(udpAddr)
+		}
+
+		backoff := time.Duration(interval) * time.Minute
+		if backoff <= 0 {
+			backoff = time.Duration(catalogCfg.WatchInterval) * time.Minute
+		}
+		stores := store.NewSQLiteStores(db)
+		service := catalog.NewService(stores.Manga, stores.Titles, stores.ExternalIDs, nil,
+			catalog.DefaultPriority.WithOverrides(catalogCfg.Priority), providers...)
+		watcher := catalog.NewWatcher(service, stores.Chapters, stores.Notifications, pusher,
+			backoff, time.Duration(catalogCfg.MaxBackoff)*time.Minute)
+
+		for {
+			fmt.Printf("👀 Checking subscribed manga on %s...\n\n", strings.Join(catalogCfg.Providers, ", "))
+			report, err := watcher.Poll()
+			if report != nil {
+				printPollReport(report, pusher != nil)
+			}
+			if err != nil {
+				return err
+			}
+			if interval <= 0 {
+				return nil
+			}
+			time.Sleep(time.Duration(interval) * time.Minute)
+			fmt.Println()
+		}
+	},
+}
+
+func init() {
+	CatalogCmd.AddCommand(watchCmd)
+	watchCmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+	watchCmd.Flags().String("config", defaultConfigPath, "Path to the config file with the catalog settings")
+	watchCmd.Flags().StringSlice("source", nil, "Only poll these providers (mangadex, local)")
+	watchCmd.Flags().String("mangadex-url", "", "MangaDex API base URL (default: catalog.mangadex_url)")
+	watchCmd.Flags().String("local-dir", "", "Directory of the local JSON files (default: catalog.local_dir)")
+	watchCmd.Flags().String("udp", "", "UDP notification server address (default: udp.host:udp.port)")
+	watchCmd.Flags().Bool("no-push", false, "Only store notifications, do not push them to the UDP server")
+	watchCmd.Flags().Int("interval", 0, "Keep polling every this many minutes (default: poll once)")
+}
+
+// printPollReport prints the releases a poll found and the providers that
+// failed or were backing off
+func printPollReport(report *catalog.PollReport, push bool) {
+	if len(report.Releases) == 0 {
+		fmt.Printf("No new chapters in %d subscribed manga.\n", report.Checked)
+	} else {
+		fmt.Println("┌──────────────────────────────┬──────────┬──────────┬──────────┬──────────────────────┐")
+		fmt.Printf("│ %-28s │ %-8s │ %-8s │ %-8s │ %-20s │\n", "TITLE", "PREVIOUS", "CHAPTER", "NOTIFIED", "PUSH")
+		fmt.Println("├──────────────────────────────┼──────────┼──────────┼──────────┼──────────────────────┤")
+		for _, r := range report.Releases {
+			pushed := "✓ pushed"
+			switch {
+			case r.Notified == 0:
+				pushed = "· no subscribers"
+			case !push:
+				pushed = "· stored only"
+			case r.PushError != "":
+				pushed = "✗ " + r.PushError
+			}
+			fmt.Printf("│ %-28s │ %8g │ %8g │ %8d │ %-20s │\n",
+				truncateString(r.Title, 28), r.Previous, r.Chapter, r.Notified, truncateString(pushed, 20))
+		}
+		fmt.Println("└──────────────────────────────┴──────────┴──────────┴──────────┴──────────────────────┘")
+		fmt.Printf("\n🔔 %d new releases in %d subscribed manga\n", len(report.Releases), report.Checked)
+	}
+
+	failed := make([]string, 0, len(report.Failed))
+	for source := range report.Failed {
+		failed = append(failed, source)
+	}
+	sort.Strings(failed)
+	for _, source := range failed {
+		fmt.Printf("⚠ %s failed: %s\n", source, report.Failed[source])
+	}
+	for source, retryAt := range report.Waiting {
+		fmt.Printf("⏸ %s is backing off until %s\n", source, retryAt.Format("15:04:05"))
+	}
+}
@@ -1,6 +1,13 @@
 package chat
 
-import "github.com/spf13/cobra"
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/client"
+	"mangahub/pkg/session"
+)
 
 // ChatCmd is the main chat command
 var ChatCmd = &cobra.Command{
@@ -8,3 +15,20 @@ var ChatCmd = &cobra.Command{
 	Short: "WebSocket chat system",
 	Long:  `Join chat rooms and communicate with other manga fans in real-time.`,
 }
+
+// getAPIURL returns the API server URL, where expired tokens are refreshed
+func getAPIURL() string {
+	if url := os.Getenv("MANGAHUB_API_URL"); url != "" {
+		return url
+	}
+	return "http://10.238.53.72:8080"
+}
+
+// chatToken returns the session's access token for the chat server,
+// refreshing it first when it has expired
+func chatToken(sess *session.Session) string {
+	if sess.TokenExpired() && sess.RefreshToken != "" {
+		client.NewSessionHTTPClient(getAPIURL(), sess).Refresh()
+	}
+	return sess.Token
+}
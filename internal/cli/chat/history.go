@@ -3,6 +3,7 @@ package chat
 import (
 	"fmt"
 	"mangahub/internal/cli/progress"
+	"mangahub/pkg/store"
 
 	"github.com/spf13/cobra"
 )
@@ -52,32 +53,17 @@ func showMessagesHistory(roomID string, limit int) error {
 	}
 	defer db.Close()
 
-	rows, err := db.Query(`SELECT username, message, created_at FROM chat_messages WHERE room_id = ? ORDER BY created_at DESC LIMIT ?`, roomID, limit)
+	messages, err := store.NewSQLiteChatStore(db).History(roomID, limit)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
-
-	type msg struct {
-		Username  string
-		Message   string
-		CreatedAt string
-	}
-	var messages []msg
-	for rows.Next() {
-		var m msg
-		if err := rows.Scan(&m.Username, &m.Message, &m.CreatedAt); err != nil {
-			return err
-		}
-		messages = append(messages, m)
-	}
 	if len(messages) == 0 {
 		fmt.Println("No messages found.")
 		return nil
 	}
 	// Print in reverse (oldest first)
 	for i := len(messages) - 1; i >= 0; i-- {
-		fmt.Printf("[%s] %s: %s\n", messages[i].CreatedAt, messages[i].Username, messages[i].Message)
+		fmt.Printf("[%s] %s: %s\n", messages[i].CreatedAt.Format("2006-01-02 15:04:05"), messages[i].Username, messages[i].Message)
 	}
 	return nil
 }
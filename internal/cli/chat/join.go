@@ -26,11 +26,9 @@ var joinCmd = &cobra.Command{
 		// Load session for user info
 		sess, err := session.Load()
 		if err != nil || sess.Token == "" {
-			fmt.Println("⚠ Not logged in. Using guest mode.")
-			sess = &session.Session{
-				UserID:   "guest-" + fmt.Sprintf("%d", time.Now().Unix()),
-				Username: "guest",
-			}
+			fmt.Println("⚠ Not logged in. Please login first.")
+			fmt.Println("  go run ./cmd/cli auth login --username <your-username>")
+			return nil
 		}
 
 		// Determine room ID
@@ -45,6 +43,7 @@ var joinCmd = &cobra.Command{
 
 		// Create WebSocket client
 		wsClient = client.NewWebSocketClient("ws://10.238.53.72:9093", sess.UserID, sess.Username)
+		wsClient.Token = chatToken(sess)
 
 		// Set callbacks
 		wsClient.SetCallbacks(
@@ -3,13 +3,14 @@ package chat
 import (
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/spf13/cobra"
 
 	"mangahub/internal/cli/progress"
 	"mangahub/pkg/client"
+	"mangahub/pkg/models"
 	"mangahub/pkg/session"
+	"mangahub/pkg/store"
 )
 
 var sendCmd = &cobra.Command{
@@ -39,6 +40,7 @@ var sendCmd = &cobra.Command{
 
 		// Create temporary client
 		wsClient := client.NewWebSocketClient("ws://10.238.53.72:9093", sess.UserID, sess.Username)
+		wsClient.Token = chatToken(sess)
 
 		if err := wsClient.Connect(roomID); err != nil {
 			fmt.Printf("❌ Failed to connect: %v\n", err)
@@ -59,15 +61,16 @@ var sendCmd = &cobra.Command{
 		fmt.Printf("Message: %s\n", message)
 
 		// Store message in SQLite
-		// Import progress.RequireDatabase and models.ChatMessage at top if not already
 		dbPath := "./data/mangahub.db"
 		db, err := progress.RequireDatabase(dbPath)
 		if err == nil {
 			defer db.Close()
-			msgID := fmt.Sprintf("%s-%d", sess.UserID, time.Now().UnixNano())
-			timestamp := time.Now().Unix()
-			_, err := db.Exec(`INSERT INTO chat_messages (id, user_id, username, room_id, message, timestamp) VALUES (?, ?, ?, ?, ?, ?)`,
-				msgID, sess.UserID, sess.Username, roomID, message, timestamp)
+			err := store.NewSQLiteChatStore(db).SaveMessage(&models.ChatMessage{
+				UserID:   sess.UserID,
+				Username: sess.Username,
+				RoomID:   roomID,
+				Message:  message,
+			})
 			if err != nil {
 				fmt.Printf("(SQLite) Failed to store message: %v\n", err)
 			}
@@ -32,14 +32,13 @@ var (
 // NewHTTPClient creates an HTTP client with optional auth token
 func NewHTTPClient() *client.HTTPClient {
 	apiURL := GetAPIURL()
-	token := ""
 
 	// Try to load session for auth token
 	if sess, err := session.Load(); err == nil {
-		token = sess.Token
+		return client.NewSessionHTTPClient(apiURL, sess)
 	}
 
-	return client.NewHTTPClient(apiURL, token)
+	return client.NewHTTPClient(apiURL, "")
 }
 
 // NewAuthenticatedHTTPClient creates an HTTP client and returns error if not logged in
@@ -50,5 +49,5 @@ func NewAuthenticatedHTTPClient() (*client.HTTPClient, *session.Session, error)
 	}
 
 	apiURL := GetAPIURL()
-	return client.NewHTTPClient(apiURL, sess.Token), sess, nil
+	return client.NewSessionHTTPClient(apiURL, sess), sess, nil
 }
@@ -16,7 +16,14 @@ const defaultDBPath = "./data/mangahub.db"
 var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check database integrity",
-	Long:  `Check the remote database integrity via HTTP API and verify core tables exist.`,
+	Long: `Check the remote database integrity via HTTP API, verify core tables exist,
+and compare the live schema with the one produced by the migrations.
+
+Use --fix to apply pending migrations and repair schema drift on the server.
+
+Examples:
+  mangahub db check
+  mangahub db check --fix`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get session for authentication
 		sess, err := session.Load()
@@ -26,7 +33,7 @@ var checkCmd = &cobra.Command{
 
 		// Create HTTP client
 		apiURL := getAPIURL()
-		httpClient := client.NewHTTPClient(apiURL, sess.Token)
+		httpClient := client.NewSessionHTTPClient(apiURL, sess)
 
 		// Fetch database check results from server
 		fmt.Printf("Checking remote database via HTTP API...\n")
@@ -58,16 +65,79 @@ var checkCmd = &cobra.Command{
 			fmt.Printf("✗ Missing table: %s\n", table)
 		}
 
-		if checkResp.Status == "healthy" {
+		// Display schema conformance results
+		fmt.Printf("\nSchema (version %d, latest %d):\n", checkResp.Schema.Version, checkResp.Schema.Latest)
+		if len(checkResp.Schema.Issues) == 0 {
+			fmt.Println("✓ Schema matches migrations")
+		}
+		printSchemaIssues(checkResp.Schema.Issues)
+
+		fix, _ := cmd.Flags().GetBool("fix")
+		if fix && !checkResp.Schema.OK {
+			fmt.Println("\nRepairing schema...")
+			fixResp, err := httpClient.FixDatabaseSchema()
+			if err != nil {
+				return fmt.Errorf("failed to fix database schema: %w", err)
+			}
+			for _, step := range fixResp.Steps {
+				fmt.Printf("✓ %s\n", step)
+			}
+			if len(fixResp.Steps) == 0 {
+				fmt.Println("No changes were needed.")
+			}
+			if fixResp.Status != "ok" {
+				fmt.Println("\nIssues that could not be fixed automatically:")
+				printSchemaIssues(fixResp.Remaining)
+				return fmt.Errorf("database schema repair incomplete")
+			}
+			checkResp.Schema.OK = true
+			fmt.Println("✓ Schema repaired")
+		}
+
+		if checkResp.Integrity.OK && len(checkResp.Tables.Missing) == 0 && checkResp.Schema.OK {
 			fmt.Println("\n✓ Database check completed successfully")
 			return nil
 		}
+		if !checkResp.Schema.OK {
+			fmt.Println("\nRun 'mangahub db check --fix' to repair the schema.")
+		}
 		return fmt.Errorf("database check found issues")
 	},
 }
 
+// printSchemaIssues prints schema issues as a diff, errors before warnings
+func printSchemaIssues(issues []client.SchemaIssue) {
+	for _, severity := range []string{"error", "warning"} {
+		for _, issue := range issues {
+			if issue.Severity != severity {
+				continue
+			}
+			mark := "✗"
+			if severity == "warning" {
+				mark = "⚠"
+			}
+			target := issue.Table
+			if issue.Object != "" {
+				target += "." + issue.Object
+			}
+			line := fmt.Sprintf("%s %-20s %s", mark, issue.Kind, target)
+			if issue.Expected != "" {
+				line += fmt.Sprintf("\n    - expected: %s", issue.Expected)
+			}
+			if issue.Actual != "" {
+				line += fmt.Sprintf("\n    + actual:   %s", issue.Actual)
+			}
+			if severity == "error" && !issue.Fixable {
+				line += "\n    (requires manual repair)"
+			}
+			fmt.Println(line)
+		}
+	}
+}
+
 func init() {
 	DBCmd.AddCommand(checkCmd)
+	checkCmd.Flags().Bool("fix", false, "Apply pending migrations and repair schema drift")
 }
 
 // getAPIURL returns the API URL from environment or default
@@ -0,0 +1,184 @@
+package db
+
+import (
+	"fmt"
+
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+
+	"github.com/spf13/cobra"
+)
+
+// migrateCmd handles `mangahub db migrate`.
+// Migrations operate on the local SQLite file directly, so servers using the
+// database should be stopped before rolling back.
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Manage database schema migrations",
+	Long: `Apply, roll back, and inspect versioned schema migrations on the local SQLite database.
+
+Examples:
+  mangahub db migrate status
+  mangahub db migrate up
+  mangahub db migrate up --to 3
+  mangahub db migrate down
+  mangahub db migrate down --to 1 --db ./data/mangahub.db`,
+}
+
+// migrateUpCmd handles `mangahub db migrate up`.
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "Apply pending migrations",
+	Long:  `Apply pending migrations in order, up to the latest version or the version given with --to.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		target, _ := cmd.Flags().GetInt("to")
+
+		db, err := openLocalDatabase(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		migrator := database.NewMigrator(db)
+		applied, err := migrator.RunUp(target)
+		for _, m := range applied {
+			fmt.Printf("✓ Applied %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("migration failed: %w", err)
+		}
+
+		if len(applied) == 0 {
+			fmt.Println("Database is already up to date.")
+		}
+
+		version, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nSchema version: %d (latest: %d)\n", version, migrator.LatestVersion())
+		return nil
+	},
+}
+
+// migrateDownCmd handles `mangahub db migrate down`.
+var migrateDownCmd = &cobra.Command{
+	Use:   "down",
+	Short: "Roll back migrations",
+	Long: `Roll back applied migrations newer than the version given with --to.
+Without --to, only the most recent migration is rolled back. Rolling back
+takes an admin login, checked against the role in the database.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := openLocalDatabase(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := localdb.Authorize(db, models.PermManageServer); err != nil {
+			return err
+		}
+
+		migrator := database.NewMigrator(db)
+		current, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+
+		target := current - 1
+		if cmd.Flags().Changed("to") {
+			target, _ = cmd.Flags().GetInt("to")
+		}
+		if target < 0 {
+			fmt.Println("No migrations to roll back.")
+			return nil
+		}
+
+		reverted, err := migrator.RunDown(target)
+		for _, m := range reverted {
+			fmt.Printf("✓ Rolled back %04d %s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return fmt.Errorf("rollback failed: %w", err)
+		}
+
+		if len(reverted) == 0 {
+			fmt.Println("Nothing to roll back.")
+		}
+
+		version, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nSchema version: %d (latest: %d)\n", version, migrator.LatestVersion())
+		return nil
+	},
+}
+
+// migrateStatusCmd handles `mangahub db migrate status`.
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show migration status",
+	Long:  `List every known migration with whether it has been applied, and flag unknown or modified versions.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		db, err := openLocalDatabase(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		migrator := database.NewMigrator(db)
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%-8s %-32s %-10s %s\n", "VERSION", "NAME", "STATE", "APPLIED AT")
+		for _, st := range statuses {
+			appliedAt := "-"
+			if st.AppliedAt != nil {
+				appliedAt = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-8s %-32s %-10s %s\n", fmt.Sprintf("%04d", st.Version), st.Name, st.State, appliedAt)
+		}
+
+		version, err := migrator.CurrentVersion()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("\nSchema version: %d (latest: %d)\n", version, migrator.LatestVersion())
+
+		if err := migrator.Verify(); err != nil {
+			return err
+		}
+		return nil
+	},
+}
+
+// openLocalDatabase opens the SQLite file selected with --db without running
+// migrations. Commands that throw data away check the logged-in user's role
+// in it; applying migrations does not, as the servers apply them on start
+// and a new database has no admin yet.
+func openLocalDatabase(cmd *cobra.Command) (*database.Database, error) {
+	path, _ := cmd.Flags().GetString("db")
+	if path == "" {
+		path = defaultDBPath
+	}
+
+	db, err := database.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	return db, nil
+}
+
+func init() {
+	DBCmd.AddCommand(migrateCmd)
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+
+	migrateCmd.PersistentFlags().String("db", defaultDBPath, "Path to the SQLite database file")
+	migrateUpCmd.Flags().Int("to", 0, "Target version (default: latest)")
+	migrateDownCmd.Flags().Int("to", 0, "Target version to roll back to (default: previous version)")
+}
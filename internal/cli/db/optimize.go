@@ -26,7 +26,7 @@ Use this periodically after large imports or many updates to keep the database c
 
 		// Create HTTP client
 		apiURL := getAPIURL()
-		httpClient := client.NewHTTPClient(apiURL, sess.Token)
+		httpClient := client.NewSessionHTTPClient(apiURL, sess)
 
 		// Optimize database on server
 		fmt.Printf("Optimizing remote database via HTTP API...\n\n")
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"mangahub/internal/cli/localdb"
+	"mangahub/pkg/config"
+	"mangahub/pkg/models"
+	"mangahub/pkg/retention"
+	"mangahub/pkg/utils"
+
+	"github.com/spf13/cobra"
+)
+
+// pruneCmd handles `mangahub db prune`.
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Prune old rows and rotate the server log",
+	Long: `Apply the retention policies from config.yaml to the local SQLite database:
+delete chat messages and notifications older than their retention period and
+rotate the server log once it grows past log_max_size_mb.
+
+The API server runs the same job in the background every prune_interval
+minutes. Use --dry-run to see what would be deleted without changing anything.
+Pruning takes an admin login, checked against the role in the database.
+
+Examples:
+  mangahub db prune --dry-run
+  mangahub db prune
+  mangahub db prune --db ./data/mangahub.db --config config.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		configPath, _ := cmd.Flags().GetString("config")
+
+		cfg, err := config.LoadConfig(configPath)
+		if err != nil {
+			fmt.Printf("⚠ %v, using defaults\n", err)
+			cfg = config.DefaultConfig()
+		}
+
+		db, err := openLocalDatabase(cmd)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := localdb.Authorize(db, models.PermManageServer); err != nil {
+			return err
+		}
+
+		results, err := retention.Prune(db, retention.Policies(cfg.Retention), time.Now(), dryRun)
+		if err != nil {
+			return err
+		}
+
+		verb := "DELETED"
+		if dryRun {
+			verb = "TO DELETE"
+			fmt.Println("Dry run: nothing will be deleted.")
+		}
+		fmt.Println()
+		fmt.Println("┌──────────────────┬────────────┬─────────────────────┬────────────┐")
+		fmt.Printf("│ %-16s │ %-10s │ %-19s │ %10s │\n", "TABLE", "RETENTION", "OLDER THAN", verb)
+		fmt.Println("├──────────────────┼────────────┼─────────────────────┼────────────┤")
+		for _, p := range retention.Policies(cfg.Retention) {
+			if p.Days <= 0 {
+				fmt.Printf("│ %-16s │ %-10s │ %-19s │ %10s │\n", p.Table, "forever", "-", "-")
+				continue
+			}
+			for _, r := range results {
+				if r.Table == p.Table {
+					fmt.Printf("│ %-16s │ %-10s │ %-19s │ %10d │\n", r.Table, fmt.Sprintf("%d days", r.Days),
+						r.Before.Local().Format("2006-01-02 15:04:05"), r.Rows)
+				}
+			}
+		}
+		fmt.Println("└──────────────────┴────────────┴─────────────────────┴────────────┘")
+
+		logPath, err := utils.GetLogFilePath()
+		if err != nil {
+			return err
+		}
+		maxSize := int64(cfg.Retention.LogMaxSizeMB) << 20
+		rotation, err := retention.RotateLog(logPath, maxSize, cfg.Retention.LogMaxBackups, dryRun)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println()
+		switch {
+		case rotation.Size == 0:
+			fmt.Printf("Server log %s is empty or missing.\n", logPath)
+		case !rotation.Rotated:
+			fmt.Printf("Server log %s is %.2f MB (rotates at %d MB).\n", logPath, float64(rotation.Size)/1024/1024, cfg.Retention.LogMaxSizeMB)
+		case dryRun:
+			fmt.Printf("Server log %s (%.2f MB) would be rotated.\n", logPath, float64(rotation.Size)/1024/1024)
+		default:
+			fmt.Printf("✓ Rotated server log %s (%.2f MB)\n", logPath, float64(rotation.Size)/1024/1024)
+		}
+		return nil
+	},
+}
+
+func init() {
+	DBCmd.AddCommand(pruneCmd)
+	pruneCmd.Flags().String("db", defaultDBPath, "Path to the SQLite database file")
+	pruneCmd.Flags().String("config", "config.yaml", "Path to the config file with the retention policies")
+	pruneCmd.Flags().Bool("dry-run", false, "Report what would be deleted without deleting it")
+}
@@ -24,7 +24,7 @@ and re-run schema initialization to ensure tables and indexes exist.`,
 
 		// Create HTTP client
 		apiURL := getAPIURL()
-		httpClient := client.NewHTTPClient(apiURL, sess.Token)
+		httpClient := client.NewSessionHTTPClient(apiURL, sess)
 
 		// Repair database on server
 		fmt.Printf("Repairing remote database via HTTP API...\n\n")
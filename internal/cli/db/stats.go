@@ -13,7 +13,7 @@ import (
 var statsCmd = &cobra.Command{
 	Use:   "stats",
 	Short: "Show database statistics",
-	Long:  `Display basic statistics for the remote database via HTTP API such as size and row counts.`,
+	Long:  `Display basic statistics for the remote database via HTTP API such as size, row counts and retention settings.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get session for authentication
 		sess, err := session.Load()
@@ -23,7 +23,7 @@ var statsCmd = &cobra.Command{
 
 		// Create HTTP client
 		apiURL := getAPIURL()
-		httpClient := client.NewHTTPClient(apiURL, sess.Token)
+		httpClient := client.NewSessionHTTPClient(apiURL, sess)
 
 		// Fetch database stats from server
 		fmt.Printf("Fetching database statistics via HTTP API...\n\n")
@@ -35,23 +35,46 @@ var statsCmd = &cobra.Command{
 		// Display file size
 		fmt.Printf("File size: %.2f MB\n", statsResp.FileSizeMB)
 
-		// Display table counts
-		fmt.Println("\nRow counts:")
-		tableOrder := []string{"users", "manga", "user_progress", "chat_messages", "notifications"}
+		// Display table counts next to their retention settings
+		fmt.Println()
+		fmt.Println("┌──────────────────┬────────────┬──────────────────────┐")
+		fmt.Printf("│ %-16s │ %10s │ %-20s │\n", "TABLE", "ROWS", "RETENTION")
+		fmt.Println("├──────────────────┼────────────┼──────────────────────┤")
+		tableOrder := []string{"users", "manga", "user_progress", "progress_events", "chat_messages", "notifications"}
 		for _, name := range tableOrder {
-			if count, exists := statsResp.Tables[name]; exists {
-				if count >= 0 {
-					fmt.Printf("  %s: %d rows\n", name, count)
-				} else {
-					fmt.Printf("  %s: error\n", name)
-				}
+			count, exists := statsResp.Tables[name]
+			if !exists {
+				continue
 			}
+			rows := "error"
+			if count >= 0 {
+				rows = fmt.Sprintf("%d", count)
+			}
+			fmt.Printf("│ %-16s │ %10s │ %-20s │\n", name, rows, retentionLabel(statsResp, name))
 		}
+		fmt.Println("└──────────────────┴────────────┴──────────────────────┘")
 
 		return nil
 	},
 }
 
+// retentionLabel describes how long rows of a table are kept
+func retentionLabel(stats *client.DatabaseStatsResponse, table string) string {
+	if days, ok := stats.RetentionDays[table]; ok {
+		if days <= 0 {
+			return "forever"
+		}
+		return fmt.Sprintf("%d days", days)
+	}
+	if table == "manga" || table == "user_progress" {
+		if stats.TrashRetentionDays <= 0 {
+			return "trash kept forever"
+		}
+		return fmt.Sprintf("trash %d days", stats.TrashRetentionDays)
+	}
+	return "-"
+}
+
 func init() {
 	DBCmd.AddCommand(statsCmd)
 }
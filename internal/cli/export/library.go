@@ -61,7 +61,7 @@ func runExportLibraryForPath(format, output string) error {
 
 	// Create HTTP client with authentication token
 	fmt.Printf("Fetching library from API server via HTTP...\n")
-	httpClient := client.NewHTTPClient(getAPIURL(), sess.Token)
+	httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
 
 	// Fetch library from HTTP API (using network protocol!)
 	entries, err := httpClient.GetLibrary("", 10000, 0)
@@ -54,7 +54,7 @@ func runExportProgressForPath(format, output string) error {
 
 	// Create HTTP client and fetch from API server
 	fmt.Printf("Fetching progress from API server via HTTP...\n")
-	httpClient := client.NewHTTPClient(getAPIURL(), sess.Token)
+	httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
 
 	entries, err := httpClient.GetLibrary("", 10000, 0)
 	if err != nil {
@@ -1,6 +1,10 @@
 package grpc
 
-import "github.com/spf13/cobra"
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+)
 
 // GRPCCmd is the main gRPC command
 var GRPCCmd = &cobra.Command{
@@ -8,3 +12,11 @@ var GRPCCmd = &cobra.Command{
 	Short: "gRPC service operations",
 	Long:  `Query and manipulate manga data via gRPC service calls.`,
 }
+
+// getAPIURL returns the API server URL, where expired tokens are refreshed
+func getAPIURL() string {
+	if url := os.Getenv("MANGAHUB_API_URL"); url != "" {
+		return url
+	}
+	return "http://10.238.53.72:8080"
+}
@@ -2,8 +2,12 @@ package grpc
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 
 	"mangahub/pkg/client"
+	"mangahub/pkg/models"
+	"mangahub/pkg/output"
 
 	"github.com/spf13/cobra"
 )
@@ -71,11 +75,14 @@ func runGetManga(cmd *cobra.Command, args []string) error {
 // searchCmd is the search subcommand under manga
 var searchCmd = &cobra.Command{
 	Use:   "search",
-	Short: "Search manga by query",
-	Long: `Search manga from gRPC server using a search query.
+	Short: "Search manga by query and filters",
+	Long: `Search manga from gRPC server using a search query and the same filters
+and sort modes as 'mangahub manga advanced-search'.
 
-Example:
-  mangahub grpc manga search --query "naruto"`,
+Examples:
+  mangahub grpc manga search --query "naruto"
+  mangahub grpc manga search --genre romance --min-rating 8 --sort-by rating
+  mangahub grpc manga search --year-from 2010 --sort-by popularity`,
 	RunE: runSearchManga,
 }
 
@@ -83,9 +90,24 @@ func runSearchManga(cmd *cobra.Command, args []string) error {
 	query, _ := cmd.Flags().GetString("query")
 	serverAddr, _ := cmd.Flags().GetString("server")
 	limit, _ := cmd.Flags().GetInt("limit")
+	genres, _ := cmd.Flags().GetString("genre")
+	filter := &models.MangaFilter{Query: query, Limit: limit}
+	filter.Author, _ = cmd.Flags().GetString("author")
+	filter.Status, _ = cmd.Flags().GetString("status")
+	filter.YearFrom, _ = cmd.Flags().GetInt("year-from")
+	filter.YearTo, _ = cmd.Flags().GetInt("year-to")
+	filter.MinChapters, _ = cmd.Flags().GetInt("min-chapters")
+	filter.MinRating, _ = cmd.Flags().GetFloat64("min-rating")
+	filter.SortBy, _ = cmd.Flags().GetString("sort-by")
+	filter.Order, _ = cmd.Flags().GetString("order")
+	if genres != "" {
+		filter.Genres = strings.Split(genres, ",")
+	}
 
-	if query == "" {
-		return fmt.Errorf("search query is required. Use --query or -q flag")
+	if query == "" && filter.Author == "" && filter.Status == "" && len(filter.Genres) == 0 &&
+		filter.YearFrom == 0 && filter.YearTo == 0 && filter.MinChapters == 0 && filter.MinRating == 0 &&
+		filter.SortBy == "" {
+		return fmt.Errorf("a search query or filter is required. Use --query or -q, or a filter flag")
 	}
 
 	fmt.Printf("Connecting to gRPC server at %s...\n", serverAddr)
@@ -97,10 +119,12 @@ func runSearchManga(cmd *cobra.Command, args []string) error {
 	}
 	defer grpcClient.Close()
 
-	fmt.Printf("Searching for: %s\n\n", query)
+	if query != "" {
+		fmt.Printf("Searching for: %s\n\n", query)
+	}
 
 	// Call gRPC server
-	resp, err := grpcClient.SearchManga(query, limit)
+	resp, err := grpcClient.SearchManga(filter)
 	if err != nil {
 		return fmt.Errorf("gRPC error: %w", err)
 	}
@@ -115,7 +139,196 @@ func runSearchManga(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("Found %d results:\n", len(resp.Results))
 	for i, manga := range resp.Results {
-		fmt.Printf("  %d. %s (%s) - %s\n", i+1, manga.Title, manga.Author, manga.Status)
+		fmt.Printf("  %d. %s (%s) - %s", i+1, manga.Title, manga.Author, manga.Status)
+		if manga.Year > 0 {
+			fmt.Printf(", %d", manga.Year)
+		}
+		fmt.Printf(" · %d readers", manga.Readers)
+		if manga.Rating > 0 {
+			fmt.Printf(" · ★ %.1f", manga.Rating)
+		}
+		fmt.Println()
+		if manga.Snippet != "" {
+			fmt.Printf("     %s\n", output.FormatSnippet(manga.Snippet))
+		}
+	}
+
+	return nil
+}
+
+// chaptersCmd is the chapters subcommand under manga
+var chaptersCmd = &cobra.Command{
+	Use:   "chapters",
+	Short: "List the chapters of a manga",
+	Long: `List a manga's chapters from the gRPC server.
+
+Example:
+  mangahub grpc manga chapters --id one-piece --lang en`,
+	RunE: runListChapters,
+}
+
+func runListChapters(cmd *cobra.Command, args []string) error {
+	mangaID, _ := cmd.Flags().GetString("id")
+	serverAddr, _ := cmd.Flags().GetString("server")
+	lang, _ := cmd.Flags().GetString("lang")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if mangaID == "" {
+		return fmt.Errorf("manga ID is required. Use --id or -i flag")
+	}
+
+	fmt.Printf("Connecting to gRPC server at %s...\n", serverAddr)
+
+	// Create gRPC client and connect
+	grpcClient := client.NewGRPCClient(serverAddr)
+	if err := grpcClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer grpcClient.Close()
+
+	// Call gRPC server
+	resp, err := grpcClient.ListChapters(mangaID, lang, limit, 0)
+	if err != nil {
+		return fmt.Errorf("gRPC error: %w", err)
+	}
+
+	fmt.Println("✓ Chapters retrieved via gRPC")
+	fmt.Println()
+
+	if len(resp.Chapters) == 0 {
+		fmt.Println("No chapters found.")
+		return nil
+	}
+
+	for _, ch := range resp.Chapters {
+		line := fmt.Sprintf("  Ch. %-6s", strconv.FormatFloat(ch.Number, 'f', -1, 64))
+		if ch.Volume > 0 {
+			line += fmt.Sprintf(" Vol. %-3d", ch.Volume)
+		}
+		if ch.Title != "" {
+			line += " " + ch.Title
+		}
+		line += " [" + ch.Language + "]"
+		if ch.ReleasedAt != "" {
+			line += " " + ch.ReleasedAt[:10]
+		}
+		fmt.Println(line)
+	}
+
+	return nil
+}
+
+// topCmd is the top subcommand under manga
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show the manga rankings",
+	Long: `Show the highest ranked manga from the gRPC server, ranked by active
+readers, completions, ratings and reading activity.
+
+Examples:
+  mangahub grpc manga top
+  mangahub grpc manga top --window month --genre action`,
+	RunE: runTopManga,
+}
+
+func runTopManga(cmd *cobra.Command, args []string) error {
+	serverAddr, _ := cmd.Flags().GetString("server")
+	window, _ := cmd.Flags().GetString("window")
+	genre, _ := cmd.Flags().GetString("genre")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	fmt.Printf("Connecting to gRPC server at %s...\n", serverAddr)
+
+	// Create gRPC client and connect
+	grpcClient := client.NewGRPCClient(serverAddr)
+	if err := grpcClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer grpcClient.Close()
+
+	// Call gRPC server
+	resp, err := grpcClient.GetRankings(window, genre, limit)
+	if err != nil {
+		return fmt.Errorf("gRPC error: %w", err)
+	}
+
+	fmt.Println("✓ Rankings retrieved via gRPC")
+	fmt.Println()
+
+	if len(resp.Rankings) == 0 {
+		fmt.Println("No ranked manga yet.")
+		return nil
+	}
+
+	fmt.Printf("Top manga (%s", resp.Window)
+	if resp.Genre != "" {
+		fmt.Printf(", %s", resp.Genre)
+	}
+	fmt.Printf(") as of %s:\n", resp.GeneratedAt)
+	for _, manga := range resp.Rankings {
+		fmt.Printf("  %2d. %s (%s) · %.1f pts · %d readers · %d completed",
+			manga.Rank, manga.Title, manga.Author, manga.Score, manga.Readers, manga.Completions)
+		if manga.Rating > 0 {
+			fmt.Printf(" · ★ %.1f", manga.Rating)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// similarCmd is the similar subcommand under manga
+var similarCmd = &cobra.Command{
+	Use:   "similar",
+	Short: "Show manga similar to a series",
+	Long: `Show the manga most similar to a series from the gRPC server, by genre
+overlap and co-reading.
+
+Example:
+  mangahub grpc manga similar --id one-piece`,
+	RunE: runSimilarManga,
+}
+
+func runSimilarManga(cmd *cobra.Command, args []string) error {
+	mangaID, _ := cmd.Flags().GetString("id")
+	serverAddr, _ := cmd.Flags().GetString("server")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	if mangaID == "" {
+		return fmt.Errorf("manga ID is required. Use --id or -i flag")
+	}
+
+	fmt.Printf("Connecting to gRPC server at %s...\n", serverAddr)
+
+	// Create gRPC client and connect
+	grpcClient := client.NewGRPCClient(serverAddr)
+	if err := grpcClient.Connect(); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer grpcClient.Close()
+
+	// Call gRPC server
+	resp, err := grpcClient.GetSimilarManga(mangaID, limit)
+	if err != nil {
+		return fmt.Errorf("gRPC error: %w", err)
+	}
+
+	fmt.Println("✓ Similar manga retrieved via gRPC")
+	fmt.Println()
+
+	if len(resp.Similar) == 0 {
+		fmt.Println("No similar manga found yet.")
+		return nil
+	}
+
+	fmt.Printf("Similar to %s", resp.MangaID)
+	if resp.ComputedAt != "" {
+		fmt.Printf(" as of %s", resp.ComputedAt)
+	}
+	fmt.Println(":")
+	for i, manga := range resp.Similar {
+		fmt.Printf("  %2d. %s (%s) · %.2f · %s\n",
+			i+1, manga.Title, manga.Author, manga.Score, strings.Join(manga.Genres, ", "))
 	}
 
 	return nil
@@ -125,11 +338,37 @@ func init() {
 	GRPCCmd.AddCommand(mangaCmd)
 	mangaCmd.AddCommand(getCmd)
 	mangaCmd.AddCommand(searchCmd)
+	mangaCmd.AddCommand(chaptersCmd)
+	mangaCmd.AddCommand(topCmd)
+	mangaCmd.AddCommand(similarCmd)
 
 	getCmd.Flags().StringP("id", "i", "", "Manga ID (required)")
 	getCmd.Flags().StringP("server", "s", "10.238.53.72:9092", "gRPC server address")
 
-	searchCmd.Flags().StringP("query", "q", "", "Search query (required)")
+	searchCmd.Flags().StringP("query", "q", "", "Search query")
 	searchCmd.Flags().StringP("server", "s", "10.238.53.72:9092", "gRPC server address")
 	searchCmd.Flags().IntP("limit", "l", 10, "Maximum number of results")
+	searchCmd.Flags().StringP("genre", "g", "", "Genres (comma-separated)")
+	searchCmd.Flags().String("status", "", "Status filter")
+	searchCmd.Flags().StringP("author", "a", "", "Author name")
+	searchCmd.Flags().Int("year-from", 0, "Earliest publication year")
+	searchCmd.Flags().Int("year-to", 0, "Latest publication year")
+	searchCmd.Flags().Int("min-chapters", 0, "Minimum chapter count")
+	searchCmd.Flags().Float64("min-rating", 0, "Minimum average user rating (0-10)")
+	searchCmd.Flags().String("sort-by", "", "Sort mode: "+strings.Join(models.MangaSortModes, ", "))
+	searchCmd.Flags().String("order", "", "Sort order (asc/desc)")
+
+	chaptersCmd.Flags().StringP("id", "i", "", "Manga ID (required)")
+	chaptersCmd.Flags().StringP("server", "s", "10.238.53.72:9092", "gRPC server address")
+	chaptersCmd.Flags().String("lang", "", "Only chapters in this language")
+	chaptersCmd.Flags().IntP("limit", "l", 100, "Maximum number of chapters")
+
+	topCmd.Flags().StringP("server", "s", "10.238.53.72:9092", "gRPC server address")
+	topCmd.Flags().StringP("window", "w", "week", "Ranking window: "+strings.Join(models.RankingWindows, ", "))
+	topCmd.Flags().StringP("genre", "g", "", "Only rank manga of this genre")
+	topCmd.Flags().IntP("limit", "l", 10, "Number of manga to show")
+
+	similarCmd.Flags().StringP("id", "i", "", "Manga ID (required)")
+	similarCmd.Flags().StringP("server", "s", "10.238.53.72:9092", "gRPC server address")
+	similarCmd.Flags().IntP("limit", "l", 10, "Number of manga to show (max 50)")
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"mangahub/pkg/client"
+	"mangahub/pkg/session"
 
 	"github.com/spf13/cobra"
 )
@@ -19,7 +20,9 @@ var progressCmd = &cobra.Command{
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "Update reading progress",
-	Long: `Update reading progress for a manga via gRPC server.
+	Long: `Update your reading progress for a manga via gRPC server. The call is made
+with your login session; updating another user's progress with --user-id
+takes an admin.
 
 Example:
   mangahub grpc progress update --manga-id one-piece --chapter 1095`,
@@ -39,10 +42,32 @@ func runUpdateProgress(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("chapter number is required. Use --chapter or -c flag")
 	}
 
+	sess, err := session.Load()
+	if err != nil || sess.Token == "" {
+		fmt.Println("You are not logged in.")
+		fmt.Println("\nPlease login first:")
+		fmt.Println("  mangahub auth login --username <username>")
+		return nil
+	}
+	if userID == "" {
+		userID = sess.UserID
+	}
+
 	fmt.Printf("Connecting to gRPC server at %s...\n", serverAddr)
 
 	// Create gRPC client and connect
 	grpcClient := client.NewGRPCClient(serverAddr)
+	grpcClient.Token = sess.Token
+	if sess.RefreshToken != "" {
+		httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+		grpcClient.RefreshToken = func() (string, error) {
+			resp, err := httpClient.Refresh()
+			if err != nil {
+				return "", err
+			}
+			return resp.Token, nil
+		}
+	}
 	if err := grpcClient.Connect(); err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -76,6 +101,6 @@ func init() {
 
 	updateCmd.Flags().StringP("manga-id", "m", "", "Manga ID (required)")
 	updateCmd.Flags().IntP("chapter", "c", 0, "Chapter number (required)")
-	updateCmd.Flags().StringP("user-id", "u", "default-user", "User ID")
+	updateCmd.Flags().StringP("user-id", "u", "", "User ID (default: the logged-in user)")
 	updateCmd.Flags().StringP("server", "s", "10.238.53.72:9092", "gRPC server address")
 }
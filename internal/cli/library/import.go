@@ -0,0 +1,153 @@
+package library
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/models"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import --format mal|anilist <file>",
+	Short: "Import your library from MyAnimeList or AniList",
+	Long: `Bring your reading history over from another tracker via the API server.
+
+Formats:
+  mal      MyAnimeList manga list export (XML, or the .xml.gz MyAnimeList hands out)
+  anilist  AniList MediaListCollection JSON of your manga lists
+
+Entries are matched to catalog manga by their MyAnimeList or AniList ID, which
+MangaDex imports link, and otherwise by title. Statuses are mapped onto the
+library's (Plan to Read and PLANNING become plan-to-read, PAUSED on-hold, and
+so on). An import only moves entries forward: chapters never go back, completed
+manga stay completed, and ratings or notes you already set are kept. Titles
+that match no catalog manga are listed at the end.
+
+Examples:
+  # See what would change first
+  mangahub library import --format mal animelist_1700000000_-_12345.xml.gz --dry-run
+
+  # Import an AniList export
+  mangahub library import --format anilist anilist.json`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		format = strings.ToLower(format)
+		if format != "mal" && format != "anilist" {
+			return fmt.Errorf("invalid format '%s'. Valid options: mal, anilist", format)
+		}
+
+		export, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", args[0], err)
+		}
+
+		httpClient, _, err := newAuthenticatedHTTPClient()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		if dryRun {
+			fmt.Printf("🔍 Checking %s against your library (dry run)...\n\n", args[0])
+		} else {
+			fmt.Printf("📥 Importing %s into your library...\n\n", args[0])
+		}
+
+		report, err := httpClient.ImportLibrary(format, export, dryRun)
+		if err != nil && report == nil {
+			return fmt.Errorf("failed to import library: %w", err)
+		}
+
+		printImportReport(report)
+		if err != nil {
+			if report.DryRun {
+				fmt.Printf("\n⚠ The check stopped part way; only the %d entries above were checked.\n", len(report.Results))
+			} else {
+				fmt.Printf("\n⚠ The import stopped part way; the %d entries above were imported and the rest were not.\n", len(report.Results))
+				fmt.Println("Running it again is safe: entries already imported come back unchanged.")
+			}
+			return err
+		}
+		return nil
+	},
+}
+
+func init() {
+	LibraryCmd.AddCommand(importCmd)
+	importCmd.Flags().StringP("format", "f", "", "Export format (mal, anilist)")
+	importCmd.Flags().Bool("dry-run", false, "Show what would change without writing anything")
+	importCmd.MarkFlagRequired("format")
+}
+
+// printImportReport prints the changes of an import, then the entries it
+// could not match or skipped
+func printImportReport(report *models.ImportReport) {
+	icons := map[string]string{
+		models.ImportAdded:   "✚",
+		models.ImportUpdated: "↻",
+	}
+
+	var changed, unmatched, skipped []models.ImportResult
+	for _, r := range report.Results {
+		switch r.Action {
+		case models.ImportAdded, models.ImportUpdated:
+			changed = append(changed, r)
+		case models.ImportUnmatched:
+			unmatched = append(unmatched, r)
+		case models.ImportSkipped:
+			skipped = append(skipped, r)
+		}
+	}
+
+	if len(changed) > 0 {
+		fmt.Println("┌───────────┬──────────────────────────────┬──────────────────────┬────────────────────────────────────────┐")
+		fmt.Printf("│ %-9s │ %-28s │ %-20s │ %-38s │\n", "ACTION", "TITLE", "MANGA ID", "CHANGES")
+		fmt.Println("├───────────┼──────────────────────────────┼──────────────────────┼────────────────────────────────────────┤")
+		for _, r := range changed {
+			fmt.Printf("│ %s %-7s │ %-28s │ %-20s │ %-38s │\n",
+				icons[r.Action], r.Action, truncateString(r.Title, 28), truncateString(r.MangaID, 20),
+				truncateString(formatChanges(r.Changes), 38))
+		}
+		fmt.Println("└───────────┴──────────────────────────────┴──────────────────────┴────────────────────────────────────────┘")
+	}
+
+	if len(unmatched) > 0 {
+		fmt.Printf("\n❓ %d titles match no catalog manga:\n", len(unmatched))
+		for _, r := range unmatched {
+			fmt.Printf("  • %s (%s %s)\n", r.Title, r.Source, r.ExternalID)
+		}
+	}
+	if len(skipped) > 0 {
+		fmt.Printf("\n⏭  %d entries skipped:\n", len(skipped))
+		for _, r := range skipped {
+			fmt.Printf("  • %s: %s\n", r.Title, r.Note)
+		}
+	}
+
+	fmt.Printf("\n✓ Added %d · Updated %d · Unchanged %d · Unmatched %d · Skipped %d\n",
+		report.Added, report.Updated, report.Unchanged, report.Unmatched, report.Skipped)
+	if report.DryRun {
+		fmt.Println("Dry run: nothing was written. Run again without --dry-run to import.")
+	}
+}
+
+// formatChanges shows changes as "status reading → completed, chapter 120"
+func formatChanges(changes []models.ImportChange) string {
+	parts := make([]string, 0, len(changes))
+	for _, c := range changes {
+		if c.From == "" {
+			parts = append(parts, fmt.Sprintf("%s %s", c.Field, c.To))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s %s → %s", c.Field, c.From, c.To))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,69 @@
+package library
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var recommendCmd = &cobra.Command{
+	Use:   "recommend",
+	Short: "Get manga recommendations",
+	Long: `Recommend manga that are not in your library yet via the API server.
+
+Recommendations come from the series similar to the ones in your library,
+weighted by your ratings, and favor your most read genres.
+
+Examples:
+  mangahub library recommend
+  mangahub library recommend --limit 20`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		// Check if user is logged in
+		httpClient, session, err := newAuthenticatedHTTPClient()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		recs, err := httpClient.GetRecommendations(limit)
+		if err != nil {
+			return fmt.Errorf("failed to get recommendations: %w", err)
+		}
+
+		fmt.Printf("💡 Recommended for %s\n", session.Username)
+		if len(recs.FavoriteGenres) > 0 {
+			fmt.Printf("Favorite genres: %s\n", strings.Join(recs.FavoriteGenres, ", "))
+		}
+		fmt.Println()
+
+		if len(recs.Recommendations) == 0 {
+			fmt.Println("No recommendations yet. Add and rate some manga first:")
+			fmt.Println("  mangahub library add --manga-id <id> --rating <1-10>")
+			return nil
+		}
+
+		fmt.Println("┌──────────────┬────────────────────────────────┬───────┬──────────────────────────────────┐")
+		fmt.Printf("│ %-12s │ %-30s │ %5s │ %-32s │\n", "ID", "TITLE", "SCORE", "BECAUSE YOU READ")
+		fmt.Println("├──────────────┼────────────────────────────────┼───────┼──────────────────────────────────┤")
+		for _, r := range recs.Recommendations {
+			fmt.Printf("│ %-12s │ %-30s │ %5.2f │ %-32s │\n",
+				truncateString(r.Manga.ID, 12), truncateString(r.Manga.Title, 30), r.Score,
+				truncateString(strings.Join(r.Because, ", "), 32))
+		}
+		fmt.Println("└──────────────┴────────────────────────────────┴───────┴──────────────────────────────────┘")
+		fmt.Printf("\nShowing %d of %d recommendations\n", len(recs.Recommendations), recs.Total)
+		fmt.Println("Use 'mangahub manga info <id>' to view details")
+
+		return nil
+	},
+}
+
+func init() {
+	LibraryCmd.AddCommand(recommendCmd)
+	recommendCmd.Flags().IntP("limit", "l", 10, "Number of recommendations (max 50)")
+}
@@ -13,6 +13,8 @@ var removeCmd = &cobra.Command{
 	Use:   "remove --manga-id <id>",
 	Short: "Remove manga from library",
 	Long: `Remove a manga from your personal library via the API server.
+Removed manga go to the trash and can be brought back with
+'mangahub library restore' until the server purges them.
 
 Examples:
   mangahub library remove --manga-id completed-series
@@ -56,7 +58,8 @@ Examples:
 			return fmt.Errorf("failed to remove from library: %w", err)
 		}
 
-		fmt.Printf("\n✓ Successfully removed '%s' from your library.\n", mangaID)
+		fmt.Printf("\n✓ Moved '%s' to the trash.\n", mangaID)
+		fmt.Printf("  Restore with: mangahub library restore --manga-id %s\n", mangaID)
 
 		return nil
 	},
@@ -0,0 +1,118 @@
+package library
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/models"
+)
+
+var trashCmd = &cobra.Command{
+	Use:   "trash",
+	Short: "View removed manga",
+	Long: `List manga removed from your library via the API server. Removed entries
+keep their chapter, rating and notes until they are restored or purged after
+the server's retention period.
+
+Examples:
+  mangahub library trash
+  mangahub library restore --manga-id one-piece`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		// Check if user is logged in
+		httpClient, session, err := newAuthenticatedHTTPClient()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		trash, err := httpClient.GetLibraryTrash(limit, 0)
+		if err != nil {
+			return fmt.Errorf("failed to get library trash: %w", err)
+		}
+
+		fmt.Printf("🗑️  %s's Library Trash\n\n", session.Username)
+
+		if len(trash) == 0 {
+			fmt.Println("The trash is empty.")
+			return nil
+		}
+
+		printTrashTable(trash)
+		fmt.Printf("\nTotal: %d manga in trash\n", len(trash))
+		fmt.Println("\nRestore with:")
+		fmt.Println("  mangahub library restore --manga-id <id>")
+
+		return nil
+	},
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore --manga-id <id>",
+	Short: "Restore removed manga",
+	Long: `Move a manga from the trash back into your library via the API server,
+with its chapter, rating and notes intact.
+
+Examples:
+  mangahub library restore --manga-id one-piece
+  mangahub library restore -m naruto`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mangaID, _ := cmd.Flags().GetString("manga-id")
+
+		if mangaID == "" {
+			return fmt.Errorf("--manga-id is required")
+		}
+
+		// Check if user is logged in and get HTTP client
+		httpClient, _, err := newAuthenticatedHTTPClient()
+		if err != nil {
+			fmt.Println("You are not logged in.")
+			fmt.Println("\nPlease login first:")
+			fmt.Println("  mangahub auth login --username <username>")
+			return nil
+		}
+
+		if err := httpClient.RestoreLibraryEntry(mangaID); err != nil {
+			return fmt.Errorf("failed to restore: %w", err)
+		}
+
+		fmt.Printf("✓ Restored '%s' to your library.\n", mangaID)
+
+		return nil
+	},
+}
+
+func init() {
+	LibraryCmd.AddCommand(trashCmd)
+	trashCmd.Flags().IntP("limit", "l", 50, "Maximum entries to show")
+
+	LibraryCmd.AddCommand(restoreCmd)
+	restoreCmd.Flags().StringP("manga-id", "m", "", "Manga ID (required)")
+	restoreCmd.MarkFlagRequired("manga-id")
+}
+
+// printTrashTable prints trashed library entries in a formatted table
+func printTrashTable(trash []models.Progress) {
+	fmt.Println("┌──────────────────────────────────────────────────────────────────────────────────────┐")
+	fmt.Printf("│ %-20s │ %-12s │ %-10s │ %-8s │ %-19s │\n", "MANGA", "STATUS", "CHAPTER", "RATING", "REMOVED")
+	fmt.Println("├──────────────────────────────────────────────────────────────────────────────────────┤")
+
+	for _, p := range trash {
+		mangaName := truncateString(p.MangaID, 20)
+		rating := "-"
+		if p.Rating > 0 {
+			rating = fmt.Sprintf("%d/10", p.Rating)
+		}
+		removed := "-"
+		if p.DeletedAt != nil {
+			removed = p.DeletedAt.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Printf("│ %-20s │ %-12s │ %10d │ %-8s │ %-19s │\n",
+			mangaName, p.Status, p.CurrentChapter, rating, removed)
+	}
+	fmt.Println("└──────────────────────────────────────────────────────────────────────────────────────┘")
+}
@@ -0,0 +1,48 @@
+// Package localdb opens the local SQLite database for the CLI commands
+// that write to it directly, and checks the logged-in user may do so. The
+// role checked is the one in that database, as the API server would.
+package localdb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"mangahub/internal/user"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+	"mangahub/pkg/session"
+	"mangahub/pkg/store"
+)
+
+// Open opens and migrates the SQLite database at path
+func Open(path string) (*database.Database, error) {
+	db, err := database.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", path, err)
+	}
+	if err := db.Init(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+	return db, nil
+}
+
+// Authorize checks that the role of the logged-in user in db grants perm
+func Authorize(db *database.Database, perm models.Permission) error {
+	sess, err := session.Load()
+	if err != nil || sess.UserID == "" {
+		return fmt.Errorf("you are not logged in; run 'mangahub auth login --username <username>' first")
+	}
+	if _, err := user.NewService(db).Authorize(sess.UserID, perm); err != nil {
+		switch {
+		case errors.Is(err, user.ErrForbidden):
+			return fmt.Errorf("insufficient permissions: this takes the %s role",
+				strings.Join(models.RolesWith(perm), " or "))
+		case errors.Is(err, store.ErrUserNotFound):
+			return fmt.Errorf("logged-in user %s is not in this database", sess.Username)
+		}
+		return fmt.Errorf("failed to check permissions: %w", err)
+	}
+	return nil
+}
@@ -14,7 +14,14 @@ var advancedSearchCmd = &cobra.Command{
 	Short: "Advanced manga search with filters",
 	Long: `Perform advanced search with multiple filter options via the API server.
 
+Sort modes: relevance (text queries only), title, author, status,
+total_chapters, year, popularity (readers), rating (average user rating),
+recent (last catalog update), created_at and updated_at. Popularity, rating
+and recent sort descending unless --order is given.
+
 Examples:
+  mangahub manga advanced-search --sort-by popularity --limit 10
+  mangahub manga advanced-search --genre romance --min-rating 8 --sort-by rating
   mangahub manga advanced-search "keyword" --genre "action,adventure" --status "ongoing" --author "author name" --year-from 2020 --year-to 2024 --min-chapters 50 --sort-by "popularity" --order "desc"`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		query := ""
@@ -25,7 +32,10 @@ Examples:
 		genres, _ := cmd.Flags().GetString("genre")
 		status, _ := cmd.Flags().GetString("status")
 		author, _ := cmd.Flags().GetString("author")
+		yearFrom, _ := cmd.Flags().GetInt("year-from")
+		yearTo, _ := cmd.Flags().GetInt("year-to")
 		minChapters, _ := cmd.Flags().GetInt("min-chapters")
+		minRating, _ := cmd.Flags().GetFloat64("min-rating")
 		sortBy, _ := cmd.Flags().GetString("sort-by")
 		order, _ := cmd.Flags().GetString("order")
 		limit, _ := cmd.Flags().GetInt("limit")
@@ -43,10 +53,22 @@ Examples:
 		if author != "" {
 			fmt.Printf("  Author: %s\n", author)
 		}
+		if yearFrom > 0 || yearTo > 0 {
+			fmt.Printf("  Years: %s-%s\n", yearLabel(yearFrom), yearLabel(yearTo))
+		}
 		if minChapters > 0 {
 			fmt.Printf("  Minimum Chapters: %d\n", minChapters)
 		}
-		fmt.Printf("  Sort: %s (%s)\n", sortBy, order)
+		if minRating > 0 {
+			fmt.Printf("  Minimum Rating: %.1f\n", minRating)
+		}
+		if sortBy != "" {
+			fmt.Printf("  Sort: %s", sortBy)
+			if order != "" {
+				fmt.Printf(" (%s)", order)
+			}
+			fmt.Println()
+		}
 		fmt.Println()
 
 		// Get HTTP client
@@ -57,7 +79,10 @@ Examples:
 			Query:       query,
 			Status:      status,
 			Author:      author,
+			YearFrom:    yearFrom,
+			YearTo:      yearTo,
 			MinChapters: minChapters,
+			MinRating:   minRating,
 			SortBy:      sortBy,
 			Order:       order,
 			Limit:       limit,
@@ -74,11 +99,20 @@ Examples:
 
 		if len(results.Manga) == 0 {
 			fmt.Println("No manga found matching your criteria.")
+			printSuggestions(results)
 			return nil
 		}
 
-		fmt.Printf("Found %d results:\n\n", len(results.Manga))
-		printMangaResults(results.Manga)
+		if results.Fuzzy {
+			fmt.Printf("No exact matches. Did you mean \"%s\"? Showing %d similar titles:\n\n", results.DidYouMean, len(results.Manga))
+		} else {
+			fmt.Printf("Found %d results (showing %d):\n\n", results.Total, len(results.Manga))
+		}
+		printRankedResults(results.Manga)
+		if !results.Fuzzy {
+			printSuggestions(results)
+		}
+		printFacets(results.Facets)
 
 		return nil
 	},
@@ -89,8 +123,38 @@ func init() {
 	advancedSearchCmd.Flags().StringP("genre", "g", "", "Genres (comma-separated)")
 	advancedSearchCmd.Flags().StringP("status", "s", "", "Status filter")
 	advancedSearchCmd.Flags().StringP("author", "a", "", "Author name")
+	advancedSearchCmd.Flags().Int("year-from", 0, "Earliest publication year")
+	advancedSearchCmd.Flags().Int("year-to", 0, "Latest publication year")
 	advancedSearchCmd.Flags().Int("min-chapters", 0, "Minimum chapter count")
-	advancedSearchCmd.Flags().String("sort-by", "title", "Sort field (title, total_chapters)")
-	advancedSearchCmd.Flags().String("order", "asc", "Sort order (asc/desc)")
+	advancedSearchCmd.Flags().Float64("min-rating", 0, "Minimum average user rating (0-10)")
+	advancedSearchCmd.Flags().String("sort-by", "", "Sort mode: "+strings.Join(models.MangaSortModes, ", ")+" (default relevance for queries, else title)")
+	advancedSearchCmd.Flags().String("order", "", "Sort order (asc/desc)")
 	advancedSearchCmd.Flags().IntP("limit", "l", 20, "Maximum results")
 }
+
+// yearLabel renders an open end of a year range as "any"
+func yearLabel(year int) string {
+	if year == 0 {
+		return "any"
+	}
+	return fmt.Sprint(year)
+}
+
+// printRankedResults prints manga with their year, readers and average rating
+func printRankedResults(mangaList []models.Manga) {
+	fmt.Println("┌──────────────┬────────────────────────────────┬────────────┬──────┬─────────┬────────┐")
+	fmt.Printf("│ %-12s │ %-30s │ %-10s │ %4s │ %7s │ %6s │\n", "ID", "TITLE", "STATUS", "YEAR", "READERS", "RATING")
+	fmt.Println("├──────────────┼────────────────────────────────┼────────────┼──────┼─────────┼────────┤")
+	for _, m := range mangaList {
+		year, rating := "-", "-"
+		if m.Year > 0 {
+			year = fmt.Sprint(m.Year)
+		}
+		if m.AverageRating > 0 {
+			rating = fmt.Sprintf("%.1f", m.AverageRating)
+		}
+		fmt.Printf("│ %-12s │ %-30s │ %-10s │ %4s │ %7d │ %6s │\n",
+			truncateString(m.ID, 12), truncateString(m.Title, 30), m.Status, year, m.Readers, rating)
+	}
+	fmt.Println("└──────────────┴────────────────────────────────┴────────────┴──────┴─────────┴────────┘")
+}
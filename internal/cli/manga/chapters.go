@@ -0,0 +1,136 @@
+package manga
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/client"
+	"mangahub/pkg/models"
+	"mangahub/pkg/session"
+)
+
+var chaptersCmd = &cobra.Command{
+	Use:   "chapters <manga-id>",
+	Short: "List the chapters of a manga",
+	Long: `List a manga's chapters with their volume, title, page count, language and
+release date via the API server.
+
+When you are logged in and the manga is in your library, chapters after your
+current chapter are marked NEW. Use --new to list only those.
+
+Examples:
+  mangahub manga chapters one-piece
+  mangahub manga chapters one-piece --new
+  mangahub manga chapters one-piece --lang en --desc --limit 10`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		mangaID := args[0]
+		page, _ := cmd.Flags().GetInt("page")
+		limit, _ := cmd.Flags().GetInt("limit")
+		lang, _ := cmd.Flags().GetString("lang")
+		desc, _ := cmd.Flags().GetBool("desc")
+		onlyNew, _ := cmd.Flags().GetBool("new")
+
+		current, inLibrary := currentChapter(mangaID)
+		if onlyNew && !inLibrary {
+			return fmt.Errorf("--new needs you to be logged in with %s in your library", mangaID)
+		}
+
+		var after float64
+		if onlyNew {
+			after = float64(current)
+		}
+		order := "asc"
+		if desc {
+			order = "desc"
+		}
+
+		httpClient := getHTTPClient()
+		list, err := httpClient.ListChapters(mangaID, lang, after, order, limit, (page-1)*limit)
+		if err != nil {
+			return fmt.Errorf("failed to list chapters: %w", err)
+		}
+
+		if len(list.Chapters) == 0 {
+			if onlyNew {
+				fmt.Printf("No new chapters after chapter %d.\n", current)
+			} else {
+				fmt.Println("No chapters found.")
+			}
+			return nil
+		}
+
+		fmt.Printf("Chapters of %s (page %d)\n", mangaID, page)
+		if inLibrary {
+			fmt.Printf("Your progress: chapter %d\n", current)
+		}
+		fmt.Println()
+		printChapterTable(list.Chapters, current, inLibrary)
+
+		fmt.Printf("\nShowing %d chapters (page %d)\n", len(list.Chapters), page)
+		if len(list.Chapters) == limit {
+			fmt.Println("Use --page <n> to see more chapters")
+		}
+		return nil
+	},
+}
+
+func init() {
+	MangaCmd.AddCommand(chaptersCmd)
+	chaptersCmd.Flags().IntP("page", "p", 1, "Page number")
+	chaptersCmd.Flags().IntP("limit", "l", 50, "Chapters per page")
+	chaptersCmd.Flags().String("lang", "", "Only chapters in this language (e.g. en)")
+	chaptersCmd.Flags().Bool("desc", false, "Newest chapters first")
+	chaptersCmd.Flags().Bool("new", false, "Only chapters after your current chapter")
+}
+
+// currentChapter returns the logged-in user's current chapter of a manga
+// and whether the manga is in their library
+func currentChapter(mangaID string) (int, bool) {
+	sess, err := session.Load()
+	if err != nil || sess.Token == "" {
+		return 0, false
+	}
+
+	httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
+	entries, err := httpClient.GetLibrary("", 1000, 0)
+	if err != nil {
+		return 0, false
+	}
+	for _, entry := range entries {
+		if entry.MangaID == mangaID {
+			return entry.CurrentChapter, true
+		}
+	}
+	return 0, false
+}
+
+// printChapterTable prints chapters in a formatted table, marking those
+// after the reader's current chapter
+func printChapterTable(chapters []models.Chapter, current int, markNew bool) {
+	fmt.Println("┌─────────┬────────┬────────────────────────────────────┬───────┬──────┬────────────┬─────┐")
+	fmt.Printf("│ %-7s │ %-6s │ %-34s │ %-5s │ %-4s │ %-10s │ %-3s │\n", "CHAPTER", "VOLUME", "TITLE", "PAGES", "LANG", "RELEASED", "NEW")
+	fmt.Println("├─────────┼────────┼────────────────────────────────────┼───────┼──────┼────────────┼─────┤")
+
+	for _, ch := range chapters {
+		volume, pages, released, isNew := "-", "-", "-", ""
+		if ch.Volume > 0 {
+			volume = strconv.Itoa(ch.Volume)
+		}
+		if ch.Pages > 0 {
+			pages = strconv.Itoa(ch.Pages)
+		}
+		if ch.ReleasedAt != nil {
+			released = ch.ReleasedAt.Local().Format("2006-01-02")
+		}
+		if markNew && ch.Number > float64(current) {
+			isNew = "✓"
+		}
+		fmt.Printf("│ %7s │ %-6s │ %-34s │ %5s │ %-4s │ %-10s │ %-3s │\n",
+			strconv.FormatFloat(ch.Number, 'f', -1, 64), volume, truncateString(ch.Title, 34),
+			pages, ch.Language, released, isNew)
+	}
+	fmt.Println("└─────────┴────────┴────────────────────────────────────┴───────┴──────┴────────────┴─────┘")
+}
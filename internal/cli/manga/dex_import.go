@@ -0,0 +1,158 @@
+package manga
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/internal/catalog"
+	"mangahub/internal/cli/localdb"
+	"mangahub/internal/mangadex"
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// dexImportCmd upserts MangaDex manga into the local catalog database,
+// replacing the manual scripts/load_data.go step for MangaDex data
+var dexImportCmd = &cobra.Command{
+	Use:   "import [query]",
+	Short: "Import MangaDex manga into the catalog",
+	Long: `Upsert manga from MangaDex into the local SQLite catalog.
+
+Manga imported before are found by their MangaDex ID and merged again with
+the latest MangaDex data, by the field priorities of the catalog: MangaDex
+supplies status and chapter count, hand-kept data wins for titles and
+descriptions, and alternate titles are added. A new manga whose
+title matches a catalog manga is skipped as a duplicate, or with --link
+tied to that manga and updated; the others are added under a slug of their
+title.
+
+Servers using the database pick up the changes right away.
+
+Examples:
+  # Import the 100 most followed series
+  mangahub manga dex import
+
+  # Import search results and store their covers
+  mangahub manga dex import "attack on titan" --limit 5 --covers
+
+  # Import a file saved with 'mangahub manga dex --output'
+  mangahub manga dex import --file data/manga_api.json`,
+	Args: cobra.RangeArgs(0, 1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var query string
+		if len(args) > 0 {
+			query = strings.Join(args, " ")
+		}
+
+		limit, _ := cmd.Flags().GetInt("limit")
+		file, _ := cmd.Flags().GetString("file")
+		apiURL, _ := cmd.Flags().GetString("api-url")
+		coverURL, _ := cmd.Flags().GetString("cover-url")
+		dbPath, _ := cmd.Flags().GetString("db")
+		withCovers, _ := cmd.Flags().GetBool("covers")
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		link, _ := cmd.Flags().GetBool("link")
+
+		var results []mangadex.Manga
+		var err error
+		if file != "" {
+			results, err = loadMangaDexJSON(file)
+		} else {
+			client := mangadex.NewClient(apiURL)
+			client.SetCoverURL(coverURL)
+			results, err = client.Search(query, limit)
+		}
+		if err != nil {
+			return err
+		}
+		if len(results) == 0 {
+			fmt.Println("No results found on MangaDex.")
+			return nil
+		}
+
+		db, err := localdb.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		if err := localdb.Authorize(db, models.PermEditCatalog); err != nil {
+			return err
+		}
+
+		var coverStore *covers.Store
+		if withCovers {
+			if dataDir == "" {
+				dataDir = filepath.Dir(dbPath)
+			}
+			coverStore = covers.NewStore(dataDir, 0)
+		}
+
+		fmt.Printf("📥 Importing %d MangaDex manga into %s...\n\n", len(results), dbPath)
+
+		entries := make([]models.CatalogEntry, 0, len(results))
+		for _, m := range results {
+			entries = append(entries, catalog.MangaDexEntry(m))
+		}
+
+		service := catalog.NewService(store.NewSQLiteMangaStore(db), store.NewSQLiteTitleStore(db),
+			store.NewSQLiteExternalIDStore(db), coverStore, nil)
+		service.LinkDuplicates = link
+		report, err := service.Import(entries)
+		if len(report.Results) > 0 {
+			printImportTable(report.Results)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\n✓ Created %d · Updated %d · Skipped %d\n", report.Created, report.Updated, report.Skipped)
+		return nil
+	},
+}
+
+func init() {
+	dexCmd.AddCommand(dexImportCmd)
+	dexImportCmd.Flags().Int("limit", 100, "Maximum results to fetch (1-100)")
+	dexImportCmd.Flags().String("file", "", "Import a JSON file saved with 'mangahub manga dex --output' instead of calling MangaDex")
+	dexImportCmd.Flags().String("db", "./data/mangahub.db", "Path to the SQLite database file")
+	dexImportCmd.Flags().Bool("link", false, "Link manga that duplicate a catalog manga by title to it and update it")
+	dexImportCmd.Flags().Bool("covers", false, "Download covers into the cover store")
+	dexImportCmd.Flags().String("data-dir", "", "Directory covers are stored under (default: the database's directory)")
+}
+
+// loadMangaDexJSON reads results saved by saveMangaDexJSON
+func loadMangaDexJSON(path string) ([]mangadex.Manga, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var results []mangadex.Manga
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return results, nil
+}
+
+// printImportTable prints what an import did with each manga
+func printImportTable(results []catalog.Result) {
+	icons := map[string]string{
+		catalog.ActionCreated: "✚",
+		catalog.ActionUpdated: "↻",
+		catalog.ActionSkipped: "·",
+	}
+
+	fmt.Println("┌───────────┬──────────────────────────────┬──────────────────────┬──────────────────────────────┐")
+	fmt.Printf("│ %-9s │ %-28s │ %-20s │ %-28s │\n", "ACTION", "TITLE", "MANGA ID", "NOTE")
+	fmt.Println("├───────────┼──────────────────────────────┼──────────────────────┼──────────────────────────────┤")
+	for _, r := range results {
+		fmt.Printf("│ %s %-7s │ %-28s │ %-20s │ %-28s │\n",
+			icons[r.Action], r.Action, truncateString(r.Title, 28), truncateString(r.MangaID, 20), truncateString(r.Note, 28))
+	}
+	fmt.Println("└───────────┴──────────────────────────────┴──────────────────────┴──────────────────────────────┘")
+}
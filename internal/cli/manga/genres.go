@@ -0,0 +1,106 @@
+package manga
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/models"
+)
+
+var genresCmd = &cobra.Command{
+	Use:   "genres",
+	Short: "List the genre and tag taxonomy",
+	Long: `List every genre with its kind, aliases and the number of manga carrying it
+via the API server.
+
+Demographics (shounen, seinen, ...) are listed first, then genres, then tags.
+Any alias can be used wherever a genre is accepted, e.g. --genre shonen.
+
+Examples:
+  mangahub manga genres
+  mangahub manga genres --kind demographic`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		kind, _ := cmd.Flags().GetString("kind")
+		used, _ := cmd.Flags().GetBool("used")
+
+		httpClient := getHTTPClient()
+		genres, err := httpClient.GetGenres(kind)
+		if err != nil {
+			return fmt.Errorf("failed to list genres: %w", err)
+		}
+
+		if used {
+			var filtered []models.Genre
+			for _, g := range genres {
+				if g.MangaCount > 0 {
+					filtered = append(filtered, g)
+				}
+			}
+			genres = filtered
+		}
+
+		if len(genres) == 0 {
+			fmt.Println("No genres found.")
+			return nil
+		}
+
+		printGenreTable(genres)
+		fmt.Printf("\n%d genres\n", len(genres))
+		fmt.Println("Use 'mangahub manga search <query> --genre <slug>' to filter by genre")
+
+		return nil
+	},
+}
+
+func init() {
+	MangaCmd.AddCommand(genresCmd)
+	genresCmd.Flags().String("kind", "", "Only list one kind (demographic, genre, tag)")
+	genresCmd.Flags().Bool("used", false, "Only list genres carried by at least one manga")
+}
+
+// printGenreTable prints genres in a formatted table
+func printGenreTable(genres []models.Genre) {
+	fmt.Println("┌──────────────────┬──────────────────┬─────────────┬───────┬──────────────────────────────┐")
+	fmt.Printf("│ %-16s │ %-16s │ %-11s │ %5s │ %-28s │\n", "SLUG", "NAME", "KIND", "MANGA", "ALIASES")
+	fmt.Println("├──────────────────┼──────────────────┼─────────────┼───────┼──────────────────────────────┤")
+	for _, g := range genres {
+		fmt.Printf("│ %-16s │ %-16s │ %-11s │ %5d │ %-28s │\n",
+			truncateString(g.Slug, 16), truncateString(g.Name, 16), g.Kind, g.MangaCount,
+			truncateString(strings.Join(g.Aliases, ", "), 28))
+	}
+	fmt.Println("└──────────────────┴──────────────────┴─────────────┴───────┴──────────────────────────────┘")
+}
+
+// printFacets prints how the matches of a search split by genre and status
+func printFacets(facets *models.SearchFacets) {
+	if facets == nil || (len(facets.Genres) == 0 && len(facets.Status) == 0) {
+		return
+	}
+
+	const maxGenres = 8
+	fmt.Println("\nRefine by:")
+	if len(facets.Genres) > 0 {
+		var parts []string
+		for i, f := range facets.Genres {
+			if i == maxGenres {
+				parts = append(parts, fmt.Sprintf("+%d more", len(facets.Genres)-maxGenres))
+				break
+			}
+			parts = append(parts, fmt.Sprintf("%s (%d)", f.Value, f.Count))
+		}
+		fmt.Printf("  Genre:  %s\n", strings.Join(parts, ", "))
+	}
+	if len(facets.Status) > 0 {
+		var parts []string
+		for _, f := range facets.Status {
+			status := f.Value
+			if status == "" {
+				status = "unknown"
+			}
+			parts = append(parts, fmt.Sprintf("%s (%d)", status, f.Count))
+		}
+		fmt.Printf("  Status: %s\n", strings.Join(parts, ", "))
+	}
+}
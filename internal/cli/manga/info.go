@@ -40,11 +40,26 @@ Example:
 		fmt.Println("Basic Information:")
 		fmt.Printf("  ID:      %s\n", m.ID)
 		fmt.Printf("  Title:   %s\n", m.Title)
+		if m.OriginalTitle != "" {
+			fmt.Printf("  Original: %s\n", m.OriginalTitle)
+		}
 		fmt.Printf("  Author:  %s\n", m.Author)
 		fmt.Printf("  Genres:  %s\n", strings.Join(m.Genres, ", "))
 		fmt.Printf("  Status:  %s\n", m.Status)
 		fmt.Println()
 
+		if len(m.Titles) > 0 {
+			fmt.Println("Alternate Titles:")
+			for _, t := range m.Titles {
+				marker := ""
+				if t.IsPrimary {
+					marker = " *"
+				}
+				fmt.Printf("  [%-5s] %s%s\n", t.Language, t.Title, marker)
+			}
+			fmt.Println()
+		}
+
 		fmt.Println("Progress:")
 		fmt.Printf("  Total Chapters: %d\n", m.TotalChapters)
 		fmt.Printf("  Created:        %s\n", m.CreatedAt.Format("2006-01-02"))
@@ -7,12 +7,14 @@ import (
 	"github.com/spf13/cobra"
 
 	"mangahub/pkg/models"
+	"mangahub/pkg/output"
 )
 
 var searchCmd = &cobra.Command{
 	Use:   "search <query>",
 	Short: "Search for manga",
-	Long: `Search for manga by title, author, or other criteria via the API server.
+	Long: `Search for manga by title, author, or description via the API server.
+Results are ranked by relevance and show the matching text.
 
 Examples:
   mangahub manga search "attack on titan"
@@ -50,11 +52,21 @@ Examples:
 
 		if len(results.Manga) == 0 {
 			fmt.Println("No manga found matching your search.")
+			printSuggestions(results)
 			return nil
 		}
 
-		fmt.Printf("Found %d results:\n\n", len(results.Manga))
+		if results.Fuzzy {
+			fmt.Printf("No exact matches. Did you mean \"%s\"? Showing %d similar titles:\n\n", results.DidYouMean, len(results.Manga))
+		} else {
+			fmt.Printf("Found %d results (best match first, showing %d):\n\n", results.Total, len(results.Manga))
+		}
 		printMangaResults(results.Manga)
+		printSnippets(results.Manga)
+		if !results.Fuzzy {
+			printSuggestions(results)
+		}
+		printFacets(results.Facets)
 		fmt.Println("\nUse 'mangahub manga info <id>' to view details")
 		fmt.Println("Use 'mangahub library add --manga-id <id>' to add to your library")
 
@@ -64,7 +76,7 @@ Examples:
 
 func init() {
 	MangaCmd.AddCommand(searchCmd)
-	searchCmd.Flags().StringP("genre", "g", "", "Filter by genre")
+	searchCmd.Flags().StringP("genre", "g", "", "Filter by genre slug or alias (see 'mangahub manga genres')")
 	searchCmd.Flags().StringP("status", "s", "", "Filter by status (ongoing, completed)")
 	searchCmd.Flags().IntP("limit", "l", 10, "Maximum results to show")
 }
@@ -85,6 +97,37 @@ func printMangaResults(mangaList []models.Manga) {
 	fmt.Println("└──────────────────────────────────────────────────────────────────────────────────────────┘")
 }
 
+// printSnippets prints the highlighted match context of full-text results
+func printSnippets(mangaList []models.Manga) {
+	printed := false
+	for _, m := range mangaList {
+		if m.Snippet == "" {
+			continue
+		}
+		if !printed {
+			fmt.Println("\nMatches:")
+			printed = true
+		}
+		fmt.Printf("  %-12s %s\n", truncateString(m.ID, 12), output.FormatSnippet(m.Snippet))
+	}
+}
+
+// printSuggestions prints the titles and authors resembling the query of a
+// search with few exact hits
+func printSuggestions(results *models.SearchResult) {
+	if len(results.Suggestions) == 0 {
+		return
+	}
+	fmt.Printf("\n💡 Did you mean \"%s\"?\n", results.DidYouMean)
+	for _, sg := range results.Suggestions {
+		line := fmt.Sprintf("  %-12s %s", truncateString(sg.MangaID, 12), sg.Title)
+		if sg.Field == models.SuggestionFieldAuthor {
+			line += " by " + sg.Author
+		}
+		fmt.Printf("%s (%.0f%% match)\n", line, sg.Score*100)
+	}
+}
+
 // truncateString truncates a string to max length with ellipsis
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
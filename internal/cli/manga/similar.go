@@ -0,0 +1,69 @@
+package manga
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/models"
+)
+
+var similarCmd = &cobra.Command{
+	Use:   "similar <manga-id>",
+	Short: "Show manga similar to a series",
+	Long: `Show the manga most similar to a series via the API server.
+
+Similarity combines genre overlap with co-reading: series read by the users
+who rated this one highly. Scores are recomputed in the background, so new
+ratings may take a while to show up.
+
+Examples:
+  mangahub manga similar one-piece
+  mangahub manga similar berserk --limit 20`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		httpClient := getHTTPClient()
+		similar, err := httpClient.GetSimilarManga(args[0], limit)
+		if err != nil {
+			return fmt.Errorf("failed to get similar manga: %w", err)
+		}
+
+		fmt.Printf("🔗 Manga similar to %s\n\n", similar.MangaID)
+
+		if len(similar.Similar) == 0 {
+			fmt.Println("No similar manga found yet.")
+			return nil
+		}
+
+		printSimilarTable(similar.Similar)
+		fmt.Printf("\nShowing %d similar manga", len(similar.Similar))
+		if similar.ComputedAt != nil {
+			fmt.Printf(" · updated %s", similar.ComputedAt.Local().Format("2006-01-02 15:04"))
+		}
+		fmt.Println()
+		fmt.Println("Use 'mangahub manga info <id>' to view details")
+
+		return nil
+	},
+}
+
+func init() {
+	MangaCmd.AddCommand(similarCmd)
+	similarCmd.Flags().IntP("limit", "l", 10, "Number of manga to show (max 50)")
+}
+
+// printSimilarTable prints similar manga with the signals behind their score
+func printSimilarTable(similar []models.SimilarManga) {
+	fmt.Println("┌──────────────┬────────────────────────────────┬───────┬────────┬─────────┬──────────────────────┐")
+	fmt.Printf("│ %-12s │ %-30s │ %5s │ %6s │ %7s │ %-20s │\n", "ID", "TITLE", "SCORE", "GENRE", "CO-READ", "GENRES")
+	fmt.Println("├──────────────┼────────────────────────────────┼───────┼────────┼─────────┼──────────────────────┤")
+	for _, s := range similar {
+		fmt.Printf("│ %-12s │ %-30s │ %5.2f │ %5.0f%% │ %6.0f%% │ %-20s │\n",
+			truncateString(s.Manga.ID, 12), truncateString(s.Manga.Title, 30), s.Score,
+			s.GenreScore*100, s.CoReadScore*100, truncateString(strings.Join(s.Manga.Genres, ", "), 20))
+	}
+	fmt.Println("└──────────────┴────────────────────────────────┴───────┴────────┴─────────┴──────────────────────┘")
+}
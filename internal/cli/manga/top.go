@@ -0,0 +1,90 @@
+package manga
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"mangahub/pkg/models"
+)
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show the manga rankings",
+	Long: `Show the highest ranked manga via the API server.
+
+Manga are ranked by their active readers, completions, average rating and
+reading activity within the window. Rankings are refreshed in the background,
+so recent progress may take a few minutes to show up.
+
+Examples:
+  mangahub manga top
+  mangahub manga top --window month --limit 20
+  mangahub manga top --window all --genre romance`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		window, _ := cmd.Flags().GetString("window")
+		genre, _ := cmd.Flags().GetString("genre")
+		limit, _ := cmd.Flags().GetInt("limit")
+
+		httpClient := getHTTPClient()
+		rankings, err := httpClient.GetRankings(window, genre, limit)
+		if err != nil {
+			return fmt.Errorf("failed to get rankings: %w", err)
+		}
+
+		title := "🏆 Top manga " + windowLabel(rankings.Window)
+		if rankings.Genre != "" {
+			title += " in " + rankings.Genre
+		}
+		fmt.Println(title)
+		fmt.Println()
+
+		if len(rankings.Rankings) == 0 {
+			fmt.Println("No reading activity in this window yet.")
+			return nil
+		}
+
+		printRankingTable(rankings.Rankings)
+		fmt.Printf("\nShowing %d of %d ranked manga · updated %s\n",
+			len(rankings.Rankings), rankings.Total, rankings.GeneratedAt.Local().Format("2006-01-02 15:04"))
+		fmt.Println("Use 'mangahub manga info <id>' to view details")
+
+		return nil
+	},
+}
+
+func init() {
+	MangaCmd.AddCommand(topCmd)
+	topCmd.Flags().StringP("window", "w", "week", "Ranking window: "+strings.Join(models.RankingWindows, ", "))
+	topCmd.Flags().StringP("genre", "g", "", "Only rank manga of this genre")
+	topCmd.Flags().IntP("limit", "l", 10, "Number of manga to show (max 100)")
+}
+
+// windowLabel describes a ranking window for headings
+func windowLabel(window string) string {
+	switch window {
+	case models.RankingWindowWeek:
+		return "this week"
+	case models.RankingWindowMonth:
+		return "this month"
+	}
+	return "of all time"
+}
+
+// printRankingTable prints ranked manga with the figures behind their score
+func printRankingTable(ranked []models.RankedManga) {
+	fmt.Println("┌─────┬──────────────┬────────────────────────────────┬────────┬─────────┬───────────┬────────┐")
+	fmt.Printf("│ %3s │ %-12s │ %-30s │ %6s │ %7s │ %9s │ %6s │\n", "#", "ID", "TITLE", "SCORE", "READERS", "COMPLETED", "RATING")
+	fmt.Println("├─────┼──────────────┼────────────────────────────────┼────────┼─────────┼───────────┼────────┤")
+	for _, r := range ranked {
+		rating := "-"
+		if r.Ratings > 0 {
+			rating = fmt.Sprintf("%.1f", r.AverageRating)
+		}
+		fmt.Printf("│ %3d │ %-12s │ %-30s │ %6.1f │ %7d │ %9d │ %6s │\n",
+			r.Rank, truncateString(r.Manga.ID, 12), truncateString(r.Manga.Title, 30),
+			r.Score, r.ActiveReaders, r.Completions, rating)
+	}
+	fmt.Println("└─────┴──────────────┴────────────────────────────────┴────────┴─────────┴───────────┴────────┘")
+}
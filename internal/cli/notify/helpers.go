@@ -5,11 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
 
 	"mangahub/internal/user"
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/store"
 )
 
 // Session stores the current user session
@@ -71,7 +71,7 @@ func getLibraryService() (*user.LibraryService, error) {
 
 // NotificationService handles notification operations
 type NotificationService struct {
-	db *database.Database
+	store store.NotificationStore
 }
 
 // getNotificationService creates and returns a notification service
@@ -83,116 +83,54 @@ func getNotificationService() (*NotificationService, error) {
 	}
 
 	// Ensure notification tables exist
-	_, _ = db.Exec(`
-		CREATE TABLE IF NOT EXISTS notification_subscriptions (
-			user_id TEXT NOT NULL,
-			manga_id TEXT NOT NULL,
-			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-			PRIMARY KEY (user_id, manga_id)
-		)
-	`)
-	_, _ = db.Exec(`
-		CREATE TABLE IF NOT EXISTS notification_preferences (
-			user_id TEXT PRIMARY KEY,
-			chapter_releases BOOLEAN DEFAULT 1,
-			email_notifications BOOLEAN DEFAULT 1,
-			sound_enabled BOOLEAN DEFAULT 1,
-			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-		)
-	`)
-
-	return &NotificationService{db: db}, nil
+	if err := db.Init(); err != nil {
+		return nil, fmt.Errorf("failed to initialize database: %w", err)
+	}
+
+	return &NotificationService{store: store.NewSQLiteNotificationStore(db)}, nil
 }
 
 // Subscribe subscribes user to manga notifications
 func (ns *NotificationService) Subscribe(userID, mangaID string) error {
-	query := `
-		INSERT OR REPLACE INTO notification_subscriptions (user_id, manga_id, created_at)
-		VALUES (?, ?, ?)
-	`
-	_, err := ns.db.Exec(query, userID, mangaID, time.Now())
-	return err
+	return ns.store.Subscribe(userID, mangaID)
 }
 
 // Unsubscribe unsubscribes user from manga notifications
 func (ns *NotificationService) Unsubscribe(userID, mangaID string) error {
-	query := `DELETE FROM notification_subscriptions WHERE user_id = ? AND manga_id = ?`
-	_, err := ns.db.Exec(query, userID, mangaID)
-	return err
+	return ns.store.Unsubscribe(userID, mangaID)
 }
 
 // UnsubscribeAll unsubscribes user from all notifications
 func (ns *NotificationService) UnsubscribeAll(userID string) error {
-	query := `DELETE FROM notification_subscriptions WHERE user_id = ?`
-	_, err := ns.db.Exec(query, userID)
-	return err
+	return ns.store.UnsubscribeAll(userID)
 }
 
 // GetSubscriptions gets all subscriptions for a user
 func (ns *NotificationService) GetSubscriptions(userID string) ([]string, error) {
-	query := `SELECT manga_id FROM notification_subscriptions WHERE user_id = ?`
-	rows, err := ns.db.Query(query, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var subscriptions []string
-	for rows.Next() {
-		var mangaID string
-		if err := rows.Scan(&mangaID); err != nil {
-			continue
-		}
-		subscriptions = append(subscriptions, mangaID)
-	}
-	return subscriptions, nil
+	return ns.store.Subscriptions(userID)
 }
 
 // EnableNotifications enables general notifications for a user
 func (ns *NotificationService) EnableNotifications(userID string) error {
-	query := `
-		INSERT OR REPLACE INTO notification_preferences 
-		(user_id, chapter_releases, email_notifications, sound_enabled, updated_at)
-		VALUES (?, 1, 1, 1, ?)
-	`
-	_, err := ns.db.Exec(query, userID, time.Now())
-	return err
+	return ns.store.SavePreferences(&models.NotificationPreferences{
+		UserID:             userID,
+		ChapterReleases:    true,
+		EmailNotifications: true,
+		SoundEnabled:       true,
+	})
 }
 
 // DisableNotifications disables all notifications for a user
 func (ns *NotificationService) DisableNotifications(userID string) error {
-	query := `
-		INSERT OR REPLACE INTO notification_preferences 
-		(user_id, chapter_releases, email_notifications, sound_enabled, updated_at)
-		VALUES (?, 0, 0, 0, ?)
-	`
-	_, err := ns.db.Exec(query, userID, time.Now())
-	return err
+	return ns.store.SavePreferences(&models.NotificationPreferences{UserID: userID})
 }
 
 // GetPreferences gets notification preferences for a user
 func (ns *NotificationService) GetPreferences(userID string) (*models.NotificationPreferences, error) {
-	query := `
-		SELECT user_id, chapter_releases, email_notifications, sound_enabled 
-		FROM notification_preferences WHERE user_id = ?
-	`
-	row := ns.db.QueryRow(query, userID)
-
-	var prefs models.NotificationPreferences
-	err := row.Scan(&prefs.UserID, &prefs.ChapterReleases, &prefs.EmailNotifications, &prefs.SoundEnabled)
-	if err != nil {
-		return nil, err
-	}
-	return &prefs, nil
+	return ns.store.GetPreferences(userID)
 }
 
 // UpdatePreferences updates notification preferences
 func (ns *NotificationService) UpdatePreferences(prefs *models.NotificationPreferences) error {
-	query := `
-		INSERT OR REPLACE INTO notification_preferences 
-		(user_id, chapter_releases, email_notifications, sound_enabled, updated_at)
-		VALUES (?, ?, ?, ?, ?)
-	`
-	_, err := ns.db.Exec(query, prefs.UserID, prefs.ChapterReleases, prefs.EmailNotifications, prefs.SoundEnabled, time.Now())
-	return err
+	return ns.store.SavePreferences(prefs)
 }
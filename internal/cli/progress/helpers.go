@@ -23,6 +23,6 @@ func newAuthenticatedHTTPClient() (*client.HTTPClient, *session.Session, error)
 		return nil, nil, fmt.Errorf("not logged in: %w", err)
 	}
 
-	httpClient := client.NewHTTPClient(getAPIURL(), sess.Token)
+	httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
 	return httpClient, sess, nil
 }
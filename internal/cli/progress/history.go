@@ -2,6 +2,7 @@ package progress
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -11,13 +12,33 @@ import (
 var historyCmd = &cobra.Command{
 	Use:   "history",
 	Short: "View reading history",
-	Long: `View your manga reading history and progress via the API server.
+	Long: `View the log of your reading progress changes via the API server.
+Every chapter update, status change and library removal is listed with the
+client it came from, newest first.
 
 Examples:
   mangahub progress history
-  mangahub progress history --limit 20`,
+  mangahub progress history --limit 20
+  mangahub progress history --manga-id one-piece
+  mangahub progress history --since 2024-01-01 --until 2024-02-01`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("limit")
+		mangaID, _ := cmd.Flags().GetString("manga-id")
+		sinceFlag, _ := cmd.Flags().GetString("since")
+		untilFlag, _ := cmd.Flags().GetString("until")
+
+		since, err := parseDateFlag(sinceFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --since date: %w (expected YYYY-MM-DD)", err)
+		}
+		until, err := parseDateFlag(untilFlag)
+		if err != nil {
+			return fmt.Errorf("invalid --until date: %w (expected YYYY-MM-DD)", err)
+		}
+		if !until.IsZero() {
+			// Include the whole --until day
+			until = until.AddDate(0, 0, 1)
+		}
 
 		// Check if user is logged in and get HTTP client
 		httpClient, session, err := newAuthenticatedHTTPClient()
@@ -30,13 +51,12 @@ Examples:
 
 		fmt.Printf("📖 Reading History for %s\n\n", session.Username)
 
-		// Get library via API
-		library, err := httpClient.GetLibrary("", limit, 0)
+		events, err := httpClient.GetProgressEvents(mangaID, since, until, limit, 0)
 		if err != nil {
 			return fmt.Errorf("failed to get history: %w", err)
 		}
 
-		if len(library) == 0 {
+		if len(events) == 0 {
 			fmt.Println("No reading history found.")
 			fmt.Println("\nStart reading with:")
 			fmt.Println("  mangahub progress update --manga-id <id> --chapter <n>")
@@ -44,31 +64,42 @@ Examples:
 		}
 
 		// Print history table
-		printHistoryTable(library)
+		printHistoryTable(events)
 
-		fmt.Printf("\nShowing %d entries\n", len(library))
+		fmt.Printf("\nShowing %d events\n", len(events))
 
 		return nil
 	},
 }
 
-// printHistoryTable prints reading history in a formatted table
-func printHistoryTable(library []models.Progress) {
-	fmt.Println("┌──────────────────────────────────────────────────────────────────────────────────────┐")
-	fmt.Printf("│ %-20s │ %-12s │ %-10s │ %-8s │ %-19s │\n", "MANGA", "STATUS", "CHAPTER", "RATING", "LAST UPDATED")
-	fmt.Println("├──────────────────────────────────────────────────────────────────────────────────────┤")
-
-	for _, p := range library {
-		mangaName := truncateString(p.MangaID, 20)
-		rating := "-"
-		if p.Rating > 0 {
-			rating = fmt.Sprintf("%d/10", p.Rating)
+// parseDateFlag parses an optional YYYY-MM-DD flag as local midnight
+func parseDateFlag(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+// printHistoryTable prints progress events in a formatted table
+func printHistoryTable(events []models.ProgressEvent) {
+	fmt.Println("┌──────────────────────────────────────────────────────────────────────────────────────────────┐")
+	fmt.Printf("│ %-16s │ %-20s │ %-13s │ %-25s │ %-6s │\n", "TIME", "MANGA", "CHAPTER", "STATUS", "SOURCE")
+	fmt.Println("├──────────────────────────────────────────────────────────────────────────────────────────────┤")
+
+	for _, e := range events {
+		mangaName := truncateString(e.MangaID, 20)
+		chapter := fmt.Sprintf("%d", e.ToChapter)
+		if e.FromChapter != e.ToChapter {
+			chapter = fmt.Sprintf("%d → %d", e.FromChapter, e.ToChapter)
+		}
+		status := e.ToStatus
+		if e.FromStatus != "" && e.FromStatus != e.ToStatus {
+			status = e.FromStatus + " → " + e.ToStatus
 		}
-		updated := p.UpdatedAt.Format("2006-01-02 15:04")
-		fmt.Printf("│ %-20s │ %-12s │ %10d │ %-8s │ %-19s │\n",
-			mangaName, p.Status, p.CurrentChapter, rating, updated)
+		fmt.Printf("│ %-16s │ %-20s │ %-13s │ %-25s │ %-6s │\n",
+			e.CreatedAt.Local().Format("2006-01-02 15:04"), mangaName, chapter, truncateString(status, 25), e.Source)
 	}
-	fmt.Println("└──────────────────────────────────────────────────────────────────────────────────────┘")
+	fmt.Println("└──────────────────────────────────────────────────────────────────────────────────────────────┘")
 }
 
 // truncateString truncates a string to max length with ellipsis
@@ -81,5 +112,8 @@ func truncateString(s string, maxLen int) string {
 
 func init() {
 	ProgressCmd.AddCommand(historyCmd)
-	historyCmd.Flags().IntP("limit", "l", 50, "Maximum entries to show")
+	historyCmd.Flags().IntP("limit", "l", 50, "Maximum events to show")
+	historyCmd.Flags().String("manga-id", "", "Only show events for this manga")
+	historyCmd.Flags().String("since", "", "Only show events on or after this date (YYYY-MM-DD)")
+	historyCmd.Flags().String("until", "", "Only show events up to and including this date (YYYY-MM-DD)")
 }
@@ -4,8 +4,8 @@ import (
 	"fmt"
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/store"
 	"os"
-	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -40,7 +40,8 @@ var syncCmd = &cobra.Command{
 		}
 		defer db.Close()
 
-		localProgress, err := fetchLocalProgress(db, sess.UserID)
+		library := store.NewSQLiteLibraryStore(db)
+		localProgress, err := library.List(sess.UserID, "", -1, 0)
 		if err != nil {
 			return fmt.Errorf("failed to fetch local progress: %w", err)
 		}
@@ -56,9 +57,10 @@ var syncCmd = &cobra.Command{
 			}
 		}
 
-		// 5. Update local if needed
+		// 5. Update local if needed, keeping the server's timestamps
+		origin := models.EventOrigin{Source: models.EventSourceCLI, DeviceID: httpClient.DeviceID}
 		for _, p := range toUpdateLocal {
-			err := updateLocalProgress(db, p)
+			err := library.Put(&p, origin)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Failed to update local for manga %s: %v\n", p.MangaID, err)
 			}
@@ -82,50 +84,6 @@ func RequireDatabase(dbPath string) (*database.Database, error) {
 	return db, nil
 }
 
-// fetchLocalProgress retrieves all progress for a user from SQLite
-func fetchLocalProgress(db *database.Database, userID string) ([]models.Progress, error) {
-	rows, err := db.Query(`SELECT user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at FROM user_progress WHERE user_id = ?`, userID)
-	if err != nil {
-		return nil, err
-	}
-	defer rows.Close()
-
-	var result []models.Progress
-	for rows.Next() {
-		var p models.Progress
-		var startedAt, updatedAt string
-		var completedAt *string
-		err := rows.Scan(&p.UserID, &p.MangaID, &p.CurrentChapter, &p.Status, &p.Rating, &p.Notes, &startedAt, &completedAt, &updatedAt)
-		if err != nil {
-			return nil, err
-		}
-		p.StartedAt, _ = time.Parse(time.RFC3339, startedAt)
-		if completedAt != nil {
-			t, _ := time.Parse(time.RFC3339, *completedAt)
-			p.CompletedAt = &t
-		}
-		p.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
-		result = append(result, p)
-	}
-	return result, nil
-}
-
-// updateLocalProgress updates or inserts a progress row in SQLite
-func updateLocalProgress(db *database.Database, p models.Progress) error {
-	_, err := db.Exec(`INSERT OR REPLACE INTO user_progress (user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		p.UserID, p.MangaID, p.CurrentChapter, p.Status, p.Rating, p.Notes, p.StartedAt.Format(time.RFC3339),
-		func() interface{} {
-			if p.CompletedAt != nil {
-				return p.CompletedAt.Format(time.RFC3339)
-			} else {
-				return nil
-			}
-		}(),
-		p.UpdatedAt.Format(time.RFC3339),
-	)
-	return err
-}
-
 // mergeProgress merges local and remote progress, returns merged, toUpdateLocal, toUpdateServer
 func mergeProgress(local, remote []models.Progress) (merged, toUpdateLocal, toUpdateServer []models.Progress) {
 	byID := make(map[string]models.Progress)
@@ -1,7 +1,10 @@
 package cli
 
 import (
+	"mangahub/internal/cli/admin"
 	"mangahub/internal/cli/auth"
+	"mangahub/internal/cli/backup"
+	"mangahub/internal/cli/catalog"
 	"mangahub/internal/cli/chat"
 	"mangahub/internal/cli/config"
 	"mangahub/internal/cli/db"
@@ -66,6 +69,9 @@ func init() {
 	rootCmd.AddCommand(config.ConfigCmd)
 	rootCmd.AddCommand(db.DBCmd)
 	rootCmd.AddCommand(profile.ProfileCmd)
+	rootCmd.AddCommand(backup.BackupCmd)
+	rootCmd.AddCommand(catalog.CatalogCmd)
+	rootCmd.AddCommand(admin.AdminCmd)
 }
 
 func Execute() error {
@@ -27,7 +27,7 @@ var logsCmd = &cobra.Command{
 
 		// Create HTTP client
 		apiURL := getAPIURL()
-		httpClient := client.NewHTTPClient(apiURL, sess.Token)
+		httpClient := client.NewSessionHTTPClient(apiURL, sess)
 
 		// Fetch logs from server
 		fmt.Printf("Fetching server logs via HTTP API...\n")
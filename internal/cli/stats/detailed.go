@@ -6,6 +6,8 @@ import (
 	"strings"
 	"time"
 
+	"mangahub/pkg/models"
+
 	"github.com/spf13/cobra"
 )
 
@@ -84,7 +86,9 @@ func runDetailed(cmd *cobra.Command, args []string) error {
 }
 
 // calculateStats calculates statistics from progress and manga data.
-func calculateStats(progressList []ProgressWithManga, fromDate, toDate *time.Time) *StatsData {
+// Chapter counts, activity dates and the streak come from the progress
+// event log; library status, ratings and genres from the entries.
+func calculateStats(progressList []ProgressWithManga, events []models.ProgressEvent, fromDate, toDate *time.Time) *StatsData {
 	stats := &StatsData{
 		GenreBreakdown:   make(map[string]int),
 		ChaptersByStatus: make(map[string]int),
@@ -94,8 +98,6 @@ func calculateStats(progressList []ProgressWithManga, fromDate, toDate *time.Tim
 
 	var totalRating int
 	var ratingCount int
-	var lastReadDate time.Time
-	dayActivity := make(map[string]int)
 
 	for _, pm := range progressList {
 		// Count by status
@@ -115,26 +117,12 @@ func calculateStats(progressList []ProgressWithManga, fromDate, toDate *time.Tim
 		// Count chapters by status
 		stats.ChaptersByStatus[pm.Progress.Status] += pm.Progress.CurrentChapter
 
-		// Total chapters read (only for reading/completed)
-		if pm.Progress.Status == "reading" || pm.Progress.Status == "completed" {
-			stats.TotalChaptersRead += pm.Progress.CurrentChapter
-		}
-
 		// Average rating (only count rated items)
 		if pm.Progress.Rating > 0 {
 			totalRating += pm.Progress.Rating
 			ratingCount++
 		}
 
-		// Track last read date
-		if pm.Progress.UpdatedAt.After(lastReadDate) {
-			lastReadDate = pm.Progress.UpdatedAt
-		}
-
-		// Track day activity
-		day := pm.Progress.UpdatedAt.Weekday().String()
-		dayActivity[day]++
-
 		// Genre breakdown (from manga data)
 		if pm.Manga != nil {
 			for _, genre := range pm.Manga.Genres {
@@ -144,13 +132,33 @@ func calculateStats(progressList []ProgressWithManga, fromDate, toDate *time.Tim
 	}
 
 	stats.TotalManga = len(progressList)
-	stats.LastReadDate = lastReadDate
 
 	// Calculate average rating
 	if ratingCount > 0 {
 		stats.AverageRating = float64(totalRating) / float64(ratingCount)
 	}
 
+	// Chapters read, last read date and day activity within the period
+	dayActivity := make(map[string]int)
+	for _, e := range events {
+		read := e.ToChapter - e.FromChapter
+		if read <= 0 {
+			continue
+		}
+		if fromDate != nil && e.CreatedAt.Before(*fromDate) {
+			continue
+		}
+		if toDate != nil && e.CreatedAt.After(*toDate) {
+			continue
+		}
+
+		stats.TotalChaptersRead += read
+		if e.CreatedAt.After(stats.LastReadDate) {
+			stats.LastReadDate = e.CreatedAt
+		}
+		dayActivity[e.CreatedAt.Local().Weekday().String()] += read
+	}
+
 	// Find most active day
 	if len(dayActivity) > 0 {
 		maxCount := 0
@@ -163,70 +171,32 @@ func calculateStats(progressList []ProgressWithManga, fromDate, toDate *time.Tim
 	}
 
 	// Calculate reading streak
-	stats.ReadingStreak = calculateReadingStreak(progressList)
+	stats.ReadingStreak = calculateReadingStreak(events, time.Now())
 
 	return stats
 }
 
-// calculateReadingStreak calculates consecutive days with reading activity.
-func calculateReadingStreak(progressList []ProgressWithManga) int {
-	if len(progressList) == 0 {
-		return 0
-	}
-
-	// Get all unique dates with activity
+// calculateReadingStreak counts consecutive days, ending today or
+// yesterday, on which at least one chapter was read.
+func calculateReadingStreak(events []models.ProgressEvent, now time.Time) int {
 	activityDates := make(map[string]bool)
-	for _, pm := range progressList {
-		if pm.Progress.Status == "reading" || pm.Progress.Status == "completed" {
-			date := pm.Progress.UpdatedAt.Format("2006-01-02")
-			activityDates[date] = true
+	for _, e := range events {
+		if e.ToChapter > e.FromChapter {
+			activityDates[e.CreatedAt.In(now.Location()).Format("2006-01-02")] = true
 		}
 	}
 
-	if len(activityDates) == 0 {
-		return 0
-	}
-
-	// Convert to sorted slice
-	var dates []time.Time
-	for dateStr := range activityDates {
-		t, _ := time.Parse("2006-01-02", dateStr)
-		dates = append(dates, t)
-	}
-	sort.Slice(dates, func(i, j int) bool {
-		return dates[i].After(dates[j])
-	})
-
-	// Calculate streak from today backwards
-	today := time.Now()
-	currentDate := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
-	streak := 0
-
-	// Check if there's activity today or yesterday (allow for timezone differences)
-	foundToday := false
-	for _, date := range dates {
-		dateOnly := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-		daysDiff := int(currentDate.Sub(dateOnly).Hours() / 24)
-		if daysDiff == 0 || daysDiff == 1 {
-			foundToday = true
-			break
-		}
-	}
-
-	if !foundToday {
-		return 0
+	// A streak is still alive if today has no activity yet
+	checkDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if !activityDates[checkDate.Format("2006-01-02")] {
+		checkDate = checkDate.AddDate(0, 0, -1)
 	}
 
 	// Count consecutive days
-	checkDate := currentDate
-	for {
-		dateStr := checkDate.Format("2006-01-02")
-		if activityDates[dateStr] {
-			streak++
-			checkDate = checkDate.AddDate(0, 0, -1)
-		} else {
-			break
-		}
+	streak := 0
+	for activityDates[checkDate.Format("2006-01-02")] {
+		streak++
+		checkDate = checkDate.AddDate(0, 0, -1)
 	}
 
 	return streak
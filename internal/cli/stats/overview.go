@@ -154,7 +154,7 @@ func getStats() (*StatsData, error) {
 
 	// Fetch library from HTTP API instead of local database
 	fmt.Printf("Fetching library data for user: %s (profile: %s)\n", sess.Username, session.GetProfile())
-	httpClient := client.NewHTTPClient(getAPIURL(), sess.Token)
+	httpClient := client.NewSessionHTTPClient(getAPIURL(), sess)
 
 	progressList, err := httpClient.GetLibrary("", 10000, 0)
 	if err != nil {
@@ -215,7 +215,14 @@ func getStats() (*StatsData, error) {
 		})
 	}
 
+	// Reading activity comes from the progress event log. The whole log is
+	// fetched because the streak counts back from today whatever the period.
+	events, err := httpClient.GetProgressEvents("", time.Time{}, time.Time{}, 10000, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch progress events via HTTP API: %w", err)
+	}
+
 	// Calculate statistics
-	stats := calculateStats(progressWithManga, fromDatePtr, toDatePtr)
+	stats := calculateStats(progressWithManga, events, fromDatePtr, toDatePtr)
 	return stats, nil
 }
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"mangahub/internal/auth"
+	"mangahub/internal/user"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// methodAccess lists the RPCs that need a signed-in caller, with the
+// scope a personal access token needs for each and the permission each
+// needs on top, if any. The other RPCs are public, like the HTTP API's
+// manga routes.
+var methodAccess = map[string]access{
+	"/manga.MangaService/UpdateProgress": {scope: models.ScopeLibraryWrite},
+}
+
+type access struct {
+	scope string
+	perm  models.Permission
+}
+
+type claimsKey struct{}
+
+// NewAuthInterceptor checks the bearer token sent in the "authorization"
+// metadata and its session the same way the HTTP API does, and hands its
+// claims on to the RPC. A token is only required by the RPCs in
+// methodAccess, but one that comes along is always checked.
+func NewAuthInterceptor(sessions *auth.SessionService, users *user.Service) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		rule, protected := methodAccess[info.FullMethod]
+
+		token := bearerToken(ctx)
+		if token == "" {
+			if protected {
+				return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+			}
+			return handler(ctx, req)
+		}
+		claims, err := sessions.Authenticate(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+
+		if rule.scope != "" && !claims.HasScope(rule.scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "access token lacks the %s scope", rule.scope)
+		}
+		if rule.perm != "" {
+			if err := authorize(users, claims, rule.perm); err != nil {
+				return nil, err
+			}
+		}
+		return handler(context.WithValue(ctx, claimsKey{}, claims), req)
+	}
+}
+
+// authorize checks that both the token's role and the user's current role
+// grant perm, and that a personal access token has the admin scope, and
+// returns the gRPC status error to answer with otherwise
+func authorize(users *user.Service, claims *auth.Claims, perm models.Permission) error {
+	if !claims.HasScope(models.ScopeAdmin) {
+		return status.Error(codes.PermissionDenied, "access token lacks the admin scope")
+	}
+	if !models.RoleAllows(claims.Role, perm) {
+		return status.Error(codes.PermissionDenied, "insufficient permissions")
+	}
+	if _, err := users.Authorize(claims.UserID, perm); err != nil {
+		switch {
+		case errors.Is(err, user.ErrForbidden):
+			return status.Error(codes.PermissionDenied, "insufficient permissions")
+		case errors.Is(err, store.ErrUserNotFound):
+			return status.Error(codes.Unauthenticated, "invalid token")
+		default:
+			return status.Error(codes.Internal, "failed to check permissions")
+		}
+	}
+	return nil
+}
+
+// bearerToken returns the token of the "authorization: Bearer <token>"
+// metadata, or "" when there is none
+func bearerToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(values[0], "Bearer ")
+}
+
+// claimsFrom returns the claims of the caller's token, or nil when the RPC
+// came without one
+func claimsFrom(ctx context.Context) *auth.Claims {
+	claims, _ := ctx.Value(claimsKey{}).(*auth.Claims)
+	return claims
+}
@@ -2,26 +2,40 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
 
 	"mangahub/internal/manga"
+	"mangahub/internal/recommend"
+	"mangahub/internal/user"
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/store"
 	"mangahub/pkg/utils"
 	pb "mangahub/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // MangaService implements the gRPC MangaService
 type MangaService struct {
-	mangaService *manga.Service
-	logger       *utils.Logger
+	mangaService   *manga.Service
+	libraryService *user.LibraryService
+	userService    *user.Service
+	recommender    *recommend.Service
+	logger         *utils.Logger
 }
 
 // NewMangaService creates a new gRPC manga service
 func NewMangaService(db *database.Database, logger *utils.Logger) *MangaService {
 	return &MangaService{
-		mangaService: manga.NewService(db),
-		logger:       logger,
+		mangaService:   manga.NewService(db),
+		libraryService: user.NewLibraryService(db),
+		userService:    user.NewService(db),
+		recommender:    recommend.NewService(db),
+		logger:         logger,
 	}
 }
 
@@ -47,11 +61,18 @@ func (s *MangaService) GetManga(ctx context.Context, req *pb.MangaRequest) (*pb.
 // SearchManga searches for manga
 func (s *MangaService) SearchManga(ctx context.Context, req *pb.SearchRequest) (*pb.SearchResponse, error) {
 	filter := &models.MangaFilter{
-		Query:  req.Title,
-		Author: req.Author,
-		Status: req.Status,
-		Genres: req.Genres,
-		Limit:  int(req.Limit),
+		Query:       req.Title,
+		Author:      req.Author,
+		Status:      req.Status,
+		Genres:      req.Genres,
+		YearFrom:    int(req.YearFrom),
+		YearTo:      int(req.YearTo),
+		MinChapters: int(req.MinChapters),
+		MinRating:   req.MinRating,
+		SortBy:      req.SortBy,
+		Order:       req.Order,
+		Limit:       int(req.Limit),
+		Offset:      int(req.Offset),
 	}
 
 	if filter.Limit == 0 {
@@ -60,20 +81,28 @@ func (s *MangaService) SearchManga(ctx context.Context, req *pb.SearchRequest) (
 
 	results, err := s.mangaService.Search(filter)
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("failed to search manga: %v", err))
+		if errors.Is(err, manga.ErrInvalidFilter) {
+			return nil, err
+		}
+		s.logger.Error("failed to search manga: %v", err)
 		return nil, fmt.Errorf("search failed")
 	}
 
 	var mangaResults []*pb.MangaResponse
 	for _, m := range results.Manga {
 		mangaResults = append(mangaResults, &pb.MangaResponse{
-			ID:       m.ID,
-			Title:    m.Title,
-			Author:   m.Author,
-			Status:   m.Status,
-			Chapters: int32(m.TotalChapters),
-			Synopsis: m.Description,
-			Genres:   m.Genres,
+			ID:        m.ID,
+			Title:     m.Title,
+			Author:    m.Author,
+			Status:    m.Status,
+			Chapters:  int32(m.TotalChapters),
+			Synopsis:  m.Description,
+			Genres:    m.Genres,
+			Rating:    float32(m.AverageRating),
+			Snippet:   m.Snippet,
+			Relevance: m.Relevance,
+			Readers:   int32(m.Readers),
+			Year:      int32(m.Year),
 		})
 	}
 
@@ -82,9 +111,36 @@ func (s *MangaService) SearchManga(ctx context.Context, req *pb.SearchRequest) (
 	}, nil
 }
 
-// UpdateProgress updates reading progress
+// UpdateProgress updates the caller's reading progress. The user ID may be
+// left out; naming another user takes an admin.
 func (s *MangaService) UpdateProgress(ctx context.Context, req *pb.UpdateProgressRequest) (*pb.UpdateProgressResponse, error) {
-	// TODO: Implement progress update in database
+	claims := claimsFrom(ctx)
+	if claims == nil {
+		return nil, status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	if req.UserID == "" {
+		req.UserID = claims.UserID
+	}
+	if req.UserID != claims.UserID {
+		if err := authorize(s.userService, claims, models.PermManageUsers); err != nil {
+			return nil, err
+		}
+	}
+
+	update := &models.ProgressUpdate{
+		UserID:    req.UserID,
+		MangaID:   req.MangaID,
+		Chapter:   int(req.Chapter),
+		Timestamp: time.Now().Unix(),
+	}
+	if err := s.libraryService.SaveProgress(update, models.EventOrigin{Source: models.EventSourceGRPC}); err != nil {
+		s.logger.Error(fmt.Sprintf("failed to update progress: %v", err))
+		return &pb.UpdateProgressResponse{
+			Success: false,
+			Message: "Failed to update progress",
+		}, nil
+	}
+
 	s.logger.Info(fmt.Sprintf("Progress updated for user %s on manga %s", req.UserID, req.MangaID))
 
 	return &pb.UpdateProgressResponse{
@@ -93,28 +149,134 @@ func (s *MangaService) UpdateProgress(ctx context.Context, req *pb.UpdateProgres
 	}, nil
 }
 
-// GetTop10Manga retrieves top 10 manga
+// GetTop10Manga returns the ten highest ranked manga of the week
 func (s *MangaService) GetTop10Manga(ctx context.Context, req *pb.Empty) (*pb.Top10Response, error) {
-	mangaList, err := s.mangaService.List(10, 0)
+	return s.GetRankings(ctx, &pb.RankingsRequest{Window: models.RankingWindowWeek, Limit: 10})
+}
+
+// GetRankings returns the cached ranking of a window, optionally within a genre
+func (s *MangaService) GetRankings(ctx context.Context, req *pb.RankingsRequest) (*pb.Top10Response, error) {
+	rankings, err := s.mangaService.Rankings(req.Window, req.Genre, int(req.Limit))
 	if err != nil {
-		s.logger.Error(fmt.Sprintf("failed to get top manga: %v", err))
-		return nil, fmt.Errorf("failed to get manga")
+		if errors.Is(err, manga.ErrInvalidRanking) {
+			return nil, err
+		}
+		s.logger.Error("failed to rank manga: %v", err)
+		return nil, fmt.Errorf("failed to get rankings")
 	}
 
 	var mangaResults []*pb.MangaResponse
-	for _, m := range mangaList {
+	for _, r := range rankings.Rankings {
 		mangaResults = append(mangaResults, &pb.MangaResponse{
-			ID:       m.ID,
-			Title:    m.Title,
-			Author:   m.Author,
-			Status:   m.Status,
-			Chapters: int32(m.TotalChapters),
-			Synopsis: m.Description,
-			Genres:   m.Genres,
+			ID:          r.Manga.ID,
+			Title:       r.Manga.Title,
+			Author:      r.Manga.Author,
+			Status:      r.Manga.Status,
+			Chapters:    int32(r.Manga.TotalChapters),
+			Synopsis:    r.Manga.Description,
+			Genres:      r.Manga.Genres,
+			Rating:      float32(r.AverageRating),
+			Readers:     int32(r.ActiveReaders),
+			Year:        int32(r.Manga.Year),
+			Rank:        int32(r.Rank),
+			Score:       r.Score,
+			Completions: int32(r.Completions),
+			Activity:    int32(r.Activity),
 		})
 	}
 
 	return &pb.Top10Response{
-		Rankings: mangaResults,
+		Rankings:    mangaResults,
+		Window:      rankings.Window,
+		Genre:       rankings.Genre,
+		GeneratedAt: rankings.GeneratedAt.Format(time.RFC3339),
+		Total:       int32(rankings.Total),
+	}, nil
+}
+
+// RunRankingRefresh recomputes the cached manga rankings once immediately
+// and then every interval. It never returns.
+func (s *MangaService) RunRankingRefresh(interval time.Duration) {
+	for {
+		if err := s.mangaService.RefreshRankings(); err != nil {
+			s.logger.Error("failed to refresh rankings: %v", err)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// GetSimilarManga returns the manga most similar to a manga by genre and
+// co-reading
+func (s *MangaService) GetSimilarManga(ctx context.Context, req *pb.SimilarRequest) (*pb.SimilarResponse, error) {
+	similar, err := s.recommender.Similar(req.MangaID, int(req.Limit))
+	if err != nil {
+		if errors.Is(err, recommend.ErrInvalidRequest) {
+			return nil, err
+		}
+		s.logger.Error("failed to find similar manga: %v", err)
+		if errors.Is(err, store.ErrMangaNotFound) {
+			return nil, fmt.Errorf("manga not found")
+		}
+		return nil, fmt.Errorf("failed to find similar manga")
+	}
+
+	var results []*pb.MangaResponse
+	for _, sm := range similar.Similar {
+		results = append(results, &pb.MangaResponse{
+			ID:       sm.Manga.ID,
+			Title:    sm.Manga.Title,
+			Author:   sm.Manga.Author,
+			Status:   sm.Manga.Status,
+			Chapters: int32(sm.Manga.TotalChapters),
+			Synopsis: sm.Manga.Description,
+			Genres:   sm.Manga.Genres,
+			Year:     int32(sm.Manga.Year),
+			Score:    sm.Score,
+		})
+	}
+
+	resp := &pb.SimilarResponse{MangaID: similar.MangaID, Similar: results}
+	if similar.ComputedAt != nil {
+		resp.ComputedAt = similar.ComputedAt.Format(time.RFC3339)
+	}
+	return resp, nil
+}
+
+// ListChapters lists the chapters of a manga
+func (s *MangaService) ListChapters(ctx context.Context, req *pb.ListChaptersRequest) (*pb.ListChaptersResponse, error) {
+	chapters, err := s.mangaService.ListChapters(models.ChapterFilter{
+		MangaID:  req.MangaID,
+		Language: req.Language,
+		After:    req.After,
+		Limit:    int(req.Limit),
+		Offset:   int(req.Offset),
+	})
+	if err != nil {
+		s.logger.Error("failed to list chapters: %v", err)
+		if errors.Is(err, store.ErrMangaNotFound) {
+			return nil, fmt.Errorf("manga not found")
+		}
+		return nil, fmt.Errorf("failed to list chapters")
+	}
+
+	var results []*pb.ChapterResponse
+	for _, ch := range chapters {
+		resp := &pb.ChapterResponse{
+			ID:       ch.ID,
+			MangaID:  ch.MangaID,
+			Number:   ch.Number,
+			Volume:   int32(ch.Volume),
+			Title:    ch.Title,
+			Language: ch.Language,
+			Pages:    int32(ch.Pages),
+		}
+		if ch.ReleasedAt != nil {
+			resp.ReleasedAt = ch.ReleasedAt.Format(time.RFC3339)
+		}
+		results = append(results, resp)
+	}
+
+	return &pb.ListChaptersResponse{
+		Chapters: results,
 	}, nil
 }
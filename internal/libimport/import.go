@@ -0,0 +1,200 @@
+package libimport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"mangahub/internal/catalog"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// Ways an entry was matched to a catalog manga
+const (
+	MatchMAL     = models.SourceMAL
+	MatchAniList = models.SourceAniList
+	MatchTitle   = "title"
+)
+
+// Importer merges list exports into user libraries
+type Importer struct {
+	manga       store.MangaStore
+	externalIDs store.ExternalIDStore
+	library     store.LibraryStore
+	catalog     *catalog.Service
+}
+
+// NewImporter creates an importer matching entries against the catalog,
+// by the external IDs linked to its manga and then by title through the
+// catalog service
+func NewImporter(manga store.MangaStore, externalIDs store.ExternalIDStore, library store.LibraryStore,
+	service *catalog.Service) *Importer {
+	return &Importer{manga: manga, externalIDs: externalIDs, library: library, catalog: service}
+}
+
+// Import merges export entries into a user's library. An entry is matched
+// to a catalog manga by its tracker ID, then its MyAnimeList ID, then its
+// titles; unmatched entries are reported and left out. An import only moves
+// an entry forward: the chapter never goes back, a completed entry stays
+// completed, and a rating or notes already set are kept. With dryRun
+// nothing is written and the report shows what would change. It stops at
+// the first store error, returning it with the report of the entries
+// before, which stay imported: each entry is written on its own.
+func (im *Importer) Import(userID string, entries []Entry, dryRun bool, origin models.EventOrigin) (*models.ImportReport, error) {
+	report := &models.ImportReport{DryRun: dryRun, Results: []models.ImportResult{}}
+	imported := make(map[string]string)
+	for _, e := range entries {
+		result, err := im.importOne(userID, e, dryRun, origin, imported)
+		if err != nil {
+			return report, fmt.Errorf("failed to import %s: %w", e.Title, err)
+		}
+		report.Add(result)
+	}
+	return report, nil
+}
+
+func (im *Importer) importOne(userID string, e Entry, dryRun bool, origin models.EventOrigin,
+	imported map[string]string) (models.ImportResult, error) {
+	result := models.ImportResult{Title: e.Title, Source: e.Source, ExternalID: e.ExternalID, Action: models.ImportSkipped}
+	if e.Status == "" {
+		result.Note = fmt.Sprintf("unknown status %q", e.RawStatus)
+		return result, nil
+	}
+
+	mangaID, matchedBy, err := im.match(e)
+	if err != nil {
+		return result, err
+	}
+	if mangaID == "" {
+		result.Action = models.ImportUnmatched
+		return result, nil
+	}
+	result.MangaID, result.MatchedBy = mangaID, matchedBy
+	if title, ok := imported[mangaID]; ok {
+		result.Note = "same manga as " + title
+		return result, nil
+	}
+	imported[mangaID] = e.Title
+
+	existing, err := im.library.Get(userID, mangaID)
+	if errors.Is(err, store.ErrEntryNotFound) {
+		existing = nil
+	} else if err != nil {
+		return result, err
+	}
+
+	progress, changes := merge(existing, e)
+	progress.UserID, progress.MangaID = userID, mangaID
+	result.Changes = changes
+	switch {
+	case existing == nil:
+		result.Action = models.ImportAdded
+	case len(changes) > 0:
+		result.Action = models.ImportUpdated
+	default:
+		result.Action = models.ImportUnchanged
+		return result, nil
+	}
+
+	if !dryRun {
+		if err := im.library.Put(progress, origin); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// match finds the catalog manga an entry is, returning "" when there is
+// none, and how it was found
+func (im *Importer) match(e Entry) (string, string, error) {
+	ids := []struct{ source, id string }{{e.Source, e.ExternalID}}
+	if e.MALID != "" && e.Source != models.SourceMAL {
+		ids = append(ids, struct{ source, id string }{models.SourceMAL, e.MALID})
+	}
+	for _, link := range ids {
+		if link.id == "" {
+			continue
+		}
+		mangaID, err := im.externalIDs.Find(link.source, link.id)
+		if errors.Is(err, store.ErrExternalIDNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", "", err
+		}
+		// Links outlive trashed manga
+		if _, err := im.manga.GetByID(mangaID); errors.Is(err, store.ErrMangaNotFound) {
+			continue
+		} else if err != nil {
+			return "", "", err
+		}
+		return mangaID, link.source, nil
+	}
+
+	mangaID, err := im.catalog.FindByTitle(append([]string{e.Title}, e.Titles...)...)
+	if err != nil || mangaID == "" {
+		return "", "", err
+	}
+	return mangaID, MatchTitle, nil
+}
+
+// merge applies an entry to a library entry, a new one when existing is
+// nil, and lists the fields that change
+func merge(existing *models.Progress, e Entry) (*models.Progress, []models.ImportChange) {
+	now := time.Now()
+	progress := &models.Progress{StartedAt: now}
+	if existing != nil {
+		copied := *existing
+		progress = &copied
+	}
+	progress.UpdatedAt = now
+	progress.DeletedAt = nil
+
+	var changes []models.ImportChange
+	change := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, models.ImportChange{Field: field, From: from, To: to})
+		}
+	}
+
+	status := e.Status
+	if progress.Status == "completed" {
+		status = progress.Status
+	}
+	change("status", progress.Status, status)
+	progress.Status = status
+
+	if e.Chapter > progress.CurrentChapter {
+		change("chapter", chapterString(progress.CurrentChapter, existing), strconv.Itoa(e.Chapter))
+		progress.CurrentChapter = e.Chapter
+	}
+	if progress.Rating == 0 && e.Rating > 0 {
+		change("rating", "", strconv.Itoa(e.Rating))
+		progress.Rating = e.Rating
+	}
+	if progress.Notes == "" && e.Notes != "" {
+		change("notes", "", e.Notes)
+		progress.Notes = e.Notes
+	}
+	if e.StartedAt != nil && (existing == nil || e.StartedAt.Before(progress.StartedAt)) {
+		progress.StartedAt = *e.StartedAt
+	}
+	if progress.Status == "completed" && progress.CompletedAt == nil {
+		completedAt := now
+		if e.CompletedAt != nil {
+			completedAt = *e.CompletedAt
+		}
+		progress.CompletedAt = &completedAt
+	}
+	return progress, changes
+}
+
+// chapterString shows the chapter an entry was at, empty for new entries
+func chapterString(chapter int, existing *models.Progress) string {
+	if existing == nil {
+		return ""
+	}
+	return strconv.Itoa(chapter)
+}
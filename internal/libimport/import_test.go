@@ -0,0 +1,131 @@
+package libimport
+
+import (
+	"errors"
+	"testing"
+
+	"mangahub/internal/catalog"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// failingLibrary fails every write for one manga
+type failingLibrary struct {
+	store.LibraryStore
+	failOn string
+}
+
+var errDiskFull = errors.New("disk full")
+
+func (l *failingLibrary) Put(progress *models.Progress, origin models.EventOrigin) error {
+	if progress.MangaID == l.failOn {
+		return errDiskFull
+	}
+	return l.LibraryStore.Put(progress, origin)
+}
+
+// newTestImporter returns an importer over in-memory stores holding
+// Berserk, linked at AniList; Vagabond, linked at MyAnimeList; Monster,
+// only known by its titles; and trashed Pluto, still linked at AniList
+func newTestImporter(t *testing.T) (*Importer, *store.Stores) {
+	t.Helper()
+	stores := store.NewMemoryStores()
+	for _, m := range []models.Manga{
+		{ID: "berserk", Title: "Berserk", Status: "ongoing"},
+		{ID: "vagabond", Title: "Vagabond", Status: "hiatus"},
+		{ID: "monster", Title: "Monster", Status: "completed"},
+		{ID: "pluto", Title: "Pluto", Status: "completed"},
+	} {
+		if err := stores.Manga.Create(&m); err != nil {
+			t.Fatalf("create manga %s: %v", m.ID, err)
+		}
+	}
+	for _, link := range []models.ExternalID{
+		{Source: models.SourceAniList, ExternalID: "30002", MangaID: "berserk"},
+		{Source: models.SourceMAL, ExternalID: "656", MangaID: "vagabond"},
+		{Source: models.SourceAniList, ExternalID: "30656", MangaID: "pluto"},
+	} {
+		if err := stores.ExternalIDs.Link(&link); err != nil {
+			t.Fatalf("link %s: %v", link.MangaID, err)
+		}
+	}
+	if err := stores.Manga.Delete("pluto"); err != nil {
+		t.Fatalf("trash manga: %v", err)
+	}
+	if err := stores.Users.Create(&models.User{ID: "user-1", Username: "alice", Email: "alice@example.com"}); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	service := catalog.NewService(stores.Manga, stores.Titles, stores.ExternalIDs, nil, nil)
+	return NewImporter(stores.Manga, stores.ExternalIDs, stores.Library, service), stores
+}
+
+func TestImportMatching(t *testing.T) {
+	tests := []struct {
+		name      string
+		entry     Entry
+		mangaID   string
+		matchedBy string
+	}{
+		{name: "tracker ID first",
+			entry:   Entry{Source: models.SourceAniList, ExternalID: "30002", MALID: "656", Title: "Monster"},
+			mangaID: "berserk", matchedBy: MatchAniList},
+		{name: "MyAnimeList ID of an AniList entry",
+			entry:   Entry{Source: models.SourceAniList, ExternalID: "1", MALID: "656", Title: "Monster"},
+			mangaID: "vagabond", matchedBy: MatchMAL},
+		{name: "MyAnimeList entry by its own ID",
+			entry:   Entry{Source: models.SourceMAL, ExternalID: "656", Title: "Monster"},
+			mangaID: "vagabond", matchedBy: MatchMAL},
+		{name: "title when no ID is linked",
+			entry:   Entry{Source: models.SourceAniList, ExternalID: "1", MALID: "2", Title: "MONSTER!"},
+			mangaID: "monster", matchedBy: MatchTitle},
+		{name: "alternate title",
+			entry:   Entry{Source: models.SourceMAL, ExternalID: "1", Title: "モンスター", Titles: []string{"Monster"}},
+			mangaID: "monster", matchedBy: MatchTitle},
+		{name: "link to a trashed manga falls through to the title",
+			entry:   Entry{Source: models.SourceAniList, ExternalID: "30656", Title: "Monster"},
+			mangaID: "monster", matchedBy: MatchTitle},
+		{name: "no match",
+			entry: Entry{Source: models.SourceMAL, ExternalID: "1", Title: "Pluto"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			im, _ := newTestImporter(t)
+			mangaID, matchedBy, err := im.match(tt.entry)
+			if err != nil {
+				t.Fatalf("match: %v", err)
+			}
+			if mangaID != tt.mangaID || matchedBy != tt.matchedBy {
+				t.Errorf("matched %q by %q, want %q by %q", mangaID, matchedBy, tt.mangaID, tt.matchedBy)
+			}
+		})
+	}
+}
+
+func TestImportReportsEntriesBeforeStoreError(t *testing.T) {
+	im, stores := newTestImporter(t)
+	im.library = &failingLibrary{LibraryStore: stores.Library, failOn: "vagabond"}
+
+	entries := []Entry{
+		{Source: models.SourceAniList, ExternalID: "30002", Title: "Berserk", Status: "reading", Chapter: 100},
+		{Source: models.SourceMAL, ExternalID: "1", Title: "Unknown Manga", Status: "reading"},
+		{Source: models.SourceMAL, ExternalID: "656", Title: "Vagabond", Status: "reading", Chapter: 300},
+		{Source: models.SourceMAL, ExternalID: "2", Title: "Monster", Status: "completed", Chapter: 162},
+	}
+	report, err := im.Import("user-1", entries, false, models.EventOrigin{Source: models.EventSourceHTTP})
+	if !errors.Is(err, errDiskFull) {
+		t.Fatalf("error = %v, want the store error", err)
+	}
+	if report == nil || len(report.Results) != 2 || report.Added != 1 || report.Unmatched != 1 {
+		t.Fatalf("report = %+v, want the two entries before the failing one", report)
+	}
+
+	if _, err := stores.Library.Get("user-1", "berserk"); err != nil {
+		t.Errorf("entry reported as added is not in the library: %v", err)
+	}
+	for _, id := range []string{"vagabond", "monster"} {
+		if _, err := stores.Library.Get("user-1", id); !errors.Is(err, store.ErrEntryNotFound) {
+			t.Errorf("get %s after the import stopped: %v, want it left out", id, err)
+		}
+	}
+}
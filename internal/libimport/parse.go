@@ -0,0 +1,325 @@
+// Package libimport brings a user's reading history over from other
+// trackers: it parses MyAnimeList and AniList list exports, matches their
+// entries to catalog manga and merges them into the user's library.
+package libimport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// Export formats
+const (
+	FormatMAL     = "mal"
+	FormatAniList = "anilist"
+)
+
+// maxExportSize bounds a decompressed export
+const maxExportSize = 64 << 20
+
+var (
+	// ErrUnknownFormat is returned for a format other than mal and anilist
+	ErrUnknownFormat = errors.New("unknown export format")
+	// ErrInvalidExport is returned when an export cannot be read in its
+	// format
+	ErrInvalidExport = errors.New("invalid export")
+)
+
+// Entry is one manga of a list export, with its status already mapped onto
+// the library's statuses
+type Entry struct {
+	// Source and ExternalID identify the manga at the tracker it was
+	// exported from
+	Source     string `json:"source"`
+	ExternalID string `json:"external_id"`
+	// MALID is the MyAnimeList ID AniList entries also carry
+	MALID string `json:"mal_id,omitempty"`
+	Title string `json:"title"`
+	// Titles are other titles of the manga to match on
+	Titles []string `json:"titles,omitempty"`
+	// Status is "" when the tracker's status, kept in RawStatus, has no
+	// library equivalent
+	Status      string     `json:"status"`
+	RawStatus   string     `json:"raw_status"`
+	Chapter     int        `json:"chapter"`
+	Rating      int        `json:"rating"`
+	Notes       string     `json:"notes,omitempty"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Parse reads a list export in the given format. Gzipped exports, as
+// MyAnimeList hands them out, are decompressed first.
+func Parse(format string, data []byte) ([]Entry, error) {
+	if bytes.HasPrefix(data, []byte{0x1f, 0x8b}) {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+		data, err = io.ReadAll(io.LimitReader(zr, maxExportSize))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidExport, err)
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case FormatMAL:
+		return ParseMAL(data)
+	case FormatAniList:
+		return ParseAniList(data)
+	default:
+		return nil, fmt.Errorf("%w %q: use %s or %s", ErrUnknownFormat, format, FormatMAL, FormatAniList)
+	}
+}
+
+// malExport is the XML list export of MyAnimeList
+type malExport struct {
+	Manga []malManga `xml:"manga"`
+	Anime []struct{} `xml:"anime"`
+}
+
+type malManga struct {
+	ID           string `xml:"manga_mangadb_id"`
+	Title        string `xml:"manga_title"`
+	ReadChapters int    `xml:"my_read_chapters"`
+	Score        int    `xml:"my_score"`
+	Status       string `xml:"my_status"`
+	Comments     string `xml:"my_comments"`
+	StartDate    string `xml:"my_start_date"`
+	FinishDate   string `xml:"my_finish_date"`
+}
+
+// malStatuses maps MyAnimeList statuses, by name and by the numbers older
+// exports use, onto the library's
+var malStatuses = map[string]string{
+	"reading":      "reading",
+	"completed":    "completed",
+	"on-hold":      "on-hold",
+	"dropped":      "dropped",
+	"plan to read": "plan-to-read",
+	"1":            "reading",
+	"2":            "completed",
+	"3":            "on-hold",
+	"4":            "dropped",
+	"6":            "plan-to-read",
+}
+
+// ParseMAL reads a MyAnimeList manga list export (XML)
+func ParseMAL(data []byte) ([]Entry, error) {
+	var export malExport
+	if err := xml.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: not a MyAnimeList XML export: %v", ErrInvalidExport, err)
+	}
+	if len(export.Manga) == 0 && len(export.Anime) > 0 {
+		return nil, fmt.Errorf("%w: this is an anime list, export the manga list instead", ErrInvalidExport)
+	}
+
+	entries := make([]Entry, 0, len(export.Manga))
+	for _, m := range export.Manga {
+		status := strings.TrimSpace(m.Status)
+		entries = append(entries, Entry{
+			Source:      models.SourceMAL,
+			ExternalID:  strings.TrimSpace(m.ID),
+			Title:       strings.TrimSpace(m.Title),
+			Status:      malStatuses[strings.ToLower(status)],
+			RawStatus:   status,
+			Chapter:     m.ReadChapters,
+			Rating:      clampRating(m.Score),
+			Notes:       strings.TrimSpace(m.Comments),
+			StartedAt:   parseMALDate(m.StartDate),
+			CompletedAt: parseMALDate(m.FinishDate),
+		})
+	}
+	return entries, nil
+}
+
+// parseMALDate reads a MyAnimeList date, nil for "0000-00-00" and other
+// unset or partial dates
+func parseMALDate(s string) *time.Time {
+	t, err := time.Parse("2006-01-02", strings.TrimSpace(s))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// aniListExport is a MediaListCollection as the AniList GraphQL API returns
+// it, with or without the response envelope
+type aniListExport struct {
+	Data *struct {
+		MediaListCollection *aniListCollection `json:"MediaListCollection"`
+	} `json:"data"`
+	MediaListCollection *aniListCollection `json:"MediaListCollection"`
+	Lists               []aniListList      `json:"lists"`
+}
+
+type aniListCollection struct {
+	Lists []aniListList `json:"lists"`
+}
+
+type aniListList struct {
+	Name         string         `json:"name"`
+	IsCustomList bool           `json:"isCustomList"`
+	Entries      []aniListEntry `json:"entries"`
+}
+
+type aniListEntry struct {
+	MediaID     int         `json:"mediaId"`
+	Status      string      `json:"status"`
+	Score       float64     `json:"score"`
+	Progress    int         `json:"progress"`
+	Notes       string      `json:"notes"`
+	StartedAt   aniListDate `json:"startedAt"`
+	CompletedAt aniListDate `json:"completedAt"`
+	Media       struct {
+		ID    int    `json:"id"`
+		IDMal int    `json:"idMal"`
+		Type  string `json:"type"`
+		Title struct {
+			Romaji  string `json:"romaji"`
+			English string `json:"english"`
+			Native  string `json:"native"`
+		} `json:"title"`
+		Synonyms []string `json:"synonyms"`
+	} `json:"media"`
+}
+
+// aniListDate is an AniList fuzzy date, any part of which may be missing
+type aniListDate struct {
+	Year  int `json:"year"`
+	Month int `json:"month"`
+	Day   int `json:"day"`
+}
+
+// time returns the date, nil without a year; a missing month or day is
+// taken as the first
+func (d aniListDate) time() *time.Time {
+	if d.Year <= 0 {
+		return nil
+	}
+	month, day := d.Month, d.Day
+	if month <= 0 {
+		month = 1
+	}
+	if day <= 0 {
+		day = 1
+	}
+	t := time.Date(d.Year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+// aniListStatuses maps AniList list statuses onto the library's
+var aniListStatuses = map[string]string{
+	"CURRENT":   "reading",
+	"REPEATING": "reading",
+	"COMPLETED": "completed",
+	"PAUSED":    "on-hold",
+	"DROPPED":   "dropped",
+	"PLANNING":  "plan-to-read",
+}
+
+// ParseAniList reads an AniList manga list export: the JSON of a
+// MediaListCollection query (type: MANGA), with or without its "data"
+// envelope. Custom lists repeat entries of the status lists and are
+// skipped. Scores above 10 are taken as 100-point scores.
+func ParseAniList(data []byte) ([]Entry, error) {
+	var export aniListExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("%w: not an AniList JSON export: %v", ErrInvalidExport, err)
+	}
+	lists := export.Lists
+	switch {
+	case export.Data != nil && export.Data.MediaListCollection != nil:
+		lists = export.Data.MediaListCollection.Lists
+	case export.MediaListCollection != nil:
+		lists = export.MediaListCollection.Lists
+	}
+	if lists == nil {
+		return nil, fmt.Errorf("%w: no MediaListCollection lists in AniList export", ErrInvalidExport)
+	}
+
+	var entries []Entry
+	seen := make(map[int]bool)
+	for _, list := range lists {
+		if list.IsCustomList {
+			continue
+		}
+		for _, e := range list.Entries {
+			if e.Media.Type == "ANIME" {
+				return nil, fmt.Errorf("%w: this is an anime list, export the manga list instead", ErrInvalidExport)
+			}
+			id := e.Media.ID
+			if id == 0 {
+				id = e.MediaID
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+
+			title := e.Media.Title
+			entry := Entry{
+				Source:      models.SourceAniList,
+				ExternalID:  strconv.Itoa(id),
+				Title:       firstNonEmpty(title.English, title.Romaji, title.Native),
+				Status:      aniListStatuses[strings.ToUpper(e.Status)],
+				RawStatus:   e.Status,
+				Chapter:     e.Progress,
+				Rating:      aniListRating(e.Score),
+				Notes:       strings.TrimSpace(e.Notes),
+				StartedAt:   e.StartedAt.time(),
+				CompletedAt: e.CompletedAt.time(),
+			}
+			if e.Media.IDMal > 0 {
+				entry.MALID = strconv.Itoa(e.Media.IDMal)
+			}
+			for _, t := range append([]string{title.Romaji, title.English, title.Native}, e.Media.Synonyms...) {
+				if t = strings.TrimSpace(t); t != "" && t != entry.Title {
+					entry.Titles = append(entry.Titles, t)
+				}
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// aniListRating turns an AniList score into a 0-10 rating
+func aniListRating(score float64) int {
+	if score > 10 {
+		score /= 10
+	}
+	return clampRating(int(math.Round(score)))
+}
+
+// clampRating keeps a rating within 0-10
+func clampRating(rating int) int {
+	if rating < 0 {
+		return 0
+	}
+	if rating > 10 {
+		return 10
+	}
+	return rating
+}
+
+// firstNonEmpty returns the first of the strings that is not blank
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,154 @@
+package libimport
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+const malExportXML = `<?xml version="1.0" encoding="UTF-8" ?>
+<myanimelist>
+	<myinfo><user_name>alice</user_name></myinfo>
+	<manga>
+		<manga_mangadb_id> 2 </manga_mangadb_id>
+		<manga_title><![CDATA[Berserk]]></manga_title>
+		<my_read_chapters>374</my_read_chapters>
+		<my_score>10</my_score>
+		<my_status>Reading</my_status>
+		<my_comments><![CDATA[ Best arc: Golden Age ]]></my_comments>
+		<my_start_date>2020-03-01</my_start_date>
+		<my_finish_date>0000-00-00</my_finish_date>
+	</manga>
+	<manga>
+		<manga_mangadb_id>13</manga_mangadb_id>
+		<manga_title>One Piece</manga_title>
+		<my_read_chapters>0</my_read_chapters>
+		<my_score>0</my_score>
+		<my_status>6</my_status>
+	</manga>
+	<manga>
+		<manga_mangadb_id>99</manga_mangadb_id>
+		<manga_title>Odd One</manga_title>
+		<my_status>Rereading</my_status>
+		<my_score>12</my_score>
+	</manga>
+</myanimelist>`
+
+const aniListExportJSON = `{"data": {"MediaListCollection": {"lists": [
+	{"name": "Reading", "isCustomList": false, "entries": [
+		{"mediaId": 30002, "status": "CURRENT", "score": 85, "progress": 120, "notes": " reread ",
+		 "startedAt": {"year": 2021, "month": 5, "day": null}, "completedAt": {},
+		 "media": {"id": 30002, "idMal": 2, "type": "MANGA",
+		  "title": {"romaji": "Berserk", "english": "", "native": "ベルセルク"}, "synonyms": ["Berserk: The Prototype"]}}
+	]},
+	{"name": "Completed", "isCustomList": false, "entries": [
+		{"mediaId": 30013, "status": "COMPLETED", "score": 7.6, "progress": 1100,
+		 "completedAt": {"year": 2023, "month": 1, "day": 2},
+		 "media": {"id": 30013, "type": "MANGA", "title": {"romaji": "ONE PIECE", "english": "One Piece"}}}
+	]},
+	{"name": "Favourites", "isCustomList": true, "entries": [
+		{"mediaId": 30013, "status": "COMPLETED", "media": {"id": 30013, "type": "MANGA", "title": {"romaji": "ONE PIECE"}}}
+	]}
+]}}}`
+
+func date(year int, month time.Month, day int) *time.Time {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return &t
+}
+
+func gzipped(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestParse(t *testing.T) {
+	malEntries := []Entry{
+		{Source: models.SourceMAL, ExternalID: "2", Title: "Berserk", Status: "reading", RawStatus: "Reading",
+			Chapter: 374, Rating: 10, Notes: "Best arc: Golden Age", StartedAt: date(2020, time.March, 1)},
+		{Source: models.SourceMAL, ExternalID: "13", Title: "One Piece", Status: "plan-to-read", RawStatus: "6"},
+		{Source: models.SourceMAL, ExternalID: "99", Title: "Odd One", Status: "", RawStatus: "Rereading", Rating: 10},
+	}
+	aniListEntries := []Entry{
+		{Source: models.SourceAniList, ExternalID: "30002", MALID: "2", Title: "Berserk",
+			Titles: []string{"ベルセルク", "Berserk: The Prototype"}, Status: "reading", RawStatus: "CURRENT",
+			Chapter: 120, Rating: 9, Notes: "reread", StartedAt: date(2021, time.May, 1)},
+		{Source: models.SourceAniList, ExternalID: "30013", Title: "One Piece", Titles: []string{"ONE PIECE"},
+			Status: "completed", RawStatus: "COMPLETED", Chapter: 1100, Rating: 8, CompletedAt: date(2023, time.January, 2)},
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		data    []byte
+		want    []Entry
+		wantErr error
+	}{
+		{name: "mal xml", format: FormatMAL, data: []byte(malExportXML), want: malEntries},
+		{name: "mal gzip", format: FormatMAL, data: gzipped(t, malExportXML), want: malEntries},
+		{name: "format is case insensitive", format: "MAL", data: []byte(malExportXML), want: malEntries},
+		{name: "anilist with envelope", format: FormatAniList, data: []byte(aniListExportJSON), want: aniListEntries},
+		{name: "anilist gzip", format: FormatAniList, data: gzipped(t, aniListExportJSON), want: aniListEntries},
+		{name: "anilist without envelope", format: FormatAniList,
+			data: []byte(`{"lists": [{"entries": [{"mediaId": 7, "status": "PAUSED", "media": {"title": {"native": "ナナ"}}}]}]}`),
+			want: []Entry{{Source: models.SourceAniList, ExternalID: "7", Title: "ナナ", Status: "on-hold", RawStatus: "PAUSED"}}},
+		{name: "unknown format", format: "kitsu", data: []byte(malExportXML), wantErr: ErrUnknownFormat},
+		{name: "mal anime list", format: FormatMAL,
+			data: []byte(`<myanimelist><anime><series_title>Monster</series_title></anime></myanimelist>`), wantErr: ErrInvalidExport},
+		{name: "mal not xml", format: FormatMAL, data: []byte(`{"lists": []}`), wantErr: ErrInvalidExport},
+		{name: "anilist anime list", format: FormatAniList,
+			data: []byte(`{"lists": [{"entries": [{"mediaId": 1, "media": {"type": "ANIME"}}]}]}`), wantErr: ErrInvalidExport},
+		{name: "anilist without lists", format: FormatAniList, data: []byte(`{"data": {}}`), wantErr: ErrInvalidExport},
+		{name: "anilist not json", format: FormatAniList, data: []byte(malExportXML), wantErr: ErrInvalidExport},
+		{name: "corrupt gzip", format: FormatMAL, data: []byte{0x1f, 0x8b, 0x00}, wantErr: ErrInvalidExport},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.format, tt.data)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("entries:\n got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAniListRating(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  int
+	}{
+		{0, 0},
+		{7, 7},
+		{7.5, 8},
+		{10, 10},
+		{85, 9},
+		{100, 10},
+		{-3, 0},
+	}
+	for _, tt := range tests {
+		if got := aniListRating(tt.score); got != tt.want {
+			t.Errorf("aniListRating(%v) = %d, want %d", tt.score, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package manga
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+const (
+	// DefaultRankingLimit is the ranking length when none is requested
+	DefaultRankingLimit = 10
+
+	// MaxRankingLimit is the number of ranked manga kept per cached ranking
+	MaxRankingLimit = 100
+
+	// rankingIdleTTL is how long a genre ranking nobody asks for is still
+	// refreshed before it is dropped from the cache
+	rankingIdleTTL = 24 * time.Hour
+)
+
+// Score weights. Picking a series up and finishing it count most, every
+// progress update adds a little, and the average rating is damped by
+// ratingPrior so a single 10/10 does not outrank a well-read series.
+const (
+	readerWeight     = 3.0
+	completionWeight = 5.0
+	activityWeight   = 0.5
+	ratingWeight     = 2.0
+	ratingPrior      = 3.0
+)
+
+// rankingScore combines the reading figures of a manga into its score
+func rankingScore(stats models.RankingStats) float64 {
+	confidence := float64(stats.Ratings) / (float64(stats.Ratings) + ratingPrior)
+	return readerWeight*float64(stats.ActiveReaders) +
+		completionWeight*float64(stats.Completions) +
+		activityWeight*float64(stats.Activity) +
+		ratingWeight*stats.AverageRating*confidence
+}
+
+type rankingKey struct{ window, genre string }
+
+type cachedRanking struct {
+	rankings *models.Rankings
+	lastUsed time.Time
+}
+
+// rankingCache holds the computed rankings per window and genre
+type rankingCache struct {
+	mu      sync.Mutex
+	entries map[rankingKey]*cachedRanking
+}
+
+func newRankingCache() *rankingCache {
+	return &rankingCache{entries: make(map[rankingKey]*cachedRanking)}
+}
+
+// Rankings returns the top manga of a window ("week", "month" or "all",
+// default "week"), optionally within a genre. Rankings are served from the
+// cache and only computed here the first time a window and genre are asked
+// for; RefreshRankings keeps them current.
+func (s *Service) Rankings(window, genre string, limit int) (*models.Rankings, error) {
+	window = strings.ToLower(window)
+	if window == "" {
+		window = models.RankingWindowWeek
+	}
+	if !validWindow(window) {
+		return nil, fmt.Errorf("%w: window must be one of %s", ErrInvalidRanking, strings.Join(models.RankingWindows, ", "))
+	}
+	if limit == 0 {
+		limit = DefaultRankingLimit
+	}
+	if limit < 0 || limit > MaxRankingLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRanking, MaxRankingLimit)
+	}
+
+	key := rankingKey{window: window, genre: models.GenreSlug(genre)}
+	now := time.Now()
+
+	var rankings *models.Rankings
+	s.rankings.mu.Lock()
+	if cached, ok := s.rankings.entries[key]; ok {
+		cached.lastUsed = now
+		rankings = cached.rankings
+	}
+	s.rankings.mu.Unlock()
+
+	if rankings == nil {
+		computed, err := s.computeRankings(key, now)
+		if err != nil {
+			return nil, err
+		}
+		s.rankings.mu.Lock()
+		s.rankings.entries[key] = &cachedRanking{rankings: computed, lastUsed: now}
+		s.rankings.mu.Unlock()
+		rankings = computed
+	}
+
+	// Cached rankings are shared, so hand out a trimmed copy
+	result := *rankings
+	if len(result.Rankings) > limit {
+		result.Rankings = result.Rankings[:limit]
+	}
+	result.Rankings = append([]models.RankedManga(nil), result.Rankings...)
+	return &result, nil
+}
+
+// RefreshRankings recomputes every window without a genre and the genre
+// rankings asked for within the last day, and drops the others
+func (s *Service) RefreshRankings() error {
+	now := time.Now()
+	keys := make(map[rankingKey]bool)
+	for _, window := range models.RankingWindows {
+		keys[rankingKey{window: window}] = true
+	}
+
+	s.rankings.mu.Lock()
+	for key, cached := range s.rankings.entries {
+		if key.genre == "" {
+			continue
+		}
+		if now.Sub(cached.lastUsed) > rankingIdleTTL {
+			delete(s.rankings.entries, key)
+			continue
+		}
+		keys[key] = true
+	}
+	s.rankings.mu.Unlock()
+
+	for key := range keys {
+		rankings, err := s.computeRankings(key, now)
+		if err != nil {
+			return err
+		}
+		s.rankings.mu.Lock()
+		if cached, ok := s.rankings.entries[key]; ok {
+			cached.rankings = rankings
+		} else {
+			s.rankings.entries[key] = &cachedRanking{rankings: rankings}
+		}
+		s.rankings.mu.Unlock()
+	}
+	return nil
+}
+
+// computeRankings scores and orders the manga of one window and genre,
+// breaking ties by active readers and then title
+func (s *Service) computeRankings(key rankingKey, now time.Time) (*models.Rankings, error) {
+	ranked, err := s.store.Rankings(models.RankingWindowStart(key.window, now), key.genre)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ranked {
+		ranked[i].Score = rankingScore(ranked[i].RankingStats)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.ActiveReaders != b.ActiveReaders {
+			return a.ActiveReaders > b.ActiveReaders
+		}
+		return a.Manga.Title < b.Manga.Title
+	})
+
+	total := len(ranked)
+	if len(ranked) > MaxRankingLimit {
+		ranked = ranked[:MaxRankingLimit]
+	}
+	for i := range ranked {
+		ranked[i].Rank = i + 1
+	}
+	return &models.Rankings{
+		Window:      key.window,
+		Genre:       key.genre,
+		GeneratedAt: now.UTC(),
+		Rankings:    ranked,
+		Total:       total,
+	}, nil
+}
+
+func validWindow(window string) bool {
+	for _, w := range models.RankingWindows {
+		if window == w {
+			return true
+		}
+	}
+	return false
+}
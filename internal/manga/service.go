@@ -1,217 +1,320 @@
 package manga
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+var (
+	// ErrInvalidChapter is returned when a chapter has impossible values
+	ErrInvalidChapter = errors.New("invalid chapter")
+
+	// ErrInvalidFilter is returned when a search filter has an unknown sort
+	// mode or order, or impossible ranges
+	ErrInvalidFilter = errors.New("invalid search filter")
+
+	// ErrInvalidRanking is returned when rankings are requested for an
+	// unknown window or with an out-of-range limit
+	ErrInvalidRanking = errors.New("invalid ranking request")
+
+	// ErrInvalidTitle is returned when an alternate title has no text or
+	// language
+	ErrInvalidTitle = errors.New("invalid title")
 )
 
 // Service handles manga operations
 type Service struct {
-	db *database.Database
+	store    store.MangaStore
+	chapters store.ChapterStore
+	genres   store.GenreStore
+	titles   store.TitleStore
+	rankings *rankingCache
 }
 
-// NewService creates a new manga service
+// NewService creates a new manga service backed by the SQLite database
 func NewService(db *database.Database) *Service {
-	return &Service{db: db}
+	return NewServiceWithStores(store.NewSQLiteMangaStore(db), store.NewSQLiteChapterStore(db),
+		store.NewSQLiteGenreStore(db), store.NewSQLiteTitleStore(db))
+}
+
+// NewServiceWithStores creates a new manga service on top of any MangaStore,
+// ChapterStore, GenreStore and TitleStore
+func NewServiceWithStores(s store.MangaStore, chapters store.ChapterStore, genres store.GenreStore, titles store.TitleStore) *Service {
+	return &Service{store: s, chapters: chapters, genres: genres, titles: titles, rankings: newRankingCache()}
 }
 
 // Create creates a new manga entry
 func (s *Service) Create(manga *models.Manga) error {
-	genres, _ := models.MangaToJSON(manga.Genres)
-	query := `
-		INSERT INTO manga (id, title, author, genres, status, chapters, description, cover_url, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`
-	now := time.Now()
-	_, err := s.db.Exec(query, manga.ID, manga.Title, manga.Author, genres, manga.Status, manga.TotalChapters, manga.Description, manga.CoverURL, now, now)
+	return s.store.Create(manga)
+}
+
+// GetByID retrieves a manga by ID together with its alternate titles
+func (s *Service) GetByID(id string) (*models.Manga, error) {
+	manga, err := s.store.GetByID(id)
 	if err != nil {
-		return fmt.Errorf("failed to create manga: %w", err)
+		return nil, err
 	}
-	return nil
+	if manga.Titles, err = s.titles.List(id); err != nil {
+		return nil, err
+	}
+	return manga, nil
 }
 
-// GetByID retrieves a manga by ID
-func (s *Service) GetByID(id string) (*models.Manga, error) {
-	query := `SELECT id, title, author, genres, status, chapters, description, cover_url, created_at, updated_at FROM manga WHERE id = ?`
+// Search searches for manga, using full-text ranking when a query is given.
+// The result carries facet counts over every match.
+func (s *Service) Search(filter *models.MangaFilter) (*models.SearchResult, error) {
+	filter.SortBy = strings.ToLower(filter.SortBy)
+	filter.Order = strings.ToLower(filter.Order)
 
-	var manga models.Manga
-	var genresJSON, description, coverURL sql.NullString
-	err := s.db.QueryRow(query, id).Scan(
-		&manga.ID, &manga.Title, &manga.Author, &genresJSON, &manga.Status,
-		&manga.TotalChapters, &description, &coverURL,
-		&manga.CreatedAt, &manga.UpdatedAt,
-	)
+	// Text searches rank by relevance unless another order is requested
+	if filter.SortBy == "" {
+		filter.SortBy = "title"
+		if filter.Query != "" {
+			filter.SortBy = "relevance"
+		}
+	}
+	if filter.Order == "" {
+		filter.Order = "asc"
+		switch filter.SortBy {
+		case "popularity", "rating", "recent":
+			filter.Order = "desc"
+		}
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 10
+	}
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
 
+	mangaList, err := s.store.Search(filter)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("manga not found")
-		}
-		return nil, fmt.Errorf("failed to get manga: %w", err)
+		return nil, err
+	}
+	facets, err := s.store.Facets(filter)
+	if err != nil {
+		return nil, err
 	}
 
-	if genresJSON.Valid {
-		manga.Genres, _ = models.JSONToManga(genresJSON.String)
+	// Every match has exactly one status, so the status facets add up to
+	// the total
+	total := 0
+	for _, f := range facets.Status {
+		total += f.Count
 	}
-	if description.Valid {
-		manga.Description = description.String
+
+	result := &models.SearchResult{
+		Total:  total,
+		Manga:  mangaList,
+		Page:   (filter.Offset / filter.Limit) + 1,
+		Limit:  filter.Limit,
+		Facets: facets,
 	}
-	if coverURL.Valid {
-		manga.CoverURL = coverURL.String
+
+	if filter.Query != "" && total < minExactHits {
+		if err := s.suggest(filter, result); err != nil {
+			return nil, err
+		}
 	}
-	return &manga, nil
+
+	return result, nil
 }
 
-// Search searches for manga
-func (s *Service) Search(filter *models.MangaFilter) (*models.SearchResult, error) {
-	query := "SELECT id, title, author, genres, status, chapters, description, cover_url, created_at, updated_at FROM manga WHERE 1=1"
-	var args []interface{}
+const (
+	// minExactHits is the number of exact hits below which a text search
+	// also suggests similar titles and authors
+	minExactHits = 3
+
+	// maxSuggestions is the number of suggestions a search carries
+	maxSuggestions = 5
 
-	if filter.Query != "" {
-		query += " AND (title LIKE ? OR author LIKE ?)"
-		searchTerm := "%" + filter.Query + "%"
-		args = append(args, searchTerm, searchTerm)
+	// MaxAutocomplete is the largest number of autocomplete suggestions
+	MaxAutocomplete = 20
+)
+
+// suggest adds the titles and authors resembling the query to a search
+// with few hits. When nothing matched exactly, the first page is replaced
+// by the suggested manga that pass the other filters, most similar first.
+func (s *Service) suggest(filter *models.MangaFilter, result *models.SearchResult) error {
+	suggestions, err := s.store.Fuzzy(filter.Query, maxSuggestions+len(result.Manga))
+	if err != nil {
+		return err
 	}
 
-	if len(filter.Genres) > 0 {
-		for _, genre := range filter.Genres {
-			query += " AND genres LIKE ?"
-			args = append(args, "%"+genre+"%")
+	found := make(map[string]bool, len(result.Manga))
+	for _, m := range result.Manga {
+		found[m.ID] = true
+	}
+	for _, sg := range suggestions {
+		if !found[sg.MangaID] && len(result.Suggestions) < maxSuggestions {
+			result.Suggestions = append(result.Suggestions, sg)
 		}
 	}
+	if len(result.Suggestions) == 0 {
+		return nil
+	}
+	result.DidYouMean = result.Suggestions[0].Matched
 
-	if filter.Status != "" {
-		query += " AND status = ?"
-		args = append(args, filter.Status)
+	if result.Total > 0 || filter.Offset > 0 {
+		return nil
 	}
 
-	if filter.MinChapters > 0 {
-		query += " AND chapters >= ?"
-		args = append(args, filter.MinChapters)
+	fallback := *filter
+	fallback.Query = ""
+	fallback.SortBy = "title"
+	fallback.Order = "asc"
+	fallback.Offset = 0
+	fallback.IDs = nil
+	for _, sg := range result.Suggestions {
+		fallback.IDs = append(fallback.IDs, sg.MangaID)
 	}
+	fallback.Limit = len(fallback.IDs)
 
-	if filter.SortBy == "" {
-		filter.SortBy = "title"
+	mangaList, err := s.store.Search(&fallback)
+	if err != nil {
+		return err
 	}
-	// Map sortBy to actual column names
-	sortColumn := filter.SortBy
-	if sortColumn == "total_chapters" {
-		sortColumn = "chapters"
+	if len(mangaList) == 0 {
+		return nil
 	}
-	if filter.Order == "" {
-		filter.Order = "asc"
+	facets, err := s.store.Facets(&fallback)
+	if err != nil {
+		return err
 	}
 
-	query += " ORDER BY " + sortColumn + " " + filter.Order
-
-	if filter.Limit == 0 {
-		filter.Limit = 10
+	rank := make(map[string]int, len(fallback.IDs))
+	for i, id := range fallback.IDs {
+		rank[id] = i
+	}
+	sort.SliceStable(mangaList, func(i, j int) bool { return rank[mangaList[i].ID] < rank[mangaList[j].ID] })
+	result.Total = len(mangaList)
+	if len(mangaList) > filter.Limit {
+		mangaList = mangaList[:filter.Limit]
 	}
 
-	query += " LIMIT ? OFFSET ?"
-	args = append(args, filter.Limit, filter.Offset)
+	result.Manga = mangaList
+	result.Facets = facets
+	result.Fuzzy = true
+	return nil
+}
 
-	rows, err := s.db.Query(query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to search manga: %w", err)
-	}
-	defer rows.Close()
-
-	var mangaList []models.Manga
-	for rows.Next() {
-		var manga models.Manga
-		var genresJSON, description, coverURL sql.NullString
-		err := rows.Scan(
-			&manga.ID, &manga.Title, &manga.Author, &genresJSON, &manga.Status,
-			&manga.TotalChapters, &description, &coverURL,
-			&manga.CreatedAt, &manga.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan manga: %w", err)
-		}
-		if genresJSON.Valid {
-			manga.Genres, _ = models.JSONToManga(genresJSON.String)
-		}
-		if description.Valid {
-			manga.Description = description.String
-		}
-		if coverURL.Valid {
-			manga.CoverURL = coverURL.String
-		}
-		mangaList = append(mangaList, manga)
+// Autocomplete suggests titles starting with the prefix for interactive
+// clients, at most limit (default 10) of them
+func (s *Service) Autocomplete(prefix string, limit int) ([]models.Suggestion, error) {
+	if limit == 0 {
+		limit = 10
 	}
-
-	result := &models.SearchResult{
-		Total: len(mangaList),
-		Manga: mangaList,
-		Page:  (filter.Offset / filter.Limit) + 1,
-		Limit: filter.Limit,
+	if limit < 0 || limit > MaxAutocomplete {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidFilter, MaxAutocomplete)
 	}
-
-	return result, nil
+	return s.store.Autocomplete(prefix, limit)
 }
 
 // List lists all manga
 func (s *Service) List(limit, offset int) ([]models.Manga, error) {
-	query := "SELECT id, title, author, genres, status, chapters, description, cover_url, created_at, updated_at FROM manga LIMIT ? OFFSET ?"
+	return s.store.List(limit, offset)
+}
 
-	rows, err := s.db.Query(query, limit, offset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to list manga: %w", err)
-	}
-	defer rows.Close()
-
-	var mangaList []models.Manga
-	for rows.Next() {
-		var manga models.Manga
-		var genresJSON, description, coverURL sql.NullString
-		err := rows.Scan(
-			&manga.ID, &manga.Title, &manga.Author, &genresJSON, &manga.Status,
-			&manga.TotalChapters, &description, &coverURL,
-			&manga.CreatedAt, &manga.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan manga: %w", err)
-		}
-		if genresJSON.Valid {
-			manga.Genres, _ = models.JSONToManga(genresJSON.String)
-		}
-		if description.Valid {
-			manga.Description = description.String
-		}
-		if coverURL.Valid {
-			manga.CoverURL = coverURL.String
-		}
-		mangaList = append(mangaList, manga)
+// Update updates a manga entry
+func (s *Service) Update(manga *models.Manga) error {
+	return s.store.Update(manga)
+}
+
+// Delete moves a manga entry to the trash
+func (s *Service) Delete(id string) error {
+	return s.store.Delete(id)
+}
+
+// Restore moves a manga entry out of the trash
+func (s *Service) Restore(id string) error {
+	return s.store.Restore(id)
+}
+
+// Trash lists trashed manga
+func (s *Service) Trash(limit, offset int) ([]models.Manga, error) {
+	return s.store.Trash(limit, offset)
+}
+
+// PurgeTrash permanently deletes manga trashed before the cutoff
+func (s *Service) PurgeTrash(before time.Time) (int64, error) {
+	return s.store.PurgeTrash(before)
+}
+
+// Genres lists the genre taxonomy, optionally only one kind
+func (s *Service) Genres(kind string) ([]models.Genre, error) {
+	return s.genres.List(kind)
+}
+
+// ListChapters lists the chapters of a manga. It returns
+// store.ErrMangaNotFound when the manga does not exist or is trashed.
+func (s *Service) ListChapters(filter models.ChapterFilter) ([]models.Chapter, error) {
+	if _, err := s.store.GetByID(filter.MangaID); err != nil {
+		return nil, err
+	}
+	if filter.Limit == 0 {
+		filter.Limit = 100
 	}
+	return s.chapters.List(filter)
+}
 
-	return mangaList, nil
+// GetChapter retrieves a chapter by ID
+func (s *Service) GetChapter(id int64) (*models.Chapter, error) {
+	return s.chapters.Get(id)
 }
 
-// Update updates a manga entry
-func (s *Service) Update(manga *models.Manga) error {
-	genres, _ := models.MangaToJSON(manga.Genres)
-	query := `
-		UPDATE manga
-		SET title = ?, author = ?, genres = ?, status = ?, chapters = ?, description = ?, cover_url = ?, updated_at = ?
-		WHERE id = ?
-	`
-	_, err := s.db.Exec(query, manga.Title, manga.Author, genres, manga.Status, manga.TotalChapters, manga.Description, manga.CoverURL, time.Now(), manga.ID)
-	if err != nil {
-		return fmt.Errorf("failed to update manga: %w", err)
+// CreateChapter adds a chapter to a manga
+func (s *Service) CreateChapter(chapter *models.Chapter) error {
+	if err := validateChapter(chapter); err != nil {
+		return err
+	}
+	return s.chapters.Create(chapter)
+}
+
+// UpdateChapter updates a chapter
+func (s *Service) UpdateChapter(chapter *models.Chapter) error {
+	if err := validateChapter(chapter); err != nil {
+		return err
+	}
+	return s.chapters.Update(chapter)
+}
+
+// DeleteChapter deletes a chapter
+func (s *Service) DeleteChapter(id int64) error {
+	return s.chapters.Delete(id)
+}
+
+func validateFilter(filter *models.MangaFilter) error {
+	if !models.ValidMangaSort(filter.SortBy) {
+		return fmt.Errorf("%w: sort must be one of %s", ErrInvalidFilter, strings.Join(models.MangaSortModes, ", "))
+	}
+	if filter.Order != "asc" && filter.Order != "desc" {
+		return fmt.Errorf("%w: order must be asc or desc", ErrInvalidFilter)
+	}
+	if filter.YearFrom > 0 && filter.YearTo > 0 && filter.YearFrom > filter.YearTo {
+		return fmt.Errorf("%w: year range %d-%d is empty", ErrInvalidFilter, filter.YearFrom, filter.YearTo)
+	}
+	if filter.MinRating < 0 || filter.MinRating > 10 {
+		return fmt.Errorf("%w: minimum rating must be between 0 and 10", ErrInvalidFilter)
+	}
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return fmt.Errorf("%w: limit and offset must not be negative", ErrInvalidFilter)
 	}
 	return nil
 }
 
-// Delete deletes a manga entry
-func (s *Service) Delete(id string) error {
-	query := "DELETE FROM manga WHERE id = ?"
-	_, err := s.db.Exec(query, id)
-	if err != nil {
-		return fmt.Errorf("failed to delete manga: %w", err)
+func validateChapter(chapter *models.Chapter) error {
+	if chapter.Number < 0 {
+		return fmt.Errorf("%w: number must not be negative", ErrInvalidChapter)
+	}
+	if chapter.Volume < 0 || chapter.Pages < 0 {
+		return fmt.Errorf("%w: volume and pages must not be negative", ErrInvalidChapter)
 	}
 	return nil
 }
@@ -0,0 +1,95 @@
+package manga
+
+import (
+	"slices"
+	"testing"
+
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// newTestService returns a service over in-memory stores holding One
+// Piece, One Punch-Man and Monster
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	stores := store.NewMemoryStores()
+	for _, m := range []models.Manga{
+		{ID: "one-piece", Title: "One Piece", Author: "Eiichiro Oda", Status: "ongoing", Genres: []string{"Adventure"}},
+		{ID: "one-punch-man", Title: "One Punch-Man", Author: "ONE", Status: "ongoing", Genres: []string{"Action"}},
+		{ID: "monster", Title: "Monster", Author: "Naoki Urasawa", Status: "completed", Genres: []string{"Mystery"}},
+	} {
+		if err := stores.Manga.Create(&m); err != nil {
+			t.Fatalf("create manga %s: %v", m.ID, err)
+		}
+	}
+	return NewServiceWithStores(stores.Manga, stores.Chapters, stores.Genres, stores.Titles)
+}
+
+func resultIDs(result *models.SearchResult) []string {
+	var ids []string
+	for _, m := range result.Manga {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func TestSearchFallsBackToSuggestions(t *testing.T) {
+	s := newTestService(t)
+
+	result, err := s.Search(&models.MangaFilter{Query: "one peice"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if !result.Fuzzy || result.DidYouMean != "One Piece" {
+		t.Errorf("fuzzy = %v, did you mean = %q; want the suggested manga in place of no hits", result.Fuzzy, result.DidYouMean)
+	}
+	if ids := resultIDs(result); !slices.Equal(ids, []string{"one-piece"}) || result.Total != 1 {
+		t.Errorf("fallback = %v of %d, want one-piece", ids, result.Total)
+	}
+	if want := []models.FacetCount{{Value: "ongoing", Count: 1}}; !slices.Equal(result.Facets.Status, want) {
+		t.Errorf("fallback status facets = %+v, want %+v", result.Facets.Status, want)
+	}
+}
+
+func TestSearchFallbackKeepsFilters(t *testing.T) {
+	s := newTestService(t)
+
+	result, err := s.Search(&models.MangaFilter{Query: "one peice", Status: "completed"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if result.Fuzzy || len(result.Manga) != 0 || result.Total != 0 {
+		t.Errorf("fallback = %v, fuzzy %v; want no manga, as no suggestion is completed", resultIDs(result), result.Fuzzy)
+	}
+	if result.DidYouMean != "One Piece" {
+		t.Errorf("did you mean = %q, want One Piece still suggested", result.DidYouMean)
+	}
+}
+
+func TestSearchSuggestsBelowMinExactHits(t *testing.T) {
+	s := newTestService(t)
+
+	// Two hits, below minExactHits: they stay, and only manga not among
+	// them are suggested
+	result, err := s.Search(&models.MangaFilter{Query: "one"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if result.Fuzzy || result.Total != 2 {
+		t.Errorf("search = %v of %d, fuzzy %v; want the exact hits", resultIDs(result), result.Total, result.Fuzzy)
+	}
+	for _, sg := range result.Suggestions {
+		if sg.MangaID == "one-piece" || sg.MangaID == "one-punch-man" {
+			t.Errorf("suggested %s, which is already a hit", sg.MangaID)
+		}
+	}
+
+	// A search without a text query never suggests
+	result, err = s.Search(&models.MangaFilter{Status: "completed"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(result.Suggestions) != 0 || result.Fuzzy {
+		t.Errorf("filter-only search suggested %+v", result.Suggestions)
+	}
+}
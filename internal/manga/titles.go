@@ -0,0 +1,92 @@
+package manga
+
+import (
+	"fmt"
+	"strings"
+
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// Titles lists the alternate and localized titles of a manga
+func (s *Service) Titles(mangaID string) ([]models.MangaTitle, error) {
+	return s.titles.List(mangaID)
+}
+
+// AddTitle adds an alternate title to a manga. A primary title replaces the
+// manga's previous primary title in that language.
+func (s *Service) AddTitle(title *models.MangaTitle) error {
+	title.Title = strings.TrimSpace(title.Title)
+	title.Language = models.NormalizeLanguage(title.Language)
+	if err := validateTitle(title); err != nil {
+		return err
+	}
+	return s.titles.Add(title)
+}
+
+// ReplaceTitles swaps every alternate title of a manga for the given ones,
+// as importers do when they resync a series
+func (s *Service) ReplaceTitles(mangaID string, titles []models.MangaTitle) error {
+	for i := range titles {
+		if err := validateTitle(&titles[i]); err != nil {
+			return err
+		}
+	}
+	return s.titles.Replace(mangaID, titles)
+}
+
+// DeleteTitle removes an alternate title of a manga. It returns
+// store.ErrTitleNotFound when the manga has no title with that ID.
+func (s *Service) DeleteTitle(mangaID string, id int64) error {
+	titles, err := s.titles.List(mangaID)
+	if err != nil {
+		return err
+	}
+	for _, t := range titles {
+		if t.ID == id {
+			return s.titles.Delete(id)
+		}
+	}
+	return store.ErrTitleNotFound
+}
+
+// Localize shows each manga under its title in the language, keeping the
+// catalog title in OriginalTitle. Manga without a title in the language
+// are left alone.
+func (s *Service) Localize(mangaList []models.Manga, language string) error {
+	manga := make([]*models.Manga, len(mangaList))
+	for i := range mangaList {
+		manga[i] = &mangaList[i]
+	}
+	return s.LocalizeAll(manga, language)
+}
+
+// LocalizeAll localizes manga embedded in other results, such as rankings
+// and recommendations, like Localize
+func (s *Service) LocalizeAll(manga []*models.Manga, language string) error {
+	if language == "" || len(manga) == 0 {
+		return nil
+	}
+	ids := make([]string, len(manga))
+	for i, m := range manga {
+		ids[i] = m.ID
+	}
+	titles, err := s.titles.InLanguage(ids, language)
+	if err != nil {
+		return err
+	}
+	for _, m := range manga {
+		m.Localize(titles[m.ID], language)
+	}
+	return nil
+}
+
+func validateTitle(title *models.MangaTitle) error {
+	if strings.TrimSpace(title.Title) == "" {
+		return fmt.Errorf("%w: title is required", ErrInvalidTitle)
+	}
+	if models.NormalizeLanguage(title.Language) == "" {
+		return fmt.Errorf("%w: language is required", ErrInvalidTitle)
+	}
+	return nil
+}
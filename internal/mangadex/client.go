@@ -0,0 +1,416 @@
+// Package mangadex reads manga and their chapters from the public
+// MangaDex API.
+package mangadex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+const (
+	// DefaultBaseURL is the public MangaDex API
+	DefaultBaseURL = "https://api.mangadex.org"
+
+	// DefaultCoverURL serves MangaDex cover art
+	DefaultCoverURL = "https://uploads.mangadex.org/covers"
+
+	// MaxLimit is the most manga MangaDex returns per request
+	MaxLimit = 100
+
+	// feedPageSize is the most chapters MangaDex returns per feed request,
+	// and maxFeedOffset the furthest into a feed it pages
+	feedPageSize  = 500
+	maxFeedOffset = 10000
+)
+
+// ErrNotFound is returned when MangaDex has no manga with the given ID
+var ErrNotFound = errors.New("manga not found on MangaDex")
+
+// Manga is a simplified view of a MangaDex manga. Titles holds the main and
+// alternate titles in every language, ready for the manga_titles table;
+// CoverURL is the cover art for the loader to download. The JSON field
+// names match what scripts/load_data.go reads.
+type Manga struct {
+	ID          string              `json:"id"`
+	Title       string              `json:"title"`
+	Titles      []models.MangaTitle `json:"titles,omitempty"`
+	Author      string              `json:"author,omitempty"`
+	Description string              `json:"description"`
+	Status      string              `json:"status"`
+	Genres      []string            `json:"genres"`
+	Chapters    int                 `json:"chapters,omitempty"`
+	Year        int                 `json:"year,omitempty"`
+	CoverURL    string              `json:"cover_url,omitempty"`
+	// MALID and AniListID are the manga's IDs at MyAnimeList and AniList
+	MALID     string `json:"mal_id,omitempty"`
+	AniListID string `json:"anilist_id,omitempty"`
+}
+
+// Chapter is a simplified view of a MangaDex chapter
+type Chapter struct {
+	ID          string    `json:"id"`
+	Number      float64   `json:"number"`
+	Volume      int       `json:"volume,omitempty"`
+	Title       string    `json:"title,omitempty"`
+	Language    string    `json:"language"`
+	Pages       int       `json:"pages,omitempty"`
+	PublishedAt time.Time `json:"published_at"`
+}
+
+// Client calls the MangaDex API at a base URL and builds cover URLs on a
+// cover host, so a mirror or a fake server can stand in for both
+type Client struct {
+	baseURL  string
+	coverURL string
+	client   *http.Client
+}
+
+// NewClient creates a MangaDex client for the API at baseURL, the public
+// API when empty. Covers come from DefaultCoverURL until SetCoverURL says
+// otherwise.
+func NewClient(baseURL string) *Client {
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	return &Client{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		coverURL: DefaultCoverURL,
+		client:   &http.Client{Timeout: 8 * time.Second},
+	}
+}
+
+// SetCoverURL sets where cover art is served from, DefaultCoverURL when
+// empty
+func (c *Client) SetCoverURL(coverURL string) {
+	if coverURL == "" {
+		coverURL = DefaultCoverURL
+	}
+	c.coverURL = strings.TrimSuffix(coverURL, "/")
+}
+
+// Search returns up to limit manga (at most 100) whose title matches the
+// query, most followed first. An empty query returns the most followed
+// series.
+func (c *Client) Search(query string, limit int) ([]Manga, error) {
+	if limit <= 0 || limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	params := url.Values{}
+	if query != "" {
+		params.Set("title", query)
+	}
+	params.Set("limit", strconv.Itoa(limit))
+	// Order by followed count to get "popular" series when no query is given.
+	params.Set("order[followedCount]", "desc")
+	params.Add("includes[]", "author")
+	params.Add("includes[]", "cover_art")
+
+	var payload struct {
+		Data []mangaData `json:"data"`
+	}
+	if err := c.get("/manga?"+params.Encode(), &payload); err != nil {
+		return nil, err
+	}
+
+	var out []Manga
+	for _, item := range payload.Data {
+		out = append(out, item.toManga(c.coverURL))
+	}
+	return out, nil
+}
+
+// Fetch returns the manga with the given MangaDex ID, ErrNotFound when
+// there is none
+func (c *Client) Fetch(id string) (*Manga, error) {
+	params := url.Values{}
+	params.Add("includes[]", "author")
+	params.Add("includes[]", "cover_art")
+
+	var payload struct {
+		Data mangaData `json:"data"`
+	}
+	if err := c.get("/manga/"+url.PathEscape(id)+"?"+params.Encode(), &payload); err != nil {
+		return nil, err
+	}
+	m := payload.Data.toManga(c.coverURL)
+	return &m, nil
+}
+
+// Chapters returns the chapters of a manga translated into the given
+// language, in chapter order. MangaDex lists a chapter once per
+// scanlation group; only the first upload of each number is kept.
+// Chapters without a number, such as oneshots, are left out.
+func (c *Client) Chapters(id, language string) ([]Chapter, error) {
+	if language == "" {
+		language = "en"
+	}
+
+	var chapters []Chapter
+	seen := make(map[float64]bool)
+	for offset := 0; offset < maxFeedOffset; offset += feedPageSize {
+		params := url.Values{}
+		params.Add("translatedLanguage[]", language)
+		params.Set("order[chapter]", "asc")
+		params.Set("limit", strconv.Itoa(feedPageSize))
+		params.Set("offset", strconv.Itoa(offset))
+
+		var payload feedResponse
+		if err := c.get("/manga/"+url.PathEscape(id)+"/feed?"+params.Encode(), &payload); err != nil {
+			return nil, err
+		}
+		for _, item := range payload.Data {
+			ch, ok := item.toChapter()
+			if !ok || seen[ch.Number] {
+				continue
+			}
+			seen[ch.Number] = true
+			chapters = append(chapters, ch)
+		}
+		if len(payload.Data) < feedPageSize || offset+feedPageSize >= payload.Total {
+			break
+		}
+	}
+	return chapters, nil
+}
+
+// get calls the API and decodes its JSON response into out
+func (c *Client) get(path string, out interface{}) error {
+	resp, err := c.client.Get(c.baseURL + path)
+	if err != nil {
+		return fmt.Errorf("failed to call MangaDex: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("MangaDex returned HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse MangaDex response: %w", err)
+	}
+	return nil
+}
+
+// --- Minimal MangaDex response models ---
+
+type mangaData struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Title                  map[string]string   `json:"title"`
+		AltTitles              []map[string]string `json:"altTitles"`
+		Description            map[string]string   `json:"description"`
+		Status                 string              `json:"status"`
+		Year                   *int                `json:"year"`
+		LastChapter            string              `json:"lastChapter"`
+		Links                  map[string]string   `json:"links"`
+		PublicationDemographic string              `json:"publicationDemographic"`
+		Tags                   []tag               `json:"tags"`
+	} `json:"attributes"`
+	Relationships []relationship `json:"relationships"`
+}
+
+// toManga simplifies a MangaDex manga, pointing its cover at coverURL
+func (item mangaData) toManga(coverURL string) Manga {
+	attrs := item.Attributes
+	m := Manga{
+		ID:          item.ID,
+		Title:       pickFirstString(attrs.Title),
+		Titles:      collectTitles(attrs.Title, attrs.AltTitles),
+		Description: pickFirstString(attrs.Description),
+		Status:      mapStatus(attrs.Status),
+		Genres:      extractGenres(attrs.PublicationDemographic, attrs.Tags),
+		Chapters:    parseChapter(attrs.LastChapter),
+		CoverURL:    coverArtURL(coverURL, item.ID, item.Relationships),
+		MALID:       attrs.Links["mal"],
+		AniListID:   attrs.Links["al"],
+	}
+	if attrs.Year != nil {
+		m.Year = *attrs.Year
+	}
+	for _, rel := range item.Relationships {
+		if rel.Type == "author" && rel.Attributes.Name != "" {
+			m.Author = rel.Attributes.Name
+			break
+		}
+	}
+	return m
+}
+
+type feedResponse struct {
+	Data  []feedItem `json:"data"`
+	Total int        `json:"total"`
+}
+
+type feedItem struct {
+	ID         string `json:"id"`
+	Attributes struct {
+		Chapter            *string   `json:"chapter"`
+		Volume             *string   `json:"volume"`
+		Title              *string   `json:"title"`
+		TranslatedLanguage string    `json:"translatedLanguage"`
+		Pages              int       `json:"pages"`
+		PublishAt          time.Time `json:"publishAt"`
+	} `json:"attributes"`
+}
+
+type tag struct {
+	Attributes struct {
+		Name map[string]string `json:"name"`
+	} `json:"attributes"`
+}
+
+// relationship is a related entity; with includes[]=author and
+// includes[]=cover_art it carries the author's name and the cover's file
+// name
+type relationship struct {
+	Type       string `json:"type"`
+	Attributes struct {
+		Name     string `json:"name"`
+		FileName string `json:"fileName"`
+	} `json:"attributes"`
+}
+
+// toChapter simplifies a feed chapter; ok is false when it has no number
+func (item feedItem) toChapter() (Chapter, bool) {
+	attrs := item.Attributes
+	if attrs.Chapter == nil {
+		return Chapter{}, false
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(*attrs.Chapter), 64)
+	if err != nil || number < 0 {
+		return Chapter{}, false
+	}
+	ch := Chapter{
+		ID:          item.ID,
+		Number:      number,
+		Language:    attrs.TranslatedLanguage,
+		Pages:       attrs.Pages,
+		PublishedAt: attrs.PublishAt,
+	}
+	if attrs.Volume != nil {
+		ch.Volume, _ = strconv.Atoi(*attrs.Volume)
+	}
+	if attrs.Title != nil {
+		ch.Title = *attrs.Title
+	}
+	return ch, true
+}
+
+// coverArtURL builds the URL of a manga's cover art on the cover host at
+// baseURL, empty when it has none
+func coverArtURL(baseURL, mangaID string, relationships []relationship) string {
+	for _, rel := range relationships {
+		if rel.Type == "cover_art" && rel.Attributes.FileName != "" {
+			return fmt.Sprintf("%s/%s/%s", baseURL, mangaID, rel.Attributes.FileName)
+		}
+	}
+	return ""
+}
+
+// mapStatus maps a MangaDex publication status onto the catalog's
+// statuses. The catalog has no cancelled status; a cancelled series has
+// ended like a completed one.
+func mapStatus(status string) string {
+	switch status {
+	case "ongoing", "completed", "hiatus":
+		return status
+	case "cancelled":
+		return "completed"
+	default:
+		return ""
+	}
+}
+
+// parseChapter turns MangaDex's lastChapter ("1110", "10.5" or "") into a
+// chapter count
+func parseChapter(last string) int {
+	n, err := strconv.ParseFloat(strings.TrimSpace(last), 64)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return int(math.Floor(n))
+}
+
+// pickFirstString chooses a localized string, preferring English.
+func pickFirstString(m map[string]string) string {
+	if len(m) == 0 {
+		return ""
+	}
+	if v, ok := m["en"]; ok && v != "" {
+		return v
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if m[k] != "" {
+			return m[k]
+		}
+	}
+	return ""
+}
+
+// collectTitles flattens the MangaDex main and alternate titles. The first
+// title in each language is its primary one, so the main title wins over
+// the alternates.
+func collectTitles(main map[string]string, alts []map[string]string) []models.MangaTitle {
+	var titles []models.MangaTitle
+	seen := make(map[string]bool)
+	primary := make(map[string]bool)
+	add := func(lang, title string) {
+		lang = models.NormalizeLanguage(lang)
+		title = strings.TrimSpace(title)
+		if lang == "" || title == "" || seen[lang+"\x00"+title] {
+			return
+		}
+		seen[lang+"\x00"+title] = true
+		titles = append(titles, models.MangaTitle{Language: lang, Title: title, IsPrimary: !primary[lang]})
+		primary[lang] = true
+	}
+
+	langs := make([]string, 0, len(main))
+	for lang := range main {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	for _, lang := range langs {
+		add(lang, main[lang])
+	}
+	for _, alt := range alts {
+		for lang, title := range alt {
+			add(lang, title)
+		}
+	}
+	return titles
+}
+
+// extractGenres pulls readable genre names from the publication
+// demographic and the MangaDex tags; the catalog taxonomy resolves them.
+func extractGenres(demographic string, tags []tag) []string {
+	var genres []string
+	if demographic != "" {
+		genres = append(genres, strings.ToUpper(demographic[:1])+demographic[1:])
+	}
+	for _, t := range tags {
+		name := pickFirstString(t.Attributes.Name)
+		if name != "" {
+			genres = append(genres, name)
+		}
+	}
+	return genres
+}
@@ -0,0 +1,280 @@
+package mangadex_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"mangahub/internal/catalog"
+	"mangahub/internal/mangadex"
+	"mangahub/pkg/covers"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// fakeManga is a manga served by fakeMangaDex, in the MangaDex API's shape
+type fakeManga struct {
+	ID          string
+	Title       string
+	AltTitles   []map[string]string
+	Status      string
+	LastChapter string
+	Tags        []string
+	Author      string
+	CoverFile   string
+}
+
+// fakeMangaDex serves /manga search results and cover art the way the
+// MangaDex API and cover host do
+type fakeMangaDex struct {
+	mu        sync.Mutex
+	manga     []fakeManga
+	coverHits atomic.Int32
+	coverPNG  []byte
+	*httptest.Server
+}
+
+func newFakeMangaDex(t *testing.T, manga ...fakeManga) *fakeMangaDex {
+	t.Helper()
+	var cover bytes.Buffer
+	if err := png.Encode(&cover, image.NewRGBA(image.Rect(0, 0, 40, 60))); err != nil {
+		t.Fatalf("encode cover: %v", err)
+	}
+
+	f := &fakeMangaDex{manga: manga, coverPNG: cover.Bytes()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manga", f.search)
+	mux.HandleFunc("/covers/", func(w http.ResponseWriter, r *http.Request) {
+		f.coverHits.Add(1)
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(f.coverPNG)
+	})
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// setLastChapter changes the latest chapter MangaDex reports for a manga
+func (f *fakeMangaDex) setLastChapter(id, chapter string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.manga {
+		if f.manga[i].ID == id {
+			f.manga[i].LastChapter = chapter
+		}
+	}
+}
+
+func (f *fakeMangaDex) search(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var data []map[string]interface{}
+	for _, m := range f.manga {
+		var tags []map[string]interface{}
+		for _, name := range m.Tags {
+			tags = append(tags, map[string]interface{}{
+				"attributes": map[string]interface{}{"name": map[string]string{"en": name}},
+			})
+		}
+		relationships := []map[string]interface{}{
+			{"type": "author", "attributes": map[string]string{"name": m.Author}},
+		}
+		if m.CoverFile != "" {
+			relationships = append(relationships,
+				map[string]interface{}{"type": "cover_art", "attributes": map[string]string{"fileName": m.CoverFile}})
+		}
+		data = append(data, map[string]interface{}{
+			"id": m.ID,
+			"attributes": map[string]interface{}{
+				"title":       map[string]string{"en": m.Title},
+				"altTitles":   m.AltTitles,
+				"description": map[string]string{"en": m.Title + " description"},
+				"status":      m.Status,
+				"lastChapter": m.LastChapter,
+				"tags":        tags,
+			},
+			"relationships": relationships,
+		})
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+// importer fetches from the fake MangaDex and imports into the catalog,
+// the way 'mangahub manga dex import' does
+type importer struct {
+	t       *testing.T
+	client  *mangadex.Client
+	stores  *store.Stores
+	service *catalog.Service
+}
+
+func newImporter(t *testing.T, fake *fakeMangaDex) *importer {
+	client := mangadex.NewClient(fake.URL)
+	client.SetCoverURL(fake.URL + "/covers")
+	stores := store.NewMemoryStores()
+	service := catalog.NewService(stores.Manga, stores.Titles, stores.ExternalIDs,
+		covers.NewStore(t.TempDir(), 0), nil)
+	return &importer{t: t, client: client, stores: stores, service: service}
+}
+
+func (im *importer) run() *catalog.Report {
+	im.t.Helper()
+	results, err := im.client.Search("", 10)
+	if err != nil {
+		im.t.Fatalf("search: %v", err)
+	}
+	entries := make([]models.CatalogEntry, 0, len(results))
+	for _, m := range results {
+		entries = append(entries, catalog.MangaDexEntry(m))
+	}
+	report, err := im.service.Import(entries)
+	if err != nil {
+		im.t.Fatalf("import: %v", err)
+	}
+	return report
+}
+
+// result returns the result of the entry with the given MangaDex ID
+func result(t *testing.T, report *catalog.Report, externalID string) catalog.Result {
+	t.Helper()
+	for _, r := range report.Results {
+		if r.ExternalID == externalID {
+			return r
+		}
+	}
+	t.Fatalf("no result for %s in %+v", externalID, report.Results)
+	return catalog.Result{}
+}
+
+func TestImportCreatesManga(t *testing.T) {
+	fake := newFakeMangaDex(t, fakeManga{
+		ID:          "md-one-piece",
+		Title:       "One Piece",
+		AltTitles:   []map[string]string{{"ja": "ワンピース"}},
+		Status:      "ongoing",
+		LastChapter: "1100.5",
+		Tags:        []string{"Action", "Adventure"},
+		Author:      "Oda Eiichiro",
+		CoverFile:   "cover.png",
+	})
+	im := newImporter(t, fake)
+
+	report := im.run()
+	if report.Created != 1 || report.Updated != 0 || report.Skipped != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+	r := result(t, report, "md-one-piece")
+	if r.Action != catalog.ActionCreated || r.MangaID != "one-piece" {
+		t.Errorf("result = %+v", r)
+	}
+
+	manga, err := im.stores.Manga.GetByID("one-piece")
+	if err != nil {
+		t.Fatalf("get imported manga: %v", err)
+	}
+	if manga.Title != "One Piece" || manga.Author != "Oda Eiichiro" || manga.Status != "ongoing" || manga.TotalChapters != 1100 {
+		t.Errorf("imported manga = %+v", manga)
+	}
+	if len(manga.Genres) != 2 {
+		t.Errorf("genres = %v, want the two tags", manga.Genres)
+	}
+	if manga.CoverURL != covers.URL("one-piece") || fake.coverHits.Load() != 1 {
+		t.Errorf("cover URL %q after %d cover requests; want the stored cover fetched from the fake host",
+			manga.CoverURL, fake.coverHits.Load())
+	}
+
+	id, err := im.stores.ExternalIDs.Find(models.SourceMangaDex, "md-one-piece")
+	if err != nil || id != "one-piece" {
+		t.Errorf("external ID links to %q, %v", id, err)
+	}
+	titles, err := im.stores.Titles.List("one-piece")
+	if err != nil {
+		t.Fatalf("list titles: %v", err)
+	}
+	var japanese bool
+	for _, title := range titles {
+		japanese = japanese || (title.Language == "ja" && title.Title == "ワンピース")
+	}
+	if !japanese {
+		t.Errorf("titles = %+v, want the Japanese alt title", titles)
+	}
+}
+
+func TestImportUpdatesAndSkipsUnchanged(t *testing.T) {
+	fake := newFakeMangaDex(t, fakeManga{ID: "md-berserk", Title: "Berserk", Status: "hiatus", LastChapter: "374"})
+	im := newImporter(t, fake)
+	im.run()
+
+	report := im.run()
+	if r := result(t, report, "md-berserk"); r.Action != catalog.ActionSkipped || r.Note != "unchanged" {
+		t.Errorf("re-import of unchanged manga = %+v", r)
+	}
+
+	fake.setLastChapter("md-berserk", "375")
+	report = im.run()
+	if r := result(t, report, "md-berserk"); r.Action != catalog.ActionUpdated || r.MangaID != "berserk" {
+		t.Errorf("re-import after a new chapter = %+v", r)
+	}
+	manga, err := im.stores.Manga.GetByID("berserk")
+	if err != nil {
+		t.Fatalf("get manga: %v", err)
+	}
+	if manga.TotalChapters != 375 {
+		t.Errorf("total chapters = %d, want 375", manga.TotalChapters)
+	}
+}
+
+func TestImportDetectsDuplicatesByTitle(t *testing.T) {
+	fake := newFakeMangaDex(t, fakeManga{ID: "md-naruto", Title: "NARUTO!", Status: "cancelled", LastChapter: "700"})
+	im := newImporter(t, fake)
+
+	// A manga loaded from the hand-kept local files
+	handKept := &models.Manga{ID: "naruto", Title: "Naruto", Author: "Masashi Kishimoto", Status: "ongoing", TotalChapters: 650}
+	if err := im.stores.Manga.Create(handKept); err != nil {
+		t.Fatalf("seed manga: %v", err)
+	}
+	local := &models.CatalogEntry{Source: models.SourceLocal, ExternalID: "naruto", Title: "Naruto",
+		Author: "Masashi Kishimoto", Status: "ongoing", Chapters: 650}
+	if err := im.stores.ExternalIDs.SaveEntry("naruto", local); err != nil {
+		t.Fatalf("seed local entry: %v", err)
+	}
+
+	report := im.run()
+	r := result(t, report, "md-naruto")
+	if r.Action != catalog.ActionSkipped || r.MangaID != "naruto" || !strings.Contains(r.Note, "duplicate") {
+		t.Errorf("import of a duplicate = %+v", r)
+	}
+	if _, err := im.stores.ExternalIDs.Find(models.SourceMangaDex, "md-naruto"); err == nil {
+		t.Error("skipped duplicate was linked")
+	}
+	all, err := im.stores.Manga.List(10, 0)
+	if err != nil {
+		t.Fatalf("list manga: %v", err)
+	}
+	if len(all) != 1 {
+		t.Errorf("catalog has %d manga after skipping a duplicate, want 1", len(all))
+	}
+
+	im.service.LinkDuplicates = true
+	report = im.run()
+	if r := result(t, report, "md-naruto"); r.Action != catalog.ActionUpdated || r.MangaID != "naruto" {
+		t.Errorf("import of a duplicate with LinkDuplicates = %+v", r)
+	}
+	manga, err := im.stores.Manga.GetByID("naruto")
+	if err != nil {
+		t.Fatalf("get manga: %v", err)
+	}
+	// MangaDex wins status and chapters; the local title and author stay
+	if manga.Status != "completed" || manga.TotalChapters != 700 || manga.Title != "Naruto" || manga.Author != "Masashi Kishimoto" {
+		t.Errorf("linked manga = %+v", manga)
+	}
+}
@@ -0,0 +1,376 @@
+package recommend
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// ErrInvalidRequest is returned when recommendations are requested with an
+// out-of-range limit
+var ErrInvalidRequest = errors.New("invalid recommendation request")
+
+const (
+	// DefaultLimit is the number of recommendations when none is requested
+	DefaultLimit = 10
+
+	// MaxLimit is the largest number of recommendations per request
+	MaxLimit = 50
+
+	// maxNeighbours is the number of similar manga stored per manga
+	maxNeighbours = 30
+
+	// minScore is the similarity below which a pair is not stored
+	minScore = 0.05
+
+	// highRating is the rating from which a reader counts as a fan
+	highRating = 7
+)
+
+// Similarity weights. Co-reading says more than genre overlap, which on
+// its own mostly finds series of the same demographic.
+const (
+	genreWeight  = 0.4
+	coReadWeight = 0.6
+
+	// favoriteGenreWeight is the weight of the user's favorite genres in
+	// personalized recommendations
+	favoriteGenreWeight = 0.3
+)
+
+// Service computes and serves manga recommendations
+type Service struct {
+	similarity store.SimilarityStore
+	manga      store.MangaStore
+	library    store.LibraryStore
+}
+
+// NewService creates a new recommendation service backed by the SQLite
+// database
+func NewService(db *database.Database) *Service {
+	return NewServiceWithStores(store.NewSQLiteSimilarityStore(db), store.NewSQLiteMangaStore(db), store.NewSQLiteLibraryStore(db))
+}
+
+// NewServiceWithStores creates a new recommendation service on top of any
+// SimilarityStore, MangaStore and LibraryStore
+func NewServiceWithStores(similarity store.SimilarityStore, manga store.MangaStore, library store.LibraryStore) *Service {
+	return &Service{similarity: similarity, manga: manga, library: library}
+}
+
+// Refresh recomputes the similar manga of every manga and stores them
+func (s *Service) Refresh() error {
+	signals, err := s.similarity.Signals()
+	if err != nil {
+		return err
+	}
+	return s.similarity.Replace(computeSimilarity(signals), time.Now())
+}
+
+// Similar returns the manga most similar to a manga, at most limit
+// (default 10) of them. It returns store.ErrMangaNotFound when the manga
+// does not exist or is trashed.
+func (s *Service) Similar(mangaID string, limit int) (*models.SimilarList, error) {
+	limit, err := checkLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	similar, err := s.similarity.Similar(mangaID, limit)
+	if err != nil {
+		return nil, err
+	}
+	computedAt, err := s.similarity.ComputedAt()
+	if err != nil {
+		return nil, err
+	}
+	if similar == nil {
+		similar = []models.SimilarManga{}
+	}
+	return &models.SimilarList{MangaID: mangaID, Similar: similar, Total: len(similar), ComputedAt: computedAt}, nil
+}
+
+// ForUser recommends manga similar to the ones in a user's library,
+// leaving out the library itself. Each library entry counts by its rating
+// or else its status, and manga in the user's favorite genres rank higher.
+func (s *Service) ForUser(userID string, limit int) (*models.Recommendations, error) {
+	limit, err := checkLimit(limit)
+	if err != nil {
+		return nil, err
+	}
+	computedAt, err := s.similarity.ComputedAt()
+	if err != nil {
+		return nil, err
+	}
+	result := &models.Recommendations{Recommendations: []models.Recommendation{}, ComputedAt: computedAt}
+
+	entries, err := s.library.List(userID, "", -1, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return result, nil
+	}
+
+	owned := make(map[string]bool, len(entries))
+	weights := make(map[string]float64, len(entries))
+	var seeds []string
+	for _, e := range entries {
+		owned[e.MangaID] = true
+		if w := seedWeight(e); w > 0 {
+			weights[e.MangaID] = w
+			seeds = append(seeds, e.MangaID)
+		}
+	}
+
+	library, err := s.lookup(keys(owned))
+	if err != nil {
+		return nil, err
+	}
+	favorites := favoriteGenres(entries, library)
+	result.FavoriteGenres = topGenres(favorites, 5)
+
+	neighbours, err := s.similarity.Neighbours(seeds)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]float64)
+	because := make(map[string]map[string]float64)
+	for _, sim := range neighbours {
+		if owned[sim.SimilarID] {
+			continue
+		}
+		contribution := weights[sim.MangaID] * sim.Score
+		scores[sim.SimilarID] += contribution
+		if because[sim.SimilarID] == nil {
+			because[sim.SimilarID] = make(map[string]float64)
+		}
+		because[sim.SimilarID][sim.MangaID] += contribution
+	}
+
+	candidates, err := s.lookup(keys(scores))
+	if err != nil {
+		return nil, err
+	}
+	for id, manga := range candidates {
+		rec := models.Recommendation{
+			Manga: manga,
+			Score: scores[id] + favoriteGenreWeight*genreAffinity(manga.Genres, favorites),
+		}
+		for _, seed := range strongest(because[id], 2) {
+			if m, ok := library[seed]; ok {
+				rec.Because = append(rec.Because, m.Title)
+			}
+		}
+		result.Recommendations = append(result.Recommendations, rec)
+	}
+	sort.Slice(result.Recommendations, func(i, j int) bool {
+		a, b := result.Recommendations[i], result.Recommendations[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Manga.Title < b.Manga.Title
+	})
+
+	result.Total = len(result.Recommendations)
+	if len(result.Recommendations) > limit {
+		result.Recommendations = result.Recommendations[:limit]
+	}
+	return result, nil
+}
+
+// lookup loads the manga outside the trash among the IDs, keyed by ID
+func (s *Service) lookup(ids []string) (map[string]models.Manga, error) {
+	found := make(map[string]models.Manga, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+	mangaList, err := s.manga.Search(&models.MangaFilter{IDs: ids, SortBy: "title", Order: "asc", Limit: len(ids)})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range mangaList {
+		found[m.ID] = m
+	}
+	return found, nil
+}
+
+func checkLimit(limit int) (int, error) {
+	if limit == 0 {
+		return DefaultLimit, nil
+	}
+	if limit < 0 || limit > MaxLimit {
+		return 0, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidRequest, MaxLimit)
+	}
+	return limit, nil
+}
+
+// seedWeight is how much a library entry says about the user's taste: its
+// rating when rated, else a guess from its status. Dropped series say
+// nothing.
+func seedWeight(entry models.Progress) float64 {
+	if entry.Status == "dropped" {
+		return 0
+	}
+	if entry.Rating > 0 {
+		return float64(entry.Rating) / 10
+	}
+	switch entry.Status {
+	case "completed":
+		return 0.7
+	case "reading":
+		return 0.6
+	case "on-hold":
+		return 0.4
+	default:
+		return 0.3
+	}
+}
+
+// favoriteGenres counts the genres over every library entry, like the
+// genre breakdown of `mangahub stats`, as a share of the library size
+func favoriteGenres(entries []models.Progress, library map[string]models.Manga) map[string]float64 {
+	favorites := make(map[string]float64)
+	for _, e := range entries {
+		for _, genre := range library[e.MangaID].Genres {
+			favorites[genre] += 1 / float64(len(entries))
+		}
+	}
+	return favorites
+}
+
+// genreAffinity is the average share of the library in each of a manga's
+// genres, from 0 to 1
+func genreAffinity(genres []string, favorites map[string]float64) float64 {
+	if len(genres) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, genre := range genres {
+		sum += favorites[genre]
+	}
+	return sum / float64(len(genres))
+}
+
+// topGenres returns the n most frequent genres, ties broken by name
+func topGenres(favorites map[string]float64, n int) []string {
+	genres := keys(favorites)
+	sort.Slice(genres, func(i, j int) bool {
+		if favorites[genres[i]] != favorites[genres[j]] {
+			return favorites[genres[i]] > favorites[genres[j]]
+		}
+		return genres[i] < genres[j]
+	})
+	if len(genres) > n {
+		genres = genres[:n]
+	}
+	return genres
+}
+
+// strongest returns the n keys with the highest values
+func strongest(values map[string]float64, n int) []string {
+	ids := keys(values)
+	sort.Slice(ids, func(i, j int) bool {
+		if values[ids[i]] != values[ids[j]] {
+			return values[ids[i]] > values[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+	return ids
+}
+
+// computeSimilarity scores every pair of manga by genre overlap (Jaccard
+// similarity of their genres) and co-reading: the share of the fans of one
+// manga who also read the other, as a cosine so that merely popular series
+// do not top every list. Only the best maxNeighbours of each manga are kept.
+func computeSimilarity(signals *models.SimilaritySignals) []models.Similarity {
+	fans := make(map[string][]string)
+	readers := make(map[string]int)
+	read := make(map[string][]string)
+	for _, e := range signals.Entries {
+		if _, ok := signals.Genres[e.MangaID]; !ok {
+			continue
+		}
+		if e.Rating >= highRating {
+			fans[e.MangaID] = append(fans[e.MangaID], e.UserID)
+		}
+		if e.Status != "plan-to-read" {
+			readers[e.MangaID]++
+			read[e.UserID] = append(read[e.UserID], e.MangaID)
+		}
+	}
+
+	genres := make(map[string]map[string]bool, len(signals.Genres))
+	for id, names := range signals.Genres {
+		genres[id] = make(map[string]bool, len(names))
+		for _, name := range names {
+			genres[id][name] = true
+		}
+	}
+
+	var scores []models.Similarity
+	for id := range signals.Genres {
+		coRead := make(map[string]int)
+		for _, user := range fans[id] {
+			for _, other := range read[user] {
+				if other != id {
+					coRead[other]++
+				}
+			}
+		}
+
+		var neighbours []models.Similarity
+		for other := range signals.Genres {
+			if other == id {
+				continue
+			}
+			sim := models.Similarity{MangaID: id, SimilarID: other, GenreScore: jaccard(genres[id], genres[other])}
+			if n := coRead[other]; n > 0 {
+				sim.CoReadScore = float64(n) / math.Sqrt(float64(len(fans[id]))*float64(readers[other]))
+			}
+			sim.Score = genreWeight*sim.GenreScore + coReadWeight*sim.CoReadScore
+			if sim.Score >= minScore {
+				neighbours = append(neighbours, sim)
+			}
+		}
+		sort.Slice(neighbours, func(i, j int) bool {
+			if neighbours[i].Score != neighbours[j].Score {
+				return neighbours[i].Score > neighbours[j].Score
+			}
+			return neighbours[i].SimilarID < neighbours[j].SimilarID
+		})
+		if len(neighbours) > maxNeighbours {
+			neighbours = neighbours[:maxNeighbours]
+		}
+		scores = append(scores, neighbours...)
+	}
+	return scores
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for g := range a {
+		if b[g] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
+
+func keys[V any](m map[string]V) []string {
+	ids := make([]string, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -6,10 +6,10 @@ import (
 	"fmt"
 	"net"
 	"sync"
-	"time"
 
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/store"
 	"mangahub/pkg/utils"
 )
 
@@ -23,11 +23,22 @@ type Server struct {
 	mutex       sync.RWMutex
 	done        chan bool
 	logger      *utils.Logger
-	db          *database.Database
+	progress    store.LibraryStore
 }
 
-// NewServer creates a new TCP server
+// NewServer creates a new TCP server that saves progress to the SQLite database.
+// A nil db disables persistence.
 func NewServer(port string, logger *utils.Logger, db *database.Database) *Server {
+	var progress store.LibraryStore
+	if db != nil {
+		progress = store.NewSQLiteLibraryStore(db)
+	}
+	return NewServerWithStore(port, logger, progress)
+}
+
+// NewServerWithStore creates a new TCP server that saves progress to the given store.
+// A nil store disables persistence.
+func NewServerWithStore(port string, logger *utils.Logger, progress store.LibraryStore) *Server {
 	return &Server{
 		Port:        port,
 		Connections: make(map[string]net.Conn),
@@ -36,7 +47,7 @@ func NewServer(port string, logger *utils.Logger, db *database.Database) *Server
 		Unregister:  make(chan net.Conn),
 		done:        make(chan bool),
 		logger:      logger,
-		db:          db,
+		progress:    progress,
 	}
 }
 
@@ -95,7 +106,7 @@ func (s *Server) handleConnection(connID string, conn net.Conn) {
 		}
 
 		// Save progress update to database
-		if s.db != nil {
+		if s.progress != nil {
 			if err := s.saveProgressUpdate(&update); err != nil {
 				s.logger.Error(fmt.Sprintf("Error saving progress to database: %v", err))
 				// Continue to broadcast even if database save fails
@@ -143,49 +154,16 @@ func (s *Server) GetConnectionCount() int {
 	return len(s.Connections)
 }
 
-// saveProgressUpdate saves a progress update to the database
+// saveProgressUpdate saves a progress update to the store
 func (s *Server) saveProgressUpdate(update *models.ProgressUpdate) error {
-	if s.db == nil {
+	if s.progress == nil {
 		return fmt.Errorf("database not initialized")
 	}
 
-	// Check if progress entry exists
-	var exists bool
-	err := s.db.QueryRow(
-		"SELECT EXISTS(SELECT 1 FROM user_progress WHERE user_id = ? AND manga_id = ?)",
-		update.UserID, update.MangaID,
-	).Scan(&exists)
-	if err != nil {
-		return fmt.Errorf("failed to check existing progress: %w", err)
-	}
-
-	timestamp := time.Unix(update.Timestamp, 0)
-
-	if exists {
-		// Update existing progress
-		query := `
-			UPDATE user_progress
-			SET current_chapter = ?, updated_at = ?
-			WHERE user_id = ? AND manga_id = ?
-		`
-		_, err = s.db.Exec(query, update.Chapter, timestamp, update.UserID, update.MangaID)
-		if err != nil {
-			return fmt.Errorf("failed to update progress: %w", err)
-		}
-	} else {
-		// Insert new progress entry
-		// Note: Status is set to 'reading' because user has already read to a specific chapter
-		// This differs from schema default 'plan-to-read' which is for library entries without progress
-		query := `
-			INSERT INTO user_progress (user_id, manga_id, current_chapter, status, started_at, updated_at)
-			VALUES (?, ?, ?, 'reading', ?, ?)
-		`
-		_, err = s.db.Exec(query, update.UserID, update.MangaID, update.Chapter, timestamp, timestamp)
-		if err != nil {
-			return fmt.Errorf("failed to insert progress: %w", err)
-		}
+	if err := s.progress.SaveProgress(update, models.EventOrigin{Source: models.EventSourceTCP, DeviceID: update.DeviceID}); err != nil {
+		return err
 	}
 
-	s.logger.Info(fmt.Sprintf("Saved progress to database: User %s - Manga %s - Chapter %d", update.UserID, update.MangaID, update.Chapter))
+	s.logger.Info("Saved progress to database: User %s - Manga %s - Chapter %d", update.UserID, update.MangaID, update.Chapter)
 	return nil
 }
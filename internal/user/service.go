@@ -1,233 +1,187 @@
 package user
 
 import (
-	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"mangahub/pkg/database"
 	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+var (
+	// ErrForbidden is returned when a user's role does not allow what
+	// they asked for
+	ErrForbidden = errors.New("insufficient permissions")
+	// ErrLastAdmin is returned when a role change would leave no admin
+	ErrLastAdmin = errors.New("cannot take the admin role from the last admin")
 )
 
 // Service handles user operations
 type Service struct {
-	db *database.Database
+	store store.UserStore
 }
 
-// NewService creates a new user service
+// NewService creates a new user service backed by the SQLite database
 func NewService(db *database.Database) *Service {
-	return &Service{db: db}
+	return NewServiceWithStore(store.NewSQLiteUserStore(db))
+}
+
+// NewServiceWithStore creates a new user service on top of any UserStore
+func NewServiceWithStore(s store.UserStore) *Service {
+	return &Service{store: s}
 }
 
 // Create creates a new user
 func (s *Service) Create(user *models.User) error {
-	query := `
-		INSERT INTO users (id, username, email, password_hash, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`
-	now := time.Now()
-	_, err := s.db.Exec(query, user.ID, user.Username, user.Email, user.PasswordHash, now, now)
-	if err != nil {
-		return fmt.Errorf("failed to create user: %w", err)
-	}
-	return nil
+	return s.store.Create(user)
 }
 
 // GetByID retrieves a user by ID
 func (s *Service) GetByID(id string) (*models.User, error) {
-	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE id = ?`
-
-	var user models.User
-	err := s.db.QueryRow(query, id).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	return &user, nil
+	return s.store.GetByID(id)
 }
 
 // GetByUsername retrieves a user by username
 func (s *Service) GetByUsername(username string) (*models.User, error) {
-	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE username = ?`
-
-	var user models.User
-	err := s.db.QueryRow(query, username).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	return &user, nil
+	return s.store.GetByUsername(username)
 }
 
 // GetByEmail retrieves a user by email
 func (s *Service) GetByEmail(email string) (*models.User, error) {
-	query := `SELECT id, username, email, password_hash, created_at, updated_at FROM users WHERE email = ?`
-
-	var user models.User
-	err := s.db.QueryRow(query, email).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
-		}
-		return nil, fmt.Errorf("failed to get user: %w", err)
-	}
-	return &user, nil
+	return s.store.GetByEmail(email)
 }
 
 // Update updates a user
 func (s *Service) Update(user *models.User) error {
-	query := `
-		UPDATE users
-		SET username = ?, email = ?, password_hash = ?, updated_at = ?
-		WHERE id = ?
-	`
-	_, err := s.db.Exec(query, user.Username, user.Email, user.PasswordHash, time.Now(), user.ID)
-	if err != nil {
-		return fmt.Errorf("failed to update user: %w", err)
-	}
-	return nil
+	return s.store.Update(user)
 }
 
 // UpdatePassword updates a user's password
 func (s *Service) UpdatePassword(userID string, hashedPassword string) error {
-	query := `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`
-	_, err := s.db.Exec(query, hashedPassword, time.Now(), userID)
+	return s.store.UpdatePassword(userID, hashedPassword)
+}
+
+// Authorize returns the user when their current role grants perm and
+// ErrForbidden otherwise. The role is read from the store, so a role
+// revoked since the user's token was issued no longer counts.
+func (s *Service) Authorize(userID string, perm models.Permission) (*models.User, error) {
+	user, err := s.store.GetByID(userID)
 	if err != nil {
-		return fmt.Errorf("failed to update password: %w", err)
+		return nil, err
 	}
-	return nil
+	if !models.RoleAllows(user.Role, perm) {
+		return nil, ErrForbidden
+	}
+	return user, nil
 }
 
-// Delete deletes a user
-func (s *Service) Delete(id string) error {
-	query := "DELETE FROM users WHERE id = ?"
-	_, err := s.db.Exec(query, id)
+// ListByRole lists the users holding role
+func (s *Service) ListByRole(role string) ([]models.User, error) {
+	return s.store.ListByRole(role)
+}
+
+// SetRole gives a user role. Taking the admin role from the last admin
+// fails with ErrLastAdmin.
+func (s *Service) SetRole(userID, role string) error {
+	if !models.ValidRole(role) {
+		return fmt.Errorf("invalid role '%s'", role)
+	}
+	user, err := s.store.GetByID(userID)
 	if err != nil {
-		return fmt.Errorf("failed to delete user: %w", err)
+		return err
+	}
+	if user.Role == models.RoleAdmin && role != models.RoleAdmin {
+		admins, err := s.store.ListByRole(models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if len(admins) <= 1 {
+			return ErrLastAdmin
+		}
 	}
-	return nil
+	return s.store.SetRole(userID, role)
+}
+
+// Delete deletes a user
+func (s *Service) Delete(id string) error {
+	return s.store.Delete(id)
 }
 
 // LibraryService handles user library operations
 type LibraryService struct {
-	db *database.Database
+	store store.LibraryStore
 }
 
-// NewLibraryService creates a new library service
+// NewLibraryService creates a new library service backed by the SQLite database
 func NewLibraryService(db *database.Database) *LibraryService {
-	return &LibraryService{db: db}
+	return NewLibraryServiceWithStore(store.NewSQLiteLibraryStore(db))
+}
+
+// NewLibraryServiceWithStore creates a new library service on top of any LibraryStore
+func NewLibraryServiceWithStore(s store.LibraryStore) *LibraryService {
+	return &LibraryService{store: s}
 }
 
 // AddToLibrary adds a manga to user's library
-func (ls *LibraryService) AddToLibrary(userID, mangaID string, status string, rating int, notes string) error {
-	query := `
-		INSERT INTO user_progress (user_id, manga_id, status, rating, notes, current_chapter, started_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, 0, ?, ?)
-	`
-	now := time.Now()
-	_, err := ls.db.Exec(query, userID, mangaID, status, rating, notes, now, now)
-	if err != nil {
-		return fmt.Errorf("failed to add to library: %w", err)
-	}
-	return nil
+func (ls *LibraryService) AddToLibrary(userID, mangaID string, status string, rating int, notes string, origin models.EventOrigin) error {
+	return ls.store.Add(&models.Progress{
+		UserID:    userID,
+		MangaID:   mangaID,
+		Status:    status,
+		Rating:    rating,
+		Notes:     notes,
+		StartedAt: time.Now(),
+	}, origin)
 }
 
 // GetLibraryEntry retrieves a single library entry
 func (ls *LibraryService) GetLibraryEntry(userID, mangaID string) (*models.Progress, error) {
-	query := `
-		SELECT user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at
-		FROM user_progress WHERE user_id = ? AND manga_id = ?
-	`
-	row := ls.db.QueryRow(query, userID, mangaID)
-
-	var progress models.Progress
-	err := row.Scan(&progress.UserID, &progress.MangaID, &progress.CurrentChapter, &progress.Status,
-		&progress.Rating, &progress.Notes, &progress.StartedAt, &progress.CompletedAt, &progress.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-	return &progress, nil
+	return ls.store.Get(userID, mangaID)
 }
 
-// RemoveFromLibrary removes a manga from user's library
-func (ls *LibraryService) RemoveFromLibrary(userID, mangaID string) error {
-	query := `DELETE FROM user_progress WHERE user_id = ? AND manga_id = ?`
-	_, err := ls.db.Exec(query, userID, mangaID)
-	if err != nil {
-		return fmt.Errorf("failed to remove from library: %w", err)
-	}
-	return nil
+// RemoveFromLibrary moves a manga from user's library to the trash
+func (ls *LibraryService) RemoveFromLibrary(userID, mangaID string, origin models.EventOrigin) error {
+	return ls.store.Remove(userID, mangaID, origin)
 }
 
-// GetLibrary retrieves user's library
-func (ls *LibraryService) GetLibrary(userID string, limit, offset int) ([]models.Progress, error) {
-	query := `
-		SELECT user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at
-		FROM user_progress WHERE user_id = ? LIMIT ? OFFSET ?
-	`
+// RestoreFromTrash moves a trashed entry back into user's library
+func (ls *LibraryService) RestoreFromTrash(userID, mangaID string, origin models.EventOrigin) error {
+	return ls.store.Restore(userID, mangaID, origin)
+}
 
-	rows, err := ls.db.Query(query, userID, limit, offset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get library: %w", err)
-	}
-	defer rows.Close()
+// GetTrash retrieves user's trashed library entries
+func (ls *LibraryService) GetTrash(userID string, limit, offset int) ([]models.Progress, error) {
+	return ls.store.Trash(userID, limit, offset)
+}
 
-	var progressList []models.Progress
-	for rows.Next() {
-		var progress models.Progress
-		err := rows.Scan(&progress.UserID, &progress.MangaID, &progress.CurrentChapter, &progress.Status,
-			&progress.Rating, &progress.Notes, &progress.StartedAt, &progress.CompletedAt, &progress.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan progress: %w", err)
-		}
-		progressList = append(progressList, progress)
-	}
+// PurgeTrash permanently deletes entries trashed before the cutoff
+func (ls *LibraryService) PurgeTrash(before time.Time) (int64, error) {
+	return ls.store.PurgeTrash(before)
+}
 
-	return progressList, nil
+// GetLibrary retrieves user's library
+func (ls *LibraryService) GetLibrary(userID string, limit, offset int) ([]models.Progress, error) {
+	return ls.store.List(userID, "", limit, offset)
 }
 
 // GetLibraryByStatus retrieves user's library filtered by status
 func (ls *LibraryService) GetLibraryByStatus(userID, status string, limit, offset int) ([]models.Progress, error) {
-	query := `
-		SELECT user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at
-		FROM user_progress WHERE user_id = ? AND status = ? LIMIT ? OFFSET ?
-	`
-
-	rows, err := ls.db.Query(query, userID, status, limit, offset)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get library: %w", err)
-	}
-	defer rows.Close()
+	return ls.store.List(userID, status, limit, offset)
+}
 
-	var progressList []models.Progress
-	for rows.Next() {
-		var progress models.Progress
-		err := rows.Scan(&progress.UserID, &progress.MangaID, &progress.CurrentChapter, &progress.Status,
-			&progress.Rating, &progress.Notes, &progress.StartedAt, &progress.CompletedAt, &progress.UpdatedAt)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan progress: %w", err)
-		}
-		progressList = append(progressList, progress)
-	}
+// UpdateLibraryEntry updates a library entry
+func (ls *LibraryService) UpdateLibraryEntry(progress *models.Progress, origin models.EventOrigin) error {
+	return ls.store.Update(progress, origin)
+}
 
-	return progressList, nil
+// SaveProgress records a synced chapter update
+func (ls *LibraryService) SaveProgress(update *models.ProgressUpdate, origin models.EventOrigin) error {
+	return ls.store.SaveProgress(update, origin)
 }
 
-// UpdateLibraryEntry updates a library entry
-func (ls *LibraryService) UpdateLibraryEntry(progress *models.Progress) error {
-	query := `
-		UPDATE user_progress
-		SET current_chapter = ?, status = ?, rating = ?, notes = ?, completed_at = ?, updated_at = ?
-		WHERE user_id = ? AND manga_id = ?
-	`
-	_, err := ls.db.Exec(query, progress.CurrentChapter, progress.Status, progress.Rating, progress.Notes,
-		progress.CompletedAt, time.Now(), progress.UserID, progress.MangaID)
-	if err != nil {
-		return fmt.Errorf("failed to update library entry: %w", err)
-	}
-	return nil
+// GetProgressEvents retrieves the reading event log, newest first
+func (ls *LibraryService) GetProgressEvents(filter models.ProgressEventFilter) ([]models.ProgressEvent, error) {
+	return ls.store.Events(filter)
 }
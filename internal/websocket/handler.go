@@ -3,10 +3,12 @@ package websocket
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 
+	"mangahub/internal/auth"
 	"mangahub/pkg/models"
 )
 
@@ -19,17 +21,28 @@ var upgrader = websocket.Upgrader{
 	},
 }
 
-// HandleConnection handles a new WebSocket connection from Gin
-func HandleConnection(c *gin.Context, hub *Hub, room string) {
-	// Get user info from query params
-	userID := c.Query("user_id")
-	username := c.Query("username")
+// HandleConnection handles a new WebSocket connection from Gin. The user
+// comes from a bearer token, in the Authorization header or the token
+// query parameter, and a connection without one that checks out is turned
+// away; personal access tokens without the chat:write scope can only read.
+// Without sessions to check tokens against, everybody joins as a guest.
+func HandleConnection(c *gin.Context, hub *Hub, room string, sessions *auth.SessionService) {
+	userID, username := "anonymous", "guest"
+	readOnly := false
 
-	if userID == "" {
-		userID = "anonymous"
-	}
-	if username == "" {
-		username = "guest"
+	if sessions != nil {
+		token := bearerToken(c)
+		if token == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "authentication required"})
+			return
+		}
+		claims, err := sessions.Authenticate(token)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+			return
+		}
+		userID, username = claims.UserID, claims.Username
+		readOnly = !claims.HasScope(models.ScopeChatWrite)
 	}
 
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
@@ -43,6 +56,7 @@ func HandleConnection(c *gin.Context, hub *Hub, room string) {
 		UserID:   userID,
 		Username: username,
 		RoomID:   room,
+		ReadOnly: readOnly,
 	}
 
 	log.Printf("New WebSocket connection: user=%s, room=%s", username, room)
@@ -53,3 +67,13 @@ func HandleConnection(c *gin.Context, hub *Hub, room string) {
 	// Handle connection
 	hub.HandleConnection(conn, client)
 }
+
+// bearerToken returns the token of the "Authorization: Bearer <token>"
+// header, or of the token query parameter for clients that cannot set
+// headers, such as browsers
+func bearerToken(c *gin.Context) string {
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimPrefix(header, "Bearer ")
+	}
+	return c.Query("token")
+}
@@ -0,0 +1,91 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"mangahub/internal/auth"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// newTestServer serves the chat endpoint the way the WebSocket server
+// does, with tokens checked against in-memory stores, and returns its ws://
+// URL with a login for alice
+func newTestServer(t *testing.T) (string, string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	stores := store.NewMemoryStores()
+	tokens := auth.NewAccessTokenService(stores.AccessTokens, stores.Users)
+	sessions := auth.NewSessionService(auth.NewAuthService("test-secret"), stores.Sessions, tokens, stores.Users)
+	alice := &models.User{ID: "user-alice", Username: "alice", Email: "alice@example.com", Role: models.RoleUser}
+	if err := stores.Users.Create(alice); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	login, err := sessions.Start(alice, "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("start session: %v", err)
+	}
+
+	hub := NewHub()
+	go hub.Run()
+	t.Cleanup(hub.Stop)
+
+	engine := gin.New()
+	engine.GET("/ws/:room", func(c *gin.Context) {
+		HandleConnection(c, hub, c.Param("room"), sessions)
+	})
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return "ws" + strings.TrimPrefix(server.URL, "http"), login.Token
+}
+
+func TestHandleConnectionRequiresToken(t *testing.T) {
+	url, _ := newTestServer(t)
+
+	for name, query := range map[string]string{
+		"no token":          "",
+		"user in the query": "?user_id=user-alice&username=alice",
+		"invalid token":     "?token=not-a-token&user_id=user-alice&username=alice",
+	} {
+		conn, resp, err := websocket.DefaultDialer.Dial(url+"/ws/general"+query, nil)
+		if err == nil {
+			conn.Close()
+			t.Errorf("%s: connection accepted", name)
+			continue
+		}
+		if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s: %v, want 401", name, err)
+		}
+	}
+}
+
+func TestHandleConnectionTakesUserFromToken(t *testing.T) {
+	url, token := newTestServer(t)
+
+	header := http.Header{"Authorization": []string{"Bearer " + token}}
+	conn, _, err := websocket.DefaultDialer.Dial(url+"/ws/general?user_id=user-mallory&username=mallory", header)
+	if err != nil {
+		t.Fatalf("dial with a token: %v", err)
+	}
+	defer conn.Close()
+
+	if err := conn.WriteJSON(models.ChatMessage{UserID: "user-mallory", Username: "mallory", Message: "hello"}); err != nil {
+		t.Fatalf("send message: %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg models.ChatMessage
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("read broadcast: %v", err)
+	}
+	if msg.UserID != "user-alice" || msg.Username != "alice" || msg.RoomID != "general" || msg.Message != "hello" {
+		t.Errorf("broadcast = %+v, want alice's message in general", msg)
+	}
+}
@@ -101,6 +101,20 @@ func (h *Hub) HandleConnection(conn *websocket.Conn, client *models.ChatClient)
 			return
 		}
 
+		if client.ReadOnly {
+			// Broadcasts write under the read lock, so the write lock keeps
+			// this reply from interleaving with them
+			h.mutex.Lock()
+			conn.WriteJSON(models.ChatMessage{
+				Username:  "system",
+				RoomID:    client.RoomID,
+				Message:   "message not sent: access token lacks the chat:write scope",
+				Timestamp: time.Now().Unix(),
+			})
+			h.mutex.Unlock()
+			continue
+		}
+
 		msg.UserID = client.UserID
 		msg.Username = client.Username
 		msg.RoomID = client.RoomID
@@ -7,11 +7,15 @@ import (
 	"strings"
 	"time"
 
+	"mangahub/pkg/models"
 	pb "mangahub/proto"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func init() {
@@ -34,8 +38,13 @@ func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
 // GRPCClient represents a gRPC client for manga service
 type GRPCClient struct {
 	ServerAddr string
-	conn       *grpc.ClientConn
-	client     pb.MangaServiceClient
+	// Token is the API token sent with every call; UpdateProgress needs one
+	Token string
+	// RefreshToken, when set, returns a new token once the server turns
+	// Token away, and the call is made again with it
+	RefreshToken func() (string, error)
+	conn         *grpc.ClientConn
+	client       pb.MangaServiceClient
 }
 
 // NewGRPCClient creates a new gRPC client
@@ -72,6 +81,15 @@ func (c *GRPCClient) Close() error {
 	return nil
 }
 
+// callContext returns the context for a call, carrying the token if set
+func (c *GRPCClient) callContext() (context.Context, context.CancelFunc) {
+	ctx := context.Background()
+	if c.Token != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+c.Token)
+	}
+	return context.WithTimeout(ctx, 10*time.Second)
+}
+
 // GRPCMangaResponse wraps manga response with success flag
 type GRPCMangaResponse struct {
 	Success bool
@@ -84,7 +102,7 @@ func (c *GRPCClient) GetManga(mangaID string) (*GRPCMangaResponse, error) {
 		return nil, fmt.Errorf("not connected to server")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := c.callContext()
 	defer cancel()
 
 	resp, err := c.client.GetManga(ctx, &pb.MangaRequest{ID: mangaID})
@@ -98,18 +116,28 @@ func (c *GRPCClient) GetManga(mangaID string) (*GRPCMangaResponse, error) {
 	}, nil
 }
 
-// SearchManga searches for manga
-func (c *GRPCClient) SearchManga(query string, limit int) (*pb.SearchResponse, error) {
+// SearchManga searches for manga with the same filters as the HTTP API
+func (c *GRPCClient) SearchManga(filter *models.MangaFilter) (*pb.SearchResponse, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("not connected to server")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := c.callContext()
 	defer cancel()
 
 	resp, err := c.client.SearchManga(ctx, &pb.SearchRequest{
-		Title: query,
-		Limit: int32(limit),
+		Title:       filter.Query,
+		Author:      filter.Author,
+		Genres:      filter.Genres,
+		Status:      filter.Status,
+		Limit:       int32(filter.Limit),
+		Offset:      int32(filter.Offset),
+		YearFrom:    int32(filter.YearFrom),
+		YearTo:      int32(filter.YearTo),
+		MinChapters: int32(filter.MinChapters),
+		MinRating:   filter.MinRating,
+		SortBy:      filter.SortBy,
+		Order:       filter.Order,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to search manga: %w", err)
@@ -118,20 +146,26 @@ func (c *GRPCClient) SearchManga(query string, limit int) (*pb.SearchResponse, e
 	return resp, nil
 }
 
-// UpdateProgress updates reading progress
+// UpdateProgress updates reading progress as the token's user; userID
+// may be left empty, and naming another user takes an admin token
 func (c *GRPCClient) UpdateProgress(userID, mangaID string, chapter int) (*pb.UpdateProgressResponse, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("not connected to server")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	resp, err := c.client.UpdateProgress(ctx, &pb.UpdateProgressRequest{
+	req := &pb.UpdateProgressRequest{
 		UserID:  userID,
 		MangaID: mangaID,
 		Chapter: int32(chapter),
-	})
+	}
+	resp, err := c.updateProgress(req)
+	if status.Code(err) == codes.Unauthenticated && c.RefreshToken != nil {
+		token, refreshErr := c.RefreshToken()
+		if refreshErr == nil {
+			c.Token = token
+			resp, err = c.updateProgress(req)
+		}
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to update progress: %w", err)
 	}
@@ -139,13 +173,20 @@ func (c *GRPCClient) UpdateProgress(userID, mangaID string, chapter int) (*pb.Up
 	return resp, nil
 }
 
+func (c *GRPCClient) updateProgress(req *pb.UpdateProgressRequest) (*pb.UpdateProgressResponse, error) {
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	return c.client.UpdateProgress(ctx, req)
+}
+
 // GetTop10Manga retrieves top 10 manga
 func (c *GRPCClient) GetTop10Manga() (*pb.Top10Response, error) {
 	if c.client == nil {
 		return nil, fmt.Errorf("not connected to server")
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := c.callContext()
 	defer cancel()
 
 	resp, err := c.client.GetTop10Manga(ctx, &pb.Empty{})
@@ -156,6 +197,69 @@ func (c *GRPCClient) GetTop10Manga() (*pb.Top10Response, error) {
 	return resp, nil
 }
 
+// GetRankings retrieves the ranking of a window, optionally within a genre
+func (c *GRPCClient) GetRankings(window, genre string, limit int) (*pb.Top10Response, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.GetRankings(ctx, &pb.RankingsRequest{
+		Window: window,
+		Genre:  genre,
+		Limit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rankings: %w", err)
+	}
+
+	return resp, nil
+}
+
+// GetSimilarManga retrieves the manga most similar to a manga
+func (c *GRPCClient) GetSimilarManga(mangaID string, limit int) (*pb.SimilarResponse, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.GetSimilarManga(ctx, &pb.SimilarRequest{
+		MangaID: mangaID,
+		Limit:   int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get similar manga: %w", err)
+	}
+
+	return resp, nil
+}
+
+// ListChapters retrieves the chapters of a manga
+func (c *GRPCClient) ListChapters(mangaID, language string, limit, offset int) (*pb.ListChaptersResponse, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("not connected to server")
+	}
+
+	ctx, cancel := c.callContext()
+	defer cancel()
+
+	resp, err := c.client.ListChapters(ctx, &pb.ListChaptersRequest{
+		MangaID:  mangaID,
+		Language: language,
+		Limit:    int32(limit),
+		Offset:   int32(offset),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapters: %w", err)
+	}
+
+	return resp, nil
+}
+
 // FormatGenres formats genres as a string
 func FormatGenres(genres []string) string {
 	if len(genres) == 0 {
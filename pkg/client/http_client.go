@@ -7,27 +7,55 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"mangahub/pkg/models"
+	"mangahub/pkg/session"
 )
 
 // HTTPClient represents an HTTP client for API calls
 type HTTPClient struct {
 	BaseURL string
 	Token   string
-	Client  *http.Client
+	// RefreshToken, when set, is traded in for a new access token the first
+	// time a request is turned away as unauthorized, and the request retried
+	RefreshToken string
+	// OnRefresh, when set, is called with the new tokens after a refresh
+	OnRefresh func(*models.LoginResponse)
+	// DeviceID is sent as X-Device-ID so the server can attribute progress
+	// events to this machine; it defaults to the hostname
+	DeviceID string
+	Client   *http.Client
 }
 
 // NewHTTPClient creates a new HTTP client
 func NewHTTPClient(baseURL, token string) *HTTPClient {
+	deviceID, _ := os.Hostname()
 	return &HTTPClient{
-		BaseURL: baseURL,
-		Token:   token,
-		Client:  &http.Client{},
+		BaseURL:  baseURL,
+		Token:    token,
+		DeviceID: deviceID,
+		Client:   &http.Client{},
 	}
 }
 
+// NewSessionHTTPClient creates an HTTP client for a logged-in CLI session.
+// Tokens it refreshes are saved back to the session file.
+func NewSessionHTTPClient(baseURL string, sess *session.Session) *HTTPClient {
+	c := NewHTTPClient(baseURL, sess.Token)
+	c.RefreshToken = sess.RefreshToken
+	c.OnRefresh = func(resp *models.LoginResponse) {
+		sess.Token = resp.Token
+		sess.RefreshToken = resp.RefreshToken
+		sess.ExpiresAt = resp.ExpiresAt.Format(session.TimeFormat)
+		session.Save(sess)
+	}
+	return c
+}
+
 // SetToken sets the authentication token
 func (c *HTTPClient) SetToken(token string) {
 	c.Token = token
@@ -80,6 +108,7 @@ func (c *HTTPClient) Login(username, password string) (*models.LoginResponse, er
 	req := models.LoginRequest{
 		Username: username,
 		Password: password,
+		Device:   c.DeviceID,
 	}
 
 	data, err := json.Marshal(req)
@@ -108,9 +137,242 @@ func (c *HTTPClient) Login(username, password string) (*models.LoginResponse, er
 	}
 
 	c.Token = loginResp.Token
+	c.RefreshToken = loginResp.RefreshToken
 	return &loginResp, nil
 }
 
+// Refresh trades the refresh token for a new access token and refresh
+// token, and starts using them
+func (c *HTTPClient) Refresh() (*models.LoginResponse, error) {
+	if c.RefreshToken == "" {
+		return nil, fmt.Errorf("no refresh token")
+	}
+	data, err := json.Marshal(models.RefreshRequest{RefreshToken: c.RefreshToken})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post("/auth/refresh", data)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if msg, ok := errResp["error"]; ok {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, fmt.Errorf("refresh failed with status %d", resp.StatusCode)
+	}
+
+	var loginResp models.LoginResponse
+	if err := json.NewDecoder(resp.Body).Decode(&loginResp); err != nil {
+		return nil, err
+	}
+
+	c.Token = loginResp.Token
+	c.RefreshToken = loginResp.RefreshToken
+	if c.OnRefresh != nil {
+		c.OnRefresh(&loginResp)
+	}
+	return &loginResp, nil
+}
+
+// Logout revokes the session of the refresh token on the server
+func (c *HTTPClient) Logout() error {
+	data, err := json.Marshal(models.RefreshRequest{RefreshToken: c.RefreshToken})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.post("/auth/logout", data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if msg, ok := errResp["error"]; ok {
+			return fmt.Errorf("%s", msg)
+		}
+		return fmt.Errorf("logout failed with status %d", resp.StatusCode)
+	}
+
+	c.Token = ""
+	c.RefreshToken = ""
+	return nil
+}
+
+// ListSessions lists the devices the user is logged in on
+func (c *HTTPClient) ListSessions() ([]models.Session, error) {
+	resp, err := c.get("/users/sessions")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("session expired or invalid")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list sessions with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Sessions []models.Session `json:"sessions"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Sessions, nil
+}
+
+// RevokeSession logs one of the user's devices out
+func (c *HTTPClient) RevokeSession(id string) error {
+	resp, err := c.delete("/users/sessions/" + url.PathEscape(id))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("session expired or invalid")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if msg, ok := errResp["error"]; ok {
+			return fmt.Errorf("%s", msg)
+		}
+		return fmt.Errorf("failed to revoke session with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// CreateAccessToken creates a personal access token with the given scopes
+// lasting expiresInDays, 0 meaning the server's default
+func (c *HTTPClient) CreateAccessToken(name string, scopes []string, expiresInDays int) (*models.CreateAccessTokenResponse, error) {
+	data, err := json.Marshal(models.CreateAccessTokenRequest{
+		Name:          name,
+		Scopes:        scopes,
+		ExpiresInDays: expiresInDays,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.post("/users/tokens", data)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		var errResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if msg, ok := errResp["error"]; ok {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, fmt.Errorf("failed to create access token with status %d", resp.StatusCode)
+	}
+
+	var token models.CreateAccessTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// ListAccessTokens lists the user's personal access tokens
+func (c *HTTPClient) ListAccessTokens() ([]models.AccessToken, error) {
+	resp, err := c.get("/users/tokens")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("session expired or invalid")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if msg, ok := errResp["error"]; ok {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, fmt.Errorf("failed to list access tokens with status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Tokens []models.AccessToken `json:"tokens"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Tokens, nil
+}
+
+// RevokeAccessToken revokes one of the user's personal access tokens
+func (c *HTTPClient) RevokeAccessToken(id string) error {
+	resp, err := c.delete("/users/tokens/" + url.PathEscape(id))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("session expired or invalid")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var errResp map[string]string
+		json.NewDecoder(resp.Body).Decode(&errResp)
+		if msg, ok := errResp["error"]; ok {
+			return fmt.Errorf("%s", msg)
+		}
+		return fmt.Errorf("failed to revoke access token with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ChangePassword changes the user's password, which logs the user's other
+// devices out, and returns how many sessions that ended
+func (c *HTTPClient) ChangePassword(currentPassword, newPassword string) (int, error) {
+	data, err := json.Marshal(models.ChangePasswordRequest{
+		CurrentPassword: currentPassword,
+		NewPassword:     newPassword,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.put("/users/password", data)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Error           string `json:"error"`
+		SessionsRevoked int    `json:"sessions_revoked"`
+	}
+	json.NewDecoder(resp.Body).Decode(&result)
+	if resp.StatusCode != http.StatusOK {
+		if result.Error != "" {
+			return 0, fmt.Errorf("%s", result.Error)
+		}
+		return 0, fmt.Errorf("failed to change password with status %d", resp.StatusCode)
+	}
+	return result.SessionsRevoked, nil
+}
+
 // GetProfile retrieves the current user's profile
 func (c *HTTPClient) GetProfile() (*models.User, error) {
 	resp, err := c.get("/users/profile")
@@ -215,47 +477,231 @@ func (c *HTTPClient) GetManga(id string) (*models.Manga, error) {
 	return &manga, nil
 }
 
-// Helper methods
+// ListChapters fetches a page of a manga's chapters. after, when positive,
+// only returns chapters numbered above it.
+func (c *HTTPClient) ListChapters(mangaID, language string, after float64, order string, limit, offset int) (*models.ChapterList, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("offset", strconv.Itoa(offset))
+	if language != "" {
+		params.Set("lang", language)
+	}
+	if after > 0 {
+		params.Set("after", strconv.FormatFloat(after, 'f', -1, 64))
+	}
+	if order != "" {
+		params.Set("order", order)
+	}
 
-func (c *HTTPClient) post(endpoint string, data []byte) (*http.Response, error) {
-	req, err := http.NewRequest("POST", c.BaseURL+endpoint, io.NopCloser(bytes.NewBuffer(data)))
+	resp, err := c.get("/manga/" + url.PathEscape(mangaID) + "/chapters?" + params.Encode())
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	req.Header.Set("Content-Type", "application/json")
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("manga not found")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("list chapters failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return c.Client.Do(req)
+	var list models.ChapterList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return &list, nil
 }
 
-func (c *HTTPClient) get(endpoint string) (*http.Response, error) {
-	req, err := http.NewRequest("GET", c.BaseURL+endpoint, nil)
+// GetGenres fetches the genre taxonomy, optionally only one kind
+func (c *HTTPClient) GetGenres(kind string) ([]models.Genre, error) {
+	path := "/genres"
+	if kind != "" {
+		path += "?kind=" + url.QueryEscape(kind)
+	}
+
+	resp, err := c.get(path)
 	if err != nil {
 		return nil, err
 	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("list genres failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Genres []models.Genre `json:"genres"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Genres, nil
+}
 
+// GetRankings fetches the manga ranking of a window, optionally within a genre
+func (c *HTTPClient) GetRankings(window, genre string, limit int) (*models.Rankings, error) {
+	params := url.Values{}
+	if window != "" {
+		params.Set("window", window)
+	}
+	if genre != "" {
+		params.Set("genre", genre)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	path := "/manga/rankings"
+	if len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	resp, err := c.get(path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get rankings failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var rankings models.Rankings
+	if err := json.NewDecoder(resp.Body).Decode(&rankings); err != nil {
+		return nil, err
+	}
+	return &rankings, nil
+}
+
+// GetSimilarManga fetches the manga most similar to a manga
+func (c *HTTPClient) GetSimilarManga(mangaID string, limit int) (*models.SimilarList, error) {
+	path := "/manga/" + url.PathEscape(mangaID) + "/similar"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+
+	resp, err := c.get(path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("manga not found: %s", mangaID)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get similar manga failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var similar models.SimilarList
+	if err := json.NewDecoder(resp.Body).Decode(&similar); err != nil {
+		return nil, err
+	}
+	return &similar, nil
+}
+
+// GetRecommendations fetches the personalized recommendations of the
+// logged-in user
+func (c *HTTPClient) GetRecommendations(limit int) (*models.Recommendations, error) {
+	path := "/users/recommendations"
+	if limit > 0 {
+		path += "?limit=" + strconv.Itoa(limit)
+	}
+
+	resp, err := c.get(path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: please login first")
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get recommendations failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var recs models.Recommendations
+	if err := json.NewDecoder(resp.Body).Decode(&recs); err != nil {
+		return nil, err
+	}
+	return &recs, nil
+}
+
+// Helper methods
+
+// setHeaders adds the bearer token and device ID to a request
+func (c *HTTPClient) setHeaders(req *http.Request) {
 	if c.Token != "" {
 		req.Header.Set("Authorization", "Bearer "+c.Token)
 	}
+	if c.DeviceID != "" {
+		req.Header.Set("X-Device-ID", c.DeviceID)
+	}
+}
 
+// do sends a request. When it is turned away as unauthorized and there is
+// a refresh token, the tokens are refreshed and the request sent again.
+func (c *HTTPClient) do(req *http.Request) (*http.Response, error) {
+	c.setHeaders(req)
+	resp, err := c.Client.Do(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || c.RefreshToken == "" ||
+		strings.Contains(req.URL.Path, "/auth/") {
+		return resp, err
+	}
+
+	if _, err := c.Refresh(); err != nil {
+		return resp, nil
+	}
+	if req.Body != nil {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		req.Body = body
+	}
+	resp.Body.Close()
+	c.setHeaders(req)
 	return c.Client.Do(req)
 }
 
-func (c *HTTPClient) put(endpoint string, data []byte) (*http.Response, error) {
-	req, err := http.NewRequest("PUT", c.BaseURL+endpoint, io.NopCloser(bytes.NewBuffer(data)))
+func (c *HTTPClient) post(endpoint string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequest("POST", c.BaseURL+endpoint, bytes.NewReader(data))
 	if err != nil {
 		return nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
+
+	return c.do(req)
+}
+
+func (c *HTTPClient) get(endpoint string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", c.BaseURL+endpoint, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.Client.Do(req)
+	return c.do(req)
+}
+
+func (c *HTTPClient) put(endpoint string, data []byte) (*http.Response, error) {
+	req, err := http.NewRequest("PUT", c.BaseURL+endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(req)
 }
 
 func (c *HTTPClient) delete(endpoint string) (*http.Response, error) {
@@ -265,11 +711,8 @@ func (c *HTTPClient) delete(endpoint string) (*http.Response, error) {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if c.Token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.Token)
-	}
 
-	return c.Client.Do(req)
+	return c.do(req)
 }
 
 // GetLibrary retrieves user's library
@@ -308,6 +751,44 @@ func (c *HTTPClient) GetLibrary(status string, limit, offset int) ([]models.Prog
 	return progressList, nil
 }
 
+// GetProgressEvents retrieves the reading event log, newest first. Zero
+// since/until leave that end of the time range open.
+func (c *HTTPClient) GetProgressEvents(mangaID string, since, until time.Time, limit, offset int) ([]models.ProgressEvent, error) {
+	params := url.Values{}
+	if mangaID != "" {
+		params.Set("manga_id", mangaID)
+	}
+	if !since.IsZero() {
+		params.Set("since", since.Format(time.RFC3339))
+	}
+	if !until.IsZero() {
+		params.Set("until", until.Format(time.RFC3339))
+	}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("offset", strconv.Itoa(offset))
+
+	resp, err := c.get("/users/progress/events?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: please login first")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get progress events: status %d", resp.StatusCode)
+	}
+
+	var events []models.ProgressEvent
+	if err := json.NewDecoder(resp.Body).Decode(&events); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
 // AddToLibrary adds a manga to the user's library
 func (c *HTTPClient) AddToLibrary(mangaID, status string, rating int, notes string) error {
 	payload := map[string]interface{}{
@@ -344,6 +825,50 @@ func (c *HTTPClient) AddToLibrary(mangaID, status string, rating int, notes stri
 	return nil
 }
 
+// ImportLibrary uploads a MyAnimeList ("mal") or AniList ("anilist") list
+// export to be merged into the user's library. With dryRun the server only
+// reports what would change. When the import stops part way, the report of
+// the entries imported before is returned along with the error.
+func (c *HTTPClient) ImportLibrary(format string, export []byte, dryRun bool) (*models.ImportReport, error) {
+	params := url.Values{}
+	params.Set("format", format)
+	params.Set("dry_run", strconv.FormatBool(dryRun))
+
+	req, err := http.NewRequest("POST", c.BaseURL+"/users/library/import?"+params.Encode(), bytes.NewReader(export))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := c.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: please login first")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var partial models.ImportReport
+		json.NewDecoder(resp.Body).Decode(&partial)
+		switch {
+		case partial.Results != nil:
+			return &partial, fmt.Errorf("%s", partial.Error)
+		case partial.Error != "":
+			return nil, fmt.Errorf("%s", partial.Error)
+		}
+		return nil, fmt.Errorf("failed to import library: status %d", resp.StatusCode)
+	}
+
+	var report models.ImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
 // RemoveFromLibrary removes a manga from the user's library
 func (c *HTTPClient) RemoveFromLibrary(mangaID string) error {
 	resp, err := c.delete("/users/library/" + mangaID)
@@ -363,6 +888,57 @@ func (c *HTTPClient) RemoveFromLibrary(mangaID string) error {
 	return nil
 }
 
+// GetLibraryTrash retrieves user's trashed library entries
+func (c *HTTPClient) GetLibraryTrash(limit, offset int) ([]models.Progress, error) {
+	params := url.Values{}
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("offset", strconv.Itoa(offset))
+
+	resp, err := c.get("/users/library/trash?" + params.Encode())
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, fmt.Errorf("unauthorized: please login first")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get library trash: status %d", resp.StatusCode)
+	}
+
+	var trash []models.Progress
+	if err := json.NewDecoder(resp.Body).Decode(&trash); err != nil {
+		return nil, err
+	}
+
+	return trash, nil
+}
+
+// RestoreLibraryEntry moves a trashed entry back into user's library
+func (c *HTTPClient) RestoreLibraryEntry(mangaID string) error {
+	resp, err := c.post("/users/library/trash/"+url.PathEscape(mangaID)+"/restore", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("unauthorized: please login first")
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("manga %s is not in the trash", mangaID)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to restore library entry: status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // UpdateProgress updates reading progress for a manga
 func (c *HTTPClient) UpdateProgress(mangaID string, chapter int, status string, rating int, notes string) error {
 	payload := map[string]interface{}{
@@ -465,6 +1041,23 @@ type DatabaseCheckResponse struct {
 		Verified []string `json:"verified"`
 		Missing  []string `json:"missing"`
 	} `json:"tables"`
+	Schema struct {
+		OK      bool          `json:"ok"`
+		Version int           `json:"version"`
+		Latest  int           `json:"latest"`
+		Issues  []SchemaIssue `json:"issues"`
+	} `json:"schema"`
+}
+
+// SchemaIssue represents one difference between the live and expected schema
+type SchemaIssue struct {
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"`
+	Table    string `json:"table"`
+	Object   string `json:"object,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Fixable  bool   `json:"fixable"`
 }
 
 // GetDatabaseCheck fetches database integrity check results from the API
@@ -488,6 +1081,34 @@ func (c *HTTPClient) GetDatabaseCheck() (*DatabaseCheckResponse, error) {
 	return &checkResp, nil
 }
 
+// DatabaseFixResponse represents the schema fix API response
+type DatabaseFixResponse struct {
+	Status    string        `json:"status"`
+	Steps     []string      `json:"steps"`
+	Remaining []SchemaIssue `json:"remaining"`
+}
+
+// FixDatabaseSchema asks the server to apply pending migrations and repair schema drift
+func (c *HTTPClient) FixDatabaseSchema() (*DatabaseFixResponse, error) {
+	resp, err := c.post("/server/database/fix", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to fix database schema with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var fixResp DatabaseFixResponse
+	if err := json.NewDecoder(resp.Body).Decode(&fixResp); err != nil {
+		return nil, fmt.Errorf("failed to decode schema fix response: %w", err)
+	}
+
+	return &fixResp, nil
+}
+
 // DatabaseOptimizeResponse represents the optimize API response
 type DatabaseOptimizeResponse struct {
 	Status string   `json:"status"`
@@ -518,9 +1139,11 @@ func (c *HTTPClient) OptimizeDatabase() (*DatabaseOptimizeResponse, error) {
 
 // DatabaseStatsResponse represents the stats API response
 type DatabaseStatsResponse struct {
-	FileSizeBytes int64          `json:"file_size_bytes"`
-	FileSizeMB    float64        `json:"file_size_mb"`
-	Tables        map[string]int `json:"tables"`
+	FileSizeBytes      int64          `json:"file_size_bytes"`
+	FileSizeMB         float64        `json:"file_size_mb"`
+	Tables             map[string]int `json:"tables"`
+	RetentionDays      map[string]int `json:"retention_days"`
+	TrashRetentionDays int            `json:"trash_retention_days"`
 }
 
 // GetDatabaseStats fetches database statistics from the API
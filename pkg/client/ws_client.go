@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net/http"
 	"net/url"
 	"sync"
 	"time"
@@ -15,6 +16,9 @@ import (
 
 // WebSocketClient represents a WebSocket client for chat
 type WebSocketClient struct {
+	// Token is sent as a bearer token and names the user to the server,
+	// which turns away connections without one
+	Token          string
 	conn           *websocket.Conn
 	serverURL      string
 	userID         string
@@ -77,14 +81,12 @@ func (c *WebSocketClient) Connect(roomID string) error {
 	// Add room to path (server expects /ws/:room)
 	u.Path = fmt.Sprintf("/ws/%s", c.roomID)
 
-	// Add query parameters for auth
-	q := u.Query()
-	q.Set("user_id", c.userID)
-	q.Set("username", c.username)
-	u.RawQuery = q.Encode()
-
 	// Connect to WebSocket server
-	conn, _, err := websocket.DefaultDialer.Dial(u.String(), nil)
+	header := http.Header{}
+	if c.Token != "" {
+		header.Set("Authorization", "Bearer "+c.Token)
+	}
+	conn, _, err := websocket.DefaultDialer.Dial(u.String(), header)
 	if err != nil {
 		return fmt.Errorf("failed to connect: %w", err)
 	}
@@ -10,13 +10,19 @@ import (
 
 // Config holds all application configuration
 type Config struct {
-	App       AppConfig       `yaml:"app"`
-	Database  DatabaseConfig  `yaml:"database"`
-	HTTP      HTTPConfig      `yaml:"http"`
-	TCP       TCPConfig       `yaml:"tcp"`
-	UDP       UDPConfig       `yaml:"udp"`
-	GRPC      gRPCConfig      `yaml:"grpc"`
-	WebSocket WebSocketConfig `yaml:"websocket"`
+	App             AppConfig             `yaml:"app"`
+	Database        DatabaseConfig        `yaml:"database"`
+	HTTP            HTTPConfig            `yaml:"http"`
+	TCP             TCPConfig             `yaml:"tcp"`
+	UDP             UDPConfig             `yaml:"udp"`
+	GRPC            gRPCConfig            `yaml:"grpc"`
+	WebSocket       WebSocketConfig       `yaml:"websocket"`
+	Retention       RetentionConfig       `yaml:"retention"`
+	Rankings        RankingsConfig        `yaml:"rankings"`
+	Recommendations RecommendationsConfig `yaml:"recommendations"`
+	Storage         StorageConfig         `yaml:"storage"`
+	Catalog         CatalogConfig         `yaml:"catalog"`
+	Auth            AuthConfig            `yaml:"auth"`
 }
 
 // AppConfig holds application-level configuration
@@ -42,6 +48,84 @@ type DatabaseConfig struct {
 	Timeout     int    `yaml:"timeout"`
 	MaxConn     int    `yaml:"max_conn"`
 	AutoMigrate bool   `yaml:"auto_migrate"`
+	// TrashRetentionDays is how long removed library entries and manga stay
+	// restorable before they are purged; 0 keeps them forever
+	TrashRetentionDays int `yaml:"trash_retention_days"`
+	// JournalMode is the SQLite journal mode, "wal" unless set
+	JournalMode string `yaml:"journal_mode"`
+	// ForeignKeys turns on SQLite foreign key enforcement
+	ForeignKeys bool `yaml:"foreign_keys"`
+	// BusyRetries is how many times a write that still finds the database
+	// busy after the busy timeout (Timeout, in seconds) is retried
+	BusyRetries int `yaml:"busy_retries"`
+}
+
+// RetentionConfig holds the retention policies enforced by the pruning job.
+// A retention of 0 keeps data forever.
+type RetentionConfig struct {
+	// PruneInterval is the number of minutes between pruning runs
+	PruneInterval     int `yaml:"prune_interval"`
+	ChatMessagesDays  int `yaml:"chat_messages_days"`
+	NotificationsDays int `yaml:"notifications_days"`
+	// LogMaxSizeMB is the size at which the server log is rotated
+	LogMaxSizeMB  int `yaml:"log_max_size_mb"`
+	LogMaxBackups int `yaml:"log_max_backups"`
+}
+
+// RankingsConfig holds the settings of the manga rankings cache
+type RankingsConfig struct {
+	// RefreshInterval is the number of minutes between ranking refreshes
+	RefreshInterval int `yaml:"refresh_interval"`
+}
+
+// RecommendationsConfig holds the settings of the batch similarity refresh
+// behind manga recommendations
+type RecommendationsConfig struct {
+	// RefreshInterval is the number of minutes between the similarity
+	// refreshes the API server runs
+	RefreshInterval int `yaml:"refresh_interval"`
+}
+
+// StorageConfig holds the settings of files kept outside the database
+type StorageConfig struct {
+	// DataDir is the directory cover images are stored under; the
+	// database's directory when empty
+	DataDir string `yaml:"data_dir"`
+	// MaxCoverSizeMB is the largest accepted cover upload
+	MaxCoverSizeMB int `yaml:"max_cover_size_mb"`
+}
+
+// CatalogConfig holds the metadata providers the catalog is refreshed from
+// and the order their fields are merged in
+type CatalogConfig struct {
+	// Providers are the enabled metadata providers, "mangadex" and "local"
+	Providers []string `yaml:"providers"`
+	// MangaDexURL is the MangaDex API; the public API when empty
+	MangaDexURL string `yaml:"mangadex_url"`
+	// MangaDexCoverURL serves MangaDex cover art; the public cover host
+	// when empty
+	MangaDexCoverURL string `yaml:"mangadex_cover_url"`
+	// LocalDir is the directory of the local JSON catalog files
+	LocalDir string `yaml:"local_dir"`
+	// Language is the language chapters are listed in
+	Language string `yaml:"language"`
+	// Priority overrides the source order of individual fields, highest
+	// first, e.g. status: [mangadex, admin, local]
+	Priority map[string][]string `yaml:"priority"`
+	// WatchInterval is the number of minutes between chapter release polls
+	WatchInterval int `yaml:"watch_interval"`
+	// MaxBackoff is the longest, in minutes, a failing provider is left
+	// alone before the watcher polls it again
+	MaxBackoff int `yaml:"max_backoff"`
+}
+
+// AuthConfig holds the lifetimes of login tokens
+type AuthConfig struct {
+	// AccessTokenTTL is the number of minutes an access token is valid
+	AccessTokenTTL int `yaml:"access_token_ttl"`
+	// RefreshTokenTTL is the number of days a session lasts without being
+	// refreshed
+	RefreshTokenTTL int `yaml:"refresh_token_ttl"`
 }
 
 // HTTPConfig holds HTTP server configuration
@@ -147,11 +231,15 @@ func DefaultConfig() *Config {
 			},
 		},
 		Database: DatabaseConfig{
-			Type:        "sqlite3",
-			Path:        filepath.Join(os.ExpandEnv("$HOME"), ".mangahub", "data.db"),
-			Timeout:     30,
-			MaxConn:     10,
-			AutoMigrate: true,
+			Type:               "sqlite3",
+			Path:               filepath.Join(os.ExpandEnv("$HOME"), ".mangahub", "data.db"),
+			Timeout:            30,
+			MaxConn:            10,
+			AutoMigrate:        true,
+			TrashRetentionDays: 30,
+			JournalMode:        "wal",
+			ForeignKeys:        true,
+			BusyRetries:        4,
 		},
 		HTTP: HTTPConfig{
 			Host:            "0.0.0.0",
@@ -189,6 +277,34 @@ func DefaultConfig() *Config {
 			MaxRooms:        50,
 			MaxClients:      500,
 		},
+		Retention: RetentionConfig{
+			PruneInterval:     60,
+			ChatMessagesDays:  90,
+			NotificationsDays: 30,
+			LogMaxSizeMB:      10,
+			LogMaxBackups:     5,
+		},
+		Rankings: RankingsConfig{
+			RefreshInterval: 10,
+		},
+		Recommendations: RecommendationsConfig{
+			RefreshInterval: 60,
+		},
+		Storage: StorageConfig{
+			DataDir:        filepath.Join(os.ExpandEnv("$HOME"), ".mangahub"),
+			MaxCoverSizeMB: 10,
+		},
+		Catalog: CatalogConfig{
+			Providers:     []string{"local", "mangadex"},
+			LocalDir:      "data",
+			Language:      "en",
+			WatchInterval: 30,
+			MaxBackoff:    360,
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15,
+			RefreshTokenTTL: 30,
+		},
 	}
 }
 
@@ -0,0 +1,267 @@
+// Package covers stores manga cover images on disk under the configured
+// data directory, together with resized copies for lists and thumbnails.
+// Uploads and MangaDex imports go through the same Store.
+package covers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	// Register the decoders of the accepted upload formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Cover sizes. Every size is stored as a JPEG.
+const (
+	SizeOriginal = "original"
+	SizeMedium   = "medium"
+	SizeThumb    = "thumb"
+)
+
+// DefaultMaxBytes is the largest accepted cover file when none is configured
+const DefaultMaxBytes = 10 << 20
+
+const (
+	// maxPixels bounds the decoded size of a cover, so a small file cannot
+	// expand into a huge image
+	maxPixels = 40_000_000
+
+	// jpegQuality is the quality every size is encoded with
+	jpegQuality = 85
+)
+
+// widths are the widths of each size; larger images are scaled down to
+// them, keeping their aspect ratio
+var widths = map[string]int{
+	SizeOriginal: 1600,
+	SizeMedium:   512,
+	SizeThumb:    256,
+}
+
+var (
+	// ErrInvalidImage is returned for files that are not a JPEG, PNG or GIF
+	// image
+	ErrInvalidImage = errors.New("unsupported or corrupt image")
+
+	// ErrTooLarge is returned for files or images past the size limits
+	ErrTooLarge = errors.New("cover image too large")
+
+	// ErrInvalidSize is returned for unknown cover sizes
+	ErrInvalidSize = errors.New("invalid cover size")
+
+	// ErrNotFound is returned when a manga has no stored cover
+	ErrNotFound = errors.New("cover not found")
+)
+
+// Store keeps covers in <data dir>/covers/<manga id>/<size>.jpg
+type Store struct {
+	dir      string
+	maxBytes int64
+	client   *http.Client
+}
+
+// NewStore creates a cover store under the data directory. maxBytes is
+// the largest accepted file, DefaultMaxBytes when 0.
+func NewStore(dataDir string, maxBytes int64) *Store {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	return &Store{
+		dir:      filepath.Join(dataDir, "covers"),
+		maxBytes: maxBytes,
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// URL is the path the API server serves a manga's cover at
+func URL(mangaID string) string {
+	return "/manga/" + mangaID + "/cover"
+}
+
+// Save decodes a cover image and stores it in every size, replacing the
+// manga's previous cover
+func (s *Store) Save(mangaID string, r io.Reader) error {
+	dir, err := s.mangaDir(mangaID)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read cover: %w", err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		return fmt.Errorf("%w: larger than %d bytes", ErrTooLarge, s.maxBytes)
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return ErrInvalidImage
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return fmt.Errorf("%w: %dx%d pixels", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return ErrInvalidImage
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cover directory: %w", err)
+	}
+	flat := flatten(img)
+	for _, size := range []string{SizeOriginal, SizeMedium, SizeThumb} {
+		if err := writeJPEG(filepath.Join(dir, size+".jpg"), resize(flat, widths[size])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Download fetches a cover from a URL and stores it like Save
+func (s *Store) Download(mangaID, url string) error {
+	resp, err := s.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download cover: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download cover: HTTP %d", resp.StatusCode)
+	}
+	return s.Save(mangaID, resp.Body)
+}
+
+// Open opens a stored cover in the given size. The caller closes the file.
+func (s *Store) Open(mangaID, size string) (*os.File, os.FileInfo, error) {
+	if _, ok := widths[size]; !ok {
+		return nil, nil, fmt.Errorf("%w: %q (use original, medium or thumb)", ErrInvalidSize, size)
+	}
+	dir, err := s.mangaDir(mangaID)
+	if err != nil {
+		return nil, nil, ErrNotFound
+	}
+
+	f, err := os.Open(filepath.Join(dir, size+".jpg"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// Delete removes every size of a manga's cover. Missing covers are not an
+// error.
+func (s *Store) Delete(mangaID string) error {
+	dir, err := s.mangaDir(mangaID)
+	if err != nil {
+		return nil
+	}
+	return os.RemoveAll(dir)
+}
+
+// mangaDir is the directory of a manga's covers. IDs that could escape the
+// covers directory are rejected.
+func (s *Store) mangaDir(mangaID string) (string, error) {
+	if mangaID == "" || mangaID == "." || mangaID == ".." || strings.ContainsAny(mangaID, `/\`) {
+		return "", fmt.Errorf("invalid manga ID %q", mangaID)
+	}
+	return filepath.Join(s.dir, mangaID), nil
+}
+
+// writeJPEG encodes an image to a temporary file and renames it into
+// place, so readers never see a partly written cover
+func writeJPEG(path string, img image.Image) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".cover-*.jpg")
+	if err != nil {
+		return fmt.Errorf("failed to store cover: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := jpeg.Encode(tmp, img, &jpeg.Options{Quality: jpegQuality}); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to encode cover: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to store cover: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to store cover: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store cover: %w", err)
+	}
+	return nil
+}
+
+// flatten draws an image over a white background, since JPEG has no
+// transparency
+func flatten(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Over)
+	return dst
+}
+
+// resize scales an image down to the given width by averaging the source
+// pixels under each destination pixel. Narrower images are returned as
+// they are.
+func resize(src *image.RGBA, width int) *image.RGBA {
+	sw, sh := src.Bounds().Dx(), src.Bounds().Dy()
+	if sw <= width {
+		return src
+	}
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += int(p[0])
+					g += int(p[1])
+					b += int(p[2])
+					a += int(p[3])
+					n++
+				}
+			}
+			i := dst.PixOffset(x, y)
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"fmt"
+	"os"
+)
+
+// BackupTo writes a consistent snapshot of the database to dest with
+// VACUUM INTO. The snapshot is taken inside a read transaction, so it is safe
+// while other connections and processes keep writing. dest must not exist.
+func (d *Database) BackupTo(dest string) error {
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("backup destination %s already exists", dest)
+	}
+	if _, err := d.DB.Exec(`VACUUM INTO ?`, dest); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return nil
+}
+
+// IntegrityCheck runs PRAGMA integrity_check and returns the problems it
+// reports, or nil when the database is intact
+func (d *Database) IntegrityCheck() ([]string, error) {
+	rows, err := d.DB.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"mangahub/pkg/models"
+)
+
+// genreTx is the part of *sql.Tx the genre helpers need
+type genreTx interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// seedGenres inserts the default taxonomy. Every slug is also stored as an
+// alias of itself so lookups only ever consult genre_aliases.
+func seedGenres(tx *sql.Tx) error {
+	for _, g := range models.DefaultGenres {
+		result, err := tx.Exec(`INSERT INTO genres (slug, name, kind) VALUES (?, ?, ?)`, g.Slug, g.Name, g.Kind)
+		if err != nil {
+			return fmt.Errorf("failed to seed genre %s: %w", g.Slug, err)
+		}
+		id, _ := result.LastInsertId()
+		for _, alias := range append([]string{g.Slug}, g.Aliases...) {
+			if _, err := tx.Exec(`INSERT OR IGNORE INTO genre_aliases (alias, genre_id) VALUES (?, ?)`, models.GenreSlug(alias), id); err != nil {
+				return fmt.Errorf("failed to seed genre alias %s: %w", alias, err)
+			}
+		}
+	}
+	return RebuildMangaGenres(tx)
+}
+
+// ResolveGenre returns the ID and canonical name of the genre a name or
+// alias refers to, adding unknown names to the taxonomy as tags. The ID is
+// 0 for names without letters or digits.
+func ResolveGenre(tx genreTx, name string) (int64, string, error) {
+	slug := models.GenreSlug(name)
+	if slug == "" {
+		return 0, "", nil
+	}
+
+	var id int64
+	var canonical string
+	err := tx.QueryRow(`
+		SELECT g.id, g.name FROM genre_aliases a JOIN genres g ON g.id = a.genre_id
+		WHERE a.alias = ?`, slug).Scan(&id, &canonical)
+	if err == nil {
+		return id, canonical, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, "", err
+	}
+
+	canonical = models.GenreName(name)
+	result, err := tx.Exec(`INSERT INTO genres (slug, name, kind) VALUES (?, ?, ?)`, slug, canonical, models.GenreKindTag)
+	if err != nil {
+		return 0, "", err
+	}
+	id, _ = result.LastInsertId()
+	if _, err := tx.Exec(`INSERT INTO genre_aliases (alias, genre_id) VALUES (?, ?)`, slug, id); err != nil {
+		return 0, "", err
+	}
+	return id, canonical, nil
+}
+
+// SyncMangaGenres replaces a manga's manga_genres rows with the given genre
+// names and rewrites manga.genres as a JSON array of their canonical names.
+// It returns the canonical names in the order given, without duplicates.
+func SyncMangaGenres(tx genreTx, mangaID string, names []string) ([]string, error) {
+	if _, err := tx.Exec(`DELETE FROM manga_genres WHERE manga_id = ?`, mangaID); err != nil {
+		return nil, err
+	}
+
+	canonical := []string{}
+	seen := make(map[int64]bool)
+	for _, name := range names {
+		id, genre, err := ResolveGenre(tx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve genre %q: %w", name, err)
+		}
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		canonical = append(canonical, genre)
+		if _, err := tx.Exec(`INSERT INTO manga_genres (manga_id, genre_id) VALUES (?, ?)`, mangaID, id); err != nil {
+			return nil, err
+		}
+	}
+
+	data, _ := json.Marshal(canonical)
+	if _, err := tx.Exec(`UPDATE manga SET genres = ? WHERE id = ?`, string(data), mangaID); err != nil {
+		return nil, err
+	}
+	return canonical, nil
+}
+
+// RebuildMangaGenres re-derives manga_genres from the manga.genres column
+// of every manga, for data written without going through the stores such
+// as the output of scripts/load_data.go
+func RebuildMangaGenres(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT id, COALESCE(genres, '') FROM manga`)
+	if err != nil {
+		return err
+	}
+
+	genres := make(map[string][]string)
+	var ids []string
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+		genres[id] = models.ParseGenres(value)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if _, err := SyncMangaGenres(tx, id, genres[id]); err != nil {
+			return fmt.Errorf("failed to migrate genres of %s: %w", id, err)
+		}
+	}
+	return nil
+}
@@ -1,67 +1,404 @@
 package database
 
 import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"time"
 )
 
-// Migration represents a database migration
+var (
+	// ErrSchemaTooNew is returned when the database has migrations applied
+	// that are newer than any migration this binary knows about.
+	ErrSchemaTooNew = errors.New("database schema is newer than this binary")
+
+	// ErrUnknownMigration is returned when the database records a migration
+	// version that is not registered with the migrator.
+	ErrUnknownMigration = errors.New("database has an unknown migration applied")
+
+	// ErrChecksumMismatch is returned when an applied migration no longer
+	// matches the registered definition.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+	// ErrPendingMigrations is returned by Database.Init when migrations are
+	// pending and automatic migration is off.
+	ErrPendingMigrations = errors.New("database has pending migrations")
+)
+
+// Migration represents a versioned database migration.
+// Up and Down are plain SQL scripts. UpFunc and DownFunc are optional and run
+// in the same transaction after the SQL, for steps that depend on the
+// current shape of the database.
+//
+// The checksum cannot cover the code of UpFunc and DownFunc, so a change to
+// either must bump Revision, which the checksum does cover.
 type Migration struct {
-	Version string
-	Up      func(*Database) error
-	Down    func(*Database) error
+	Version  int
+	Name     string
+	Revision int
+	Up       string
+	Down     string
+	UpFunc   func(tx *sql.Tx) error
+	DownFunc func(tx *sql.Tx) error
+}
+
+// Checksum returns a stable checksum of the migration definition. Revision
+// 0 adds nothing, so migrations that never had one keep their checksum.
+func (m Migration) Checksum() string {
+	h := sha256.New()
+	h.Write([]byte(strconv.Itoa(m.Version)))
+	h.Write([]byte{0})
+	h.Write([]byte(m.Name))
+	h.Write([]byte{0})
+	h.Write([]byte(m.Up))
+	h.Write([]byte{0})
+	h.Write([]byte(m.Down))
+	if m.Revision > 0 {
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.Itoa(m.Revision)))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// MigrationStatus describes the state of a single migration
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Checksum  string     `json:"checksum"`
+	// State is one of "applied", "pending", "modified" (checksum differs)
+	// or "unknown" (recorded in the database but not registered).
+	State string `json:"state"`
 }
 
 // Migrator handles database migrations
 type Migrator struct {
 	db         *Database
 	migrations []Migration
+	logf       func(format string, args ...interface{})
 }
 
-// NewMigrator creates a new migrator
+// NewMigrator creates a new migrator with the built-in schema migrations registered
 func NewMigrator(db *Database) *Migrator {
-	return &Migrator{
-		db:         db,
-		migrations: []Migration{},
+	m := &Migrator{db: db, logf: log.Printf}
+	for _, migration := range schemaMigrations {
+		m.Register(migration)
 	}
+	return m
 }
 
-// Register registers a migration
-func (m *Migrator) Register(version string, up, down func(*Database) error) {
-	m.migrations = append(m.migrations, Migration{
-		Version: version,
-		Up:      up,
-		Down:    down,
+// Register registers a migration, keeping migrations ordered by version
+func (m *Migrator) Register(migration Migration) {
+	m.migrations = append(m.migrations, migration)
+	sort.SliceStable(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
 	})
 }
 
-// RunUp runs all pending migrations
-func (m *Migrator) RunUp() error {
-	log.Println("Running migrations...")
+// Migrations returns the registered migrations in version order
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// LatestVersion returns the highest registered migration version
+func (m *Migrator) LatestVersion() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// ensureTable creates the schema_migrations bookkeeping table
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	return nil
+}
+
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	appliedAt time.Time
+}
 
+// applied returns the migrations recorded in schema_migrations keyed by version
+func (m *Migrator) applied() (map[int]appliedMigration, error) {
+	if err := m.ensureTable(); err != nil {
+		return nil, err
+	}
+
+	rows, err := m.db.Query(`SELECT version, name, checksum, applied_at FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[int]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan schema_migrations: %w", err)
+		}
+		result[a.version] = a
+	}
+	return result, rows.Err()
+}
+
+// CurrentVersion returns the highest applied migration version
+func (m *Migrator) CurrentVersion() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Status reports every registered migration plus any unknown applied versions
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []MigrationStatus
+	known := make(map[int]bool)
 	for _, migration := range m.migrations {
-		log.Printf("Running migration: %s", migration.Version)
-		if err := migration.Up(m.db); err != nil {
-			return fmt.Errorf("migration %s failed: %w", migration.Version, err)
+		known[migration.Version] = true
+		st := MigrationStatus{
+			Version:  migration.Version,
+			Name:     migration.Name,
+			Checksum: migration.Checksum(),
+			State:    "pending",
+		}
+		if a, ok := applied[migration.Version]; ok {
+			appliedAt := a.appliedAt
+			st.Applied = true
+			st.AppliedAt = &appliedAt
+			st.State = "applied"
+			if a.checksum != st.Checksum {
+				st.State = "modified"
+			}
+		}
+		statuses = append(statuses, st)
+	}
+
+	for version, a := range applied {
+		if known[version] {
+			continue
 		}
+		appliedAt := a.appliedAt
+		statuses = append(statuses, MigrationStatus{
+			Version:   version,
+			Name:      a.name,
+			Applied:   true,
+			AppliedAt: &appliedAt,
+			Checksum:  a.checksum,
+			State:     "unknown",
+		})
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+	return statuses, nil
+}
+
+// Pending returns the registered migrations not yet applied, in version order
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Verify checks that the database contains no migrations this binary does
+// not know about and that applied migrations match their definitions
+func (m *Migrator) Verify() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
 	}
 
-	log.Println("Migrations completed successfully")
+	latest := m.LatestVersion()
+	for _, st := range statuses {
+		switch st.State {
+		case "unknown":
+			if st.Version > latest {
+				return fmt.Errorf("%w: database is at version %d, binary supports up to %d", ErrSchemaTooNew, st.Version, latest)
+			}
+			return fmt.Errorf("%w: version %d (%s)", ErrUnknownMigration, st.Version, st.Name)
+		case "modified":
+			return fmt.Errorf("%w: version %d (%s)", ErrChecksumMismatch, st.Version, st.Name)
+		}
+	}
 	return nil
 }
 
-// RunDown rolls back all migrations
-func (m *Migrator) RunDown() error {
-	log.Println("Rolling back migrations...")
+// RunUp applies pending migrations up to and including target.
+// A target of 0 or less applies every pending migration.
+func (m *Migrator) RunUp(target int) ([]Migration, error) {
+	if err := m.Verify(); err != nil {
+		return nil, err
+	}
+	if target <= 0 {
+		target = m.LatestVersion()
+	}
 
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, migration := range m.migrations {
+		if migration.Version > target {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		m.logf("Applying migration %d: %s", migration.Version, migration.Name)
+		ok, err := m.apply(migration)
+		if err != nil {
+			return ran, fmt.Errorf("migration %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		if ok {
+			ran = append(ran, migration)
+		}
+	}
+
+	return ran, nil
+}
+
+// RunDown rolls back applied migrations newer than target.
+// A target of 0 rolls back every migration.
+func (m *Migrator) RunDown(target int) ([]Migration, error) {
+	if err := m.Verify(); err != nil {
+		return nil, err
+	}
+	if target < 0 {
+		return nil, fmt.Errorf("invalid target version %d", target)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
 	for i := len(m.migrations) - 1; i >= 0; i-- {
 		migration := m.migrations[i]
-		log.Printf("Rolling back migration: %s", migration.Version)
-		if err := migration.Down(m.db); err != nil {
-			return fmt.Errorf("rollback %s failed: %w", migration.Version, err)
+		if migration.Version <= target {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
 		}
+
+		m.logf("Rolling back migration %d: %s", migration.Version, migration.Name)
+		if err := m.revert(migration); err != nil {
+			return ran, fmt.Errorf("rollback %d (%s) failed: %w", migration.Version, migration.Name, err)
+		}
+		ran = append(ran, migration)
 	}
 
-	log.Println("Rollback completed successfully")
-	return nil
+	return ran, nil
+}
+
+// apply runs a migration's up steps and records it in one transaction. It
+// reports false when another process applied the migration first, as
+// happens when several servers start against the same database.
+func (m *Migrator) apply(migration Migration) (bool, error) {
+	tx, err := m.db.BeginTx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var done int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, migration.Version).Scan(&done)
+	if err != nil {
+		return false, err
+	}
+	if done > 0 {
+		return false, nil
+	}
+
+	if migration.Up != "" {
+		if _, err := tx.Exec(migration.Up); err != nil {
+			return false, err
+		}
+	}
+	if migration.UpFunc != nil {
+		if err := migration.UpFunc(tx); err != nil {
+			return false, err
+		}
+	}
+
+	_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES (?, ?, ?, ?)`,
+		migration.Version, migration.Name, migration.Checksum(), time.Now())
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// revert runs a migration's down steps and removes its record in one transaction
+func (m *Migrator) revert(migration Migration) error {
+	if migration.Down == "" && migration.DownFunc == nil {
+		return fmt.Errorf("migration is irreversible")
+	}
+
+	tx, err := m.db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if migration.DownFunc != nil {
+		if err := migration.DownFunc(tx); err != nil {
+			return err
+		}
+	}
+	if migration.Down != "" {
+		if _, err := tx.Exec(migration.Down); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, migration.Version); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
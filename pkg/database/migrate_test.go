@@ -0,0 +1,323 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testMigrations create a widgets table, give it a color column and seed
+// it from a func, skipping version 3 so a gap is left to record unknown
+// migrations in
+func testMigrations() []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "create_widgets",
+			Up:      `CREATE TABLE widgets (id INTEGER PRIMARY KEY, name TEXT NOT NULL)`,
+			Down:    `DROP TABLE widgets`,
+		},
+		{
+			Version: 2,
+			Name:    "add_widget_color",
+			Up:      `ALTER TABLE widgets ADD COLUMN color TEXT`,
+			Down:    `ALTER TABLE widgets DROP COLUMN color`,
+		},
+		{
+			Version: 4,
+			Name:    "seed_widgets",
+			UpFunc: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`INSERT INTO widgets (name, color) VALUES ('sprocket', 'red')`)
+				return err
+			},
+			DownFunc: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`DELETE FROM widgets WHERE name = 'sprocket'`)
+				return err
+			},
+		},
+	}
+}
+
+func newTestDatabase(t *testing.T) *Database {
+	t.Helper()
+	db, err := New(filepath.Join(t.TempDir(), "mangahub.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// newTestMigrator returns a migrator over db with only migrations registered
+func newTestMigrator(t *testing.T, db *Database, migrations []Migration) *Migrator {
+	m := &Migrator{db: db, logf: t.Logf}
+	for _, migration := range migrations {
+		m.Register(migration)
+	}
+	return m
+}
+
+func versions(migrations []Migration) []int {
+	var result []int
+	for _, m := range migrations {
+		result = append(result, m.Version)
+	}
+	return result
+}
+
+func countWidgets(t *testing.T, db *Database) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM widgets`).Scan(&n); err != nil {
+		t.Fatalf("count widgets: %v", err)
+	}
+	return n
+}
+
+func tableExists(t *testing.T, db *Database, name string) bool {
+	t.Helper()
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&n)
+	if err != nil {
+		t.Fatalf("look up table %s: %v", name, err)
+	}
+	return n > 0
+}
+
+func TestMigratorRunUp(t *testing.T) {
+	db := newTestDatabase(t)
+	m := newTestMigrator(t, db, testMigrations())
+
+	ran, err := m.RunUp(2)
+	if err != nil {
+		t.Fatalf("run up to 2: %v", err)
+	}
+	if !slices.Equal(versions(ran), []int{1, 2}) {
+		t.Errorf("ran %v up to 2, want [1 2]", versions(ran))
+	}
+	pending, err := m.Pending()
+	if err != nil {
+		t.Fatalf("pending: %v", err)
+	}
+	if !slices.Equal(versions(pending), []int{4}) {
+		t.Errorf("pending = %v, want [4]", versions(pending))
+	}
+
+	ran, err = m.RunUp(0)
+	if err != nil {
+		t.Fatalf("run up: %v", err)
+	}
+	if !slices.Equal(versions(ran), []int{4}) {
+		t.Errorf("ran %v, want [4]", versions(ran))
+	}
+	if n := countWidgets(t, db); n != 1 {
+		t.Errorf("%d widgets after the seed migration, want 1", n)
+	}
+	if version, err := m.CurrentVersion(); err != nil || version != 4 {
+		t.Errorf("current version = %d, %v; want 4", version, err)
+	}
+
+	ran, err = m.RunUp(0)
+	if err != nil || len(ran) != 0 {
+		t.Errorf("run up again = %v, %v; want nothing applied", versions(ran), err)
+	}
+}
+
+func TestMigratorRoundTrip(t *testing.T) {
+	db := newTestDatabase(t)
+	m := newTestMigrator(t, db, testMigrations())
+	if _, err := m.RunUp(0); err != nil {
+		t.Fatalf("run up: %v", err)
+	}
+
+	ran, err := m.RunDown(1)
+	if err != nil {
+		t.Fatalf("run down to 1: %v", err)
+	}
+	if !slices.Equal(versions(ran), []int{4, 2}) {
+		t.Errorf("rolled back %v, want [4 2]", versions(ran))
+	}
+	if n := countWidgets(t, db); n != 0 {
+		t.Errorf("%d widgets after rolling back the seed, want 0", n)
+	}
+	if _, err := db.Exec(`INSERT INTO widgets (name, color) VALUES ('gear', 'blue')`); err == nil {
+		t.Errorf("color column still there after rolling back version 2")
+	}
+
+	ran, err = m.RunUp(0)
+	if err != nil {
+		t.Fatalf("run up again: %v", err)
+	}
+	if !slices.Equal(versions(ran), []int{2, 4}) {
+		t.Errorf("reapplied %v, want [2 4]", versions(ran))
+	}
+	if n := countWidgets(t, db); n != 1 {
+		t.Errorf("%d widgets after reapplying, want 1", n)
+	}
+
+	if _, err := m.RunDown(0); err != nil {
+		t.Fatalf("run down to 0: %v", err)
+	}
+	if tableExists(t, db, "widgets") {
+		t.Errorf("widgets table left after rolling back every migration")
+	}
+	if version, err := m.CurrentVersion(); err != nil || version != 0 {
+		t.Errorf("current version = %d, %v; want 0", version, err)
+	}
+}
+
+func TestMigratorRoundTripSchema(t *testing.T) {
+	db := newTestDatabase(t)
+	m := NewMigrator(db)
+	m.logf = t.Logf
+	if _, err := m.RunUp(0); err != nil {
+		t.Fatalf("run up: %v", err)
+	}
+
+	// The initial schema has no Down step, so everything after it goes
+	ran, err := m.RunDown(1)
+	if err != nil {
+		t.Fatalf("run down to 1: %v", err)
+	}
+	if len(ran) != m.LatestVersion()-1 {
+		t.Errorf("rolled back %v, want every migration after the first", versions(ran))
+	}
+	if _, err := m.RunDown(0); err == nil {
+		t.Errorf("rolled back the initial schema")
+	}
+
+	ran, err = m.RunUp(0)
+	if err != nil {
+		t.Fatalf("run up again: %v", err)
+	}
+	if len(ran) != m.LatestVersion()-1 {
+		t.Errorf("reapplied %v, want every migration after the first", versions(ran))
+	}
+	if err := m.Verify(); err != nil {
+		t.Errorf("verify after the round trip: %v", err)
+	}
+}
+
+func TestMigratorVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		// tamper changes the database or the registered migrations after
+		// they have all been applied
+		tamper  func(t *testing.T, db *Database, migrations []Migration) []Migration
+		wantErr error
+	}{
+		{
+			name: "up to date",
+			tamper: func(t *testing.T, db *Database, migrations []Migration) []Migration {
+				return migrations
+			},
+		},
+		{
+			name: "too new",
+			tamper: func(t *testing.T, db *Database, migrations []Migration) []Migration {
+				mustExec(t, db, `INSERT INTO schema_migrations (version, name, checksum) VALUES (5, 'from_the_future', 'x')`)
+				return migrations
+			},
+			wantErr: ErrSchemaTooNew,
+		},
+		{
+			name: "unknown migration",
+			tamper: func(t *testing.T, db *Database, migrations []Migration) []Migration {
+				mustExec(t, db, `INSERT INTO schema_migrations (version, name, checksum) VALUES (3, 'from_a_branch', 'x')`)
+				return migrations
+			},
+			wantErr: ErrUnknownMigration,
+		},
+		{
+			name: "checksum mismatch",
+			tamper: func(t *testing.T, db *Database, migrations []Migration) []Migration {
+				mustExec(t, db, `UPDATE schema_migrations SET checksum = 'edited' WHERE version = 2`)
+				return migrations
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "edited sql",
+			tamper: func(t *testing.T, db *Database, migrations []Migration) []Migration {
+				migrations[1].Up = `ALTER TABLE widgets ADD COLUMN colour TEXT`
+				return migrations
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+		{
+			name: "revision bumped",
+			tamper: func(t *testing.T, db *Database, migrations []Migration) []Migration {
+				migrations[2].Revision = 1
+				return migrations
+			},
+			wantErr: ErrChecksumMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newTestDatabase(t)
+			if _, err := newTestMigrator(t, db, testMigrations()).RunUp(0); err != nil {
+				t.Fatalf("run up: %v", err)
+			}
+
+			m := newTestMigrator(t, db, tt.tamper(t, db, testMigrations()))
+			err := m.Verify()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verify = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				return
+			}
+			if _, err := m.RunUp(0); !errors.Is(err, tt.wantErr) {
+				t.Errorf("run up = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := m.RunDown(0); !errors.Is(err, tt.wantErr) {
+				t.Errorf("run down = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestChecksumRevision(t *testing.T) {
+	m := testMigrations()[2]
+	unrevised := m.Checksum()
+	m.Revision = 1
+	if m.Checksum() == unrevised {
+		t.Errorf("revision 1 has the checksum of revision 0")
+	}
+	m.UpFunc = nil
+	m.Revision = 0
+	if m.Checksum() != unrevised {
+		t.Errorf("checksum changed with the func at revision 0")
+	}
+}
+
+func TestInitWithoutAutoMigrate(t *testing.T) {
+	db := newTestDatabase(t)
+	db.AutoMigrate = false
+
+	if err := db.Init(); !errors.Is(err, ErrPendingMigrations) {
+		t.Fatalf("init of an empty database = %v, want %v", err, ErrPendingMigrations)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != 0 {
+		t.Fatalf("schema version = %d, %v; want nothing applied", version, err)
+	}
+
+	db.AutoMigrate = true
+	if err := db.Init(); err != nil {
+		t.Fatalf("init with auto migrate: %v", err)
+	}
+	db.AutoMigrate = false
+	if err := db.Init(); err != nil {
+		t.Errorf("init of an up to date database: %v", err)
+	}
+}
+
+func mustExec(t *testing.T, db *Database, query string) {
+	t.Helper()
+	if _, err := db.Exec(query); err != nil {
+		t.Fatalf("exec %q: %v", query, err)
+	}
+}
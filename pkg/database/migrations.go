@@ -0,0 +1,708 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// schemaMigrations is the ordered list of built-in schema migrations.
+// Never edit a migration that has shipped; add a new version instead.
+// The checksum covers the SQL but not UpFunc or DownFunc, so a migration
+// whose func changes must bump its Revision for Verify to notice.
+// The initial schema has no Down step so it cannot be rolled back by accident.
+var schemaMigrations = []Migration{
+	{
+		Version: 1,
+		Name:    "initial_schema",
+		Up: `
+	CREATE TABLE IF NOT EXISTS users (
+		id TEXT PRIMARY KEY,
+		username TEXT UNIQUE NOT NULL,
+		email TEXT UNIQUE NOT NULL,
+		password_hash TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS manga (
+		id TEXT PRIMARY KEY,
+		title TEXT NOT NULL,
+		author TEXT,
+		genres TEXT,
+		status TEXT,
+		total_chapters INTEGER,
+		description TEXT,
+		cover_url TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	);
+
+	CREATE TABLE IF NOT EXISTS user_progress (
+		user_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL,
+		current_chapter INTEGER DEFAULT 0,
+		status TEXT DEFAULT 'plan-to-read',
+		rating INTEGER DEFAULT 0,
+		notes TEXT,
+		started_at TIMESTAMP,
+		completed_at TIMESTAMP,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, manga_id),
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (manga_id) REFERENCES manga(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS chat_messages (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		username TEXT NOT NULL,
+		room_id TEXT NOT NULL,
+		message TEXT NOT NULL,
+		timestamp INTEGER,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS notifications (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		type TEXT NOT NULL,
+		manga_id TEXT,
+		message TEXT NOT NULL,
+		read BOOLEAN DEFAULT 0,
+		data TEXT,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS notification_subscriptions (
+		user_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL,
+		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (user_id, manga_id),
+		FOREIGN KEY (user_id) REFERENCES users(id),
+		FOREIGN KEY (manga_id) REFERENCES manga(id)
+	);
+
+	CREATE TABLE IF NOT EXISTS notification_preferences (
+		user_id TEXT PRIMARY KEY,
+		chapter_releases BOOLEAN DEFAULT 1,
+		email_notifications BOOLEAN DEFAULT 1,
+		sound_enabled BOOLEAN DEFAULT 1,
+		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_user_progress_user ON user_progress(user_id);
+	CREATE INDEX IF NOT EXISTS idx_user_progress_manga ON user_progress(manga_id);
+	CREATE INDEX IF NOT EXISTS idx_chat_room ON chat_messages(room_id);
+	CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id);
+	CREATE INDEX IF NOT EXISTS idx_notification_subs_user ON notification_subscriptions(user_id);
+	`,
+	},
+	{
+		Version: 2,
+		Name:    "manga_catalog_columns",
+		Up: `
+	CREATE INDEX IF NOT EXISTS idx_manga_title ON manga(title);
+	CREATE INDEX IF NOT EXISTS idx_manga_author ON manga(author);
+	CREATE INDEX IF NOT EXISTS idx_manga_status ON manga(status);
+	CREATE INDEX IF NOT EXISTS idx_manga_genres ON manga(genres);
+	`,
+		UpFunc: migrateMangaCatalogColumns,
+		Down: `
+	DROP INDEX IF EXISTS idx_manga_title;
+	DROP INDEX IF EXISTS idx_manga_author;
+	DROP INDEX IF EXISTS idx_manga_status;
+	DROP INDEX IF EXISTS idx_manga_genres;
+	`,
+		DownFunc: func(tx *sql.Tx) error {
+			return renameColumn(tx, "manga", "chapters", "total_chapters")
+		},
+	},
+	{
+		// manga_fts is a standalone FTS5 index keyed by manga_id rather than
+		// an external-content table, because manga has a TEXT primary key and
+		// its rowids are not stable across VACUUM.
+		Version: 3,
+		Name:    "manga_fts",
+		Up: `
+	CREATE VIRTUAL TABLE IF NOT EXISTS manga_fts USING fts5(
+		manga_id UNINDEXED,
+		title,
+		author,
+		description,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+
+	INSERT INTO manga_fts (manga_id, title, author, description)
+	SELECT id, title, COALESCE(author, ''), COALESCE(description, '') FROM manga;
+
+	-- INSERT OR REPLACE does not fire delete triggers, so clear any stale row first
+	CREATE TRIGGER IF NOT EXISTS manga_fts_ai AFTER INSERT ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = new.id;
+		INSERT INTO manga_fts (manga_id, title, author, description)
+		VALUES (new.id, new.title, COALESCE(new.author, ''), COALESCE(new.description, ''));
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_ad AFTER DELETE ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = old.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_au AFTER UPDATE OF id, title, author, description ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = old.id;
+		INSERT INTO manga_fts (manga_id, title, author, description)
+		VALUES (new.id, new.title, COALESCE(new.author, ''), COALESCE(new.description, ''));
+	END;
+	`,
+		Down: `
+	DROP TRIGGER IF EXISTS manga_fts_au;
+	DROP TRIGGER IF EXISTS manga_fts_ad;
+	DROP TRIGGER IF EXISTS manga_fts_ai;
+	DROP TABLE IF EXISTS manga_fts;
+	`,
+	},
+	{
+		// progress_events is append-only. created_at is stored as UTC text in
+		// TimestampFormat so time-range filters can compare it as a string.
+		Version: 4,
+		Name:    "progress_events",
+		Up: `
+	CREATE TABLE IF NOT EXISTS progress_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL,
+		from_chapter INTEGER NOT NULL DEFAULT 0,
+		to_chapter INTEGER NOT NULL DEFAULT 0,
+		from_status TEXT,
+		to_status TEXT,
+		source TEXT NOT NULL,
+		device_id TEXT,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_progress_events_user_time ON progress_events(user_id, created_at);
+	CREATE INDEX IF NOT EXISTS idx_progress_events_manga ON progress_events(manga_id);
+
+	CREATE TRIGGER IF NOT EXISTS progress_events_no_update BEFORE UPDATE ON progress_events BEGIN
+		SELECT RAISE(ABORT, 'progress_events is append-only');
+	END;
+	`,
+		// Revision stays 0: the one edit to backfillProgressEvents skips
+		// entries of deleted users, which made the migration fail outright,
+		// so every database that applied it ran the same statements
+		UpFunc: backfillProgressEvents,
+		Down: `
+	DROP TRIGGER IF EXISTS progress_events_no_update;
+	DROP TABLE IF EXISTS progress_events;
+	`,
+	},
+	{
+		// Library entries and manga are moved to the trash by setting
+		// deleted_at (TimestampFormat) and purged after a retention period.
+		Version: 5,
+		Name:    "soft_delete",
+		Up: `
+	ALTER TABLE user_progress ADD COLUMN deleted_at TIMESTAMP;
+	ALTER TABLE manga ADD COLUMN deleted_at TIMESTAMP;
+
+	CREATE INDEX IF NOT EXISTS idx_user_progress_deleted ON user_progress(deleted_at);
+	CREATE INDEX IF NOT EXISTS idx_manga_deleted ON manga(deleted_at);
+	`,
+		Down: `
+	DROP INDEX IF EXISTS idx_manga_deleted;
+	DROP INDEX IF EXISTS idx_user_progress_deleted;
+	ALTER TABLE manga DROP COLUMN deleted_at;
+	ALTER TABLE user_progress DROP COLUMN deleted_at;
+	`,
+	},
+	{
+		// chapters holds per-chapter catalog data. Chapter numbers are REAL
+		// so extras like 10.5 fit, and the triggers keep manga.chapters at
+		// the highest chapter number once a manga has chapter rows.
+		// released_at, created_at and updated_at use TimestampFormat.
+		Version: 6,
+		Name:    "chapters",
+		Up: `
+	CREATE TABLE IF NOT EXISTS chapters (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		manga_id TEXT NOT NULL,
+		number REAL NOT NULL,
+		volume INTEGER,
+		title TEXT,
+		language TEXT NOT NULL DEFAULT 'en',
+		pages INTEGER,
+		released_at TIMESTAMP,
+		created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (manga_id, language, number),
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_chapters_manga_number ON chapters(manga_id, number);
+	CREATE INDEX IF NOT EXISTS idx_chapters_released ON chapters(released_at);
+
+	CREATE TRIGGER IF NOT EXISTS chapters_total_ai AFTER INSERT ON chapters BEGIN
+		UPDATE manga SET chapters = (SELECT CAST(MAX(number) AS INTEGER) FROM chapters WHERE manga_id = new.manga_id)
+		WHERE id = new.manga_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS chapters_total_au AFTER UPDATE OF manga_id, number ON chapters BEGIN
+		UPDATE manga SET chapters = COALESCE((SELECT CAST(MAX(number) AS INTEGER) FROM chapters WHERE manga_id = old.manga_id), 0)
+		WHERE id = old.manga_id;
+		UPDATE manga SET chapters = (SELECT CAST(MAX(number) AS INTEGER) FROM chapters WHERE manga_id = new.manga_id)
+		WHERE id = new.manga_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS chapters_total_ad AFTER DELETE ON chapters BEGIN
+		UPDATE manga SET chapters = COALESCE((SELECT CAST(MAX(number) AS INTEGER) FROM chapters WHERE manga_id = old.manga_id), 0)
+		WHERE id = old.manga_id;
+	END;
+	`,
+		Down: `
+	DROP TRIGGER IF EXISTS chapters_total_ad;
+	DROP TRIGGER IF EXISTS chapters_total_au;
+	DROP TRIGGER IF EXISTS chapters_total_ai;
+	DROP TABLE IF EXISTS chapters;
+	`,
+	},
+	{
+		// genres is the normalized taxonomy behind manga.genres. Names and
+		// aliases resolve through genre_aliases keyed by models.GenreSlug,
+		// and manga.genres stays as a JSON array of canonical names that
+		// the stores rewrite whenever they write manga_genres.
+		Version: 7,
+		Name:    "genres",
+		Up: `
+	CREATE TABLE IF NOT EXISTS genres (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		slug TEXT UNIQUE NOT NULL,
+		name TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT 'tag'
+	);
+
+	CREATE TABLE IF NOT EXISTS genre_aliases (
+		alias TEXT PRIMARY KEY,
+		genre_id INTEGER NOT NULL,
+		FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS manga_genres (
+		manga_id TEXT NOT NULL,
+		genre_id INTEGER NOT NULL,
+		PRIMARY KEY (manga_id, genre_id),
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+		FOREIGN KEY (genre_id) REFERENCES genres(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_genre_aliases_genre ON genre_aliases(genre_id);
+	CREATE INDEX IF NOT EXISTS idx_manga_genres_genre ON manga_genres(genre_id);
+	`,
+		UpFunc: seedGenres,
+		Down: `
+	DROP TABLE IF EXISTS manga_genres;
+	DROP TABLE IF EXISTS genre_aliases;
+	DROP TABLE IF EXISTS genres;
+	`,
+	},
+	{
+		// manga_titles holds alternate and localized titles. manga_fts is
+		// rebuilt with an alt_titles column that the triggers keep in step
+		// with both tables, so text search matches every title.
+		// users.title_language is the language titles are shown in.
+		Version: 8,
+		Name:    "manga_titles",
+		Up: `
+	CREATE TABLE IF NOT EXISTS manga_titles (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		manga_id TEXT NOT NULL,
+		language TEXT NOT NULL,
+		title TEXT NOT NULL,
+		is_primary INTEGER NOT NULL DEFAULT 0,
+		UNIQUE (manga_id, language, title),
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_manga_titles_manga ON manga_titles(manga_id);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_manga_titles_primary ON manga_titles(manga_id, language) WHERE is_primary = 1;
+
+	ALTER TABLE users ADD COLUMN title_language TEXT;
+
+	DROP TRIGGER IF EXISTS manga_fts_au;
+	DROP TRIGGER IF EXISTS manga_fts_ad;
+	DROP TRIGGER IF EXISTS manga_fts_ai;
+	DROP TABLE IF EXISTS manga_fts;
+
+	CREATE VIRTUAL TABLE manga_fts USING fts5(
+		manga_id UNINDEXED,
+		title,
+		author,
+		description,
+		alt_titles,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+
+	INSERT INTO manga_fts (manga_id, title, author, description, alt_titles)
+	SELECT id, title, COALESCE(author, ''), COALESCE(description, ''), '' FROM manga;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_ai AFTER INSERT ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = new.id;
+		INSERT INTO manga_fts (manga_id, title, author, description, alt_titles)
+		VALUES (new.id, new.title, COALESCE(new.author, ''), COALESCE(new.description, ''),
+			COALESCE((SELECT group_concat(title, ' ') FROM manga_titles WHERE manga_id = new.id), ''));
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_ad AFTER DELETE ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = old.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_au AFTER UPDATE OF id, title, author, description ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = old.id;
+		INSERT INTO manga_fts (manga_id, title, author, description, alt_titles)
+		VALUES (new.id, new.title, COALESCE(new.author, ''), COALESCE(new.description, ''),
+			COALESCE((SELECT group_concat(title, ' ') FROM manga_titles WHERE manga_id = new.id), ''));
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_titles_fts_ai AFTER INSERT ON manga_titles BEGIN
+		UPDATE manga_fts SET alt_titles = COALESCE((SELECT group_concat(title, ' ') FROM manga_titles WHERE manga_id = new.manga_id), '')
+		WHERE manga_id = new.manga_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_titles_fts_au AFTER UPDATE ON manga_titles BEGIN
+		UPDATE manga_fts SET alt_titles = COALESCE((SELECT group_concat(title, ' ') FROM manga_titles WHERE manga_id = old.manga_id), '')
+		WHERE manga_id = old.manga_id;
+		UPDATE manga_fts SET alt_titles = COALESCE((SELECT group_concat(title, ' ') FROM manga_titles WHERE manga_id = new.manga_id), '')
+		WHERE manga_id = new.manga_id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_titles_fts_ad AFTER DELETE ON manga_titles BEGIN
+		UPDATE manga_fts SET alt_titles = COALESCE((SELECT group_concat(title, ' ') FROM manga_titles WHERE manga_id = old.manga_id), '')
+		WHERE manga_id = old.manga_id;
+	END;
+	`,
+		Down: `
+	DROP TRIGGER IF EXISTS manga_titles_fts_ad;
+	DROP TRIGGER IF EXISTS manga_titles_fts_au;
+	DROP TRIGGER IF EXISTS manga_titles_fts_ai;
+	DROP TRIGGER IF EXISTS manga_fts_au;
+	DROP TRIGGER IF EXISTS manga_fts_ad;
+	DROP TRIGGER IF EXISTS manga_fts_ai;
+	DROP TABLE IF EXISTS manga_fts;
+
+	CREATE VIRTUAL TABLE manga_fts USING fts5(
+		manga_id UNINDEXED,
+		title,
+		author,
+		description,
+		tokenize = 'unicode61 remove_diacritics 2'
+	);
+
+	INSERT INTO manga_fts (manga_id, title, author, description)
+	SELECT id, title, COALESCE(author, ''), COALESCE(description, '') FROM manga;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_ai AFTER INSERT ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = new.id;
+		INSERT INTO manga_fts (manga_id, title, author, description)
+		VALUES (new.id, new.title, COALESCE(new.author, ''), COALESCE(new.description, ''));
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_ad AFTER DELETE ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = old.id;
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS manga_fts_au AFTER UPDATE OF id, title, author, description ON manga BEGIN
+		DELETE FROM manga_fts WHERE manga_id = old.id;
+		INSERT INTO manga_fts (manga_id, title, author, description)
+		VALUES (new.id, new.title, COALESCE(new.author, ''), COALESCE(new.description, ''));
+	END;
+
+	ALTER TABLE users DROP COLUMN title_language;
+	DROP TABLE IF EXISTS manga_titles;
+	`,
+	},
+	{
+		// manga_similarity holds the similar manga of each manga, computed
+		// in batch from genre overlap and co-reading so recommendation
+		// requests only read it
+		Version: 9,
+		Name:    "manga_similarity",
+		Up: `
+	CREATE TABLE IF NOT EXISTS manga_similarity (
+		manga_id TEXT NOT NULL,
+		similar_id TEXT NOT NULL,
+		score REAL NOT NULL,
+		genre_score REAL NOT NULL DEFAULT 0,
+		coread_score REAL NOT NULL DEFAULT 0,
+		computed_at DATETIME NOT NULL,
+		PRIMARY KEY (manga_id, similar_id),
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE,
+		FOREIGN KEY (similar_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_manga_similarity_score ON manga_similarity(manga_id, score DESC);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS manga_similarity;
+	`,
+	},
+	{
+		// manga_external_ids links catalog manga to their IDs at outside
+		// catalogs so importers update a manga instead of adding it again.
+		// Manga loaded from `mangahub manga dex` exports use their MangaDex
+		// UUID as their own ID and are linked to it.
+		Version: 10,
+		Name:    "manga_external_ids",
+		Up: `
+	CREATE TABLE IF NOT EXISTS manga_external_ids (
+		source TEXT NOT NULL,
+		external_id TEXT NOT NULL,
+		manga_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (source, external_id),
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_manga_external_ids_manga ON manga_external_ids(manga_id);
+
+	INSERT OR IGNORE INTO manga_external_ids (source, external_id, manga_id)
+	SELECT 'mangadex', id, id FROM manga
+	WHERE length(id) = 36 AND id GLOB '[0-9a-f]*-[0-9a-f]*-[0-9a-f]*-[0-9a-f]*-[0-9a-f]*';
+	`,
+		Down: `
+	DROP TABLE IF EXISTS manga_external_ids;
+	`,
+	},
+	{
+		// manga_external_ids.data keeps the latest entry of each source as
+		// JSON so the catalog can merge the sources again, and
+		// manga_field_sources records which source supplied each field of a
+		// manga after the last merge
+		Version: 11,
+		Name:    "catalog_sources",
+		Up: `
+	ALTER TABLE manga_external_ids ADD COLUMN data TEXT;
+	ALTER TABLE manga_external_ids ADD COLUMN fetched_at DATETIME;
+
+	CREATE TABLE IF NOT EXISTS manga_field_sources (
+		manga_id TEXT NOT NULL,
+		field TEXT NOT NULL,
+		source TEXT NOT NULL,
+		PRIMARY KEY (manga_id, field),
+		FOREIGN KEY (manga_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS manga_field_sources;
+	ALTER TABLE manga_external_ids DROP COLUMN fetched_at;
+	ALTER TABLE manga_external_ids DROP COLUMN data;
+	`,
+	},
+	{
+		// manga_redirects remembers the IDs of duplicate manga merged into
+		// another by `mangahub admin manga merge`, so clients still holding
+		// a dropped ID are sent to the manga that was kept
+		Version: 12,
+		Name:    "manga_redirects",
+		Up: `
+	CREATE TABLE IF NOT EXISTS manga_redirects (
+		from_id TEXT PRIMARY KEY,
+		to_id TEXT NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (to_id) REFERENCES manga(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_manga_redirects_to ON manga_redirects(to_id);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS manga_redirects;
+	`,
+	},
+	{
+		// users.role is what the account may do beyond its own library:
+		// "user", "moderator" or "admin"
+		Version: 13,
+		Name:    "user_roles",
+		Up: `
+	ALTER TABLE users ADD COLUMN role TEXT NOT NULL DEFAULT 'user';
+
+	CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+	`,
+		Down: `
+	DROP INDEX IF EXISTS idx_users_role;
+	ALTER TABLE users DROP COLUMN role;
+	`,
+	},
+	{
+		// sessions are logins, one per device. Refresh tokens are stored as
+		// SHA-256 hashes; previous_hash is the token a refresh replaced,
+		// kept to spot a stolen copy coming back. Times use TimestampFormat.
+		Version: 14,
+		Name:    "sessions",
+		Up: `
+	CREATE TABLE IF NOT EXISTS sessions (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		refresh_hash TEXT NOT NULL UNIQUE,
+		previous_hash TEXT,
+		device TEXT,
+		ip TEXT,
+		created_at TIMESTAMP NOT NULL,
+		last_used_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		revoked_at TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id);
+	CREATE INDEX IF NOT EXISTS idx_sessions_previous_hash ON sessions(previous_hash);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS sessions;
+	`,
+	},
+	{
+		// access_tokens are personal access tokens for scripts and bots,
+		// stored as SHA-256 hashes with the first characters kept as a hint.
+		// scopes is a space-separated list. Times use TimestampFormat.
+		Version: 15,
+		Name:    "access_tokens",
+		Up: `
+	CREATE TABLE IF NOT EXISTS access_tokens (
+		id TEXT PRIMARY KEY,
+		user_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		hint TEXT NOT NULL,
+		scopes TEXT NOT NULL,
+		created_at TIMESTAMP NOT NULL,
+		expires_at TIMESTAMP NOT NULL,
+		last_used_at TIMESTAMP,
+		revoked_at TIMESTAMP,
+		FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_access_tokens_user ON access_tokens(user_id);
+	`,
+		Down: `
+	DROP TABLE IF EXISTS access_tokens;
+	`,
+	},
+}
+
+// migrateMangaCatalogColumns aligns the manga table with what the manga
+// service and the data loader use: the chapter count lives in "chapters"
+// and the catalog carries artist, volumes, year, rating and source.
+func migrateMangaCatalogColumns(tx *sql.Tx) error {
+	if err := renameColumn(tx, "manga", "total_chapters", "chapters"); err != nil {
+		return err
+	}
+
+	columns := []struct{ name, def string }{
+		{"chapters", "INTEGER"},
+		{"artist", "TEXT"},
+		{"volumes", "INTEGER"},
+		{"year", "INTEGER"},
+		{"rating", "REAL"},
+		{"source", "TEXT"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, "manga", c.name, c.def); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TimestampFormat is the sortable UTC layout used for timestamp columns that
+// are compared in SQL: progress_events.created_at and the deleted_at columns
+const TimestampFormat = "2006-01-02 15:04:05.000"
+
+// backfillProgressEvents records one event per existing library entry so
+// history and streaks have a starting point
+func backfillProgressEvents(tx *sql.Tx) error {
+	// Entries of users that no longer exist cannot satisfy the foreign key
+	rows, err := tx.Query(`
+		SELECT user_id, manga_id, COALESCE(current_chapter, 0), COALESCE(status, ''), updated_at, started_at
+		FROM user_progress WHERE user_id IN (SELECT id FROM users)`)
+	if err != nil {
+		return err
+	}
+
+	type entry struct {
+		userID, mangaID, status string
+		chapter                 int
+		at                      time.Time
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		var updatedAt, startedAt sql.NullTime
+		if err := rows.Scan(&e.userID, &e.mangaID, &e.chapter, &e.status, &updatedAt, &startedAt); err != nil {
+			rows.Close()
+			return err
+		}
+		switch {
+		case updatedAt.Valid:
+			e.at = updatedAt.Time
+		case startedAt.Valid:
+			e.at = startedAt.Time
+		default:
+			e.at = time.Now()
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		_, err := tx.Exec(`
+			INSERT INTO progress_events (user_id, manga_id, from_chapter, to_chapter, to_status, source, created_at)
+			VALUES (?, ?, 0, ?, ?, 'backfill', ?)`,
+			e.userID, e.mangaID, e.chapter, e.status, e.at.UTC().Format(TimestampFormat))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// columnExists reports whether a table has the given column
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to inspect %s.%s: %w", table, column, err)
+	}
+	return count > 0, nil
+}
+
+// renameColumn renames a column when the old name exists and the new one does not
+func renameColumn(tx *sql.Tx, table, from, to string) error {
+	hasFrom, err := columnExists(tx, table, from)
+	if err != nil {
+		return err
+	}
+	hasTo, err := columnExists(tx, table, to)
+	if err != nil {
+		return err
+	}
+	if !hasFrom || hasTo {
+		return nil
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s RENAME COLUMN %s TO %s`, table, from, to))
+	return err
+}
+
+// addColumnIfMissing adds a column when the table does not already have it
+func addColumnIfMissing(tx *sql.Tx, table, column, def string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`, table, column, def))
+	return err
+}
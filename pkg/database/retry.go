@@ -0,0 +1,77 @@
+package database
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// RetryPolicy bounds how operations that fail with SQLITE_BUSY or
+// SQLITE_LOCKED are retried. Delays double from BaseDelay up to MaxDelay
+// with up to 50% random jitter so competing processes fall out of step.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy retries a busy write four times over roughly a second,
+// on top of the time SQLite itself already waited in busy_timeout
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    500 * time.Millisecond,
+}
+
+// Do runs op until it succeeds, fails with a non-busy error, or the attempts
+// are used up. The zero policy runs op once.
+func (p RetryPolicy) Do(op func() error) error {
+	delay := p.BaseDelay
+	for attempt := 1; ; attempt++ {
+		err := op()
+		if err == nil || !IsBusy(err) || attempt >= p.MaxAttempts {
+			return err
+		}
+
+		sleep := delay
+		if sleep > 0 {
+			sleep += time.Duration(rand.Int64N(int64(sleep)/2 + 1))
+		}
+		time.Sleep(sleep)
+
+		delay *= 2
+		if delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+	}
+}
+
+// IsBusy reports whether err is SQLite refusing a lock held by another
+// connection (SQLITE_BUSY or SQLITE_LOCKED, including extended codes)
+func IsBusy(err error) bool {
+	var coder interface{ Code() int }
+	if !errors.As(err, &coder) {
+		return false
+	}
+	switch coder.Code() & 0xff {
+	case sqlite3.SQLITE_BUSY, sqlite3.SQLITE_LOCKED:
+		return true
+	}
+	return false
+}
+
+// IsUniqueViolation reports whether err is a UNIQUE or PRIMARY KEY
+// constraint failure
+func IsUniqueViolation(err error) bool {
+	var coder interface{ Code() int }
+	if !errors.As(err, &coder) {
+		return false
+	}
+	switch coder.Code() {
+	case sqlite3.SQLITE_CONSTRAINT_UNIQUE, sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,581 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ColumnInfo describes a table column as reported by PRAGMA table_info
+type ColumnInfo struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	NotNull    bool   `json:"not_null"`
+	Default    string `json:"default,omitempty"`
+	PrimaryKey int    `json:"primary_key"`
+}
+
+// IndexInfo describes an explicitly created index
+type IndexInfo struct {
+	Name    string   `json:"name"`
+	Table   string   `json:"table"`
+	Unique  bool     `json:"unique"`
+	Columns []string `json:"columns"`
+	SQL     string   `json:"sql"`
+}
+
+// ForeignKeyInfo describes a single foreign key column reference
+type ForeignKeyInfo struct {
+	From     string `json:"from"`
+	RefTable string `json:"ref_table"`
+	RefCol   string `json:"ref_column"`
+}
+
+// TableInfo describes a table and the objects attached to it
+type TableInfo struct {
+	Name        string           `json:"name"`
+	SQL         string           `json:"sql"`
+	Virtual     bool             `json:"virtual"`
+	Columns     []ColumnInfo     `json:"columns"`
+	ForeignKeys []ForeignKeyInfo `json:"foreign_keys"`
+}
+
+// Schema is a snapshot of the tables, indexes and triggers in a database
+type Schema struct {
+	Tables   map[string]*TableInfo `json:"tables"`
+	Indexes  map[string]*IndexInfo `json:"indexes"`
+	Triggers map[string]string     `json:"triggers"`
+}
+
+// SchemaIssue describes one difference between the live and expected schema
+type SchemaIssue struct {
+	// Kind is one of missing_table, extra_table, missing_column,
+	// extra_column, column_mismatch, missing_foreign_key, extra_foreign_key,
+	// missing_index, extra_index, index_mismatch, missing_trigger.
+	Kind     string `json:"kind"`
+	Severity string `json:"severity"` // "error" or "warning"
+	Table    string `json:"table"`
+	Object   string `json:"object,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+	Fixable  bool   `json:"fixable"`
+}
+
+// String renders the issue as a single diff-style line
+func (i SchemaIssue) String() string {
+	target := i.Table
+	if i.Object != "" {
+		target += "." + i.Object
+	}
+	switch {
+	case i.Expected != "" && i.Actual != "":
+		return fmt.Sprintf("%s %s: expected %s, found %s", i.Kind, target, i.Expected, i.Actual)
+	case i.Expected != "":
+		return fmt.Sprintf("%s %s: expected %s", i.Kind, target, i.Expected)
+	case i.Actual != "":
+		return fmt.Sprintf("%s %s: found %s", i.Kind, target, i.Actual)
+	default:
+		return fmt.Sprintf("%s %s", i.Kind, target)
+	}
+}
+
+// isInternalTable reports whether a table is managed by SQLite itself
+func isInternalTable(name string) bool {
+	return strings.HasPrefix(name, "sqlite_")
+}
+
+// Snapshot reads the current schema of the database
+func (d *Database) Snapshot() (*Schema, error) {
+	schema := &Schema{
+		Tables:   make(map[string]*TableInfo),
+		Indexes:  make(map[string]*IndexInfo),
+		Triggers: make(map[string]string),
+	}
+
+	rows, err := d.Query(`SELECT type, name, tbl_name, COALESCE(sql, '') FROM sqlite_master WHERE type IN ('table', 'index', 'trigger')`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sqlite_master: %w", err)
+	}
+
+	type masterRow struct{ kind, name, table, sql string }
+	var entries []masterRow
+	for rows.Next() {
+		var r masterRow
+		if err := rows.Scan(&r.kind, &r.name, &r.table, &r.sql); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan sqlite_master: %w", err)
+		}
+		entries = append(entries, r)
+	}
+	rows.Close()
+
+	// Shadow tables of virtual tables are owned by their module
+	virtual := make(map[string]bool)
+	for _, e := range entries {
+		if e.kind == "table" && strings.HasPrefix(strings.ToUpper(strings.TrimSpace(e.sql)), "CREATE VIRTUAL TABLE") {
+			virtual[e.name] = true
+		}
+	}
+	isShadow := func(name string) bool {
+		for v := range virtual {
+			if strings.HasPrefix(name, v+"_") {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, e := range entries {
+		if isInternalTable(e.table) || isShadow(e.table) {
+			continue
+		}
+		switch e.kind {
+		case "table":
+			info := &TableInfo{Name: e.name, SQL: e.sql, Virtual: virtual[e.name]}
+			if info.Columns, err = d.tableColumns(e.name); err != nil {
+				return nil, err
+			}
+			if info.ForeignKeys, err = d.tableForeignKeys(e.name); err != nil {
+				return nil, err
+			}
+			schema.Tables[e.name] = info
+		case "index":
+			// Automatic indexes backing PRIMARY KEY / UNIQUE have no SQL
+			if e.sql == "" {
+				continue
+			}
+			info := &IndexInfo{Name: e.name, Table: e.table, SQL: e.sql}
+			info.Unique = strings.Contains(strings.ToUpper(e.sql), "UNIQUE INDEX")
+			if info.Columns, err = d.indexColumns(e.name); err != nil {
+				return nil, err
+			}
+			schema.Indexes[e.name] = info
+		case "trigger":
+			schema.Triggers[e.name] = e.sql
+		}
+	}
+
+	return schema, nil
+}
+
+func (d *Database) tableColumns(table string) ([]ColumnInfo, error) {
+	rows, err := d.Query(`SELECT name, type, "notnull", COALESCE(dflt_value, ''), pk FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var columns []ColumnInfo
+	for rows.Next() {
+		var c ColumnInfo
+		if err := rows.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default, &c.PrimaryKey); err != nil {
+			return nil, fmt.Errorf("failed to scan columns of %s: %w", table, err)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
+func (d *Database) tableForeignKeys(table string) ([]ForeignKeyInfo, error) {
+	rows, err := d.Query(`SELECT "from", "table", COALESCE("to", '') FROM pragma_foreign_key_list(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys of %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var keys []ForeignKeyInfo
+	for rows.Next() {
+		var fk ForeignKeyInfo
+		if err := rows.Scan(&fk.From, &fk.RefTable, &fk.RefCol); err != nil {
+			return nil, fmt.Errorf("failed to scan foreign keys of %s: %w", table, err)
+		}
+		keys = append(keys, fk)
+	}
+	return keys, rows.Err()
+}
+
+func (d *Database) indexColumns(index string) ([]string, error) {
+	rows, err := d.Query(`SELECT COALESCE(name, '') FROM pragma_index_info(?) ORDER BY seqno`, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns of index %s: %w", index, err)
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan columns of index %s: %w", index, err)
+		}
+		columns = append(columns, name)
+	}
+	return columns, rows.Err()
+}
+
+// ExpectedSchema builds the schema the code expects by applying every
+// registered migration to a scratch in-memory database
+func ExpectedSchema() (*Schema, error) {
+	scratch, err := New(":memory:")
+	if err != nil {
+		return nil, err
+	}
+	defer scratch.Close()
+	// Each pooled connection would otherwise get its own empty database
+	scratch.DB.SetMaxOpenConns(1)
+
+	migrator := NewMigrator(scratch)
+	migrator.logf = func(string, ...interface{}) {}
+	if _, err := migrator.RunUp(0); err != nil {
+		return nil, fmt.Errorf("failed to build expected schema: %w", err)
+	}
+	return scratch.Snapshot()
+}
+
+// DiffSchema compares an actual schema against the expected one
+func DiffSchema(expected, actual *Schema) []SchemaIssue {
+	var issues []SchemaIssue
+
+	for _, name := range sortedKeys(expected.Tables) {
+		want := expected.Tables[name]
+		have, ok := actual.Tables[name]
+		if !ok {
+			issues = append(issues, SchemaIssue{Kind: "missing_table", Severity: "error", Table: name, Fixable: true})
+			continue
+		}
+		issues = append(issues, diffColumns(want, have)...)
+		issues = append(issues, diffForeignKeys(want, have)...)
+	}
+	for _, name := range sortedKeys(actual.Tables) {
+		if _, ok := expected.Tables[name]; !ok {
+			issues = append(issues, SchemaIssue{Kind: "extra_table", Severity: "warning", Table: name})
+		}
+	}
+
+	for _, name := range sortedKeys(expected.Indexes) {
+		want := expected.Indexes[name]
+		have, ok := actual.Indexes[name]
+		if !ok {
+			issues = append(issues, SchemaIssue{Kind: "missing_index", Severity: "error", Table: want.Table, Object: name,
+				Expected: formatIndex(want), Fixable: true})
+			continue
+		}
+		if have.Table != want.Table || have.Unique != want.Unique || strings.Join(have.Columns, ",") != strings.Join(want.Columns, ",") {
+			issues = append(issues, SchemaIssue{Kind: "index_mismatch", Severity: "error", Table: want.Table, Object: name,
+				Expected: formatIndex(want), Actual: formatIndex(have), Fixable: true})
+		}
+	}
+	for _, name := range sortedKeys(actual.Indexes) {
+		if _, ok := expected.Indexes[name]; !ok {
+			have := actual.Indexes[name]
+			issues = append(issues, SchemaIssue{Kind: "extra_index", Severity: "warning", Table: have.Table, Object: name,
+				Actual: formatIndex(have)})
+		}
+	}
+
+	for _, name := range sortedKeys(expected.Triggers) {
+		if _, ok := actual.Triggers[name]; !ok {
+			issues = append(issues, SchemaIssue{Kind: "missing_trigger", Severity: "error", Table: triggerTable(expected.Triggers[name]),
+				Object: name, Fixable: true})
+		}
+	}
+
+	markRebuildable(issues, actual)
+	return issues
+}
+
+func diffColumns(want, have *TableInfo) []SchemaIssue {
+	var issues []SchemaIssue
+	haveCols := make(map[string]ColumnInfo)
+	for _, c := range have.Columns {
+		haveCols[strings.ToLower(c.Name)] = c
+	}
+	wantCols := make(map[string]bool)
+
+	for _, c := range want.Columns {
+		wantCols[strings.ToLower(c.Name)] = true
+		hc, ok := haveCols[strings.ToLower(c.Name)]
+		if !ok {
+			issues = append(issues, SchemaIssue{Kind: "missing_column", Severity: "error", Table: want.Name, Object: c.Name,
+				Expected: formatColumn(c), Fixable: true})
+			continue
+		}
+		if formatColumn(hc) != formatColumn(c) {
+			issues = append(issues, SchemaIssue{Kind: "column_mismatch", Severity: "error", Table: want.Name, Object: c.Name,
+				Expected: formatColumn(c), Actual: formatColumn(hc), Fixable: true})
+		}
+	}
+	for _, c := range have.Columns {
+		if !wantCols[strings.ToLower(c.Name)] {
+			issues = append(issues, SchemaIssue{Kind: "extra_column", Severity: "warning", Table: have.Name, Object: c.Name,
+				Actual: formatColumn(c)})
+		}
+	}
+	return issues
+}
+
+func diffForeignKeys(want, have *TableInfo) []SchemaIssue {
+	var issues []SchemaIssue
+	haveKeys := make(map[string]bool)
+	for _, fk := range have.ForeignKeys {
+		haveKeys[formatForeignKey(fk)] = true
+	}
+	wantKeys := make(map[string]bool)
+	for _, fk := range want.ForeignKeys {
+		key := formatForeignKey(fk)
+		wantKeys[key] = true
+		if !haveKeys[key] {
+			issues = append(issues, SchemaIssue{Kind: "missing_foreign_key", Severity: "error", Table: want.Name, Object: fk.From,
+				Expected: key, Fixable: true})
+		}
+	}
+	for _, fk := range have.ForeignKeys {
+		key := formatForeignKey(fk)
+		if !wantKeys[key] {
+			issues = append(issues, SchemaIssue{Kind: "extra_foreign_key", Severity: "warning", Table: have.Name, Object: fk.From,
+				Actual: key})
+		}
+	}
+	return issues
+}
+
+// markRebuildable clears Fixable on table-level issues that would need a
+// rebuild of a virtual table or of a table carrying columns we don't know
+// about, since rebuilding would drop that data
+func markRebuildable(issues []SchemaIssue, actual *Schema) {
+	extraCols := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.Kind == "extra_column" {
+			extraCols[issue.Table] = true
+		}
+	}
+	for i := range issues {
+		if !needsRebuild(issues[i].Kind) {
+			continue
+		}
+		table := actual.Tables[issues[i].Table]
+		if extraCols[issues[i].Table] || (table != nil && table.Virtual) {
+			issues[i].Fixable = false
+		}
+	}
+}
+
+// needsRebuild reports whether fixing an issue kind requires rebuilding the table
+func needsRebuild(kind string) bool {
+	switch kind {
+	case "missing_column", "column_mismatch", "missing_foreign_key":
+		return true
+	}
+	return false
+}
+
+// CheckSchema compares the live database with the expected schema
+func (d *Database) CheckSchema() ([]SchemaIssue, error) {
+	expected, err := ExpectedSchema()
+	if err != nil {
+		return nil, err
+	}
+	actual, err := d.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return DiffSchema(expected, actual), nil
+}
+
+// FixSchema applies pending migrations and then repairs whatever drift is
+// left: missing tables, indexes and triggers are created, and tables with
+// missing or mismatched columns or foreign keys are rebuilt from the
+// expected definition. It returns the steps taken and the issues that
+// remain afterwards.
+func (d *Database) FixSchema() ([]string, []SchemaIssue, error) {
+	var steps []string
+
+	applied, err := NewMigrator(d).RunUp(0)
+	if err != nil {
+		return steps, nil, err
+	}
+	for _, m := range applied {
+		steps = append(steps, fmt.Sprintf("Applied migration %d (%s)", m.Version, m.Name))
+	}
+
+	expected, err := ExpectedSchema()
+	if err != nil {
+		return steps, nil, err
+	}
+	actual, err := d.Snapshot()
+	if err != nil {
+		return steps, nil, err
+	}
+	issues := DiffSchema(expected, actual)
+
+	rebuilt := make(map[string]bool)
+	for _, issue := range issues {
+		if issue.Kind != "missing_table" {
+			continue
+		}
+		if _, err := d.Exec(expected.Tables[issue.Table].SQL); err != nil {
+			return steps, nil, fmt.Errorf("failed to create table %s: %w", issue.Table, err)
+		}
+		steps = append(steps, fmt.Sprintf("Created table %s", issue.Table))
+		rebuilt[issue.Table] = true
+	}
+
+	for _, issue := range issues {
+		if !issue.Fixable || !needsRebuild(issue.Kind) || rebuilt[issue.Table] {
+			continue
+		}
+		if err := d.rebuildTable(expected.Tables[issue.Table], actual.Tables[issue.Table]); err != nil {
+			return steps, nil, fmt.Errorf("failed to rebuild table %s: %w", issue.Table, err)
+		}
+		steps = append(steps, fmt.Sprintf("Rebuilt table %s", issue.Table))
+		rebuilt[issue.Table] = true
+	}
+
+	// Rebuilt and newly created tables lose their indexes and triggers, so
+	// recreate every expected object that is now absent or different
+	actual, err = d.Snapshot()
+	if err != nil {
+		return steps, nil, err
+	}
+	for _, name := range sortedKeys(expected.Indexes) {
+		want := expected.Indexes[name]
+		if have, ok := actual.Indexes[name]; ok {
+			if strings.Join(have.Columns, ",") == strings.Join(want.Columns, ",") && have.Unique == want.Unique && have.Table == want.Table {
+				continue
+			}
+			if _, err := d.Exec(fmt.Sprintf(`DROP INDEX IF EXISTS %s`, name)); err != nil {
+				return steps, nil, fmt.Errorf("failed to drop index %s: %w", name, err)
+			}
+		}
+		if _, err := d.Exec(want.SQL); err != nil {
+			return steps, nil, fmt.Errorf("failed to create index %s: %w", name, err)
+		}
+		steps = append(steps, fmt.Sprintf("Created index %s", name))
+	}
+	for _, name := range sortedKeys(expected.Triggers) {
+		if _, ok := actual.Triggers[name]; ok {
+			continue
+		}
+		if _, err := d.Exec(expected.Triggers[name]); err != nil {
+			return steps, nil, fmt.Errorf("failed to create trigger %s: %w", name, err)
+		}
+		steps = append(steps, fmt.Sprintf("Created trigger %s", name))
+	}
+
+	remaining, err := d.CheckSchema()
+	if err != nil {
+		return steps, nil, err
+	}
+	return steps, remaining, nil
+}
+
+var createTableName = regexp.MustCompile(`(?is)^\s*CREATE\s+TABLE\s+(IF\s+NOT\s+EXISTS\s+)?("[^"]+"|\S+)`)
+
+// rebuildTable recreates a table from its expected definition and copies
+// over every column the old and new shapes have in common. Foreign key
+// enforcement is switched off on the connection for the duration, as the
+// SQLite documentation prescribes for this procedure.
+func (d *Database) rebuildTable(want, have *TableInfo) error {
+	ctx := context.Background()
+	conn, err := d.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var fkEnabled bool
+	if err := conn.QueryRowContext(ctx, `PRAGMA foreign_keys`).Scan(&fkEnabled); err != nil {
+		return err
+	}
+	if fkEnabled {
+		if _, err := conn.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			return err
+		}
+		defer conn.ExecContext(ctx, `PRAGMA foreign_keys = ON`)
+	}
+
+	tmpName := want.Name + "__rebuild"
+	createSQL := createTableName.ReplaceAllString(want.SQL, "CREATE TABLE "+tmpName)
+
+	haveCols := make(map[string]bool)
+	for _, c := range have.Columns {
+		haveCols[strings.ToLower(c.Name)] = true
+	}
+	var common []string
+	for _, c := range want.Columns {
+		if haveCols[strings.ToLower(c.Name)] {
+			common = append(common, c.Name)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, tmpName)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(createSQL); err != nil {
+		return err
+	}
+	if len(common) > 0 {
+		cols := strings.Join(common, ", ")
+		if _, err := tx.Exec(fmt.Sprintf(`INSERT INTO %s (%s) SELECT %s FROM %s`, tmpName, cols, cols, want.Name)); err != nil {
+			return err
+		}
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`DROP TABLE %s`, want.Name)); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(fmt.Sprintf(`ALTER TABLE %s RENAME TO %s`, tmpName, want.Name)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func formatColumn(c ColumnInfo) string {
+	parts := []string{strings.ToUpper(c.Type)}
+	if c.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Default != "" {
+		parts = append(parts, "DEFAULT "+c.Default)
+	}
+	if c.PrimaryKey > 0 {
+		parts = append(parts, fmt.Sprintf("PK#%d", c.PrimaryKey))
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatIndex(i *IndexInfo) string {
+	prefix := ""
+	if i.Unique {
+		prefix = "UNIQUE "
+	}
+	return fmt.Sprintf("%sON %s(%s)", prefix, i.Table, strings.Join(i.Columns, ", "))
+}
+
+func formatForeignKey(fk ForeignKeyInfo) string {
+	return fmt.Sprintf("%s -> %s(%s)", fk.From, fk.RefTable, fk.RefCol)
+}
+
+var triggerTableName = regexp.MustCompile(`(?is)\bON\s+("[^"]+"|\w+)`)
+
+func triggerTable(sql string) string {
+	if m := triggerTableName.FindStringSubmatch(sql); m != nil {
+		return strings.Trim(m[1], `"`)
+	}
+	return ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
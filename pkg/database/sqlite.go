@@ -4,6 +4,11 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"mangahub/pkg/config"
 
 	_ "modernc.org/sqlite"
 )
@@ -12,120 +17,122 @@ import (
 type Database struct {
 	DB   *sql.DB
 	Path string
+	// Retry bounds how Exec and BeginTx retry SQLITE_BUSY errors
+	Retry RetryPolicy
+	// AutoMigrate lets Init apply pending migrations; without it Init only
+	// checks the schema is up to date
+	AutoMigrate bool
 }
 
-// New creates a new database connection
+// defaultBusyTimeout applies when the config leaves database.timeout unset
+const defaultBusyTimeout = 5 * time.Second
+
+// New creates a new database connection using the default database
+// configuration for everything but the path
 func New(dbPath string) (*Database, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	cfg := config.DefaultConfig().Database
+	cfg.Path = dbPath
+	return NewWithConfig(cfg)
+}
+
+// NewWithConfig creates a new database connection. Every pooled connection
+// gets the configured journal mode, busy timeout and foreign key
+// enforcement, and transactions take the write lock up front so concurrent
+// writers wait on busy_timeout instead of failing when upgrading a read lock.
+func NewWithConfig(cfg config.DatabaseConfig) (*Database, error) {
+	db, err := sql.Open("sqlite", dataSourceName(cfg))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
+	if cfg.MaxConn > 0 {
+		db.SetMaxOpenConns(cfg.MaxConn)
+		db.SetMaxIdleConns(cfg.MaxConn)
+	}
+
 	// Test the connection
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	return &Database{DB: db, Path: dbPath}, nil
+	retry := DefaultRetryPolicy
+	if cfg.BusyRetries > 0 {
+		retry.MaxAttempts = cfg.BusyRetries + 1
+	}
+
+	return &Database{DB: db, Path: cfg.Path, Retry: retry, AutoMigrate: cfg.AutoMigrate}, nil
+}
+
+// dataSourceName builds a modernc.org/sqlite DSN carrying the per-connection
+// pragmas for cfg
+func dataSourceName(cfg config.DatabaseConfig) string {
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = defaultBusyTimeout
+	}
+	journalMode := cfg.JournalMode
+	if journalMode == "" {
+		journalMode = "wal"
+	}
+	foreignKeys := 0
+	if cfg.ForeignKeys {
+		foreignKeys = 1
+	}
+
+	params := url.Values{}
+	params.Add("_pragma", fmt.Sprintf("busy_timeout(%d)", timeout.Milliseconds()))
+	params.Add("_pragma", fmt.Sprintf("journal_mode(%s)", journalMode))
+	params.Add("_pragma", fmt.Sprintf("foreign_keys(%d)", foreignKeys))
+	params.Set("_txlock", "immediate")
+
+	sep := "?"
+	if strings.Contains(cfg.Path, "?") {
+		sep = "&"
+	}
+	return cfg.Path + sep + params.Encode()
 }
 
-// Init initializes the database schema
+// Init initializes the database schema by applying any pending migrations.
+// It refuses to touch a database whose schema is newer than, or unknown to,
+// this binary. With AutoMigrate off it applies nothing and fails with
+// ErrPendingMigrations unless every migration has already been applied.
 func (d *Database) Init() error {
-	schema := `
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		username TEXT UNIQUE NOT NULL,
-		email TEXT UNIQUE NOT NULL,
-		password_hash TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS manga (
-		id TEXT PRIMARY KEY,
-		title TEXT NOT NULL,
-		author TEXT,
-		genres TEXT,
-		status TEXT,
-		total_chapters INTEGER,
-		description TEXT,
-		cover_url TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS user_progress (
-		user_id TEXT NOT NULL,
-		manga_id TEXT NOT NULL,
-		current_chapter INTEGER DEFAULT 0,
-		status TEXT DEFAULT 'plan-to-read',
-		rating INTEGER DEFAULT 0,
-		notes TEXT,
-		started_at TIMESTAMP,
-		completed_at TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (user_id, manga_id),
-		FOREIGN KEY (user_id) REFERENCES users(id),
-		FOREIGN KEY (manga_id) REFERENCES manga(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS chat_messages (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		username TEXT NOT NULL,
-		room_id TEXT NOT NULL,
-		message TEXT NOT NULL,
-		timestamp INTEGER,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS notifications (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		type TEXT NOT NULL,
-		manga_id TEXT,
-		message TEXT NOT NULL,
-		read BOOLEAN DEFAULT 0,
-		data TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS notification_subscriptions (
-		user_id TEXT NOT NULL,
-		manga_id TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (user_id, manga_id),
-		FOREIGN KEY (user_id) REFERENCES users(id),
-		FOREIGN KEY (manga_id) REFERENCES manga(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS notification_preferences (
-		user_id TEXT PRIMARY KEY,
-		chapter_releases BOOLEAN DEFAULT 1,
-		email_notifications BOOLEAN DEFAULT 1,
-		sound_enabled BOOLEAN DEFAULT 1,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_user_progress_user ON user_progress(user_id);
-	CREATE INDEX IF NOT EXISTS idx_user_progress_manga ON user_progress(manga_id);
-	CREATE INDEX IF NOT EXISTS idx_chat_room ON chat_messages(room_id);
-	CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id);
-	CREATE INDEX IF NOT EXISTS idx_notification_subs_user ON notification_subscriptions(user_id);
-	`
-
-	_, err := d.DB.Exec(schema)
+	migrator := NewMigrator(d)
+	if err := migrator.Verify(); err != nil {
+		return fmt.Errorf("failed to initialize schema: %w", err)
+	}
+
+	if !d.AutoMigrate {
+		pending, err := migrator.Pending()
+		if err != nil {
+			return fmt.Errorf("failed to initialize schema: %w", err)
+		}
+		if len(pending) > 0 {
+			return fmt.Errorf("failed to initialize schema: %w: %d up to version %d; run 'mangahub db migrate up'",
+				ErrPendingMigrations, len(pending), pending[len(pending)-1].Version)
+		}
+		log.Println("Database schema is up to date")
+		return nil
+	}
+
+	applied, err := migrator.RunUp(0)
 	if err != nil {
 		return fmt.Errorf("failed to initialize schema: %w", err)
 	}
 
+	if len(applied) > 0 {
+		log.Printf("Database schema migrated to version %d", applied[len(applied)-1].Version)
+	}
 	log.Println("Database schema initialized successfully")
 	return nil
 }
 
+// SchemaVersion returns the highest applied migration version
+func (d *Database) SchemaVersion() (int, error) {
+	return NewMigrator(d).CurrentVersion()
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
 	return d.DB.Close()
@@ -141,12 +148,26 @@ func (d *Database) QueryRow(query string, args ...interface{}) *sql.Row {
 	return d.DB.QueryRow(query, args...)
 }
 
-// Exec executes an INSERT, UPDATE, or DELETE query
+// Exec executes an INSERT, UPDATE, or DELETE query, retrying while the
+// database is busy
 func (d *Database) Exec(query string, args ...interface{}) (sql.Result, error) {
-	return d.DB.Exec(query, args...)
+	var result sql.Result
+	err := d.Retry.Do(func() error {
+		var err error
+		result, err = d.DB.Exec(query, args...)
+		return err
+	})
+	return result, err
 }
 
-// BeginTx begins a transaction
+// BeginTx begins a write transaction, retrying while another writer holds
+// the lock
 func (d *Database) BeginTx() (*sql.Tx, error) {
-	return d.DB.Begin()
+	var tx *sql.Tx
+	err := d.Retry.Do(func() error {
+		var err error
+		tx, err = d.DB.Begin()
+		return err
+	})
+	return tx, err
 }
@@ -0,0 +1,242 @@
+package database_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mangahub/pkg/config"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+	"mangahub/pkg/store"
+)
+
+// The writer processes are this test binary run again with these variables
+// set, so each one opens the database on its own the way the API, TCP,
+// gRPC and WebSocket servers do
+const (
+	stressDBEnv     = "MANGAHUB_STRESS_DB"
+	stressWorkerEnv = "MANGAHUB_STRESS_WORKER"
+)
+
+const (
+	stressProcesses  = 4
+	stressWriters    = 4
+	stressDuration   = 3 * time.Second
+	stressUserID     = "stress-user"
+	stressMangaCount = 20
+	// stressResultPrefix marks a worker's result among the test output
+	stressResultPrefix = "stress-result: "
+)
+
+// stressResult is what each writer process reports
+type stressResult struct {
+	Worker int    `json:"worker"`
+	Writes int    `json:"writes"`
+	Events int    `json:"events"`
+	Busy   int    `json:"busy"`
+	Errors int    `json:"errors"`
+	Sample string `json:"sample,omitempty"`
+}
+
+// TestConcurrentWriters runs several processes, each with several
+// goroutines, writing progress updates and chat messages to one WAL
+// database at once. No write may give up with SQLITE_BUSY after its
+// retries, and progress_events must hold one row per successful update.
+func TestConcurrentWriters(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping multi-process stress test in short mode")
+	}
+
+	path := filepath.Join(t.TempDir(), "stress.db")
+	seedStressDatabase(t, path)
+
+	results := make([]stressResult, stressProcesses)
+	errs := make([]error, stressProcesses)
+	var wg sync.WaitGroup
+	for i := 0; i < stressProcesses; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cmd := exec.Command(os.Args[0], "-test.run=^TestStressWorker$", "-test.v")
+			cmd.Env = append(os.Environ(), stressDBEnv+"="+path, stressWorkerEnv+"="+strconv.Itoa(i))
+			out, err := cmd.CombinedOutput()
+			if err != nil {
+				errs[i] = fmt.Errorf("worker %d failed: %v\n%s", i, err, out)
+				return
+			}
+			errs[i] = parseStressResult(out, &results[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var total stressResult
+	for _, r := range results {
+		t.Logf("worker %d: %d writes, %d busy, %d errors", r.Worker, r.Writes, r.Busy, r.Errors)
+		total.Writes += r.Writes
+		total.Events += r.Events
+		total.Busy += r.Busy
+		total.Errors += r.Errors
+		if total.Sample == "" {
+			total.Sample = r.Sample
+		}
+	}
+	if total.Writes == 0 {
+		t.Fatal("no write succeeded")
+	}
+	if total.Busy > 0 {
+		t.Errorf("%d writes gave up with SQLITE_BUSY; first failure: %s", total.Busy, total.Sample)
+	}
+	if total.Errors > 0 {
+		t.Errorf("%d writes failed; first failure: %s", total.Errors, total.Sample)
+	}
+
+	db, err := database.New(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	var events int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM progress_events WHERE user_id = ?`, stressUserID).Scan(&events); err != nil {
+		t.Fatalf("count progress events: %v", err)
+	}
+	if events != total.Events {
+		t.Errorf("progress_events has %d rows for %d successful progress updates", events, total.Events)
+	}
+}
+
+// TestStressWorker is one writer process of TestConcurrentWriters. It only
+// runs when started by it.
+func TestStressWorker(t *testing.T) {
+	path := os.Getenv(stressDBEnv)
+	if path == "" {
+		t.Skip("only runs as a TestConcurrentWriters worker")
+	}
+	worker, _ := strconv.Atoi(os.Getenv(stressWorkerEnv))
+
+	cfg := config.DefaultConfig().Database
+	cfg.Path = path
+	db, err := database.NewWithConfig(cfg)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+
+	library := store.NewSQLiteLibraryStore(db)
+	chat := store.NewSQLiteChatStore(db)
+	origin := models.EventOrigin{Source: models.EventSourceCLI, DeviceID: fmt.Sprintf("stress-%d", worker)}
+
+	result := stressResult{Worker: worker}
+	var mu sync.Mutex
+	record := func(err error, progress bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case err == nil:
+			result.Writes++
+			if progress {
+				result.Events++
+			}
+		case database.IsBusy(err):
+			result.Busy++
+		default:
+			result.Errors++
+		}
+		if err != nil && result.Sample == "" {
+			result.Sample = err.Error()
+		}
+	}
+
+	deadline := time.Now().Add(stressDuration)
+	var wg sync.WaitGroup
+	for w := 0; w < stressWriters; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; time.Now().Before(deadline); i++ {
+				if i%4 == 3 {
+					err := chat.SaveMessage(&models.ChatMessage{
+						ID:       fmt.Sprintf("stress-%d-%d-%d", worker, w, i),
+						UserID:   stressUserID,
+						Username: stressUserID,
+						RoomID:   "stress",
+						Message:  "stress",
+					})
+					record(err, false)
+					continue
+				}
+
+				err := library.SaveProgress(&models.ProgressUpdate{
+					UserID:    stressUserID,
+					MangaID:   fmt.Sprintf("stress-manga-%d", rand.IntN(stressMangaCount)),
+					Chapter:   i,
+					Timestamp: time.Now().Unix(),
+				}, origin)
+				record(err, true)
+
+				if _, err := library.List(stressUserID, "", 10, 0); err != nil {
+					record(err, false)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	out, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("encode result: %v", err)
+	}
+	fmt.Printf("%s%s\n", stressResultPrefix, out)
+}
+
+// seedStressDatabase migrates the database and adds the user and manga the
+// workers write against
+func seedStressDatabase(t *testing.T, path string) {
+	t.Helper()
+	db, err := database.New(path)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Init(); err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO users (id, username, email, password_hash) VALUES (?, ?, ?, '')`,
+		stressUserID, stressUserID, stressUserID+"@example.invalid")
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	for i := 0; i < stressMangaCount; i++ {
+		id := fmt.Sprintf("stress-manga-%d", i)
+		if _, err := db.Exec(`INSERT INTO manga (id, title) VALUES (?, ?)`, id, id); err != nil {
+			t.Fatalf("seed manga: %v", err)
+		}
+	}
+}
+
+// parseStressResult finds a worker's result in its test output
+func parseStressResult(out []byte, result *stressResult) error {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if line, ok := strings.CutPrefix(scanner.Text(), stressResultPrefix); ok {
+			return json.Unmarshal([]byte(line), result)
+		}
+	}
+	return fmt.Errorf("worker printed no result:\n%s", out)
+}
@@ -0,0 +1,78 @@
+package models
+
+import "time"
+
+// Scopes a personal access token can be given. A token can only do what
+// its scopes cover, and never more than its user's role allows.
+const (
+	// ScopeLibraryRead covers reading the library, progress history and
+	// recommendations
+	ScopeLibraryRead = "library:read"
+	// ScopeLibraryWrite covers adding, importing, updating, removing and
+	// restoring library entries and reading progress
+	ScopeLibraryWrite = "library:write"
+	// ScopeChatWrite covers sending chat messages
+	ScopeChatWrite = "chat:write"
+	// ScopeAdmin covers what the user's moderator or admin role allows
+	ScopeAdmin = "admin"
+)
+
+// Scopes lists every scope a personal access token can have
+var Scopes = []string{ScopeLibraryRead, ScopeLibraryWrite, ScopeChatWrite, ScopeAdmin}
+
+// ValidScope reports whether scope is one of Scopes
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AccessTokenPrefix starts every personal access token, telling them apart
+// from login tokens
+const AccessTokenPrefix = "mhp_"
+
+// AccessToken is a personal access token, which scripts and bots use
+// instead of logging in. The token itself is only shown when it is created
+// and kept hashed.
+type AccessToken struct {
+	ID     string   `json:"id"`
+	UserID string   `json:"user_id"`
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// Hint is the start of the token, to tell tokens apart when listed
+	Hint       string     `json:"hint"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	TokenHash string `json:"-"`
+}
+
+// HasScope reports whether the token was given scope
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAccessTokenRequest represents a request for a personal access
+// token. ExpiresInDays defaults to 90.
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// CreateAccessTokenResponse carries a new personal access token, the only
+// time the token itself is shown
+type CreateAccessTokenResponse struct {
+	AccessToken
+	Token string `json:"token"`
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// Catalog fields, as named in merge priorities and field sources
+const (
+	FieldTitle       = "title"
+	FieldTitles      = "titles"
+	FieldAuthor      = "author"
+	FieldDescription = "description"
+	FieldStatus      = "status"
+	FieldGenres      = "genres"
+	FieldChapters    = "chapters"
+	FieldYear        = "year"
+	FieldCoverURL    = "cover_url"
+)
+
+// CatalogFields lists every catalog field in display order
+var CatalogFields = []string{
+	FieldTitle, FieldTitles, FieldAuthor, FieldDescription, FieldStatus,
+	FieldGenres, FieldChapters, FieldYear, FieldCoverURL,
+}
+
+// CatalogEntry is what one source says about a manga. Metadata providers
+// return entries, and the catalog keeps the latest entry of every source
+// linked to a manga to merge them. Empty fields are unknown to the source.
+type CatalogEntry struct {
+	Source      string       `json:"source"`
+	ExternalID  string       `json:"external_id"`
+	Title       string       `json:"title"`
+	Titles      []MangaTitle `json:"titles,omitempty"`
+	Author      string       `json:"author,omitempty"`
+	Description string       `json:"description,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	Genres      []string     `json:"genres,omitempty"`
+	Chapters    int          `json:"chapters,omitempty"`
+	Year        int          `json:"year,omitempty"`
+	CoverURL    string       `json:"cover_url,omitempty"`
+	// Links are the manga's IDs at other sources, keyed by source
+	Links     map[string]string `json:"links,omitempty"`
+	FetchedAt time.Time         `json:"fetched_at"`
+}
+
+// Has reports whether the entry has a value for a catalog field
+func (e *CatalogEntry) Has(field string) bool {
+	switch field {
+	case FieldTitle:
+		return e.Title != ""
+	case FieldTitles:
+		return len(e.Titles) > 0
+	case FieldAuthor:
+		return e.Author != ""
+	case FieldDescription:
+		return e.Description != ""
+	case FieldStatus:
+		return e.Status != ""
+	case FieldGenres:
+		return len(e.Genres) > 0
+	case FieldChapters:
+		return e.Chapters > 0
+	case FieldYear:
+		return e.Year > 0
+	case FieldCoverURL:
+		return e.CoverURL != ""
+	}
+	return false
+}
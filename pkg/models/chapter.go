@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// DefaultChapterLanguage is the language of chapters created without one
+const DefaultChapterLanguage = "en"
+
+// Chapter is one released chapter of a manga
+type Chapter struct {
+	ID      int64   `json:"id"`
+	MangaID string  `json:"manga_id"`
+	Number  float64 `json:"number"`
+	// Volume is 0 when the chapter is not collected in a volume yet
+	Volume     int        `json:"volume,omitempty"`
+	Title      string     `json:"title,omitempty"`
+	Language   string     `json:"language"`
+	Pages      int        `json:"pages,omitempty"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// ChapterFilter selects the chapters of one manga
+type ChapterFilter struct {
+	MangaID  string
+	Language string // "" for every language
+	// After, when positive, only matches chapters numbered above it, e.g.
+	// the reader's current chapter
+	After  float64
+	Order  string // "asc" (default) or "desc" by chapter number
+	Limit  int
+	Offset int
+}
+
+// ChapterList is a page of a manga's chapters
+type ChapterList struct {
+	MangaID  string    `json:"manga_id"`
+	Chapters []Chapter `json:"chapters"`
+	Limit    int       `json:"limit"`
+	Offset   int       `json:"offset"`
+}
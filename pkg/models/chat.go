@@ -28,6 +28,9 @@ type ChatClient struct {
 	Username string
 	RoomID   string
 	ConnID   string
+	// ReadOnly clients came with a personal access token without the
+	// chat:write scope; their messages are not broadcast
+	ReadOnly bool
 }
 
 // ClientConnection represents a new client connection
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Catalog sources. MangaDex and local entries come from metadata providers;
+// admin entries are the edits made through the admin API. MyAnimeList and
+// AniList IDs are only linked, as providers report them, to match library
+// imports from those trackers.
+const (
+	SourceMangaDex = "mangadex"
+	SourceLocal    = "local"
+	SourceAdmin    = "admin"
+	SourceMAL      = "mal"
+	SourceAniList  = "anilist"
+)
+
+// ExternalID links a catalog manga to its ID at an outside catalog such as
+// MangaDex. An external ID links to at most one manga.
+type ExternalID struct {
+	Source     string    `json:"source"`
+	ExternalID string    `json:"external_id"`
+	MangaID    string    `json:"manga_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	// FetchedAt is when the source's entry for the manga was last saved
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+}
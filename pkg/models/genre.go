@@ -0,0 +1,108 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Genre kinds. Demographics are the target audience (shounen, seinen, ...),
+// genres are the main categories and tags are everything else, including
+// genres first seen on imported manga.
+const (
+	GenreKindDemographic = "demographic"
+	GenreKindGenre       = "genre"
+	GenreKindTag         = "tag"
+)
+
+// Genre is one entry of the genre and tag taxonomy
+type Genre struct {
+	ID   int64  `json:"id"`
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Aliases are alternative spellings that resolve to this genre
+	Aliases    []string `json:"aliases,omitempty"`
+	MangaCount int      `json:"manga_count"`
+}
+
+// DefaultGenres is the taxonomy every database starts with
+var DefaultGenres = []Genre{
+	{Slug: "shounen", Name: "Shounen", Kind: GenreKindDemographic, Aliases: []string{"shonen", "shōnen"}},
+	{Slug: "seinen", Name: "Seinen", Kind: GenreKindDemographic},
+	{Slug: "shoujo", Name: "Shoujo", Kind: GenreKindDemographic, Aliases: []string{"shojo", "shōjo"}},
+	{Slug: "josei", Name: "Josei", Kind: GenreKindDemographic},
+
+	{Slug: "action", Name: "Action", Kind: GenreKindGenre},
+	{Slug: "adventure", Name: "Adventure", Kind: GenreKindGenre},
+	{Slug: "comedy", Name: "Comedy", Kind: GenreKindGenre},
+	{Slug: "drama", Name: "Drama", Kind: GenreKindGenre},
+	{Slug: "fantasy", Name: "Fantasy", Kind: GenreKindGenre},
+	{Slug: "horror", Name: "Horror", Kind: GenreKindGenre},
+	{Slug: "mystery", Name: "Mystery", Kind: GenreKindGenre},
+	{Slug: "psychological", Name: "Psychological", Kind: GenreKindGenre},
+	{Slug: "romance", Name: "Romance", Kind: GenreKindGenre},
+	{Slug: "sci-fi", Name: "Sci-Fi", Kind: GenreKindGenre, Aliases: []string{"scifi", "science-fiction"}},
+	{Slug: "slice-of-life", Name: "Slice of Life", Kind: GenreKindGenre, Aliases: []string{"sol"}},
+	{Slug: "sports", Name: "Sports", Kind: GenreKindGenre, Aliases: []string{"sport"}},
+	{Slug: "supernatural", Name: "Supernatural", Kind: GenreKindGenre},
+	{Slug: "thriller", Name: "Thriller", Kind: GenreKindGenre},
+	{Slug: "historical", Name: "Historical", Kind: GenreKindGenre, Aliases: []string{"history"}},
+	{Slug: "mecha", Name: "Mecha", Kind: GenreKindGenre},
+	{Slug: "tragedy", Name: "Tragedy", Kind: GenreKindGenre},
+
+	{Slug: "school", Name: "School", Kind: GenreKindTag, Aliases: []string{"school-life"}},
+	{Slug: "martial-arts", Name: "Martial Arts", Kind: GenreKindTag},
+	{Slug: "isekai", Name: "Isekai", Kind: GenreKindTag},
+	{Slug: "gaming", Name: "Gaming", Kind: GenreKindTag, Aliases: []string{"video-games", "games"}},
+	{Slug: "music", Name: "Music", Kind: GenreKindTag},
+	{Slug: "magical-girl", Name: "Magical Girl", Kind: GenreKindTag, Aliases: []string{"magical-girls", "mahou-shoujo"}},
+	{Slug: "superhero", Name: "Superhero", Kind: GenreKindTag, Aliases: []string{"superheroes"}},
+	{Slug: "military", Name: "Military", Kind: GenreKindTag},
+	{Slug: "harem", Name: "Harem", Kind: GenreKindTag},
+	{Slug: "cooking", Name: "Cooking", Kind: GenreKindTag},
+	{Slug: "medical", Name: "Medical", Kind: GenreKindTag},
+	{Slug: "survival", Name: "Survival", Kind: GenreKindTag},
+	{Slug: "family", Name: "Family", Kind: GenreKindTag},
+	{Slug: "revenge", Name: "Revenge", Kind: GenreKindTag},
+	{Slug: "dark-fantasy", Name: "Dark Fantasy", Kind: GenreKindTag},
+	{Slug: "cyberpunk", Name: "Cyberpunk", Kind: GenreKindTag},
+	{Slug: "parody", Name: "Parody", Kind: GenreKindTag},
+	{Slug: "fashion", Name: "Fashion", Kind: GenreKindTag},
+}
+
+// GenreSlug normalizes a genre name or alias to its lookup key: lower case
+// with every run of spaces and punctuation collapsed to a single hyphen,
+// so "Slice of Life", "slice_of_life" and "slice-of-life" are the same.
+func GenreSlug(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			hyphen = false
+			b.WriteRune(r)
+			continue
+		}
+		hyphen = true
+	}
+	return b.String()
+}
+
+// GenreName is the display name given to a genre first seen on a manga.
+// Names that are all lower case are title-cased from their slug.
+func GenreName(name string) string {
+	name = strings.TrimSpace(name)
+	if name != strings.ToLower(name) {
+		return name
+	}
+	words := strings.Split(GenreSlug(name), "-")
+	for i, w := range words {
+		if w != "" {
+			r := []rune(w)
+			words[i] = string(unicode.ToUpper(r[0])) + string(r[1:])
+		}
+	}
+	return strings.Join(words, " ")
+}
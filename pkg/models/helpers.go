@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"strings"
 )
 
 // MangaToJSON converts genres to JSON string
@@ -16,3 +17,23 @@ func JSONToManga(jsonStr string) ([]string, error) {
 	err := json.Unmarshal([]byte(jsonStr), &genres)
 	return genres, err
 }
+
+// ParseGenres reads a manga.genres value, which is a JSON array when
+// written by the stores and a comma-separated list when written by the data
+// loader
+func ParseGenres(value string) []string {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	if genres, err := JSONToManga(value); err == nil {
+		return genres
+	}
+	var genres []string
+	for _, g := range strings.Split(value, ",") {
+		if g = strings.TrimSpace(g); g != "" {
+			genres = append(genres, g)
+		}
+	}
+	return genres
+}
@@ -0,0 +1,65 @@
+package models
+
+// Actions a library import takes on an export entry
+const (
+	ImportAdded     = "added"
+	ImportUpdated   = "updated"
+	ImportUnchanged = "unchanged"
+	ImportUnmatched = "unmatched"
+	ImportSkipped   = "skipped"
+)
+
+// ImportChange is one field of a library entry an import changes; From is
+// empty for new entries
+type ImportChange struct {
+	Field string `json:"field"`
+	From  string `json:"from,omitempty"`
+	To    string `json:"to"`
+}
+
+// ImportResult is what a library import did, or with a dry run would do,
+// with one export entry. MatchedBy is the source whose ID matched the
+// entry to a catalog manga, or "title".
+type ImportResult struct {
+	Title      string         `json:"title"`
+	Source     string         `json:"source"`
+	ExternalID string         `json:"external_id"`
+	MangaID    string         `json:"manga_id,omitempty"`
+	MatchedBy  string         `json:"matched_by,omitempty"`
+	Action     string         `json:"action"`
+	Changes    []ImportChange `json:"changes,omitempty"`
+	// Note says why an entry was skipped
+	Note string `json:"note,omitempty"`
+}
+
+// ImportReport is the outcome of a library import, one result per export
+// entry in the order of the export
+type ImportReport struct {
+	DryRun    bool           `json:"dry_run"`
+	Results   []ImportResult `json:"results"`
+	Added     int            `json:"added"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Unmatched int            `json:"unmatched"`
+	Skipped   int            `json:"skipped"`
+	// Error says why an import stopped before the end of the export. The
+	// entries in Results were still imported; the rest were not.
+	Error string `json:"error,omitempty"`
+}
+
+// Add counts a result and appends it
+func (r *ImportReport) Add(result ImportResult) {
+	switch result.Action {
+	case ImportAdded:
+		r.Added++
+	case ImportUpdated:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportUnmatched:
+		r.Unmatched++
+	default:
+		r.Skipped++
+	}
+	r.Results = append(r.Results, result)
+}
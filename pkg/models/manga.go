@@ -12,8 +12,28 @@ type Manga struct {
 	TotalChapters int       `json:"total_chapters"`
 	Description   string    `json:"description"`
 	CoverURL      string    `json:"cover_url"`
+	Year          int       `json:"year,omitempty"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
+	// DeletedAt is set while the manga is in the trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+	// Titles lists the alternate and localized titles; only set when a
+	// single manga is fetched. When Title was localized for the reader,
+	// OriginalTitle holds the catalog title.
+	Titles        []MangaTitle `json:"titles,omitempty"`
+	OriginalTitle string       `json:"original_title,omitempty"`
+
+	// Relevance and Snippet are only set on full-text search results.
+	// Snippet marks matched terms with <mark></mark>.
+	Relevance float64 `json:"relevance,omitempty"`
+	Snippet   string  `json:"snippet,omitempty"`
+
+	// Readers and AverageRating are only set on search results. Readers
+	// counts the library entries of the manga and AverageRating averages
+	// the non-zero ratings (0-10) of those entries.
+	Readers       int     `json:"readers,omitempty"`
+	AverageRating float64 `json:"average_rating,omitempty"`
 }
 
 // MangaFilter represents search filters for manga
@@ -21,20 +41,86 @@ type MangaFilter struct {
 	Query       string
 	Genres      []string
 	Status      string
-	Author      string
+	Author      string // case-insensitive substring of the author
 	YearFrom    int
 	YearTo      int
 	MinChapters int
-	SortBy      string // "popularity", "rating", "recent", "title"
-	Order       string // "asc", "desc"
+	MinRating   float64 // minimum average user rating, 0-10
+	SortBy      string  // one of MangaSortModes
+	Order       string  // "asc", "desc"
 	Limit       int
 	Offset      int
+
+	// IDs, when set, only matches these manga; the fuzzy search fallback
+	// uses it to apply the other filters to its suggestions
+	IDs []string `json:"-"`
+}
+
+// MangaSortModes are the accepted MangaFilter.SortBy values. "relevance"
+// only ranks text searches, "popularity" sorts by Readers, "rating" by
+// AverageRating and "recent" by the last catalog update.
+var MangaSortModes = []string{
+	"relevance", "title", "author", "status", "total_chapters", "year",
+	"popularity", "rating", "recent", "created_at", "updated_at",
+}
+
+// ValidMangaSort reports whether sortBy is one of MangaSortModes
+func ValidMangaSort(sortBy string) bool {
+	for _, mode := range MangaSortModes {
+		if sortBy == mode {
+			return true
+		}
+	}
+	return false
 }
 
-// SearchResult represents search results
+// SearchResult represents search results. Total and Facets count every
+// match, not only the returned page.
+//
+// Text searches with few hits also carry Suggestions, titles and authors
+// resembling the query, with the best one in DidYouMean. When nothing
+// matched exactly, Manga holds the suggested manga instead and Fuzzy is set.
 type SearchResult struct {
-	Total int     `json:"total"`
-	Manga []Manga `json:"manga"`
-	Page  int     `json:"page"`
-	Limit int     `json:"limit"`
+	Total       int           `json:"total"`
+	Manga       []Manga       `json:"manga"`
+	Page        int           `json:"page"`
+	Limit       int           `json:"limit"`
+	Facets      *SearchFacets `json:"facets,omitempty"`
+	Fuzzy       bool          `json:"fuzzy,omitempty"`
+	DidYouMean  string        `json:"did_you_mean,omitempty"`
+	Suggestions []Suggestion  `json:"suggestions,omitempty"`
+}
+
+// Suggestion is a manga whose title, alternate title or author resembles a
+// query. Matched is the text that resembled it and Field says which one it
+// was.
+type Suggestion struct {
+	MangaID string  `json:"manga_id"`
+	Title   string  `json:"title"`
+	Author  string  `json:"author,omitempty"`
+	Matched string  `json:"matched"`
+	Field   string  `json:"field"`
+	Score   float64 `json:"score"` // similarity, 0-1
+}
+
+// Suggestion fields
+const (
+	SuggestionFieldTitle    = "title"
+	SuggestionFieldAltTitle = "alt_title"
+	SuggestionFieldAuthor   = "author"
+)
+
+// SearchFacets counts the matches of a search per genre and per status,
+// most common first
+type SearchFacets struct {
+	Genres []FacetCount `json:"genres"`
+	Status []FacetCount `json:"status"`
+}
+
+// FacetCount is the number of matches sharing one facet value. Value is
+// the genre slug or the status; Name is the genre's display name.
+type FacetCount struct {
+	Value string `json:"value"`
+	Name  string `json:"name,omitempty"`
+	Count int    `json:"count"`
 }
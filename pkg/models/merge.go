@@ -0,0 +1,35 @@
+package models
+
+// DuplicateGroup is a set of catalog manga that look like the same series:
+// their titles match once normalized and their authors agree
+type DuplicateGroup struct {
+	// Title is the normalized title they share
+	Title string  `json:"title"`
+	Manga []Manga `json:"manga"`
+}
+
+// MergeResult is what merging a duplicate manga into another moved over to
+// the manga that was kept
+type MergeResult struct {
+	KeepID string `json:"keep_id"`
+	DropID string `json:"drop_id"`
+	// LibraryEntries counts the entries moved over as they were and
+	// MergedEntries those folded into an entry the user already had
+	LibraryEntries int `json:"library_entries"`
+	MergedEntries  int `json:"merged_entries"`
+	Subscriptions  int `json:"subscriptions"`
+	ChatMessages   int `json:"chat_messages"`
+	Notifications  int `json:"notifications"`
+	ProgressEvents int `json:"progress_events"`
+	Chapters       int `json:"chapters"`
+	Titles         int `json:"titles"`
+	ExternalIDs    int `json:"external_ids"`
+	// Redirects counts the redirects left by earlier merges into the
+	// dropped manga that now lead to the kept one
+	Redirects int `json:"redirects"`
+	// Filled lists the fields of the kept manga filled in from the dropped
+	// one, and Note what went wrong on the side such as a cover that could
+	// not be moved
+	Filled []string `json:"filled,omitempty"`
+	Note   string   `json:"note,omitempty"`
+}
@@ -2,6 +2,9 @@ package models
 
 import "time"
 
+// NotificationChapterRelease is the type of new chapter notifications
+const NotificationChapterRelease = "chapter_release"
+
 // Notification represents a notification
 type Notification struct {
 	ID        string                 `json:"id"`
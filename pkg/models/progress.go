@@ -13,6 +13,7 @@ type Progress struct {
 	StartedAt      time.Time  `json:"started_at"`
 	CompletedAt    *time.Time `json:"completed_at,omitempty"`
 	UpdatedAt      time.Time  `json:"updated_at"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"` // set while the entry is in the trash
 }
 
 // ProgressUpdate represents a progress update for sync
@@ -36,3 +37,45 @@ type ProgressStats struct {
 	ReadingStreak     int       `json:"reading_streak"`
 	LastReadDate      time.Time `json:"last_read_date"`
 }
+
+// Progress event sources
+const (
+	EventSourceHTTP     = "http"
+	EventSourceTCP      = "tcp"
+	EventSourceGRPC     = "grpc"
+	EventSourceCLI      = "cli"
+	EventSourceBackfill = "backfill"
+)
+
+// EventStatusRemoved is the to_status of an event that removed a library entry
+const EventStatusRemoved = "removed"
+
+// EventOrigin identifies where a progress change came from
+type EventOrigin struct {
+	Source   string `json:"source"`
+	DeviceID string `json:"device_id,omitempty"`
+}
+
+// ProgressEvent is one entry of the append-only reading log
+type ProgressEvent struct {
+	ID          int64     `json:"id"`
+	UserID      string    `json:"user_id"`
+	MangaID     string    `json:"manga_id"`
+	FromChapter int       `json:"from_chapter"`
+	ToChapter   int       `json:"to_chapter"`
+	FromStatus  string    `json:"from_status,omitempty"`
+	ToStatus    string    `json:"to_status,omitempty"`
+	Source      string    `json:"source"`
+	DeviceID    string    `json:"device_id,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ProgressEventFilter selects progress events; zero values match everything
+type ProgressEventFilter struct {
+	UserID  string
+	MangaID string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Offset  int
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Ranking windows bound the reading activity a ranking counts
+const (
+	RankingWindowWeek  = "week"
+	RankingWindowMonth = "month"
+	RankingWindowAll   = "all"
+)
+
+// RankingWindows are the accepted ranking windows, shortest first
+var RankingWindows = []string{RankingWindowWeek, RankingWindowMonth, RankingWindowAll}
+
+// RankingWindowStart returns the start of a window ending at now. The
+// all-time window and unknown windows return the zero time.
+func RankingWindowStart(window string, now time.Time) time.Time {
+	switch window {
+	case RankingWindowWeek:
+		return now.AddDate(0, 0, -7)
+	case RankingWindowMonth:
+		return now.AddDate(0, 0, -30)
+	}
+	return time.Time{}
+}
+
+// RankingStats are the reading figures a manga is ranked by. ActiveReaders,
+// Completions and Activity count the progress events inside the window;
+// AverageRating and Ratings cover every rating in the library.
+type RankingStats struct {
+	ActiveReaders int     `json:"active_readers"`
+	Completions   int     `json:"completions"`
+	Activity      int     `json:"activity"`
+	AverageRating float64 `json:"average_rating"`
+	Ratings       int     `json:"ratings"`
+}
+
+// RankedManga is one manga of a ranking
+type RankedManga struct {
+	Rank  int     `json:"rank"`
+	Score float64 `json:"score"`
+	Manga Manga   `json:"manga"`
+	RankingStats
+}
+
+// Rankings is a ranking of manga over a window, optionally within a genre.
+// GeneratedAt tells how fresh the cached ranking is.
+type Rankings struct {
+	Window      string        `json:"window"`
+	Genre       string        `json:"genre,omitempty"`
+	GeneratedAt time.Time     `json:"generated_at"`
+	Rankings    []RankedManga `json:"rankings"`
+	Total       int           `json:"total"`
+}
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Similarity is the stored score of SimilarID as a recommendation for
+// readers of MangaID. GenreScore and CoReadScore are the signals the score
+// combines, each from 0 to 1.
+type Similarity struct {
+	MangaID     string  `json:"manga_id"`
+	SimilarID   string  `json:"similar_id"`
+	Score       float64 `json:"score"`
+	GenreScore  float64 `json:"genre_score"`
+	CoReadScore float64 `json:"coread_score"`
+}
+
+// SimilarManga is a manga recommended to readers of another one
+type SimilarManga struct {
+	Manga       Manga   `json:"manga"`
+	Score       float64 `json:"score"`
+	GenreScore  float64 `json:"genre_score"`
+	CoReadScore float64 `json:"coread_score"`
+}
+
+// SimilarList is the stored similar manga of one manga
+type SimilarList struct {
+	MangaID    string         `json:"manga_id"`
+	Similar    []SimilarManga `json:"similar"`
+	Total      int            `json:"total"`
+	ComputedAt *time.Time     `json:"computed_at,omitempty"`
+}
+
+// Recommendation is a manga recommended to a user. Because lists the
+// titles in their library that led to it, most influential first.
+type Recommendation struct {
+	Manga   Manga    `json:"manga"`
+	Score   float64  `json:"score"`
+	Because []string `json:"because,omitempty"`
+}
+
+// Recommendations is the personalized recommendation list of a user.
+// FavoriteGenres are the genres of their library, most frequent first.
+type Recommendations struct {
+	Recommendations []Recommendation `json:"recommendations"`
+	FavoriteGenres  []string         `json:"favorite_genres,omitempty"`
+	Total           int              `json:"total"`
+	ComputedAt      *time.Time       `json:"computed_at,omitempty"`
+}
+
+// SimilaritySignals are the inputs of a similarity refresh: the genres of
+// every manga outside the trash and every library entry outside the trash
+type SimilaritySignals struct {
+	Genres  map[string][]string
+	Entries []Progress
+}
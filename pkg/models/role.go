@@ -0,0 +1,67 @@
+package models
+
+// Roles a user can hold. Every account starts as a user; moderators also
+// curate the catalog, and admins also run the server and hand out roles.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+// Roles lists the roles from least to most trusted
+var Roles = []string{RoleUser, RoleModerator, RoleAdmin}
+
+// Permission is something only some roles are allowed to do
+type Permission string
+
+const (
+	// PermEditCatalog covers creating, editing, trashing and restoring
+	// catalog manga with their chapters, titles and covers, and refreshing
+	// or importing them from metadata sources
+	PermEditCatalog Permission = "catalog:edit"
+	// PermMergeManga covers merging duplicate manga, which moves every
+	// reader's library entries, subscriptions and history
+	PermMergeManga Permission = "catalog:merge"
+	// PermManageServer covers the server logs and database maintenance
+	PermManageServer Permission = "server:manage"
+	// PermManageUsers covers granting and revoking roles and acting on
+	// behalf of other users
+	PermManageUsers Permission = "users:manage"
+)
+
+// rolePermissions lists what each role beyond a plain user may do
+var rolePermissions = map[string][]Permission{
+	RoleModerator: {PermEditCatalog},
+	RoleAdmin:     {PermEditCatalog, PermMergeManga, PermManageServer, PermManageUsers},
+}
+
+// ValidRole reports whether role is one of Roles
+func ValidRole(role string) bool {
+	for _, r := range Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleAllows reports whether role grants perm
+func RoleAllows(role string, perm Permission) bool {
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RolesWith lists the roles that grant perm, from least to most trusted
+func RolesWith(perm Permission) []string {
+	var roles []string
+	for _, role := range Roles {
+		if RoleAllows(role, perm) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// Session is a login on one device. Its refresh token is only kept hashed,
+// and a new one replaces it each time it is used.
+type Session struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id"`
+	// Device is what the client called itself at login, such as the CLI's
+	// hostname, or its User-Agent
+	Device     string     `json:"device"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	// Current marks the session of the request that listed it
+	Current bool `json:"current,omitempty"`
+
+	// RefreshHash is the hash of the session's refresh token and
+	// PreviousHash that of the one it replaced, which must not come back
+	RefreshHash  string `json:"-"`
+	PreviousHash string `json:"-"`
+}
+
+// RefreshRequest trades a refresh token for new tokens, or ends its
+// session on logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ChangePasswordRequest represents a password change
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MangaTitle is an alternate or localized title of a manga. Language is a
+// MangaDex-style code such as "en", "ja" or "ja-ro" (romaji); a manga has at
+// most one primary title per language.
+type MangaTitle struct {
+	ID        int64  `json:"id"`
+	MangaID   string `json:"manga_id"`
+	Language  string `json:"language"`
+	Title     string `json:"title"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+// NormalizeLanguage lowercases a language code and turns "ja_RO" into
+// "ja-ro"
+func NormalizeLanguage(language string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(language)), "_", "-")
+}
+
+// NormalizeTitle folds a title for duplicate detection: lowercase letters
+// and digits with every run of other characters turned into one space, so
+// "Attack on Titan!" and "attack  on titan" compare equal
+func NormalizeTitle(title string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// LocalizedTitle picks the title to show in a language: the primary title in
+// that language, else its first title in that language. ok is false when
+// the manga has no title in the language.
+func LocalizedTitle(titles []MangaTitle, language string) (title string, ok bool) {
+	language = NormalizeLanguage(language)
+	for _, t := range titles {
+		if t.Language != language {
+			continue
+		}
+		if t.IsPrimary {
+			return t.Title, true
+		}
+		if !ok {
+			title, ok = t.Title, true
+		}
+	}
+	return title, ok
+}
+
+// Localize shows the manga under its title in the language, keeping the
+// catalog title in OriginalTitle. It is a no-op when none of the titles is
+// in the language.
+func (m *Manga) Localize(titles []MangaTitle, language string) {
+	if title, ok := LocalizedTitle(titles, language); ok && title != m.Title {
+		m.OriginalTitle = m.Title
+		m.Title = title
+	}
+}
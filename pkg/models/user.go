@@ -10,6 +10,13 @@ type User struct {
 	PasswordHash string    `json:"-"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+
+	// TitleLanguage is the language manga titles are shown in, e.g. "en",
+	// "ja" or "ja-ro"; empty shows the catalog titles
+	TitleLanguage string `json:"title_language,omitempty"`
+
+	// Role is one of RoleUser, RoleModerator or RoleAdmin
+	Role string `json:"role"`
 }
 
 // LoginRequest represents a login request
@@ -17,14 +24,22 @@ type LoginRequest struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	// Device names the device the session is on; the User-Agent if empty
+	Device string `json:"device,omitempty"`
 }
 
-// LoginResponse represents a login response
+// LoginResponse represents a login response, and the new tokens a refresh
+// returns. Token is the short-lived access token; RefreshToken gets a new
+// pair until RefreshExpiresAt, and works only once.
 type LoginResponse struct {
-	UserID    string    `json:"user_id"`
-	Username  string    `json:"username"`
-	Token     string    `json:"token"`
-	ExpiresAt time.Time `json:"expires_at"`
+	UserID           string    `json:"user_id"`
+	Username         string    `json:"username"`
+	Role             string    `json:"role"`
+	Token            string    `json:"token"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        string    `json:"session_id"`
 }
 
 // RegisterRequest represents a registration request
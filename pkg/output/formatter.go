@@ -1,3 +1,14 @@
 package output
 
+import "strings"
+
 // Output formatter
+
+// FormatSnippet renders a search snippet for the terminal, replacing the
+// <mark></mark> highlight tags returned by the API with asterisks and
+// collapsing whitespace so the snippet fits on one line
+func FormatSnippet(snippet string) string {
+	snippet = strings.ReplaceAll(snippet, "<mark>", "*")
+	snippet = strings.ReplaceAll(snippet, "</mark>", "*")
+	return strings.Join(strings.Fields(snippet), " ")
+}
@@ -0,0 +1,155 @@
+// Package retention enforces the retention policies from config.yaml: it
+// prunes old rows from the chat and notification tables and rotates the
+// server log.
+package retention
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"mangahub/pkg/config"
+	"mangahub/pkg/database"
+)
+
+// pruneBatchSize bounds how many rows one DELETE removes, so a large prune
+// never holds the write lock for long
+const pruneBatchSize = 1000
+
+// Policy is the retention period of one table
+type Policy struct {
+	Table string `json:"table"`
+	Days  int    `json:"days"`
+	// age is a SQL expression giving the row's age as unix seconds
+	age string
+}
+
+// Result reports what a prune removed, or would remove, from one table
+type Result struct {
+	Table  string    `json:"table"`
+	Days   int       `json:"days"`
+	Before time.Time `json:"before"`
+	Rows   int64     `json:"rows"`
+}
+
+// Policies returns the table retention policies from the configuration
+func Policies(cfg config.RetentionConfig) []Policy {
+	return []Policy{
+		{
+			Table: "chat_messages",
+			Days:  cfg.ChatMessagesDays,
+			age:   `COALESCE(timestamp, CAST(strftime('%s', substr(created_at, 1, 19)) AS INTEGER))`,
+		},
+		{
+			Table: "notifications",
+			Days:  cfg.NotificationsDays,
+			age:   `CAST(strftime('%s', substr(created_at, 1, 19)) AS INTEGER)`,
+		},
+	}
+}
+
+// Prune deletes rows older than each policy allows. With dryRun it only
+// counts them. Policies with no retention period are skipped.
+func Prune(db *database.Database, policies []Policy, now time.Time, dryRun bool) ([]Result, error) {
+	var results []Result
+	for _, p := range policies {
+		if p.Days <= 0 {
+			continue
+		}
+		before := now.AddDate(0, 0, -p.Days)
+		where := ` WHERE ` + p.age + ` < ?`
+
+		result := Result{Table: p.Table, Days: p.Days, Before: before}
+		if dryRun {
+			if err := db.QueryRow(`SELECT COUNT(*) FROM `+p.Table+where, before.Unix()).Scan(&result.Rows); err != nil {
+				return results, fmt.Errorf("failed to count %s: %w", p.Table, err)
+			}
+			results = append(results, result)
+			continue
+		}
+
+		query := `DELETE FROM ` + p.Table + ` WHERE rowid IN (SELECT rowid FROM ` + p.Table + where + ` LIMIT ?)`
+		for {
+			res, err := db.Exec(query, before.Unix(), pruneBatchSize)
+			if err != nil {
+				return results, fmt.Errorf("failed to prune %s: %w", p.Table, err)
+			}
+			n, _ := res.RowsAffected()
+			result.Rows += n
+			if n < pruneBatchSize {
+				break
+			}
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// LogRotation reports the state of the server log
+type LogRotation struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Rotated bool   `json:"rotated"`
+}
+
+// RotateLog rotates the log at path once it grows past maxSize bytes,
+// keeping up to backups old copies as path.1 (newest) to path.N. The log is
+// copied and then truncated in place, so processes appending to it keep
+// writing to the live file. With dryRun it only reports whether the log
+// would be rotated.
+func RotateLog(path string, maxSize int64, backups int, dryRun bool) (LogRotation, error) {
+	rotation := LogRotation{Path: path}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return rotation, nil
+	}
+	if err != nil {
+		return rotation, err
+	}
+	rotation.Size = info.Size()
+	if maxSize <= 0 || info.Size() <= maxSize {
+		return rotation, nil
+	}
+	rotation.Rotated = true
+	if dryRun {
+		return rotation, nil
+	}
+
+	if backups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", path, backups))
+		for i := backups - 1; i >= 1; i-- {
+			from := fmt.Sprintf("%s.%d", path, i)
+			if _, err := os.Stat(from); err == nil {
+				if err := os.Rename(from, fmt.Sprintf("%s.%d", path, i+1)); err != nil {
+					return rotation, fmt.Errorf("failed to rotate %s: %w", from, err)
+				}
+			}
+		}
+		if err := copyFile(path, path+".1"); err != nil {
+			return rotation, fmt.Errorf("failed to rotate %s: %w", path, err)
+		}
+	}
+	if err := os.Truncate(path, 0); err != nil {
+		return rotation, fmt.Errorf("failed to truncate %s: %w", path, err)
+	}
+	return rotation, nil
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
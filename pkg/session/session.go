@@ -4,11 +4,15 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // CurrentProfile holds the active profile name
 var CurrentProfile = "default"
 
+// TimeFormat is the format ExpiresAt is stored in
+const TimeFormat = "2006-01-02 15:04:05 MST"
+
 // Session stores the current user session
 type Session struct {
 	UserID    string `json:"user_id"`
@@ -16,6 +20,19 @@ type Session struct {
 	Email     string `json:"email"`
 	Token     string `json:"token"`
 	ExpiresAt string `json:"expires_at"`
+	// RefreshToken renews Token when it expires; SessionID names the
+	// server-side session both belong to
+	RefreshToken string `json:"refresh_token,omitempty"`
+	SessionID    string `json:"session_id,omitempty"`
+}
+
+// TokenExpired reports whether Token has expired, going by ExpiresAt
+func (s *Session) TokenExpired() bool {
+	expiresAt, err := time.Parse(TimeFormat, s.ExpiresAt)
+	if err != nil {
+		return false
+	}
+	return !time.Now().Before(expiresAt)
 }
 
 // SetProfile sets the current profile name
@@ -0,0 +1,125 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+const accessTokenColumns = "id, user_id, name, token_hash, hint, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// SQLiteAccessTokenStore is an AccessTokenStore backed by SQLite
+type SQLiteAccessTokenStore struct {
+	db *database.Database
+}
+
+// NewSQLiteAccessTokenStore creates a SQLite personal access token store
+func NewSQLiteAccessTokenStore(db *database.Database) *SQLiteAccessTokenStore {
+	return &SQLiteAccessTokenStore{db: db}
+}
+
+// Create records a new personal access token
+func (s *SQLiteAccessTokenStore) Create(token *models.AccessToken) error {
+	_, err := s.db.Exec(`INSERT INTO access_tokens (`+accessTokenColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULL, NULL)`,
+		token.ID, token.UserID, token.Name, token.TokenHash, token.Hint, strings.Join(token.Scopes, " "),
+		timestamp(token.CreatedAt), timestamp(token.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to create access token: %w", err)
+	}
+	return nil
+}
+
+// GetByHash retrieves the personal access token with the hash
+func (s *SQLiteAccessTokenStore) GetByHash(hash string) (*models.AccessToken, error) {
+	token, err := scanAccessToken(s.db.QueryRow(`SELECT `+accessTokenColumns+` FROM access_tokens WHERE token_hash = ?`, hash))
+	if err == sql.ErrNoRows {
+		return nil, ErrAccessTokenNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get access token: %w", err)
+	}
+	return token, nil
+}
+
+// List lists a user's tokens that are neither revoked nor expired at now,
+// newest first
+func (s *SQLiteAccessTokenStore) List(userID string, now time.Time) ([]models.AccessToken, error) {
+	rows, err := s.db.Query(`
+		SELECT `+accessTokenColumns+` FROM access_tokens
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY created_at DESC`, userID, timestamp(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := []models.AccessToken{}
+	for rows.Next() {
+		token, err := scanAccessToken(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan access token: %w", err)
+		}
+		tokens = append(tokens, *token)
+	}
+	return tokens, rows.Err()
+}
+
+// Revoke ends one of a user's personal access tokens
+func (s *SQLiteAccessTokenStore) Revoke(userID, id string, at time.Time) error {
+	res, err := s.db.Exec(`UPDATE access_tokens SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL`,
+		timestamp(at), id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	if n == 0 {
+		return ErrAccessTokenNotFound
+	}
+	return nil
+}
+
+// Touch records that a token was used
+func (s *SQLiteAccessTokenStore) Touch(id string, at time.Time) error {
+	if _, err := s.db.Exec(`UPDATE access_tokens SET last_used_at = ? WHERE id = ?`, timestamp(at), id); err != nil {
+		return fmt.Errorf("failed to update access token: %w", err)
+	}
+	return nil
+}
+
+// PurgeEnded deletes the tokens that expired or were revoked before the
+// cutoff
+func (s *SQLiteAccessTokenStore) PurgeEnded(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM access_tokens WHERE expires_at < ?1 OR revoked_at < ?1`, timestamp(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge access tokens: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func scanAccessToken(row rowScanner) (*models.AccessToken, error) {
+	var token models.AccessToken
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&token.ID, &token.UserID, &token.Name, &token.TokenHash, &token.Hint, &scopes,
+		&token.CreatedAt, &token.ExpiresAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	token.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		t := lastUsedAt.Time
+		token.LastUsedAt = &t
+	}
+	if revokedAt.Valid {
+		t := revokedAt.Time
+		token.RevokedAt = &t
+	}
+	return &token, nil
+}
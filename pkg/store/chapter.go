@@ -0,0 +1,188 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+const chapterColumns = "id, manga_id, number, volume, title, language, pages, released_at, created_at, updated_at"
+
+// SQLiteChapterStore is a ChapterStore backed by SQLite. The chapters
+// triggers keep manga.chapters in step with the table.
+type SQLiteChapterStore struct {
+	db *database.Database
+}
+
+// NewSQLiteChapterStore creates a SQLite chapter store
+func NewSQLiteChapterStore(db *database.Database) *SQLiteChapterStore {
+	return &SQLiteChapterStore{db: db}
+}
+
+// Create adds a chapter to a manga
+func (s *SQLiteChapterStore) Create(chapter *models.Chapter) error {
+	if chapter.Language == "" {
+		chapter.Language = models.DefaultChapterLanguage
+	}
+
+	var exists int
+	err := s.db.QueryRow(`SELECT 1 FROM manga WHERE id = ? AND deleted_at IS NULL`, chapter.MangaID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrMangaNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create chapter: %w", err)
+	}
+
+	now := time.Now().UTC()
+	result, err := s.db.Exec(`
+		INSERT INTO chapters (manga_id, number, volume, title, language, pages, released_at, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		chapter.MangaID, chapter.Number, nullInt(chapter.Volume), chapter.Title, chapter.Language,
+		nullInt(chapter.Pages), nullTimestamp(chapter.ReleasedAt),
+		now.Format(database.TimestampFormat), now.Format(database.TimestampFormat))
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create chapter: %w", err)
+	}
+
+	chapter.ID, _ = result.LastInsertId()
+	chapter.CreatedAt, chapter.UpdatedAt = now, now
+	return nil
+}
+
+// Get retrieves a chapter by ID
+func (s *SQLiteChapterStore) Get(id int64) (*models.Chapter, error) {
+	chapter, err := scanChapter(s.db.QueryRow(`SELECT `+chapterColumns+` FROM chapters WHERE id = ?`, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrChapterNotFound
+		}
+		return nil, fmt.Errorf("failed to get chapter: %w", err)
+	}
+	return chapter, nil
+}
+
+// List returns a manga's chapters ordered by number
+func (s *SQLiteChapterStore) List(filter models.ChapterFilter) ([]models.Chapter, error) {
+	query := `SELECT ` + chapterColumns + ` FROM chapters WHERE manga_id = ?`
+	args := []interface{}{filter.MangaID}
+
+	if filter.After > 0 {
+		query += ` AND number > ?`
+		args = append(args, filter.After)
+	}
+	if filter.Language != "" {
+		query += ` AND language = ?`
+		args = append(args, filter.Language)
+	}
+
+	order := "ASC"
+	if strings.EqualFold(filter.Order, "desc") {
+		order = "DESC"
+	}
+	query += ` ORDER BY number ` + order + `, language ASC LIMIT ? OFFSET ?`
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list chapters: %w", err)
+	}
+	defer rows.Close()
+
+	var chapters []models.Chapter
+	for rows.Next() {
+		chapter, err := scanChapter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chapter: %w", err)
+		}
+		chapters = append(chapters, *chapter)
+	}
+	return chapters, rows.Err()
+}
+
+// Update updates a chapter
+func (s *SQLiteChapterStore) Update(chapter *models.Chapter) error {
+	if chapter.Language == "" {
+		chapter.Language = models.DefaultChapterLanguage
+	}
+
+	now := time.Now().UTC()
+	_, err := s.db.Exec(`
+		UPDATE chapters
+		SET number = ?, volume = ?, title = ?, language = ?, pages = ?, released_at = ?, updated_at = ?
+		WHERE id = ?`,
+		chapter.Number, nullInt(chapter.Volume), chapter.Title, chapter.Language, nullInt(chapter.Pages),
+		nullTimestamp(chapter.ReleasedAt), now.Format(database.TimestampFormat), chapter.ID)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to update chapter: %w", err)
+	}
+	chapter.UpdatedAt = now
+	return nil
+}
+
+// Delete deletes a chapter
+func (s *SQLiteChapterStore) Delete(id int64) error {
+	if _, err := s.db.Exec(`DELETE FROM chapters WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete chapter: %w", err)
+	}
+	return nil
+}
+
+func scanChapter(row rowScanner) (*models.Chapter, error) {
+	var chapter models.Chapter
+	var volume, pages sql.NullInt64
+	var title sql.NullString
+	var releasedAt sql.NullTime
+	err := row.Scan(&chapter.ID, &chapter.MangaID, &chapter.Number, &volume, &title, &chapter.Language,
+		&pages, &releasedAt, &chapter.CreatedAt, &chapter.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	chapter.Volume = int(volume.Int64)
+	chapter.Title = title.String
+	chapter.Pages = int(pages.Int64)
+	if releasedAt.Valid {
+		t := releasedAt.Time
+		chapter.ReleasedAt = &t
+	}
+	return &chapter, nil
+}
+
+// nullInt stores 0 as NULL for optional integer columns
+func nullInt(v int) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// nullString stores "" as NULL for optional text columns
+func nullString(v string) interface{} {
+	if v == "" {
+		return nil
+	}
+	return v
+}
+
+// nullTimestamp stores an optional time as TimestampFormat text
+func nullTimestamp(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(database.TimestampFormat)
+}
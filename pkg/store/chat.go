@@ -0,0 +1,70 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteChatStore is a ChatStore backed by SQLite
+type SQLiteChatStore struct {
+	db *database.Database
+}
+
+// NewSQLiteChatStore creates a SQLite chat store
+func NewSQLiteChatStore(db *database.Database) *SQLiteChatStore {
+	return &SQLiteChatStore{db: db}
+}
+
+// SaveMessage stores a chat message, filling in the ID and timestamps when unset
+func (s *SQLiteChatStore) SaveMessage(msg *models.ChatMessage) error {
+	fillChatMessage(msg)
+	query := `
+		INSERT INTO chat_messages (id, user_id, username, room_id, message, timestamp, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, msg.ID, msg.UserID, msg.Username, msg.RoomID, msg.Message, msg.Timestamp, msg.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store message: %w", err)
+	}
+	return nil
+}
+
+// History returns the newest messages of a room, newest first
+func (s *SQLiteChatStore) History(roomID string, limit int) ([]models.ChatMessage, error) {
+	query := `
+		SELECT id, user_id, username, room_id, message, COALESCE(timestamp, 0), created_at
+		FROM chat_messages WHERE room_id = ? ORDER BY created_at DESC LIMIT ?
+	`
+	rows, err := s.db.Query(query, roomID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chat history: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []models.ChatMessage
+	for rows.Next() {
+		var msg models.ChatMessage
+		if err := rows.Scan(&msg.ID, &msg.UserID, &msg.Username, &msg.RoomID, &msg.Message, &msg.Timestamp, &msg.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan chat message: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, rows.Err()
+}
+
+// fillChatMessage sets the defaults shared by every ChatStore
+func fillChatMessage(msg *models.ChatMessage) {
+	now := time.Now()
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = now
+	}
+	if msg.Timestamp == 0 {
+		msg.Timestamp = msg.CreatedAt.Unix()
+	}
+	if msg.ID == "" {
+		msg.ID = fmt.Sprintf("%s-%d", msg.UserID, msg.CreatedAt.UnixNano())
+	}
+}
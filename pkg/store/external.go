@@ -0,0 +1,189 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteExternalIDStore is an ExternalIDStore backed by SQLite
+type SQLiteExternalIDStore struct {
+	db *database.Database
+}
+
+// NewSQLiteExternalIDStore creates a SQLite external ID store
+func NewSQLiteExternalIDStore(db *database.Database) *SQLiteExternalIDStore {
+	return &SQLiteExternalIDStore{db: db}
+}
+
+// Link links an external ID to a manga
+func (s *SQLiteExternalIDStore) Link(id *models.ExternalID) error {
+	var exists int
+	err := s.db.QueryRow(`SELECT 1 FROM manga WHERE id = ?`, id.MangaID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrMangaNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up manga: %w", err)
+	}
+
+	now := time.Now()
+	_, err = s.db.Exec(`
+		INSERT INTO manga_external_ids (source, external_id, manga_id, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (source, external_id) DO UPDATE SET manga_id = excluded.manga_id, created_at = excluded.created_at`,
+		id.Source, id.ExternalID, id.MangaID, now)
+	if err != nil {
+		return fmt.Errorf("failed to link external ID: %w", err)
+	}
+	id.CreatedAt = now
+	return nil
+}
+
+// Find returns the ID of the manga an external ID links to
+func (s *SQLiteExternalIDStore) Find(source, externalID string) (string, error) {
+	var mangaID string
+	err := s.db.QueryRow(`SELECT manga_id FROM manga_external_ids WHERE source = ? AND external_id = ?`,
+		source, externalID).Scan(&mangaID)
+	if err == sql.ErrNoRows {
+		return "", ErrExternalIDNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to find external ID: %w", err)
+	}
+	return mangaID, nil
+}
+
+// List returns the external IDs of a manga
+func (s *SQLiteExternalIDStore) List(mangaID string) ([]models.ExternalID, error) {
+	rows, err := s.db.Query(`
+		SELECT source, external_id, manga_id, created_at, fetched_at FROM manga_external_ids
+		WHERE manga_id = ? ORDER BY source, external_id`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list external IDs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []models.ExternalID
+	for rows.Next() {
+		var id models.ExternalID
+		var createdAt, fetchedAt sql.NullTime
+		if err := rows.Scan(&id.Source, &id.ExternalID, &id.MangaID, &createdAt, &fetchedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan external ID: %w", err)
+		}
+		id.CreatedAt = createdAt.Time
+		if fetchedAt.Valid {
+			id.FetchedAt = &fetchedAt.Time
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// SaveEntry links the entry's external ID to a manga and keeps the entry
+func (s *SQLiteExternalIDStore) SaveEntry(mangaID string, entry *models.CatalogEntry) error {
+	if entry.FetchedAt.IsZero() {
+		entry.FetchedAt = time.Now()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode catalog entry: %w", err)
+	}
+
+	var exists int
+	err = s.db.QueryRow(`SELECT 1 FROM manga WHERE id = ?`, mangaID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrMangaNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up manga: %w", err)
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO manga_external_ids (source, external_id, manga_id, created_at, data, fetched_at) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (source, external_id) DO UPDATE SET manga_id = excluded.manga_id, data = excluded.data, fetched_at = excluded.fetched_at`,
+		entry.Source, entry.ExternalID, mangaID, time.Now(), string(data), entry.FetchedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save catalog entry: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the saved entries of the sources linked to a manga
+func (s *SQLiteExternalIDStore) Entries(mangaID string) ([]models.CatalogEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT data FROM manga_external_ids
+		WHERE manga_id = ? AND data IS NOT NULL ORDER BY source, external_id`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.CatalogEntry
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan catalog entry: %w", err)
+		}
+		var entry models.CatalogEntry
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode catalog entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// FieldSources returns the source of each field of a manga
+func (s *SQLiteExternalIDStore) FieldSources(mangaID string) (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT field, source FROM manga_field_sources WHERE manga_id = ?`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list field sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := make(map[string]string)
+	for rows.Next() {
+		var field, source string
+		if err := rows.Scan(&field, &source); err != nil {
+			return nil, fmt.Errorf("failed to scan field source: %w", err)
+		}
+		sources[field] = source
+	}
+	return sources, rows.Err()
+}
+
+// SetFieldSources replaces the field sources of a manga
+func (s *SQLiteExternalIDStore) SetFieldSources(mangaID string, sources map[string]string) error {
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to set field sources: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists int
+	err = tx.QueryRow(`SELECT 1 FROM manga WHERE id = ?`, mangaID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrMangaNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up manga: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM manga_field_sources WHERE manga_id = ?`, mangaID); err != nil {
+		return fmt.Errorf("failed to set field sources: %w", err)
+	}
+	for field, source := range sources {
+		if _, err := tx.Exec(`INSERT INTO manga_field_sources (manga_id, field, source) VALUES (?, ?, ?)`,
+			mangaID, field, source); err != nil {
+			return fmt.Errorf("failed to set field sources: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to set field sources: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package store
+
+import (
+	"sort"
+	"strings"
+
+	"mangahub/pkg/models"
+)
+
+const (
+	// minSimilarity is the similarity below which a title or author is not
+	// suggested; it matches the default pg_trgm threshold
+	minSimilarity = 0.3
+
+	// relativeSimilarity drops suggestions far worse than the best one, so
+	// "one peice" suggests One Piece but not every manga by ONE
+	relativeSimilarity = 0.7
+)
+
+// fuzzyCandidate is a manga with the texts a query may resemble
+type fuzzyCandidate struct {
+	id, title, author string
+	fields            []fuzzyField
+}
+
+type fuzzyField struct {
+	field, text string
+}
+
+// newFuzzyCandidate makes a candidate matching a manga's title, author and
+// alternate titles
+func newFuzzyCandidate(id, title, author string, altTitles ...string) fuzzyCandidate {
+	c := fuzzyCandidate{id: id, title: title, author: author}
+	c.fields = append(c.fields, fuzzyField{models.SuggestionFieldTitle, title})
+	for _, alt := range altTitles {
+		c.fields = append(c.fields, fuzzyField{models.SuggestionFieldAltTitle, alt})
+	}
+	if author != "" {
+		c.fields = append(c.fields, fuzzyField{models.SuggestionFieldAuthor, author})
+	}
+	return c
+}
+
+// rankSuggestions scores every candidate against the query by its most
+// similar field and returns the best ones, most similar first
+func rankSuggestions(query string, candidates []fuzzyCandidate, limit int) []models.Suggestion {
+	words := searchTerms(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	var suggestions []models.Suggestion
+	for _, c := range candidates {
+		best := models.Suggestion{MangaID: c.id, Title: c.title, Author: c.author}
+		for _, f := range c.fields {
+			if score := similarity(words, searchTerms(f.text)); score > best.Score {
+				best.Score = score
+				best.Matched = f.text
+				best.Field = f.field
+			}
+		}
+		if best.Score >= minSimilarity {
+			suggestions = append(suggestions, best)
+		}
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Title < suggestions[j].Title
+	})
+	for i, sg := range suggestions {
+		if sg.Score < suggestions[0].Score*relativeSimilarity {
+			suggestions = suggestions[:i]
+			break
+		}
+	}
+	return paginate(suggestions, limit, 0)
+}
+
+// similarity rates how closely text resembles the query, from 0 to 1. It is
+// the better of the trigram similarity of both texts and the share of query
+// words found in the text within a few typos, so both "one peice" and
+// "onepiece" resemble "One Piece".
+func similarity(query, text []string) float64 {
+	if len(query) == 0 || len(text) == 0 {
+		return 0
+	}
+
+	var credit float64
+	matched := 0
+	for _, q := range query {
+		best := 0.0
+		for _, t := range text {
+			if d := editDistance(q, t); d <= maxEdits(q) {
+				if c := 1 - float64(d)/float64(maxLen(q, t)); c > best {
+					best = c
+				}
+			}
+		}
+		if best > 0 {
+			matched++
+			credit += best
+		}
+	}
+	// Titles with many words the query does not mention rank lower
+	words := credit / float64(len(query)) * (0.8 + 0.2*float64(min(matched, len(text)))/float64(len(text)))
+
+	return max(words, trigramSimilarity(query, text))
+}
+
+// maxEdits is the number of typos tolerated in a query word
+func maxEdits(word string) int {
+	switch n := len([]rune(word)); {
+	case n <= 2:
+		return 0
+	case n <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+func maxLen(a, b string) int {
+	return max(len([]rune(a)), len([]rune(b)))
+}
+
+// trigramSimilarity is the share of trigrams two word lists have in common.
+// Like pg_trgm, every word is padded so its start and end count.
+func trigramSimilarity(a, b []string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range ta {
+		if tb[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(ta)+len(tb)-common)
+}
+
+func trigrams(words []string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range words {
+		r := []rune("  " + w + " ")
+		for i := 0; i+3 <= len(r); i++ {
+			set[string(r[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// editDistance is the optimal string alignment distance between two words:
+// insertions, deletions, substitutions and transpositions of adjacent
+// letters each count as one edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// autocompleteScore rates a title for a typed prefix: 1 when the title
+// starts with it, 0.8 when a later word does and 0 otherwise
+func autocompleteScore(prefix, title string) float64 {
+	prefix = strings.ToLower(strings.TrimSpace(prefix))
+	title = strings.ToLower(title)
+	if prefix == "" {
+		return 0
+	}
+	if strings.HasPrefix(title, prefix) {
+		return 1
+	}
+	if strings.Contains(title, " "+prefix) {
+		return 0.8
+	}
+	return 0
+}
+
+// betterCompletion orders autocomplete suggestions: higher score first, then
+// shorter and alphabetically earlier matches, then titles over alternate
+// titles
+func betterCompletion(a, b models.Suggestion) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if len(a.Matched) != len(b.Matched) {
+		return len(a.Matched) < len(b.Matched)
+	}
+	if a.Matched != b.Matched {
+		return a.Matched < b.Matched
+	}
+	return a.Field == models.SuggestionFieldTitle && b.Field != models.SuggestionFieldTitle
+}
@@ -0,0 +1,74 @@
+package store
+
+import (
+	"fmt"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteGenreStore is a GenreStore backed by SQLite
+type SQLiteGenreStore struct {
+	db *database.Database
+}
+
+// NewSQLiteGenreStore creates a SQLite genre store
+func NewSQLiteGenreStore(db *database.Database) *SQLiteGenreStore {
+	return &SQLiteGenreStore{db: db}
+}
+
+// List returns the taxonomy with aliases and manga counts
+func (s *SQLiteGenreStore) List(kind string) ([]models.Genre, error) {
+	query := `
+		SELECT g.id, g.slug, g.name, g.kind,
+			(SELECT COUNT(*) FROM manga_genres mg JOIN manga m ON m.id = mg.manga_id
+			 WHERE mg.genre_id = g.id AND m.deleted_at IS NULL)
+		FROM genres g`
+	var args []interface{}
+	if kind != "" {
+		query += ` WHERE g.kind = ?`
+		args = append(args, kind)
+	}
+	query += ` ORDER BY ` + genreKindOrder + `, g.name ASC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list genres: %w", err)
+	}
+	defer rows.Close()
+
+	var genres []models.Genre
+	index := make(map[int64]int)
+	for rows.Next() {
+		var g models.Genre
+		if err := rows.Scan(&g.ID, &g.Slug, &g.Name, &g.Kind, &g.MangaCount); err != nil {
+			return nil, fmt.Errorf("failed to scan genre: %w", err)
+		}
+		index[g.ID] = len(genres)
+		genres = append(genres, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list genres: %w", err)
+	}
+	rows.Close()
+
+	aliases, err := s.db.Query(`SELECT alias, genre_id FROM genre_aliases ORDER BY alias`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list genre aliases: %w", err)
+	}
+	defer aliases.Close()
+	for aliases.Next() {
+		var alias string
+		var id int64
+		if err := aliases.Scan(&alias, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan genre alias: %w", err)
+		}
+		if i, ok := index[id]; ok && alias != genres[i].Slug {
+			genres[i].Aliases = append(genres[i].Aliases, alias)
+		}
+	}
+	return genres, aliases.Err()
+}
+
+// genreKindOrder lists demographics first, then genres, then tags
+const genreKindOrder = `CASE g.kind WHEN 'demographic' THEN 0 WHEN 'genre' THEN 1 ELSE 2 END`
@@ -0,0 +1,377 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+const progressColumns = "user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at, deleted_at"
+
+const progressEventColumns = "id, user_id, manga_id, from_chapter, to_chapter, from_status, to_status, source, device_id, created_at"
+
+// SQLiteLibraryStore is a LibraryStore backed by SQLite
+type SQLiteLibraryStore struct {
+	db *database.Database
+}
+
+// NewSQLiteLibraryStore creates a SQLite library store
+func NewSQLiteLibraryStore(db *database.Database) *SQLiteLibraryStore {
+	return &SQLiteLibraryStore{db: db}
+}
+
+// Add adds a manga to a user's library. It returns ErrAlreadyExists when
+// the manga is already in the library outside the trash.
+func (s *SQLiteLibraryStore) Add(progress *models.Progress, origin models.EventOrigin) error {
+	query := `
+		INSERT INTO user_progress (user_id, manga_id, status, rating, notes, current_chapter, started_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	now := time.Now()
+	if progress.StartedAt.IsZero() {
+		progress.StartedAt = now
+	}
+	progress.UpdatedAt = now
+
+	err := s.withEvent(progress.UserID, progress.MangaID, origin, func(tx *sql.Tx, prev *models.Progress) (*eventState, error) {
+		// Adding a manga again replaces its trashed entry
+		if prev != nil && prev.DeletedAt != nil {
+			if _, err := tx.Exec(`DELETE FROM user_progress WHERE user_id = ? AND manga_id = ?`, progress.UserID, progress.MangaID); err != nil {
+				return nil, err
+			}
+		}
+		_, err := tx.Exec(query, progress.UserID, progress.MangaID, progress.Status, progress.Rating, progress.Notes,
+			progress.CurrentChapter, progress.StartedAt, progress.UpdatedAt)
+		return &eventState{progress.CurrentChapter, progress.Status}, err
+	})
+	if database.IsUniqueViolation(err) {
+		return ErrAlreadyExists
+	}
+	if err != nil {
+		return fmt.Errorf("failed to add to library: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a single library entry
+func (s *SQLiteLibraryStore) Get(userID, mangaID string) (*models.Progress, error) {
+	query := `SELECT ` + progressColumns + ` FROM user_progress WHERE user_id = ? AND manga_id = ? AND deleted_at IS NULL`
+
+	progress, err := scanProgress(s.db.QueryRow(query, userID, mangaID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrEntryNotFound
+		}
+		return nil, fmt.Errorf("failed to get library entry: %w", err)
+	}
+	return progress, nil
+}
+
+// List retrieves a user's library, optionally filtered by status
+func (s *SQLiteLibraryStore) List(userID, status string, limit, offset int) ([]models.Progress, error) {
+	query := `SELECT ` + progressColumns + ` FROM user_progress WHERE user_id = ? AND deleted_at IS NULL`
+	args := []interface{}{userID}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get library: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProgressRows(rows)
+}
+
+// Update updates a library entry
+func (s *SQLiteLibraryStore) Update(progress *models.Progress, origin models.EventOrigin) error {
+	query := `
+		UPDATE user_progress
+		SET current_chapter = ?, status = ?, rating = ?, notes = ?, completed_at = ?, updated_at = ?
+		WHERE user_id = ? AND manga_id = ?
+	`
+	progress.UpdatedAt = time.Now()
+
+	err := s.withEvent(progress.UserID, progress.MangaID, origin, func(tx *sql.Tx, prev *models.Progress) (*eventState, error) {
+		if prev == nil || prev.DeletedAt != nil {
+			return nil, nil
+		}
+		_, err := tx.Exec(query, progress.CurrentChapter, progress.Status, progress.Rating, progress.Notes,
+			progress.CompletedAt, progress.UpdatedAt, progress.UserID, progress.MangaID)
+		return &eventState{progress.CurrentChapter, progress.Status}, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update library entry: %w", err)
+	}
+	return nil
+}
+
+// Remove moves a library entry to the trash
+func (s *SQLiteLibraryStore) Remove(userID, mangaID string, origin models.EventOrigin) error {
+	err := s.withEvent(userID, mangaID, origin, func(tx *sql.Tx, prev *models.Progress) (*eventState, error) {
+		if prev == nil || prev.DeletedAt != nil {
+			return nil, nil
+		}
+		_, err := tx.Exec(`UPDATE user_progress SET deleted_at = ? WHERE user_id = ? AND manga_id = ?`,
+			time.Now().UTC().Format(database.TimestampFormat), userID, mangaID)
+		return &eventState{prev.CurrentChapter, models.EventStatusRemoved}, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove from library: %w", err)
+	}
+	return nil
+}
+
+// Restore moves a library entry out of the trash
+func (s *SQLiteLibraryStore) Restore(userID, mangaID string, origin models.EventOrigin) error {
+	err := s.withEvent(userID, mangaID, origin, func(tx *sql.Tx, prev *models.Progress) (*eventState, error) {
+		if prev == nil || prev.DeletedAt == nil {
+			return nil, ErrEntryNotFound
+		}
+		_, err := tx.Exec(`UPDATE user_progress SET deleted_at = NULL, updated_at = ? WHERE user_id = ? AND manga_id = ?`,
+			time.Now(), userID, mangaID)
+		return &eventState{prev.CurrentChapter, prev.Status}, err
+	})
+	if err == ErrEntryNotFound {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore library entry: %w", err)
+	}
+	return nil
+}
+
+// Trash lists a user's trashed entries, most recently removed first
+func (s *SQLiteLibraryStore) Trash(userID string, limit, offset int) ([]models.Progress, error) {
+	query := `SELECT ` + progressColumns + ` FROM user_progress
+		WHERE user_id = ? AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC LIMIT ? OFFSET ?`
+
+	rows, err := s.db.Query(query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get library trash: %w", err)
+	}
+	defer rows.Close()
+
+	return scanProgressRows(rows)
+}
+
+// PurgeTrash permanently deletes entries trashed before the cutoff
+func (s *SQLiteLibraryStore) PurgeTrash(before time.Time) (int64, error) {
+	result, err := s.db.Exec(`DELETE FROM user_progress WHERE deleted_at IS NOT NULL AND deleted_at < ?`,
+		before.UTC().Format(database.TimestampFormat))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge library trash: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Put writes an entry as given, keeping its timestamps
+func (s *SQLiteLibraryStore) Put(progress *models.Progress, origin models.EventOrigin) error {
+	query := `
+		INSERT INTO user_progress (user_id, manga_id, current_chapter, status, rating, notes, started_at, completed_at, updated_at, deleted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(user_id, manga_id) DO UPDATE SET
+			current_chapter = excluded.current_chapter,
+			status = excluded.status,
+			rating = excluded.rating,
+			notes = excluded.notes,
+			started_at = excluded.started_at,
+			completed_at = excluded.completed_at,
+			updated_at = excluded.updated_at,
+			deleted_at = excluded.deleted_at
+	`
+	var deletedAt sql.NullString
+	toStatus := progress.Status
+	if progress.DeletedAt != nil {
+		deletedAt = sql.NullString{String: progress.DeletedAt.UTC().Format(database.TimestampFormat), Valid: true}
+		toStatus = models.EventStatusRemoved
+	}
+	err := s.withEvent(progress.UserID, progress.MangaID, origin, func(tx *sql.Tx, _ *models.Progress) (*eventState, error) {
+		_, err := tx.Exec(query, progress.UserID, progress.MangaID, progress.CurrentChapter, progress.Status, progress.Rating,
+			progress.Notes, progress.StartedAt, progress.CompletedAt, progress.UpdatedAt, deletedAt)
+		return &eventState{progress.CurrentChapter, toStatus}, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save library entry: %w", err)
+	}
+	return nil
+}
+
+// SaveProgress records a synced chapter update
+func (s *SQLiteLibraryStore) SaveProgress(update *models.ProgressUpdate, origin models.EventOrigin) error {
+	timestamp := time.Unix(update.Timestamp, 0)
+
+	// Status is set to 'reading' on insert because the user has already read
+	// to a specific chapter; the schema default 'plan-to-read' is for
+	// library entries without progress. Reading a trashed manga restores it.
+	query := `
+		INSERT INTO user_progress (user_id, manga_id, current_chapter, status, started_at, updated_at)
+		VALUES (?, ?, ?, 'reading', ?, ?)
+		ON CONFLICT(user_id, manga_id) DO UPDATE SET
+			current_chapter = excluded.current_chapter,
+			updated_at = excluded.updated_at,
+			deleted_at = NULL
+	`
+	err := s.withEvent(update.UserID, update.MangaID, origin, func(tx *sql.Tx, prev *models.Progress) (*eventState, error) {
+		status := "reading"
+		if prev != nil {
+			status = prev.Status
+		}
+		_, err := tx.Exec(query, update.UserID, update.MangaID, update.Chapter, timestamp, timestamp)
+		return &eventState{update.Chapter, status}, err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save progress: %w", err)
+	}
+	return nil
+}
+
+// Events returns progress events matching the filter, newest first
+func (s *SQLiteLibraryStore) Events(filter models.ProgressEventFilter) ([]models.ProgressEvent, error) {
+	query := `SELECT ` + progressEventColumns + ` FROM progress_events WHERE 1=1`
+	var args []interface{}
+	if filter.UserID != "" {
+		query += ` AND user_id = ?`
+		args = append(args, filter.UserID)
+	}
+	if filter.MangaID != "" {
+		query += ` AND manga_id = ?`
+		args = append(args, filter.MangaID)
+	}
+	if !filter.Since.IsZero() {
+		query += ` AND created_at >= ?`
+		args = append(args, filter.Since.UTC().Format(database.TimestampFormat))
+	}
+	if !filter.Until.IsZero() {
+		query += ` AND created_at < ?`
+		args = append(args, filter.Until.UTC().Format(database.TimestampFormat))
+	}
+
+	// A negative LIMIT means no limit in SQLite
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = -1
+	}
+	query += ` ORDER BY created_at DESC, id DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get progress events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.ProgressEvent
+	for rows.Next() {
+		var event models.ProgressEvent
+		var fromStatus, toStatus, deviceID sql.NullString
+		err := rows.Scan(&event.ID, &event.UserID, &event.MangaID, &event.FromChapter, &event.ToChapter,
+			&fromStatus, &toStatus, &event.Source, &deviceID, &event.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan progress event: %w", err)
+		}
+		event.FromStatus = fromStatus.String
+		event.ToStatus = toStatus.String
+		event.DeviceID = deviceID.String
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// eventState is the chapter and status an entry is left in by a write
+type eventState struct {
+	chapter int
+	status  string
+}
+
+// withEvent runs write in a transaction and appends the matching progress
+// event. write receives the entry as it was before the change (nil when the
+// manga was not in the library) and returns the resulting state, or nil when
+// it changed nothing and no event should be recorded. Trashed entries are
+// passed as prev too, with DeletedAt set.
+func (s *SQLiteLibraryStore) withEvent(userID, mangaID string, origin models.EventOrigin,
+	write func(tx *sql.Tx, prev *models.Progress) (*eventState, error)) error {
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	prev, err := scanProgress(tx.QueryRow(`SELECT `+progressColumns+` FROM user_progress WHERE user_id = ? AND manga_id = ?`, userID, mangaID))
+	if err == sql.ErrNoRows {
+		prev = nil
+	} else if err != nil {
+		return err
+	}
+
+	state, err := write(tx, prev)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return tx.Commit()
+	}
+
+	var fromChapter int
+	var fromStatus sql.NullString
+	if prev != nil {
+		fromChapter = prev.CurrentChapter
+		fromStatus = sql.NullString{String: prev.Status, Valid: true}
+		if prev.DeletedAt != nil {
+			fromStatus.String = models.EventStatusRemoved
+		}
+	}
+	_, err = tx.Exec(`
+		INSERT INTO progress_events (user_id, manga_id, from_chapter, to_chapter, from_status, to_status, source, device_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		userID, mangaID, fromChapter, state.chapter, fromStatus, state.status,
+		origin.Source, sql.NullString{String: origin.DeviceID, Valid: origin.DeviceID != ""},
+		time.Now().UTC().Format(database.TimestampFormat))
+	if err != nil {
+		return fmt.Errorf("failed to record progress event: %w", err)
+	}
+	return tx.Commit()
+}
+
+func scanProgress(row rowScanner) (*models.Progress, error) {
+	var progress models.Progress
+	var notes sql.NullString
+	var startedAt, completedAt, deletedAt sql.NullTime
+	err := row.Scan(&progress.UserID, &progress.MangaID, &progress.CurrentChapter, &progress.Status,
+		&progress.Rating, &notes, &startedAt, &completedAt, &progress.UpdatedAt, &deletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	progress.Notes = notes.String
+	progress.StartedAt = startedAt.Time
+	if completedAt.Valid {
+		t := completedAt.Time
+		progress.CompletedAt = &t
+	}
+	if deletedAt.Valid {
+		t := deletedAt.Time
+		progress.DeletedAt = &t
+	}
+	return &progress, nil
+}
+
+func scanProgressRows(rows *sql.Rows) ([]models.Progress, error) {
+	var progressList []models.Progress
+	for rows.Next() {
+		progress, err := scanProgress(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan progress: %w", err)
+		}
+		progressList = append(progressList, *progress)
+	}
+	return progressList, rows.Err()
+}
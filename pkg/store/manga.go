@@ -0,0 +1,596 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+const mangaColumns = "id, title, author, genres, status, chapters, description, cover_url, created_at, updated_at, deleted_at, year"
+
+// SQLiteMangaStore is a MangaStore backed by SQLite
+type SQLiteMangaStore struct {
+	db *database.Database
+}
+
+// NewSQLiteMangaStore creates a SQLite manga store
+func NewSQLiteMangaStore(db *database.Database) *SQLiteMangaStore {
+	return &SQLiteMangaStore{db: db}
+}
+
+// Create creates a new manga entry. Its genres are resolved against the
+// taxonomy and replaced with their canonical names.
+func (s *SQLiteMangaStore) Create(manga *models.Manga) error {
+	query := `
+		INSERT INTO manga (id, title, author, status, chapters, description, cover_url, year, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to create manga: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	_, err = tx.Exec(query, manga.ID, manga.Title, manga.Author, manga.Status, manga.TotalChapters, manga.Description, manga.CoverURL, nullInt(manga.Year), now, now)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create manga: %w", err)
+	}
+	genres, err := database.SyncMangaGenres(tx, manga.ID, manga.Genres)
+	if err != nil {
+		return fmt.Errorf("failed to create manga: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to create manga: %w", err)
+	}
+	manga.Genres = genres
+	manga.CreatedAt, manga.UpdatedAt = now, now
+	return nil
+}
+
+// GetByID retrieves a manga by ID
+func (s *SQLiteMangaStore) GetByID(id string) (*models.Manga, error) {
+	query := `SELECT ` + mangaColumns + ` FROM manga WHERE id = ? AND deleted_at IS NULL`
+
+	manga, err := scanManga(s.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrMangaNotFound
+		}
+		return nil, fmt.Errorf("failed to get manga: %w", err)
+	}
+	return manga, nil
+}
+
+// List lists manga
+func (s *SQLiteMangaStore) List(limit, offset int) ([]models.Manga, error) {
+	query := "SELECT " + mangaColumns + " FROM manga WHERE deleted_at IS NULL LIMIT ? OFFSET ?"
+
+	rows, err := s.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list manga: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMangaRows(rows)
+}
+
+// Search returns manga matching the filter. A text query is matched
+// against title, author and description through the manga_fts index and
+// results carry a bm25 relevance score and a highlighted snippet. Every
+// result carries its reader count and average rating.
+func (s *SQLiteMangaStore) Search(filter *models.MangaFilter) ([]models.Manga, error) {
+	terms := searchTerms(filter.Query)
+
+	query := "SELECT " + prefixColumns("m", mangaColumns) + `,
+		COALESCE(stats.readers, 0), COALESCE(stats.avg_rating, 0)`
+	var args []interface{}
+	if len(terms) > 0 {
+		query += `,
+			-bm25(manga_fts, 0.0, 10.0, 5.0, 1.0, 8.0) AS relevance,
+			snippet(manga_fts, -1, '<mark>', '</mark>', '…', 12)
+			FROM manga_fts
+			JOIN manga m ON m.id = manga_fts.manga_id` + mangaStatsJoin + `
+			WHERE manga_fts MATCH ?`
+		args = append(args, ftsMatchQuery(terms))
+	} else {
+		query += `, 0, '' FROM manga m` + mangaStatsJoin + ` WHERE 1=1`
+	}
+
+	where, whereArgs := mangaFilterClauses(filter, "m.")
+	query += where
+	args = append(args, whereArgs...)
+
+	query += " ORDER BY " + mangaOrderClause(filter.SortBy, filter.Order, len(terms) > 0)
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, filter.Limit, filter.Offset)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search manga: %w", err)
+	}
+	defer rows.Close()
+
+	var mangaList []models.Manga
+	for rows.Next() {
+		var readers int
+		var rating, relevance float64
+		var snippet string
+		manga, err := scanManga(rows, &readers, &rating, &relevance, &snippet)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan manga: %w", err)
+		}
+		manga.Readers = readers
+		manga.AverageRating = rating
+		manga.Relevance = relevance
+		manga.Snippet = snippet
+		mangaList = append(mangaList, *manga)
+	}
+	return mangaList, rows.Err()
+}
+
+// mangaStatsJoin adds the reader count and average non-zero rating of
+// every manga from the library entries outside the trash
+const mangaStatsJoin = `
+	LEFT JOIN (
+		SELECT manga_id, COUNT(*) AS readers, AVG(NULLIF(rating, 0)) AS avg_rating
+		FROM user_progress WHERE deleted_at IS NULL GROUP BY manga_id
+	) stats ON stats.manga_id = m.id`
+
+// mangaSortColumns maps every models.MangaSortModes entry except relevance
+// to the expression it orders by. Sort keys never reach the SQL otherwise.
+var mangaSortColumns = map[string]string{
+	"title":          "m.title",
+	"author":         "m.author",
+	"status":         "m.status",
+	"total_chapters": "m.chapters",
+	"year":           "m.year",
+	"popularity":     "COALESCE(stats.readers, 0)",
+	"rating":         "stats.avg_rating",
+	"recent":         "m.updated_at",
+	"created_at":     "m.created_at",
+	"updated_at":     "m.updated_at",
+}
+
+// mangaOrderClause builds the ORDER BY expression for a sort mode. Unknown
+// modes sort by title and manga without a value sort last either way.
+func mangaOrderClause(sortBy, order string, ranked bool) string {
+	if sortBy == "relevance" && ranked {
+		return "relevance DESC, m.title ASC"
+	}
+	column, ok := mangaSortColumns[sortBy]
+	if !ok {
+		column = "m.title"
+	}
+	direction := "ASC"
+	if strings.EqualFold(order, "desc") {
+		direction = "DESC"
+	}
+	clause := column + " " + direction + " NULLS LAST"
+	if column != "m.title" {
+		clause += ", m.title ASC"
+	}
+	return clause
+}
+
+// Facets counts every manga matching the filter per genre and per status
+func (s *SQLiteMangaStore) Facets(filter *models.MangaFilter) (*models.SearchFacets, error) {
+	matched := "SELECT m.id FROM manga m WHERE 1=1"
+	var args []interface{}
+	if terms := searchTerms(filter.Query); len(terms) > 0 {
+		matched = `SELECT m.id FROM manga_fts JOIN manga m ON m.id = manga_fts.manga_id WHERE manga_fts MATCH ?`
+		args = append(args, ftsMatchQuery(terms))
+	}
+	where, whereArgs := mangaFilterClauses(filter, "m.")
+	matched += where
+	args = append(args, whereArgs...)
+
+	facets := &models.SearchFacets{Genres: []models.FacetCount{}, Status: []models.FacetCount{}}
+
+	rows, err := s.db.Query(`
+		SELECT g.slug, g.name, COUNT(*) FROM manga_genres mg
+		JOIN genres g ON g.id = mg.genre_id
+		WHERE mg.manga_id IN (`+matched+`)
+		GROUP BY g.id ORDER BY COUNT(*) DESC, g.name ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count genre facets: %w", err)
+	}
+	for rows.Next() {
+		var f models.FacetCount
+		if err := rows.Scan(&f.Value, &f.Name, &f.Count); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan genre facet: %w", err)
+		}
+		facets.Genres = append(facets.Genres, f)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count genre facets: %w", err)
+	}
+
+	rows, err = s.db.Query(`
+		SELECT COALESCE(status, ''), COUNT(*) FROM manga
+		WHERE id IN (`+matched+`)
+		GROUP BY 1 ORDER BY 2 DESC, 1 ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count status facets: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var f models.FacetCount
+		if err := rows.Scan(&f.Value, &f.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan status facet: %w", err)
+		}
+		facets.Status = append(facets.Status, f)
+	}
+	return facets, rows.Err()
+}
+
+// Fuzzy scores the title, alternate titles and author of every manga
+// outside the trash against the query. The catalog is scanned in full,
+// which is fine for the few thousand titles a MangaHub server holds.
+func (s *SQLiteMangaStore) Fuzzy(query string, limit int) ([]models.Suggestion, error) {
+	altTitles, err := s.altTitles()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT id, title, COALESCE(author, '') FROM manga WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fuzzy candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []fuzzyCandidate
+	for rows.Next() {
+		var id, title, author string
+		if err := rows.Scan(&id, &title, &author); err != nil {
+			return nil, fmt.Errorf("failed to scan fuzzy candidate: %w", err)
+		}
+		candidates = append(candidates, newFuzzyCandidate(id, title, author, altTitles[id]...))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load fuzzy candidates: %w", err)
+	}
+	return rankSuggestions(query, candidates, limit), nil
+}
+
+// altTitles returns the alternate titles of every manga by manga ID
+func (s *SQLiteMangaStore) altTitles() (map[string][]string, error) {
+	rows, err := s.db.Query(`SELECT manga_id, title FROM manga_titles ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load alternate titles: %w", err)
+	}
+	defer rows.Close()
+
+	titles := make(map[string][]string)
+	for rows.Next() {
+		var id, title string
+		if err := rows.Scan(&id, &title); err != nil {
+			return nil, fmt.Errorf("failed to scan alternate title: %w", err)
+		}
+		titles[id] = append(titles[id], title)
+	}
+	return titles, rows.Err()
+}
+
+// Autocomplete matches the prefix against the start of titles and
+// alternate titles and of their words. Each manga is suggested once, by
+// its best matching title.
+func (s *SQLiteMangaStore) Autocomplete(prefix string, limit int) ([]models.Suggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" {
+		return nil, nil
+	}
+	escaped := likeEscaper.Replace(prefix)
+
+	rows, err := s.db.Query(`
+		SELECT m.id, m.title, COALESCE(m.author, ''), t.matched, t.field
+		FROM (
+			SELECT id AS manga_id, title AS matched, ? AS field FROM manga
+			UNION ALL
+			SELECT manga_id, title, ? FROM manga_titles
+		) t
+		JOIN manga m ON m.id = t.manga_id
+		WHERE m.deleted_at IS NULL
+			AND (t.matched LIKE ? ESCAPE '\' OR t.matched LIKE ? ESCAPE '\')`,
+		models.SuggestionFieldTitle, models.SuggestionFieldAltTitle, escaped+"%", "% "+escaped+"%")
+	if err != nil {
+		return nil, fmt.Errorf("failed to autocomplete manga: %w", err)
+	}
+	defer rows.Close()
+
+	best := make(map[string]models.Suggestion)
+	for rows.Next() {
+		var sg models.Suggestion
+		if err := rows.Scan(&sg.MangaID, &sg.Title, &sg.Author, &sg.Matched, &sg.Field); err != nil {
+			return nil, fmt.Errorf("failed to scan manga: %w", err)
+		}
+		sg.Score = autocompleteScore(prefix, sg.Matched)
+		if prev, ok := best[sg.MangaID]; !ok || betterCompletion(sg, prev) {
+			best[sg.MangaID] = sg
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to autocomplete manga: %w", err)
+	}
+
+	suggestions := make([]models.Suggestion, 0, len(best))
+	for _, sg := range best {
+		suggestions = append(suggestions, sg)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return betterCompletion(suggestions[i], suggestions[j])
+	})
+	return paginate(suggestions, limit, 0), nil
+}
+
+// likeEscaper escapes the LIKE wildcards of user input for ESCAPE '\'
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// mangaFilterClauses builds the non-text filter conditions. Trashed manga
+// never match.
+func mangaFilterClauses(filter *models.MangaFilter, prefix string) (string, []interface{}) {
+	where := " AND " + prefix + "deleted_at IS NULL"
+	var args []interface{}
+
+	if len(filter.IDs) > 0 {
+		where += " AND " + prefix + "id IN (" + strings.TrimSuffix(strings.Repeat("?, ", len(filter.IDs)), ", ") + ")"
+		for _, id := range filter.IDs {
+			args = append(args, id)
+		}
+	}
+
+	// Genres match by slug or alias through the taxonomy, never by substring
+	for _, genre := range filter.Genres {
+		where += " AND " + prefix + `id IN (
+			SELECT mg.manga_id FROM manga_genres mg
+			JOIN genre_aliases a ON a.genre_id = mg.genre_id
+			WHERE a.alias = ?)`
+		args = append(args, models.GenreSlug(genre))
+	}
+
+	if filter.Status != "" {
+		where += " AND " + prefix + "status = ?"
+		args = append(args, filter.Status)
+	}
+
+	if filter.Author != "" {
+		where += " AND " + prefix + `author LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(filter.Author)+"%")
+	}
+
+	if filter.YearFrom > 0 {
+		where += " AND " + prefix + "year >= ?"
+		args = append(args, filter.YearFrom)
+	}
+
+	if filter.YearTo > 0 {
+		where += " AND " + prefix + "year <= ?"
+		args = append(args, filter.YearTo)
+	}
+
+	if filter.MinChapters > 0 {
+		where += " AND " + prefix + "chapters >= ?"
+		args = append(args, filter.MinChapters)
+	}
+
+	if filter.MinRating > 0 {
+		where += ` AND (SELECT AVG(rating) FROM user_progress
+			WHERE manga_id = ` + prefix + `id AND rating > 0 AND deleted_at IS NULL) >= ?`
+		args = append(args, filter.MinRating)
+	}
+
+	return where, args
+}
+
+// Update updates a manga entry
+func (s *SQLiteMangaStore) Update(manga *models.Manga) error {
+	// Manga with chapter rows keep the total derived from them
+	query := `
+		UPDATE manga
+		SET title = ?, author = ?, status = ?,
+			chapters = COALESCE((SELECT CAST(MAX(number) AS INTEGER) FROM chapters WHERE manga_id = manga.id), ?),
+			description = ?, cover_url = ?, year = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to update manga: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query, manga.Title, manga.Author, manga.Status, manga.TotalChapters, manga.Description, manga.CoverURL, nullInt(manga.Year), time.Now(), manga.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update manga: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrMangaNotFound
+	}
+	genres, err := database.SyncMangaGenres(tx, manga.ID, manga.Genres)
+	if err != nil {
+		return fmt.Errorf("failed to update manga: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update manga: %w", err)
+	}
+	manga.Genres = genres
+	return nil
+}
+
+// Delete moves a manga to the trash
+func (s *SQLiteMangaStore) Delete(id string) error {
+	result, err := s.db.Exec(`UPDATE manga SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		time.Now().UTC().Format(database.TimestampFormat), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete manga: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrMangaNotFound
+	}
+	return nil
+}
+
+// Restore moves a manga out of the trash
+func (s *SQLiteMangaStore) Restore(id string) error {
+	result, err := s.db.Exec(`UPDATE manga SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore manga: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrMangaNotFound
+	}
+	return nil
+}
+
+// Trash lists trashed manga, most recently deleted first
+func (s *SQLiteMangaStore) Trash(limit, offset int) ([]models.Manga, error) {
+	query := "SELECT " + mangaColumns + " FROM manga WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC LIMIT ? OFFSET ?"
+
+	rows, err := s.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trashed manga: %w", err)
+	}
+	defer rows.Close()
+
+	return scanMangaRows(rows)
+}
+
+// PurgeTrash permanently deletes manga trashed before the cutoff, together
+// with the chapters, library entries and subscriptions that point at them
+func (s *SQLiteMangaStore) PurgeTrash(before time.Time) (int64, error) {
+	cutoff := before.UTC().Format(database.TimestampFormat)
+	trashed := `SELECT id FROM manga WHERE deleted_at IS NOT NULL AND deleted_at < ?`
+
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge manga: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"chapters", "manga_genres", "user_progress", "notification_subscriptions"} {
+		if _, err := tx.Exec(`DELETE FROM `+table+` WHERE manga_id IN (`+trashed+`)`, cutoff); err != nil {
+			return 0, fmt.Errorf("failed to purge manga: %w", err)
+		}
+	}
+	result, err := tx.Exec(`DELETE FROM manga WHERE deleted_at IS NOT NULL AND deleted_at < ?`, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge manga: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to purge manga: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// Rankings returns the ranking figures of manga outside the trash. Active
+// readers, completions and activity come from the progress events since the
+// cutoff, ignoring removals; ratings come from the library entries.
+func (s *SQLiteMangaStore) Rankings(since time.Time, genre string) ([]models.RankedManga, error) {
+	query := "SELECT " + prefixColumns("m", mangaColumns) + `,
+		COALESCE(act.readers, 0), COALESCE(act.completions, 0), COALESCE(act.events, 0),
+		COALESCE(rt.avg_rating, 0), COALESCE(rt.rated, 0)
+		FROM manga m
+		LEFT JOIN (
+			SELECT manga_id, COUNT(DISTINCT user_id) AS readers,
+				SUM(to_status = 'completed' AND COALESCE(from_status, '') != 'completed') AS completions,
+				COUNT(*) AS events
+			FROM progress_events
+			WHERE created_at >= ? AND COALESCE(to_status, '') != ?
+			GROUP BY manga_id
+		) act ON act.manga_id = m.id
+		LEFT JOIN (
+			SELECT manga_id, AVG(rating) AS avg_rating, COUNT(*) AS rated
+			FROM user_progress WHERE rating > 0 AND deleted_at IS NULL GROUP BY manga_id
+		) rt ON rt.manga_id = m.id
+		WHERE m.deleted_at IS NULL AND (act.events > 0 OR rt.rated > 0)`
+	args := []interface{}{since.UTC().Format(database.TimestampFormat), models.EventStatusRemoved}
+	if genre != "" {
+		query += ` AND m.id IN (
+			SELECT mg.manga_id FROM manga_genres mg
+			JOIN genre_aliases a ON a.genre_id = mg.genre_id
+			WHERE a.alias = ?)`
+		args = append(args, models.GenreSlug(genre))
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank manga: %w", err)
+	}
+	defer rows.Close()
+
+	var ranked []models.RankedManga
+	for rows.Next() {
+		var r models.RankedManga
+		manga, err := scanManga(rows, &r.ActiveReaders, &r.Completions, &r.Activity, &r.AverageRating, &r.Ratings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan manga: %w", err)
+		}
+		r.Manga = *manga
+		ranked = append(ranked, r)
+	}
+	return ranked, rows.Err()
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanManga scans the mangaColumns followed by any extra destinations
+func scanManga(row rowScanner, extra ...interface{}) (*models.Manga, error) {
+	var manga models.Manga
+	var author, status, genresJSON, description, coverURL sql.NullString
+	var chapters, year sql.NullInt64
+	var deletedAt sql.NullTime
+	dest := []interface{}{
+		&manga.ID, &manga.Title, &author, &genresJSON, &status,
+		&chapters, &description, &coverURL,
+		&manga.CreatedAt, &manga.UpdatedAt, &deletedAt, &year,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	manga.Author = author.String
+	manga.Status = status.String
+	manga.TotalChapters = int(chapters.Int64)
+	manga.Year = int(year.Int64)
+	manga.Description = description.String
+	manga.CoverURL = coverURL.String
+	if deletedAt.Valid {
+		t := deletedAt.Time
+		manga.DeletedAt = &t
+	}
+	manga.Genres = models.ParseGenres(genresJSON.String)
+	return &manga, nil
+}
+
+func scanMangaRows(rows *sql.Rows) ([]models.Manga, error) {
+	var mangaList []models.Manga
+	for rows.Next() {
+		manga, err := scanManga(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan manga: %w", err)
+		}
+		mangaList = append(mangaList, *manga)
+	}
+	return mangaList, rows.Err()
+}
+
+// prefixColumns qualifies a comma-separated column list with a table alias
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, col := range parts {
+		parts[i] = alias + "." + col
+	}
+	return strings.Join(parts, ", ")
+}
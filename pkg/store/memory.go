@@ -0,0 +1,2285 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"mangahub/pkg/models"
+)
+
+// The in-memory stores mirror the SQLite behaviour: lookups of missing rows
+// return the Err*NotFound sentinels, while updates and deletes of missing
+// rows are no-ops. Every method copies values in and out so callers never
+// share memory with the store.
+
+// MemoryMangaStore is a thread-safe in-memory MangaStore
+type MemoryMangaStore struct {
+	mu    sync.RWMutex
+	manga map[string]models.Manga
+	// chapterTotals holds the TotalChapters derived from a
+	// MemoryChapterStore for manga that have chapter rows
+	chapterTotals map[string]int
+	genres        *memoryTaxonomy
+	// titles holds the alternate titles of each manga for a MemoryTitleStore
+	titles      map[string][]models.MangaTitle
+	nextTitleID int64
+	// externalIDs holds the links of a MemoryExternalIDStore, keyed by
+	// source and external ID
+	externalIDs map[[2]string]models.ExternalID
+	// catalogEntries holds the saved entry behind each external ID, and
+	// fieldSources the field sources of each manga
+	catalogEntries map[[2]string]models.CatalogEntry
+	fieldSources   map[string]map[string]string
+	// readerStats, when set, reports reader counts and ratings per manga
+	// from a MemoryLibraryStore for the popularity and rating filters
+	readerStats func() map[string]mangaStats
+	// activityStats, when set, reports the reading activity per manga since
+	// a cutoff from a MemoryLibraryStore for rankings
+	activityStats func(since time.Time) map[string]models.RankingStats
+}
+
+// mangaStats are the library figures of one manga
+type mangaStats struct {
+	readers, rated, ratingSum int
+}
+
+// average returns the average non-zero rating, 0 when nobody rated
+func (m mangaStats) average() float64 {
+	if m.rated == 0 {
+		return 0
+	}
+	return float64(m.ratingSum) / float64(m.rated)
+}
+
+// NewMemoryMangaStore creates an empty in-memory manga store with the
+// default genre taxonomy
+func NewMemoryMangaStore() *MemoryMangaStore {
+	return &MemoryMangaStore{
+		manga:         make(map[string]models.Manga),
+		chapterTotals: make(map[string]int),
+		genres:        newMemoryTaxonomy(),
+		titles:        make(map[string][]models.MangaTitle),
+		externalIDs:   make(map[[2]string]models.ExternalID),
+
+		catalogEntries: make(map[[2]string]models.CatalogEntry),
+		fieldSources:   make(map[string]map[string]string),
+	}
+}
+
+// Create creates a new manga entry
+func (s *MemoryMangaStore) Create(manga *models.Manga) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.manga[manga.ID]; ok {
+		return ErrAlreadyExists
+	}
+	now := time.Now()
+	manga.CreatedAt, manga.UpdatedAt = now, now
+	manga.Genres = s.genres.canonical(manga.Genres)
+	s.manga[manga.ID] = copyManga(*manga)
+	return nil
+}
+
+// GetByID retrieves a manga by ID
+func (s *MemoryMangaStore) GetByID(id string) (*models.Manga, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	manga, ok := s.manga[id]
+	if !ok || manga.DeletedAt != nil {
+		return nil, ErrMangaNotFound
+	}
+	manga = copyManga(manga)
+	return &manga, nil
+}
+
+// List lists manga in ID order
+func (s *MemoryMangaStore) List(limit, offset int) ([]models.Manga, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	all := make([]models.Manga, 0, len(s.manga))
+	for _, manga := range s.manga {
+		if manga.DeletedAt == nil {
+			all = append(all, copyManga(manga))
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+	return paginate(all, limit, offset), nil
+}
+
+// Search returns manga matching the filter
+func (s *MemoryMangaStore) Search(filter *models.MangaFilter) ([]models.Manga, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := s.matching(filter)
+
+	sortBy := filter.SortBy
+	if sortBy == "relevance" && len(searchTerms(filter.Query)) == 0 {
+		sortBy = "title"
+	}
+	less := mangaLess(sortBy)
+	desc := strings.EqualFold(filter.Order, "desc") || sortBy == "relevance"
+	sort.SliceStable(matches, func(i, j int) bool {
+		if desc {
+			return less(matches[j], matches[i])
+		}
+		return less(matches[i], matches[j])
+	})
+
+	return paginate(matches, filter.Limit, filter.Offset), nil
+}
+
+// Facets counts every manga matching the filter per genre and per status
+func (s *MemoryMangaStore) Facets(filter *models.MangaFilter) (*models.SearchFacets, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	genres := make(map[string]int)
+	statuses := make(map[string]int)
+	for _, manga := range s.matching(filter) {
+		for _, name := range manga.Genres {
+			genres[name]++
+		}
+		statuses[manga.Status]++
+	}
+
+	facets := &models.SearchFacets{Genres: []models.FacetCount{}, Status: []models.FacetCount{}}
+	for name, count := range genres {
+		f := models.FacetCount{Value: models.GenreSlug(name), Name: name, Count: count}
+		if g, ok := s.genres.lookup(name); ok {
+			f.Value = g.Slug
+		}
+		facets.Genres = append(facets.Genres, f)
+	}
+	for status, count := range statuses {
+		facets.Status = append(facets.Status, models.FacetCount{Value: status, Count: count})
+	}
+	sortFacets(facets.Genres, func(f models.FacetCount) string { return f.Name })
+	sortFacets(facets.Status, func(f models.FacetCount) string { return f.Value })
+	return facets, nil
+}
+
+// matching returns copies of the manga that match the filter, unsorted.
+// The caller holds s.mu.
+func (s *MemoryMangaStore) matching(filter *models.MangaFilter) []models.Manga {
+	terms := searchTerms(filter.Query)
+	var stats map[string]mangaStats
+	if s.readerStats != nil {
+		stats = s.readerStats()
+	}
+	var matches []models.Manga
+	for _, manga := range s.manga {
+		if manga.DeletedAt != nil {
+			continue
+		}
+		if len(filter.IDs) > 0 && !slices.Contains(filter.IDs, manga.ID) {
+			continue
+		}
+		relevance, snippet, ok := matchTerms(manga, s.altTitles(manga.ID), terms)
+		if !ok {
+			continue
+		}
+		if !s.hasGenres(manga.Genres, filter.Genres) {
+			continue
+		}
+		if filter.Status != "" && manga.Status != filter.Status {
+			continue
+		}
+		if filter.Author != "" && !strings.Contains(strings.ToLower(manga.Author), strings.ToLower(filter.Author)) {
+			continue
+		}
+		if filter.YearFrom > 0 && (manga.Year == 0 || manga.Year < filter.YearFrom) {
+			continue
+		}
+		if filter.YearTo > 0 && (manga.Year == 0 || manga.Year > filter.YearTo) {
+			continue
+		}
+		if filter.MinChapters > 0 && manga.TotalChapters < filter.MinChapters {
+			continue
+		}
+		st := stats[manga.ID]
+		if filter.MinRating > 0 && (st.rated == 0 || st.average() < filter.MinRating) {
+			continue
+		}
+		manga = copyManga(manga)
+		manga.Relevance, manga.Snippet = relevance, snippet
+		manga.Readers, manga.AverageRating = st.readers, st.average()
+		matches = append(matches, manga)
+	}
+	return matches
+}
+
+// Fuzzy scores the title and author of every manga outside the trash
+// against the query
+func (s *MemoryMangaStore) Fuzzy(query string, limit int) ([]models.Suggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var candidates []fuzzyCandidate
+	for _, manga := range s.manga {
+		if manga.DeletedAt == nil {
+			candidates = append(candidates, newFuzzyCandidate(manga.ID, manga.Title, manga.Author, s.altTitles(manga.ID)...))
+		}
+	}
+	return rankSuggestions(query, candidates, limit), nil
+}
+
+// Autocomplete matches the prefix against the start of titles and of
+// their words
+func (s *MemoryMangaStore) Autocomplete(prefix string, limit int) ([]models.Suggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var suggestions []models.Suggestion
+	for _, manga := range s.manga {
+		if manga.DeletedAt != nil {
+			continue
+		}
+		best := models.Suggestion{MangaID: manga.ID, Title: manga.Title, Author: manga.Author}
+		if score := autocompleteScore(prefix, manga.Title); score > 0 {
+			best.Matched, best.Field, best.Score = manga.Title, models.SuggestionFieldTitle, score
+		}
+		for _, alt := range s.altTitles(manga.ID) {
+			sg := models.Suggestion{Matched: alt, Field: models.SuggestionFieldAltTitle, Score: autocompleteScore(prefix, alt)}
+			if sg.Score > 0 && (best.Score == 0 || betterCompletion(sg, best)) {
+				best.Matched, best.Field, best.Score = sg.Matched, sg.Field, sg.Score
+			}
+		}
+		if best.Score > 0 {
+			suggestions = append(suggestions, best)
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		return betterCompletion(suggestions[i], suggestions[j])
+	})
+	return paginate(suggestions, limit, 0), nil
+}
+
+// sortFacets orders facet counts most common first, then by key
+func sortFacets(facets []models.FacetCount, key func(models.FacetCount) string) {
+	sort.Slice(facets, func(i, j int) bool {
+		if facets[i].Count != facets[j].Count {
+			return facets[i].Count > facets[j].Count
+		}
+		return key(facets[i]) < key(facets[j])
+	})
+}
+
+// Update updates a manga entry
+func (s *MemoryMangaStore) Update(manga *models.Manga) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.manga[manga.ID]
+	if !ok || existing.DeletedAt != nil {
+		return ErrMangaNotFound
+	}
+	manga.CreatedAt = existing.CreatedAt
+	manga.UpdatedAt = time.Now()
+	if total, ok := s.chapterTotals[manga.ID]; ok {
+		manga.TotalChapters = total
+	}
+	manga.Genres = s.genres.canonical(manga.Genres)
+	s.manga[manga.ID] = copyManga(*manga)
+	return nil
+}
+
+// Delete moves a manga to the trash
+func (s *MemoryMangaStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	manga, ok := s.manga[id]
+	if !ok || manga.DeletedAt != nil {
+		return ErrMangaNotFound
+	}
+	now := time.Now().UTC()
+	manga.DeletedAt = &now
+	s.manga[id] = manga
+	return nil
+}
+
+// Restore moves a manga out of the trash
+func (s *MemoryMangaStore) Restore(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	manga, ok := s.manga[id]
+	if !ok || manga.DeletedAt == nil {
+		return ErrMangaNotFound
+	}
+	manga.DeletedAt = nil
+	s.manga[id] = manga
+	return nil
+}
+
+// Trash lists trashed manga, most recently deleted first
+func (s *MemoryMangaStore) Trash(limit, offset int) ([]models.Manga, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var trashed []models.Manga
+	for _, manga := range s.manga {
+		if manga.DeletedAt != nil {
+			trashed = append(trashed, copyManga(manga))
+		}
+	}
+	sort.Slice(trashed, func(i, j int) bool { return trashed[i].DeletedAt.After(*trashed[j].DeletedAt) })
+	return paginate(trashed, limit, offset), nil
+}
+
+// PurgeTrash permanently deletes manga trashed before the cutoff. Unlike
+// the SQLite store it cannot reach library entries held by another store.
+func (s *MemoryMangaStore) PurgeTrash(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, manga := range s.manga {
+		if manga.DeletedAt != nil && manga.DeletedAt.Before(before) {
+			delete(s.manga, id)
+			delete(s.titles, id)
+			delete(s.fieldSources, id)
+			for key, link := range s.externalIDs {
+				if link.MangaID == id {
+					delete(s.externalIDs, key)
+					delete(s.catalogEntries, key)
+				}
+			}
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Rankings returns the ranking figures of manga outside the trash with
+// activity since the cutoff or a rating. Without a library store attached
+// nothing is ranked.
+func (s *MemoryMangaStore) Rankings(since time.Time, genre string) ([]models.RankedManga, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.activityStats == nil {
+		return nil, nil
+	}
+	var wanted []string
+	if genre != "" {
+		wanted = []string{genre}
+	}
+	var ranked []models.RankedManga
+	for id, stats := range s.activityStats(since) {
+		manga, ok := s.manga[id]
+		if !ok || manga.DeletedAt != nil || !s.hasGenres(manga.Genres, wanted) {
+			continue
+		}
+		if stats.Activity == 0 && stats.Ratings == 0 {
+			continue
+		}
+		ranked = append(ranked, models.RankedManga{Manga: copyManga(manga), RankingStats: stats})
+	}
+	return ranked, nil
+}
+
+// exists reports whether a manga is in the catalog and not trashed
+func (s *MemoryMangaStore) exists(id string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	manga, ok := s.manga[id]
+	return ok && manga.DeletedAt == nil
+}
+
+// setChapterTotal derives a manga's TotalChapters from its chapter rows the
+// way the SQLite chapters triggers do; has is false once none are left
+func (s *MemoryMangaStore) setChapterTotal(id string, total int, has bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if has {
+		s.chapterTotals[id] = total
+	} else {
+		delete(s.chapterTotals, id)
+	}
+	if manga, ok := s.manga[id]; ok {
+		manga.TotalChapters = total
+		s.manga[id] = manga
+	}
+}
+
+func copyManga(manga models.Manga) models.Manga {
+	if manga.DeletedAt != nil {
+		t := *manga.DeletedAt
+		manga.DeletedAt = &t
+	}
+	manga.Genres = append([]string(nil), manga.Genres...)
+	return manga
+}
+
+// matchTerms approximates the FTS5 search: every term must prefix a word of
+// the title, author or description. Matches are weighted like the bm25
+// column weights used by the SQLite store.
+func matchTerms(manga models.Manga, altTitles []string, terms []string) (float64, string, bool) {
+	if len(terms) == 0 {
+		return 0, "", true
+	}
+
+	fields := []struct {
+		text   string
+		weight float64
+	}{
+		{manga.Title, 10},
+		{manga.Author, 5},
+		{manga.Description, 1},
+		{strings.Join(altTitles, " "), 8},
+	}
+
+	var relevance float64
+	snippet := ""
+	for _, term := range terms {
+		matched := false
+		for _, f := range fields {
+			words := searchTerms(f.text)
+			for _, w := range words {
+				if strings.HasPrefix(w, term) {
+					relevance += f.weight / float64(len(words))
+					matched = true
+					if snippet == "" {
+						snippet = highlight(f.text, term)
+					}
+				}
+			}
+		}
+		if !matched {
+			return 0, "", false
+		}
+	}
+	return relevance, snippet, true
+}
+
+// highlight wraps the first case-insensitive occurrence of term in <mark></mark>
+func highlight(text, term string) string {
+	idx := strings.Index(strings.ToLower(text), term)
+	if idx < 0 {
+		return text
+	}
+	end := idx + len(term)
+	return text[:idx] + "<mark>" + text[idx:end] + "</mark>" + text[end:]
+}
+
+// hasGenres reports whether genres contains every wanted genre, matched by
+// slug or alias through the taxonomy like the SQLite store
+func (s *MemoryMangaStore) hasGenres(genres, wanted []string) bool {
+	for _, w := range wanted {
+		g, ok := s.genres.lookup(w)
+		if !ok {
+			return false
+		}
+		found := false
+		for _, name := range genres {
+			if name == g.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// genreCounts counts the manga outside the trash per canonical genre name
+func (s *MemoryMangaStore) genreCounts() map[string]int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, manga := range s.manga {
+		if manga.DeletedAt != nil {
+			continue
+		}
+		for _, name := range manga.Genres {
+			counts[name]++
+		}
+	}
+	return counts
+}
+
+func mangaLess(sortBy string) func(a, b models.Manga) bool {
+	switch sortBy {
+	case "relevance":
+		return func(a, b models.Manga) bool {
+			if a.Relevance != b.Relevance {
+				return a.Relevance < b.Relevance
+			}
+			return a.Title > b.Title
+		}
+	case "author":
+		return func(a, b models.Manga) bool { return a.Author < b.Author }
+	case "status":
+		return func(a, b models.Manga) bool { return a.Status < b.Status }
+	case "total_chapters":
+		return func(a, b models.Manga) bool { return a.TotalChapters < b.TotalChapters }
+	case "year":
+		return func(a, b models.Manga) bool { return a.Year < b.Year }
+	case "popularity":
+		return func(a, b models.Manga) bool { return a.Readers < b.Readers }
+	case "rating":
+		return func(a, b models.Manga) bool { return a.AverageRating < b.AverageRating }
+	case "recent", "updated_at":
+		return func(a, b models.Manga) bool { return a.UpdatedAt.Before(b.UpdatedAt) }
+	case "created_at":
+		return func(a, b models.Manga) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		return func(a, b models.Manga) bool { return a.Title < b.Title }
+	}
+}
+
+// memoryTaxonomy is the genre taxonomy shared by a MemoryMangaStore and
+// its MemoryGenreStore
+type memoryTaxonomy struct {
+	mu      sync.RWMutex
+	genres  []models.Genre
+	aliases map[string]int // slug or alias to index in genres
+	names   map[string]int // canonical name to index in genres
+}
+
+func newMemoryTaxonomy() *memoryTaxonomy {
+	t := &memoryTaxonomy{aliases: make(map[string]int), names: make(map[string]int)}
+	for _, g := range models.DefaultGenres {
+		t.add(g)
+	}
+	return t
+}
+
+// add appends a genre, storing its aliases in slug form. The caller holds
+// t.mu or owns t.
+func (t *memoryTaxonomy) add(g models.Genre) models.Genre {
+	g.ID = int64(len(t.genres) + 1)
+	aliases := g.Aliases
+	g.Aliases = nil
+	for _, alias := range aliases {
+		alias = models.GenreSlug(alias)
+		if _, taken := t.aliases[alias]; !taken {
+			t.aliases[alias] = len(t.genres)
+			g.Aliases = append(g.Aliases, alias)
+		}
+	}
+	t.aliases[g.Slug] = len(t.genres)
+	t.names[g.Name] = len(t.genres)
+	t.genres = append(t.genres, g)
+	return g
+}
+
+// lookup returns the genre a name or alias refers to
+func (t *memoryTaxonomy) lookup(name string) (models.Genre, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if i, ok := t.names[name]; ok {
+		return t.genres[i], true
+	}
+	if i, ok := t.aliases[models.GenreSlug(name)]; ok {
+		return t.genres[i], true
+	}
+	return models.Genre{}, false
+}
+
+// canonical resolves genre names the way database.SyncMangaGenres does:
+// unknown names become tags and duplicates are dropped
+func (t *memoryTaxonomy) canonical(names []string) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	canonical := []string{}
+	seen := make(map[int]bool)
+	for _, name := range names {
+		slug := models.GenreSlug(name)
+		if slug == "" {
+			continue
+		}
+		i, ok := t.aliases[slug]
+		if !ok {
+			t.add(models.Genre{Slug: slug, Name: models.GenreName(name), Kind: models.GenreKindTag})
+			i = len(t.genres) - 1
+		}
+		if !seen[i] {
+			seen[i] = true
+			canonical = append(canonical, t.genres[i].Name)
+		}
+	}
+	return canonical
+}
+
+// MemoryGenreStore is a thread-safe in-memory GenreStore over the taxonomy
+// of a MemoryMangaStore
+type MemoryGenreStore struct {
+	manga *MemoryMangaStore
+}
+
+// NewMemoryGenreStore creates a genre store for the manga in mangaStore
+func NewMemoryGenreStore(mangaStore *MemoryMangaStore) *MemoryGenreStore {
+	return &MemoryGenreStore{manga: mangaStore}
+}
+
+// List returns the taxonomy with aliases and manga counts
+func (s *MemoryGenreStore) List(kind string) ([]models.Genre, error) {
+	counts := s.manga.genreCounts()
+
+	t := s.manga.genres
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var genres []models.Genre
+	for _, g := range t.genres {
+		if kind != "" && g.Kind != kind {
+			continue
+		}
+		g.Aliases = append([]string(nil), g.Aliases...)
+		sort.Strings(g.Aliases)
+		g.MangaCount = counts[g.Name]
+		genres = append(genres, g)
+	}
+	rank := map[string]int{models.GenreKindDemographic: 0, models.GenreKindGenre: 1}
+	sort.SliceStable(genres, func(i, j int) bool {
+		ri, ok := rank[genres[i].Kind]
+		if !ok {
+			ri = 2
+		}
+		rj, ok := rank[genres[j].Kind]
+		if !ok {
+			rj = 2
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		return genres[i].Name < genres[j].Name
+	})
+	return genres, nil
+}
+
+// altTitles returns the alternate titles of a manga; callers hold s.mu
+func (s *MemoryMangaStore) altTitles(id string) []string {
+	var titles []string
+	for _, t := range s.titles[id] {
+		titles = append(titles, t.Title)
+	}
+	return titles
+}
+
+// MemoryTitleStore is a thread-safe in-memory TitleStore. The titles live
+// in the MemoryMangaStore so its searches match them.
+type MemoryTitleStore struct {
+	manga *MemoryMangaStore
+}
+
+// NewMemoryTitleStore creates an in-memory title store for the manga in
+// mangaStore
+func NewMemoryTitleStore(mangaStore *MemoryMangaStore) *MemoryTitleStore {
+	return &MemoryTitleStore{manga: mangaStore}
+}
+
+// List returns a manga's titles ordered by language, primary first
+func (s *MemoryTitleStore) List(mangaID string) ([]models.MangaTitle, error) {
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	if manga, ok := s.manga.manga[mangaID]; !ok || manga.DeletedAt != nil {
+		return nil, ErrMangaNotFound
+	}
+	titles := append([]models.MangaTitle(nil), s.manga.titles[mangaID]...)
+	sort.SliceStable(titles, func(i, j int) bool {
+		a, b := titles[i], titles[j]
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		if a.IsPrimary != b.IsPrimary {
+			return a.IsPrimary
+		}
+		return a.Title < b.Title
+	})
+	return titles, nil
+}
+
+// Add adds a title to a manga
+func (s *MemoryTitleStore) Add(title *models.MangaTitle) error {
+	s.manga.mu.Lock()
+	defer s.manga.mu.Unlock()
+
+	if manga, ok := s.manga.manga[title.MangaID]; !ok || manga.DeletedAt != nil {
+		return ErrMangaNotFound
+	}
+	title.Language = models.NormalizeLanguage(title.Language)
+	titles := s.manga.titles[title.MangaID]
+	for _, t := range titles {
+		if t.Language == title.Language && t.Title == title.Title {
+			return ErrAlreadyExists
+		}
+	}
+	s.manga.titles[title.MangaID] = s.manga.appendTitle(titles, *title)
+	title.ID = s.manga.nextTitleID
+	return nil
+}
+
+// Replace swaps every title of a manga for the given ones
+func (s *MemoryTitleStore) Replace(mangaID string, titles []models.MangaTitle) error {
+	s.manga.mu.Lock()
+	defer s.manga.mu.Unlock()
+
+	if manga, ok := s.manga.manga[mangaID]; !ok || manga.DeletedAt != nil {
+		return ErrMangaNotFound
+	}
+	var replaced []models.MangaTitle
+	for _, t := range dedupeTitles(mangaID, titles) {
+		replaced = s.manga.appendTitle(replaced, t)
+	}
+	s.manga.titles[mangaID] = replaced
+	return nil
+}
+
+// Delete removes a title
+func (s *MemoryTitleStore) Delete(id int64) error {
+	s.manga.mu.Lock()
+	defer s.manga.mu.Unlock()
+
+	for mangaID, titles := range s.manga.titles {
+		for i, t := range titles {
+			if t.ID == id {
+				s.manga.titles[mangaID] = append(titles[:i:i], titles[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// InLanguage returns the titles in one language of the given manga
+func (s *MemoryTitleStore) InLanguage(mangaIDs []string, language string) (map[string][]models.MangaTitle, error) {
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	language = models.NormalizeLanguage(language)
+	titles := make(map[string][]models.MangaTitle)
+	for _, id := range mangaIDs {
+		for _, t := range s.manga.titles[id] {
+			if t.Language == language {
+				titles[id] = append(titles[id], t)
+			}
+		}
+	}
+	return titles, nil
+}
+
+// appendTitle assigns the next title ID and appends the title, demoting the
+// primary title it replaces; callers hold s.mu
+func (s *MemoryMangaStore) appendTitle(titles []models.MangaTitle, title models.MangaTitle) []models.MangaTitle {
+	if title.IsPrimary {
+		for i := range titles {
+			if titles[i].Language == title.Language {
+				titles[i].IsPrimary = false
+			}
+		}
+	}
+	s.nextTitleID++
+	title.ID = s.nextTitleID
+	return append(titles, title)
+}
+
+// MemoryExternalIDStore is a thread-safe in-memory ExternalIDStore. The
+// links live in the MemoryMangaStore so purging a manga drops them.
+type MemoryExternalIDStore struct {
+	manga *MemoryMangaStore
+}
+
+// NewMemoryExternalIDStore creates an in-memory external ID store for the
+// manga in mangaStore
+func NewMemoryExternalIDStore(mangaStore *MemoryMangaStore) *MemoryExternalIDStore {
+	return &MemoryExternalIDStore{manga: mangaStore}
+}
+
+// Link links an external ID to a manga
+func (s *MemoryExternalIDStore) Link(id *models.ExternalID) error {
+	s.manga.mu.Lock()
+	defer s.manga.mu.Unlock()
+
+	if _, ok := s.manga.manga[id.MangaID]; !ok {
+		return ErrMangaNotFound
+	}
+	key := [2]string{id.Source, id.ExternalID}
+	id.CreatedAt = time.Now()
+	id.FetchedAt = s.manga.externalIDs[key].FetchedAt
+	s.manga.externalIDs[key] = *id
+	return nil
+}
+
+// Find returns the ID of the manga an external ID links to
+func (s *MemoryExternalIDStore) Find(source, externalID string) (string, error) {
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	link, ok := s.manga.externalIDs[[2]string{source, externalID}]
+	if !ok {
+		return "", ErrExternalIDNotFound
+	}
+	return link.MangaID, nil
+}
+
+// List returns the external IDs of a manga
+func (s *MemoryExternalIDStore) List(mangaID string) ([]models.ExternalID, error) {
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	var ids []models.ExternalID
+	for _, link := range s.manga.externalIDs {
+		if link.MangaID == mangaID {
+			ids = append(ids, link)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].Source != ids[j].Source {
+			return ids[i].Source < ids[j].Source
+		}
+		return ids[i].ExternalID < ids[j].ExternalID
+	})
+	return ids, nil
+}
+
+// SaveEntry links the entry's external ID to a manga and keeps the entry
+func (s *MemoryExternalIDStore) SaveEntry(mangaID string, entry *models.CatalogEntry) error {
+	s.manga.mu.Lock()
+	defer s.manga.mu.Unlock()
+
+	if _, ok := s.manga.manga[mangaID]; !ok {
+		return ErrMangaNotFound
+	}
+	key := [2]string{entry.Source, entry.ExternalID}
+	link, ok := s.manga.externalIDs[key]
+	if !ok {
+		link = models.ExternalID{Source: entry.Source, ExternalID: entry.ExternalID, CreatedAt: time.Now()}
+	}
+	if entry.FetchedAt.IsZero() {
+		entry.FetchedAt = time.Now()
+	}
+	fetchedAt := entry.FetchedAt
+	link.MangaID, link.FetchedAt = mangaID, &fetchedAt
+	s.manga.externalIDs[key] = link
+
+	saved := *entry
+	saved.Titles = append([]models.MangaTitle(nil), entry.Titles...)
+	saved.Genres = append([]string(nil), entry.Genres...)
+	s.manga.catalogEntries[key] = saved
+	return nil
+}
+
+// Entries returns the saved entries of the sources linked to a manga
+func (s *MemoryExternalIDStore) Entries(mangaID string) ([]models.CatalogEntry, error) {
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	var entries []models.CatalogEntry
+	for key, link := range s.manga.externalIDs {
+		entry, ok := s.manga.catalogEntries[key]
+		if link.MangaID != mangaID || !ok {
+			continue
+		}
+		entry.Titles = append([]models.MangaTitle(nil), entry.Titles...)
+		entry.Genres = append([]string(nil), entry.Genres...)
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Source != entries[j].Source {
+			return entries[i].Source < entries[j].Source
+		}
+		return entries[i].ExternalID < entries[j].ExternalID
+	})
+	return entries, nil
+}
+
+// FieldSources returns the source of each field of a manga
+func (s *MemoryExternalIDStore) FieldSources(mangaID string) (map[string]string, error) {
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	sources := make(map[string]string, len(s.manga.fieldSources[mangaID]))
+	for field, source := range s.manga.fieldSources[mangaID] {
+		sources[field] = source
+	}
+	return sources, nil
+}
+
+// SetFieldSources replaces the field sources of a manga
+func (s *MemoryExternalIDStore) SetFieldSources(mangaID string, sources map[string]string) error {
+	s.manga.mu.Lock()
+	defer s.manga.mu.Unlock()
+
+	if _, ok := s.manga.manga[mangaID]; !ok {
+		return ErrMangaNotFound
+	}
+	saved := make(map[string]string, len(sources))
+	for field, source := range sources {
+		saved[field] = source
+	}
+	s.manga.fieldSources[mangaID] = saved
+	return nil
+}
+
+// MemoryChapterStore is a thread-safe in-memory ChapterStore. It keeps the
+// TotalChapters of manga in the given MemoryMangaStore up to date.
+type MemoryChapterStore struct {
+	mu       sync.RWMutex
+	nextID   int64
+	chapters map[int64]models.Chapter
+	manga    *MemoryMangaStore
+}
+
+// NewMemoryChapterStore creates an empty in-memory chapter store for the
+// manga in mangaStore
+func NewMemoryChapterStore(mangaStore *MemoryMangaStore) *MemoryChapterStore {
+	return &MemoryChapterStore{chapters: make(map[int64]models.Chapter), manga: mangaStore}
+}
+
+// Create adds a chapter to a manga
+func (s *MemoryChapterStore) Create(chapter *models.Chapter) error {
+	if chapter.Language == "" {
+		chapter.Language = models.DefaultChapterLanguage
+	}
+	if !s.manga.exists(chapter.MangaID) {
+		return ErrMangaNotFound
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.taken(*chapter) {
+		return ErrAlreadyExists
+	}
+	s.nextID++
+	now := time.Now().UTC()
+	chapter.ID = s.nextID
+	chapter.CreatedAt, chapter.UpdatedAt = now, now
+	s.chapters[chapter.ID] = copyChapter(*chapter)
+	s.syncTotal(chapter.MangaID)
+	return nil
+}
+
+// Get retrieves a chapter by ID
+func (s *MemoryChapterStore) Get(id int64) (*models.Chapter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	chapter, ok := s.chapters[id]
+	if !ok {
+		return nil, ErrChapterNotFound
+	}
+	chapter = copyChapter(chapter)
+	return &chapter, nil
+}
+
+// List returns a manga's chapters ordered by number
+func (s *MemoryChapterStore) List(filter models.ChapterFilter) ([]models.Chapter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chapters []models.Chapter
+	for _, chapter := range s.chapters {
+		if chapter.MangaID != filter.MangaID {
+			continue
+		}
+		if filter.After > 0 && chapter.Number <= filter.After {
+			continue
+		}
+		if filter.Language != "" && chapter.Language != filter.Language {
+			continue
+		}
+		chapters = append(chapters, copyChapter(chapter))
+	}
+
+	desc := strings.EqualFold(filter.Order, "desc")
+	sort.Slice(chapters, func(i, j int) bool {
+		if chapters[i].Number != chapters[j].Number {
+			return (chapters[i].Number < chapters[j].Number) != desc
+		}
+		return chapters[i].Language < chapters[j].Language
+	})
+	return paginate(chapters, filter.Limit, filter.Offset), nil
+}
+
+// Update updates a chapter
+func (s *MemoryChapterStore) Update(chapter *models.Chapter) error {
+	if chapter.Language == "" {
+		chapter.Language = models.DefaultChapterLanguage
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.chapters[chapter.ID]
+	if !ok {
+		return nil
+	}
+	chapter.MangaID = existing.MangaID
+	if s.taken(*chapter) {
+		return ErrAlreadyExists
+	}
+	chapter.CreatedAt = existing.CreatedAt
+	chapter.UpdatedAt = time.Now().UTC()
+	s.chapters[chapter.ID] = copyChapter(*chapter)
+	s.syncTotal(chapter.MangaID)
+	return nil
+}
+
+// Delete deletes a chapter
+func (s *MemoryChapterStore) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chapter, ok := s.chapters[id]
+	if !ok {
+		return nil
+	}
+	delete(s.chapters, id)
+	s.syncTotal(chapter.MangaID)
+	return nil
+}
+
+// taken reports whether another chapter of the manga has the same number
+// and language. Callers hold s.mu.
+func (s *MemoryChapterStore) taken(chapter models.Chapter) bool {
+	for id, other := range s.chapters {
+		if id != chapter.ID && other.MangaID == chapter.MangaID &&
+			other.Language == chapter.Language && other.Number == chapter.Number {
+			return true
+		}
+	}
+	return false
+}
+
+// syncTotal sets the manga's TotalChapters to its highest chapter number.
+// Callers hold s.mu.
+func (s *MemoryChapterStore) syncTotal(mangaID string) {
+	highest, has := 0.0, false
+	for _, chapter := range s.chapters {
+		if chapter.MangaID == mangaID && (!has || chapter.Number > highest) {
+			highest, has = chapter.Number, true
+		}
+	}
+	s.manga.setChapterTotal(mangaID, int(highest), has)
+}
+
+func copyChapter(chapter models.Chapter) models.Chapter {
+	if chapter.ReleasedAt != nil {
+		t := *chapter.ReleasedAt
+		chapter.ReleasedAt = &t
+	}
+	return chapter
+}
+
+// MemoryUserStore is a thread-safe in-memory UserStore
+type MemoryUserStore struct {
+	mu    sync.RWMutex
+	users map[string]models.User
+}
+
+// NewMemoryUserStore creates an empty in-memory user store
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{users: make(map[string]models.User)}
+}
+
+// Create creates a new user, enforcing unique IDs, usernames and emails
+func (s *MemoryUserStore) Create(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.users {
+		if existing.ID == user.ID || existing.Username == user.Username || existing.Email == user.Email {
+			return ErrAlreadyExists
+		}
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	now := time.Now()
+	user.CreatedAt, user.UpdatedAt = now, now
+	s.users[user.ID] = *user
+	return nil
+}
+
+// GetByID retrieves a user by ID
+func (s *MemoryUserStore) GetByID(id string) (*models.User, error) {
+	return s.find(func(u models.User) bool { return u.ID == id })
+}
+
+// GetByUsername retrieves a user by username
+func (s *MemoryUserStore) GetByUsername(username string) (*models.User, error) {
+	return s.find(func(u models.User) bool { return u.Username == username })
+}
+
+// GetByEmail retrieves a user by email
+func (s *MemoryUserStore) GetByEmail(email string) (*models.User, error) {
+	return s.find(func(u models.User) bool { return u.Email == email })
+}
+
+func (s *MemoryUserStore) find(match func(models.User) bool) (*models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, user := range s.users {
+		if match(user) {
+			u := user
+			return &u, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// ListByRole lists the users holding role, by username
+func (s *MemoryUserStore) ListByRole(role string) ([]models.User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range s.users {
+		if user.Role == role {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users, nil
+}
+
+// Update updates a user, keeping its role
+func (s *MemoryUserStore) Update(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, ok := s.users[user.ID]
+	if !ok {
+		return nil
+	}
+	for id, other := range s.users {
+		if id != user.ID && (other.Username == user.Username || other.Email == user.Email) {
+			return ErrAlreadyExists
+		}
+	}
+	user.CreatedAt = existing.CreatedAt
+	user.Role = existing.Role
+	user.UpdatedAt = time.Now()
+	s.users[user.ID] = *user
+	return nil
+}
+
+// UpdatePassword updates a user's password
+func (s *MemoryUserStore) UpdatePassword(userID, passwordHash string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return nil
+	}
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = time.Now()
+	s.users[userID] = user
+	return nil
+}
+
+// SetRole changes a user's role
+func (s *MemoryUserStore) SetRole(userID, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	user, ok := s.users[userID]
+	if !ok {
+		return ErrUserNotFound
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now()
+	s.users[userID] = user
+	return nil
+}
+
+// Delete deletes a user
+func (s *MemoryUserStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.users, id)
+	return nil
+}
+
+type libraryKey struct{ userID, mangaID string }
+
+// MemoryLibraryStore is a thread-safe in-memory LibraryStore
+type MemoryLibraryStore struct {
+	mu      sync.RWMutex
+	entries map[libraryKey]models.Progress
+	events  []models.ProgressEvent
+}
+
+// NewMemoryLibraryStore creates an empty in-memory library store
+func NewMemoryLibraryStore() *MemoryLibraryStore {
+	return &MemoryLibraryStore{entries: make(map[libraryKey]models.Progress)}
+}
+
+// mangaStats counts the entries outside the trash and their non-zero
+// ratings per manga
+func (s *MemoryLibraryStore) mangaStats() map[string]mangaStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[string]mangaStats)
+	for _, entry := range s.entries {
+		if entry.DeletedAt != nil {
+			continue
+		}
+		st := stats[entry.MangaID]
+		st.readers++
+		if entry.Rating > 0 {
+			st.rated++
+			st.ratingSum += entry.Rating
+		}
+		stats[entry.MangaID] = st
+	}
+	return stats
+}
+
+// activityStats counts the progress events since the cutoff per manga,
+// ignoring removals, next to the non-zero ratings of entries outside the
+// trash
+func (s *MemoryLibraryStore) activityStats(since time.Time) map[string]models.RankingStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stats := make(map[string]models.RankingStats)
+	readers := make(map[libraryKey]bool)
+	for _, event := range s.events {
+		if event.CreatedAt.Before(since) || event.ToStatus == models.EventStatusRemoved {
+			continue
+		}
+		st := stats[event.MangaID]
+		st.Activity++
+		if event.ToStatus == "completed" && event.FromStatus != "completed" {
+			st.Completions++
+		}
+		key := libraryKey{event.UserID, event.MangaID}
+		if !readers[key] {
+			readers[key] = true
+			st.ActiveReaders++
+		}
+		stats[event.MangaID] = st
+	}
+
+	ratingSums := make(map[string]int)
+	for _, entry := range s.entries {
+		if entry.DeletedAt != nil || entry.Rating == 0 {
+			continue
+		}
+		st := stats[entry.MangaID]
+		st.Ratings++
+		ratingSums[entry.MangaID] += entry.Rating
+		stats[entry.MangaID] = st
+	}
+	for id, sum := range ratingSums {
+		st := stats[id]
+		st.AverageRating = float64(sum) / float64(st.Ratings)
+		stats[id] = st
+	}
+	return stats
+}
+
+// Add adds a manga to a user's library
+func (s *MemoryLibraryStore) Add(progress *models.Progress, origin models.EventOrigin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := libraryKey{progress.UserID, progress.MangaID}
+	var prev *models.Progress
+	if existing, ok := s.entries[key]; ok {
+		// Adding a manga again replaces its trashed entry
+		if existing.DeletedAt == nil {
+			return ErrAlreadyExists
+		}
+		prev = &existing
+	}
+	now := time.Now()
+	if progress.StartedAt.IsZero() {
+		progress.StartedAt = now
+	}
+	progress.UpdatedAt = now
+	progress.DeletedAt = nil
+	s.entries[key] = copyProgress(*progress)
+	s.recordEvent(key, prev, progress.CurrentChapter, progress.Status, origin)
+	return nil
+}
+
+// Get retrieves a single library entry
+func (s *MemoryLibraryStore) Get(userID, mangaID string) (*models.Progress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	progress, ok := s.entries[libraryKey{userID, mangaID}]
+	if !ok || progress.DeletedAt != nil {
+		return nil, ErrEntryNotFound
+	}
+	progress = copyProgress(progress)
+	return &progress, nil
+}
+
+// List retrieves a user's library in manga ID order, optionally filtered by status
+func (s *MemoryLibraryStore) List(userID, status string, limit, offset int) ([]models.Progress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var list []models.Progress
+	for key, progress := range s.entries {
+		if key.userID != userID || progress.DeletedAt != nil || (status != "" && progress.Status != status) {
+			continue
+		}
+		list = append(list, copyProgress(progress))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].MangaID < list[j].MangaID })
+	return paginate(list, limit, offset), nil
+}
+
+// Update updates a library entry
+func (s *MemoryLibraryStore) Update(progress *models.Progress, origin models.EventOrigin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := libraryKey{progress.UserID, progress.MangaID}
+	existing, ok := s.entries[key]
+	if !ok || existing.DeletedAt != nil {
+		return nil
+	}
+	prev := existing
+	existing.CurrentChapter = progress.CurrentChapter
+	existing.Status = progress.Status
+	existing.Rating = progress.Rating
+	existing.Notes = progress.Notes
+	existing.CompletedAt = progress.CompletedAt
+	existing.UpdatedAt = time.Now()
+	progress.UpdatedAt = existing.UpdatedAt
+	s.entries[key] = copyProgress(existing)
+	s.recordEvent(key, &prev, existing.CurrentChapter, existing.Status, origin)
+	return nil
+}
+
+// Remove removes a manga from a user's library
+func (s *MemoryLibraryStore) Remove(userID, mangaID string, origin models.EventOrigin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := libraryKey{userID, mangaID}
+	progress, ok := s.entries[key]
+	if !ok || progress.DeletedAt != nil {
+		return nil
+	}
+	prev := progress
+	now := time.Now().UTC()
+	progress.DeletedAt = &now
+	s.entries[key] = progress
+	s.recordEvent(key, &prev, prev.CurrentChapter, models.EventStatusRemoved, origin)
+	return nil
+}
+
+// Restore moves a library entry out of the trash
+func (s *MemoryLibraryStore) Restore(userID, mangaID string, origin models.EventOrigin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := libraryKey{userID, mangaID}
+	progress, ok := s.entries[key]
+	if !ok || progress.DeletedAt == nil {
+		return ErrEntryNotFound
+	}
+	prev := progress
+	progress.DeletedAt = nil
+	progress.UpdatedAt = time.Now()
+	s.entries[key] = progress
+	s.recordEvent(key, &prev, progress.CurrentChapter, progress.Status, origin)
+	return nil
+}
+
+// Trash lists a user's trashed entries, most recently removed first
+func (s *MemoryLibraryStore) Trash(userID string, limit, offset int) ([]models.Progress, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var trashed []models.Progress
+	for key, progress := range s.entries {
+		if key.userID == userID && progress.DeletedAt != nil {
+			trashed = append(trashed, copyProgress(progress))
+		}
+	}
+	sort.Slice(trashed, func(i, j int) bool { return trashed[i].DeletedAt.After(*trashed[j].DeletedAt) })
+	return paginate(trashed, limit, offset), nil
+}
+
+// PurgeTrash permanently deletes entries trashed before the cutoff
+func (s *MemoryLibraryStore) PurgeTrash(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, progress := range s.entries {
+		if progress.DeletedAt != nil && progress.DeletedAt.Before(before) {
+			delete(s.entries, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// Put writes an entry as given, keeping its timestamps
+func (s *MemoryLibraryStore) Put(progress *models.Progress, origin models.EventOrigin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := libraryKey{progress.UserID, progress.MangaID}
+	var prev *models.Progress
+	if existing, ok := s.entries[key]; ok {
+		prev = &existing
+	}
+	s.entries[key] = copyProgress(*progress)
+	toStatus := progress.Status
+	if progress.DeletedAt != nil {
+		toStatus = models.EventStatusRemoved
+	}
+	s.recordEvent(key, prev, progress.CurrentChapter, toStatus, origin)
+	return nil
+}
+
+// SaveProgress records a synced chapter update
+func (s *MemoryLibraryStore) SaveProgress(update *models.ProgressUpdate, origin models.EventOrigin) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	timestamp := time.Unix(update.Timestamp, 0)
+	key := libraryKey{update.UserID, update.MangaID}
+	progress, ok := s.entries[key]
+	var prev *models.Progress
+	if ok {
+		before := progress
+		prev = &before
+	} else {
+		progress = models.Progress{
+			UserID:    update.UserID,
+			MangaID:   update.MangaID,
+			Status:    "reading",
+			StartedAt: timestamp,
+		}
+	}
+	progress.CurrentChapter = update.Chapter
+	progress.UpdatedAt = timestamp
+	progress.DeletedAt = nil
+	s.entries[key] = progress
+	s.recordEvent(key, prev, progress.CurrentChapter, progress.Status, origin)
+	return nil
+}
+
+// Events returns progress events matching the filter, newest first
+func (s *MemoryLibraryStore) Events(filter models.ProgressEventFilter) ([]models.ProgressEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var events []models.ProgressEvent
+	for i := len(s.events) - 1; i >= 0; i-- {
+		event := s.events[i]
+		if filter.UserID != "" && event.UserID != filter.UserID ||
+			filter.MangaID != "" && event.MangaID != filter.MangaID ||
+			!filter.Since.IsZero() && event.CreatedAt.Before(filter.Since) ||
+			!filter.Until.IsZero() && !event.CreatedAt.Before(filter.Until) {
+			continue
+		}
+		events = append(events, event)
+	}
+	return paginate(events, filter.Limit, filter.Offset), nil
+}
+
+// recordEvent appends a progress event; callers hold the write lock
+func (s *MemoryLibraryStore) recordEvent(key libraryKey, prev *models.Progress, toChapter int, toStatus string, origin models.EventOrigin) {
+	event := models.ProgressEvent{
+		ID:        int64(len(s.events) + 1),
+		UserID:    key.userID,
+		MangaID:   key.mangaID,
+		ToChapter: toChapter,
+		ToStatus:  toStatus,
+		Source:    origin.Source,
+		DeviceID:  origin.DeviceID,
+		CreatedAt: time.Now().UTC(),
+	}
+	if prev != nil {
+		event.FromChapter = prev.CurrentChapter
+		event.FromStatus = prev.Status
+		if prev.DeletedAt != nil {
+			event.FromStatus = models.EventStatusRemoved
+		}
+	}
+	s.events = append(s.events, event)
+}
+
+func copyProgress(progress models.Progress) models.Progress {
+	if progress.CompletedAt != nil {
+		t := *progress.CompletedAt
+		progress.CompletedAt = &t
+	}
+	if progress.DeletedAt != nil {
+		t := *progress.DeletedAt
+		progress.DeletedAt = &t
+	}
+	return progress
+}
+
+// MemorySimilarityStore is a thread-safe in-memory SimilarityStore over the
+// manga of a MemoryMangaStore and the entries of a MemoryLibraryStore
+type MemorySimilarityStore struct {
+	mu         sync.RWMutex
+	scores     map[string][]models.Similarity
+	computedAt *time.Time
+	manga      *MemoryMangaStore
+	library    *MemoryLibraryStore
+}
+
+// NewMemorySimilarityStore creates an empty in-memory similarity store
+func NewMemorySimilarityStore(mangaStore *MemoryMangaStore, library *MemoryLibraryStore) *MemorySimilarityStore {
+	return &MemorySimilarityStore{scores: make(map[string][]models.Similarity), manga: mangaStore, library: library}
+}
+
+// Signals returns the genres of every manga outside the trash and every
+// library entry outside the trash
+func (s *MemorySimilarityStore) Signals() (*models.SimilaritySignals, error) {
+	signals := &models.SimilaritySignals{Genres: make(map[string][]string)}
+
+	s.manga.mu.RLock()
+	for id, manga := range s.manga.manga {
+		if manga.DeletedAt == nil {
+			signals.Genres[id] = append([]string(nil), manga.Genres...)
+		}
+	}
+	s.manga.mu.RUnlock()
+
+	s.library.mu.RLock()
+	for _, entry := range s.library.entries {
+		if entry.DeletedAt == nil {
+			signals.Entries = append(signals.Entries, entry)
+		}
+	}
+	s.library.mu.RUnlock()
+	return signals, nil
+}
+
+// Replace swaps every stored score for the given ones
+func (s *MemorySimilarityStore) Replace(scores []models.Similarity, computedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scores = make(map[string][]models.Similarity)
+	for _, sim := range scores {
+		s.scores[sim.MangaID] = append(s.scores[sim.MangaID], sim)
+	}
+	at := computedAt.UTC()
+	s.computedAt = &at
+	return nil
+}
+
+// Similar returns the stored similar manga of a manga, best first
+func (s *MemorySimilarityStore) Similar(mangaID string, limit int) ([]models.SimilarManga, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	if manga, ok := s.manga.manga[mangaID]; !ok || manga.DeletedAt != nil {
+		return nil, ErrMangaNotFound
+	}
+	var similar []models.SimilarManga
+	for _, sim := range s.scores[mangaID] {
+		manga, ok := s.manga.manga[sim.SimilarID]
+		if !ok || manga.DeletedAt != nil {
+			continue
+		}
+		similar = append(similar, models.SimilarManga{
+			Manga:       copyManga(manga),
+			Score:       sim.Score,
+			GenreScore:  sim.GenreScore,
+			CoReadScore: sim.CoReadScore,
+		})
+	}
+	sort.SliceStable(similar, func(i, j int) bool {
+		if similar[i].Score != similar[j].Score {
+			return similar[i].Score > similar[j].Score
+		}
+		return similar[i].Manga.Title < similar[j].Manga.Title
+	})
+	return paginate(similar, limit, 0), nil
+}
+
+// Neighbours returns the stored scores from the given manga to similar
+// manga outside the trash
+func (s *MemorySimilarityStore) Neighbours(mangaIDs []string) ([]models.Similarity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.manga.mu.RLock()
+	defer s.manga.mu.RUnlock()
+
+	var scores []models.Similarity
+	for _, id := range mangaIDs {
+		for _, sim := range s.scores[id] {
+			if manga, ok := s.manga.manga[sim.SimilarID]; ok && manga.DeletedAt == nil {
+				scores = append(scores, sim)
+			}
+		}
+	}
+	return scores, nil
+}
+
+// ComputedAt returns when the stored scores were computed
+func (s *MemorySimilarityStore) ComputedAt() (*time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.computedAt == nil {
+		return nil, nil
+	}
+	at := *s.computedAt
+	return &at, nil
+}
+
+// MemoryChatStore is a thread-safe in-memory ChatStore
+type MemoryChatStore struct {
+	mu    sync.RWMutex
+	rooms map[string][]models.ChatMessage
+}
+
+// NewMemoryChatStore creates an empty in-memory chat store
+func NewMemoryChatStore() *MemoryChatStore {
+	return &MemoryChatStore{rooms: make(map[string][]models.ChatMessage)}
+}
+
+// SaveMessage stores a chat message, filling in the ID and timestamps when unset
+func (s *MemoryChatStore) SaveMessage(msg *models.ChatMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fillChatMessage(msg)
+	s.rooms[msg.RoomID] = append(s.rooms[msg.RoomID], *msg)
+	return nil
+}
+
+// History returns the newest messages of a room, newest first
+func (s *MemoryChatStore) History(roomID string, limit int) ([]models.ChatMessage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored := s.rooms[roomID]
+	var messages []models.ChatMessage
+	for i := len(stored) - 1; i >= 0 && (limit <= 0 || len(messages) < limit); i-- {
+		messages = append(messages, stored[i])
+	}
+	return messages, nil
+}
+
+// MemoryNotificationStore is a thread-safe in-memory NotificationStore
+type MemoryNotificationStore struct {
+	mu            sync.RWMutex
+	subscriptions map[string][]string
+	preferences   map[string]models.NotificationPreferences
+	notifications []models.Notification
+}
+
+// NewMemoryNotificationStore creates an empty in-memory notification store
+func NewMemoryNotificationStore() *MemoryNotificationStore {
+	return &MemoryNotificationStore{
+		subscriptions: make(map[string][]string),
+		preferences:   make(map[string]models.NotificationPreferences),
+	}
+}
+
+// Subscribe subscribes a user to notifications for a manga
+func (s *MemoryNotificationStore) Subscribe(userID, mangaID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range s.subscriptions[userID] {
+		if id == mangaID {
+			return nil
+		}
+	}
+	s.subscriptions[userID] = append(s.subscriptions[userID], mangaID)
+	return nil
+}
+
+// Unsubscribe removes a user's subscription to a manga
+func (s *MemoryNotificationStore) Unsubscribe(userID, mangaID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.subscriptions[userID]
+	for i, id := range subs {
+		if id == mangaID {
+			s.subscriptions[userID] = append(subs[:i:i], subs[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// UnsubscribeAll removes every subscription of a user
+func (s *MemoryNotificationStore) UnsubscribeAll(userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscriptions, userID)
+	return nil
+}
+
+// Subscriptions returns the manga IDs a user is subscribed to
+func (s *MemoryNotificationStore) Subscriptions(userID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]string(nil), s.subscriptions[userID]...), nil
+}
+
+// GetPreferences returns a user's notification preferences
+func (s *MemoryNotificationStore) GetPreferences(userID string) (*models.NotificationPreferences, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	prefs, ok := s.preferences[userID]
+	if !ok {
+		return nil, ErrPreferencesNotFound
+	}
+	return &prefs, nil
+}
+
+// SavePreferences creates or replaces a user's notification preferences
+func (s *MemoryNotificationStore) SavePreferences(prefs *models.NotificationPreferences) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.preferences[prefs.UserID] = *prefs
+	return nil
+}
+
+// SubscribedManga returns every manga somebody is subscribed to
+func (s *MemoryNotificationStore) SubscribedManga() ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := make(map[string]bool)
+	var mangaIDs []string
+	for _, subs := range s.subscriptions {
+		for _, id := range subs {
+			if !seen[id] {
+				seen[id] = true
+				mangaIDs = append(mangaIDs, id)
+			}
+		}
+	}
+	sort.Strings(mangaIDs)
+	return mangaIDs, nil
+}
+
+// ReleaseSubscribers returns the subscribers of a manga with chapter
+// release notifications enabled
+func (s *MemoryNotificationStore) ReleaseSubscribers(mangaID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var userIDs []string
+	for userID, subs := range s.subscriptions {
+		prefs, ok := s.preferences[userID]
+		if ok && !prefs.ChapterReleases {
+			continue
+		}
+		for _, id := range subs {
+			if id == mangaID {
+				userIDs = append(userIDs, userID)
+				break
+			}
+		}
+	}
+	sort.Strings(userIDs)
+	return userIDs, nil
+}
+
+// CreateNotification stores a notification
+func (s *MemoryNotificationStore) CreateNotification(n *models.Notification) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fillNotification(n)
+	s.notifications = append(s.notifications, *n)
+	return nil
+}
+
+// paginate applies LIMIT/OFFSET semantics to an already ordered slice
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset >= len(items) {
+		return nil
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// MemoryMergeStore is an in-memory MergeStore over the other memory stores.
+// Each store is merged under its own lock, so a merge is not atomic across
+// them the way the SQLite transaction is.
+type MemoryMergeStore struct {
+	mu            sync.RWMutex
+	redirects     map[string]string
+	manga         *MemoryMangaStore
+	chapters      *MemoryChapterStore
+	library       *MemoryLibraryStore
+	notifications *MemoryNotificationStore
+	chat          *MemoryChatStore
+}
+
+// NewMemoryMergeStore creates an in-memory merge store without redirects
+func NewMemoryMergeStore(mangaStore *MemoryMangaStore, chapters *MemoryChapterStore, library *MemoryLibraryStore,
+	notifications *MemoryNotificationStore, chat *MemoryChatStore) *MemoryMergeStore {
+	return &MemoryMergeStore{
+		redirects:     make(map[string]string),
+		manga:         mangaStore,
+		chapters:      chapters,
+		library:       library,
+		notifications: notifications,
+		chat:          chat,
+	}
+}
+
+// Merge folds drop into keep and leaves a redirect
+func (s *MemoryMergeStore) Merge(keepID, dropID string) (*models.MergeResult, error) {
+	if keepID == dropID {
+		return nil, fmt.Errorf("cannot merge manga %s into itself", keepID)
+	}
+	if !s.manga.exists(keepID) || !s.manga.exists(dropID) {
+		return nil, ErrMangaNotFound
+	}
+
+	result := &models.MergeResult{KeepID: keepID, DropID: dropID}
+	s.mergeLibrary(keepID, dropID, result)
+	s.mergeNotifications(keepID, dropID, result)
+	s.mergeChat(keepID, dropID, result)
+	s.mergeChapters(keepID, dropID, result)
+	s.mergeCatalog(keepID, dropID, result)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for from, to := range s.redirects {
+		if to == dropID {
+			s.redirects[from] = keepID
+			result.Redirects++
+		}
+	}
+	delete(s.redirects, keepID)
+	s.redirects[dropID] = keepID
+	return result, nil
+}
+
+// mergeLibrary moves drop's library entries and progress events to keep
+func (s *MemoryMergeStore) mergeLibrary(keepID, dropID string, result *models.MergeResult) {
+	s.library.mu.Lock()
+	defer s.library.mu.Unlock()
+
+	for key, drop := range s.library.entries {
+		if key.mangaID != dropID {
+			continue
+		}
+		keepKey := libraryKey{key.userID, keepID}
+		if keep, ok := s.library.entries[keepKey]; ok {
+			s.library.entries[keepKey] = mergeProgress(keep, drop)
+			result.MergedEntries++
+		} else {
+			drop.MangaID = keepID
+			s.library.entries[keepKey] = drop
+			result.LibraryEntries++
+		}
+		delete(s.library.entries, key)
+	}
+	for i := range s.library.events {
+		if s.library.events[i].MangaID == dropID {
+			s.library.events[i].MangaID = keepID
+			result.ProgressEvents++
+		}
+	}
+}
+
+// mergeNotifications moves drop's subscriptions and notifications to keep
+func (s *MemoryMergeStore) mergeNotifications(keepID, dropID string, result *models.MergeResult) {
+	s.notifications.mu.Lock()
+	defer s.notifications.mu.Unlock()
+
+	for userID, subs := range s.notifications.subscriptions {
+		merged := make([]string, 0, len(subs))
+		subscribed, moved := false, false
+		for _, id := range subs {
+			switch id {
+			case keepID:
+				subscribed = true
+			case dropID:
+				moved = true
+				continue
+			}
+			merged = append(merged, id)
+		}
+		if moved && !subscribed {
+			merged = append(merged, keepID)
+			result.Subscriptions++
+		}
+		s.notifications.subscriptions[userID] = merged
+	}
+	for i := range s.notifications.notifications {
+		if s.notifications.notifications[i].MangaID == dropID {
+			s.notifications.notifications[i].MangaID = keepID
+			result.Notifications++
+		}
+	}
+}
+
+// mergeChat moves the messages of drop's room into keep's, oldest first
+func (s *MemoryMergeStore) mergeChat(keepID, dropID string, result *models.MergeResult) {
+	s.chat.mu.Lock()
+	defer s.chat.mu.Unlock()
+
+	dropped := s.chat.rooms[dropID]
+	if len(dropped) == 0 {
+		return
+	}
+	messages := append([]models.ChatMessage(nil), s.chat.rooms[keepID]...)
+	for _, msg := range dropped {
+		msg.RoomID = keepID
+		messages = append(messages, msg)
+	}
+	sort.SliceStable(messages, func(i, j int) bool { return messages[i].CreatedAt.Before(messages[j].CreatedAt) })
+	s.chat.rooms[keepID] = messages
+	delete(s.chat.rooms, dropID)
+	result.ChatMessages = len(dropped)
+}
+
+// mergeChapters copies the chapters keep lacks from drop and deletes
+// drop's chapters
+func (s *MemoryMergeStore) mergeChapters(keepID, dropID string, result *models.MergeResult) {
+	s.chapters.mu.Lock()
+	defer s.chapters.mu.Unlock()
+
+	var ids []int64
+	for id, chapter := range s.chapters.chapters {
+		if chapter.MangaID == dropID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for _, id := range ids {
+		chapter := copyChapter(s.chapters.chapters[id])
+		delete(s.chapters.chapters, id)
+		chapter.MangaID = keepID
+		chapter.ID = 0
+		if s.chapters.taken(chapter) {
+			continue
+		}
+		s.chapters.nextID++
+		chapter.ID = s.chapters.nextID
+		s.chapters.chapters[chapter.ID] = chapter
+		result.Chapters++
+	}
+	if result.Chapters > 0 {
+		s.chapters.syncTotal(keepID)
+	}
+}
+
+// mergeCatalog moves drop's titles and external IDs to keep and deletes
+// drop from the catalog
+func (s *MemoryMergeStore) mergeCatalog(keepID, dropID string, result *models.MergeResult) {
+	m := s.manga
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	titles := m.titles[keepID]
+	for _, t := range m.titles[dropID] {
+		taken := false
+		for _, have := range titles {
+			if have.Language == t.Language && have.Title == t.Title {
+				taken = true
+				break
+			}
+		}
+		if taken {
+			continue
+		}
+		t.MangaID, t.IsPrimary = keepID, false
+		titles = m.appendTitle(titles, t)
+		result.Titles++
+	}
+	if len(titles) > 0 {
+		m.titles[keepID] = titles
+	}
+
+	for key, link := range m.externalIDs {
+		if link.MangaID == dropID {
+			link.MangaID = keepID
+			m.externalIDs[key] = link
+			result.ExternalIDs++
+		}
+	}
+	delete(m.manga, dropID)
+	delete(m.titles, dropID)
+	delete(m.chapterTotals, dropID)
+	delete(m.fieldSources, dropID)
+}
+
+// Redirect returns the manga a merged ID redirects to
+func (s *MemoryMergeStore) Redirect(id string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	to, ok := s.redirects[id]
+	if !ok {
+		return "", ErrMangaNotFound
+	}
+	return to, nil
+}
+
+// MemorySessionStore is a thread-safe in-memory SessionStore
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]models.Session
+}
+
+// NewMemorySessionStore creates an empty in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]models.Session)}
+}
+
+// Create records a new session
+func (s *MemorySessionStore) Create(session *models.Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.sessions {
+		if existing.ID == session.ID || existing.RefreshHash == session.RefreshHash {
+			return ErrAlreadyExists
+		}
+	}
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+// Get retrieves a session by ID
+func (s *MemorySessionStore) Get(id string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, ErrSessionNotFound
+	}
+	return &session, nil
+}
+
+// GetByRefreshHash retrieves the session whose current or previous refresh
+// token has the hash
+func (s *MemorySessionStore) GetByRefreshHash(hash string) (*models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, session := range s.sessions {
+		if session.RefreshHash == hash || session.PreviousHash == hash {
+			return &session, nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+// Rotate replaces the refresh token hash of a live session, provided it is
+// still oldHash, and extends the session
+func (s *MemorySessionStore) Rotate(id, oldHash, newHash, ip string, usedAt, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.RefreshHash != oldHash || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	session.PreviousHash, session.RefreshHash = session.RefreshHash, newHash
+	if ip != "" {
+		session.IP = ip
+	}
+	session.LastUsedAt, session.ExpiresAt = usedAt, expiresAt
+	s.sessions[id] = session
+	return nil
+}
+
+// List lists a user's sessions that are neither revoked nor expired at now,
+// most recently used first
+func (s *MemorySessionStore) List(userID string, now time.Time) ([]models.Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, session := range s.sessions {
+		if session.UserID == userID && session.RevokedAt == nil && session.ExpiresAt.After(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastUsedAt.After(sessions[j].LastUsedAt) })
+	return sessions, nil
+}
+
+// Revoke ends a session
+func (s *MemorySessionStore) Revoke(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.RevokedAt != nil {
+		return ErrSessionNotFound
+	}
+	session.RevokedAt = &at
+	s.sessions[id] = session
+	return nil
+}
+
+// RevokeAll ends every live session of a user but exceptID, if set, and
+// returns how many it ended
+func (s *MemorySessionStore) RevokeAll(userID, exceptID string, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	revoked := 0
+	for id, session := range s.sessions {
+		if session.UserID != userID || id == exceptID || session.RevokedAt != nil {
+			continue
+		}
+		session.RevokedAt = &at
+		s.sessions[id] = session
+		revoked++
+	}
+	return revoked, nil
+}
+
+// PurgeEnded deletes the sessions that expired or were revoked before the
+// cutoff
+func (s *MemorySessionStore) PurgeEnded(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, session := range s.sessions {
+		if session.ExpiresAt.Before(before) || (session.RevokedAt != nil && session.RevokedAt.Before(before)) {
+			delete(s.sessions, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
+
+// MemoryAccessTokenStore is a thread-safe in-memory AccessTokenStore
+type MemoryAccessTokenStore struct {
+	mu     sync.RWMutex
+	tokens map[string]models.AccessToken
+}
+
+// NewMemoryAccessTokenStore creates an empty in-memory personal access
+// token store
+func NewMemoryAccessTokenStore() *MemoryAccessTokenStore {
+	return &MemoryAccessTokenStore{tokens: make(map[string]models.AccessToken)}
+}
+
+// Create records a new personal access token
+func (s *MemoryAccessTokenStore) Create(token *models.AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, existing := range s.tokens {
+		if existing.ID == token.ID || existing.TokenHash == token.TokenHash {
+			return ErrAlreadyExists
+		}
+	}
+	stored := *token
+	stored.Scopes = append([]string(nil), token.Scopes...)
+	s.tokens[token.ID] = stored
+	return nil
+}
+
+// GetByHash retrieves the personal access token with the hash
+func (s *MemoryAccessTokenStore) GetByHash(hash string) (*models.AccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, token := range s.tokens {
+		if token.TokenHash == hash {
+			return &token, nil
+		}
+	}
+	return nil, ErrAccessTokenNotFound
+}
+
+// List lists a user's tokens that are neither revoked nor expired at now,
+// newest first
+func (s *MemoryAccessTokenStore) List(userID string, now time.Time) ([]models.AccessToken, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := []models.AccessToken{}
+	for _, token := range s.tokens {
+		if token.UserID == userID && token.RevokedAt == nil && token.ExpiresAt.After(now) {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens, nil
+}
+
+// Revoke ends one of a user's personal access tokens
+func (s *MemoryAccessTokenStore) Revoke(userID, id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[id]
+	if !ok || token.UserID != userID || token.RevokedAt != nil {
+		return ErrAccessTokenNotFound
+	}
+	token.RevokedAt = &at
+	s.tokens[id] = token
+	return nil
+}
+
+// Touch records that a token was used
+func (s *MemoryAccessTokenStore) Touch(id string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if token, ok := s.tokens[id]; ok {
+		token.LastUsedAt = &at
+		s.tokens[id] = token
+	}
+	return nil
+}
+
+// PurgeEnded deletes the tokens that expired or were revoked before the
+// cutoff
+func (s *MemoryAccessTokenStore) PurgeEnded(before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for id, token := range s.tokens {
+		if token.ExpiresAt.Before(before) || (token.RevokedAt != nil && token.RevokedAt.Before(before)) {
+			delete(s.tokens, id)
+			purged++
+		}
+	}
+	return purged, nil
+}
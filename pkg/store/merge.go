@@ -0,0 +1,200 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteMergeStore is a MergeStore backed by SQLite. Merges leave their
+// redirects in the manga_redirects table.
+type SQLiteMergeStore struct {
+	db *database.Database
+}
+
+// NewSQLiteMergeStore creates a SQLite merge store
+func NewSQLiteMergeStore(db *database.Database) *SQLiteMergeStore {
+	return &SQLiteMergeStore{db: db}
+}
+
+// Merge folds drop into keep in one transaction. Progress events are
+// append-only, so drop's history is copied over in order and then removed.
+func (s *SQLiteMergeStore) Merge(keepID, dropID string) (*models.MergeResult, error) {
+	if keepID == dropID {
+		return nil, fmt.Errorf("cannot merge manga %s into itself", keepID)
+	}
+
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge manga: %w", err)
+	}
+	defer tx.Rollback()
+
+	var live int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM manga WHERE id IN (?, ?) AND deleted_at IS NULL`, keepID, dropID).Scan(&live)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge manga: %w", err)
+	}
+	if live != 2 {
+		return nil, ErrMangaNotFound
+	}
+
+	result := &models.MergeResult{KeepID: keepID, DropID: dropID}
+	if err := mergeLibrary(tx, keepID, dropID, result); err != nil {
+		return nil, fmt.Errorf("failed to merge library entries: %w", err)
+	}
+
+	moves := []struct {
+		count *int
+		query string
+	}{
+		{&result.Subscriptions, `INSERT OR IGNORE INTO notification_subscriptions (user_id, manga_id, created_at)
+			SELECT user_id, ?1, created_at FROM notification_subscriptions WHERE manga_id = ?2`},
+		{nil, `DELETE FROM notification_subscriptions WHERE manga_id = ?2`},
+		{&result.ChatMessages, `UPDATE chat_messages SET room_id = ?1 WHERE room_id = ?2`},
+		{&result.Notifications, `UPDATE notifications SET manga_id = ?1 WHERE manga_id = ?2`},
+		{&result.ProgressEvents, `INSERT INTO progress_events
+			(user_id, manga_id, from_chapter, to_chapter, from_status, to_status, source, device_id, created_at)
+			SELECT user_id, ?1, from_chapter, to_chapter, from_status, to_status, source, device_id, created_at
+			FROM progress_events WHERE manga_id = ?2 ORDER BY id`},
+		{nil, `DELETE FROM progress_events WHERE manga_id = ?2`},
+		{&result.Chapters, `INSERT OR IGNORE INTO chapters
+			(manga_id, number, volume, title, language, pages, released_at, created_at, updated_at)
+			SELECT ?1, number, volume, title, language, pages, released_at, created_at, updated_at
+			FROM chapters WHERE manga_id = ?2`},
+		{&result.Titles, `INSERT OR IGNORE INTO manga_titles (manga_id, language, title, is_primary)
+			SELECT ?1, language, title, 0 FROM manga_titles WHERE manga_id = ?2`},
+		{&result.ExternalIDs, `UPDATE manga_external_ids SET manga_id = ?1 WHERE manga_id = ?2`},
+		{&result.Redirects, `UPDATE manga_redirects SET to_id = ?1 WHERE to_id = ?2`},
+		{nil, `DELETE FROM manga_redirects WHERE from_id = ?1`},
+		{nil, `DELETE FROM manga WHERE id = ?2`},
+		{nil, `INSERT INTO manga_redirects (from_id, to_id, created_at) VALUES (?2, ?1, ?3)`},
+	}
+	now := time.Now().UTC().Format(database.TimestampFormat)
+	for _, m := range moves {
+		res, err := tx.Exec(m.query, keepID, dropID, now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to merge manga: %w", err)
+		}
+		if m.count != nil {
+			n, err := res.RowsAffected()
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge manga: %w", err)
+			}
+			*m.count = int(n)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to merge manga: %w", err)
+	}
+	return result, nil
+}
+
+// mergeLibrary moves drop's library entries over to keep, folding each into
+// the entry its user already has for keep
+func mergeLibrary(tx *sql.Tx, keepID, dropID string, result *models.MergeResult) error {
+	rows, err := tx.Query(`SELECT `+progressColumns+` FROM user_progress WHERE manga_id = ?`, dropID)
+	if err != nil {
+		return err
+	}
+	dropped, err := scanProgressRows(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	for _, drop := range dropped {
+		keep, err := scanProgress(tx.QueryRow(`SELECT `+progressColumns+` FROM user_progress WHERE user_id = ? AND manga_id = ?`,
+			drop.UserID, keepID))
+		if err == sql.ErrNoRows {
+			if _, err := tx.Exec(`UPDATE user_progress SET manga_id = ? WHERE user_id = ? AND manga_id = ?`,
+				keepID, drop.UserID, dropID); err != nil {
+				return err
+			}
+			result.LibraryEntries++
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		merged := mergeProgress(*keep, drop)
+		var deletedAt sql.NullString
+		if merged.DeletedAt != nil {
+			deletedAt = sql.NullString{String: merged.DeletedAt.UTC().Format(database.TimestampFormat), Valid: true}
+		}
+		_, err = tx.Exec(`
+			UPDATE user_progress SET current_chapter = ?, status = ?, rating = ?, notes = ?, started_at = ?,
+				completed_at = ?, updated_at = ?, deleted_at = ?
+			WHERE user_id = ? AND manga_id = ?`,
+			merged.CurrentChapter, merged.Status, merged.Rating, merged.Notes, merged.StartedAt,
+			merged.CompletedAt, merged.UpdatedAt, deletedAt, drop.UserID, keepID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`DELETE FROM user_progress WHERE user_id = ? AND manga_id = ?`, drop.UserID, dropID); err != nil {
+			return err
+		}
+		result.MergedEntries++
+	}
+	return nil
+}
+
+// mergeProgress folds a user's entry for a dropped manga into their entry
+// for the kept one. An entry outside the trash wins over a trashed one;
+// otherwise the furthest chapter is kept, the entry further along sets the
+// status unless the kept one is completed, the kept entry's rating and
+// notes win when set, and the earliest start and latest update are kept.
+func mergeProgress(keep, drop models.Progress) models.Progress {
+	if (keep.DeletedAt == nil) != (drop.DeletedAt == nil) {
+		if keep.DeletedAt != nil {
+			drop.MangaID = keep.MangaID
+			return drop
+		}
+		return keep
+	}
+
+	merged := keep
+	if drop.CurrentChapter > merged.CurrentChapter {
+		merged.CurrentChapter = drop.CurrentChapter
+		if merged.Status != "completed" {
+			merged.Status = drop.Status
+		}
+	}
+	if drop.Status == "completed" && merged.Status != "completed" {
+		merged.Status = drop.Status
+	}
+	if merged.Status == "completed" && merged.CompletedAt == nil {
+		merged.CompletedAt = drop.CompletedAt
+	}
+	if merged.Rating == 0 {
+		merged.Rating = drop.Rating
+	}
+	if merged.Notes == "" {
+		merged.Notes = drop.Notes
+	}
+	if !drop.StartedAt.IsZero() && (merged.StartedAt.IsZero() || drop.StartedAt.Before(merged.StartedAt)) {
+		merged.StartedAt = drop.StartedAt
+	}
+	if drop.UpdatedAt.After(merged.UpdatedAt) {
+		merged.UpdatedAt = drop.UpdatedAt
+	}
+	return merged
+}
+
+// Redirect returns the manga a merged ID redirects to
+func (s *SQLiteMergeStore) Redirect(id string) (string, error) {
+	var to string
+	err := s.db.QueryRow(`SELECT to_id FROM manga_redirects WHERE from_id = ?`, id).Scan(&to)
+	if err == sql.ErrNoRows {
+		return "", ErrMangaNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get manga redirect: %w", err)
+	}
+	return to, nil
+}
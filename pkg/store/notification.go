@@ -0,0 +1,177 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteNotificationStore is a NotificationStore backed by SQLite
+type SQLiteNotificationStore struct {
+	db *database.Database
+}
+
+// NewSQLiteNotificationStore creates a SQLite notification store
+func NewSQLiteNotificationStore(db *database.Database) *SQLiteNotificationStore {
+	return &SQLiteNotificationStore{db: db}
+}
+
+// Subscribe subscribes a user to notifications for a manga
+func (s *SQLiteNotificationStore) Subscribe(userID, mangaID string) error {
+	query := `
+		INSERT OR REPLACE INTO notification_subscriptions (user_id, manga_id, created_at)
+		VALUES (?, ?, ?)
+	`
+	if _, err := s.db.Exec(query, userID, mangaID, time.Now()); err != nil {
+		return fmt.Errorf("failed to subscribe: %w", err)
+	}
+	return nil
+}
+
+// Unsubscribe removes a user's subscription to a manga
+func (s *SQLiteNotificationStore) Unsubscribe(userID, mangaID string) error {
+	query := `DELETE FROM notification_subscriptions WHERE user_id = ? AND manga_id = ?`
+	if _, err := s.db.Exec(query, userID, mangaID); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// UnsubscribeAll removes every subscription of a user
+func (s *SQLiteNotificationStore) UnsubscribeAll(userID string) error {
+	if _, err := s.db.Exec(`DELETE FROM notification_subscriptions WHERE user_id = ?`, userID); err != nil {
+		return fmt.Errorf("failed to unsubscribe: %w", err)
+	}
+	return nil
+}
+
+// Subscriptions returns the manga IDs a user is subscribed to
+func (s *SQLiteNotificationStore) Subscriptions(userID string) ([]string, error) {
+	rows, err := s.db.Query(`SELECT manga_id FROM notification_subscriptions WHERE user_id = ? ORDER BY created_at`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subscriptions []string
+	for rows.Next() {
+		var mangaID string
+		if err := rows.Scan(&mangaID); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		subscriptions = append(subscriptions, mangaID)
+	}
+	return subscriptions, rows.Err()
+}
+
+// GetPreferences returns a user's notification preferences
+func (s *SQLiteNotificationStore) GetPreferences(userID string) (*models.NotificationPreferences, error) {
+	query := `
+		SELECT user_id, chapter_releases, email_notifications, sound_enabled
+		FROM notification_preferences WHERE user_id = ?
+	`
+	var prefs models.NotificationPreferences
+	err := s.db.QueryRow(query, userID).Scan(&prefs.UserID, &prefs.ChapterReleases, &prefs.EmailNotifications, &prefs.SoundEnabled)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrPreferencesNotFound
+		}
+		return nil, fmt.Errorf("failed to get preferences: %w", err)
+	}
+	return &prefs, nil
+}
+
+// SavePreferences creates or replaces a user's notification preferences
+func (s *SQLiteNotificationStore) SavePreferences(prefs *models.NotificationPreferences) error {
+	query := `
+		INSERT OR REPLACE INTO notification_preferences
+		(user_id, chapter_releases, email_notifications, sound_enabled, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, prefs.UserID, prefs.ChapterReleases, prefs.EmailNotifications, prefs.SoundEnabled, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to save preferences: %w", err)
+	}
+	return nil
+}
+
+// SubscribedManga returns every manga somebody is subscribed to
+func (s *SQLiteNotificationStore) SubscribedManga() ([]string, error) {
+	rows, err := s.db.Query(`SELECT DISTINCT manga_id FROM notification_subscriptions ORDER BY manga_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribed manga: %w", err)
+	}
+	defer rows.Close()
+
+	var mangaIDs []string
+	for rows.Next() {
+		var mangaID string
+		if err := rows.Scan(&mangaID); err != nil {
+			return nil, fmt.Errorf("failed to scan subscription: %w", err)
+		}
+		mangaIDs = append(mangaIDs, mangaID)
+	}
+	return mangaIDs, rows.Err()
+}
+
+// ReleaseSubscribers returns the subscribers of a manga with chapter
+// release notifications enabled
+func (s *SQLiteNotificationStore) ReleaseSubscribers(mangaID string) ([]string, error) {
+	rows, err := s.db.Query(`
+		SELECT s.user_id FROM notification_subscriptions s
+		LEFT JOIN notification_preferences p ON p.user_id = s.user_id
+		WHERE s.manga_id = ? AND COALESCE(p.chapter_releases, 1) = 1
+		ORDER BY s.user_id`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get subscribers: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("failed to scan subscriber: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// CreateNotification stores a notification
+func (s *SQLiteNotificationStore) CreateNotification(n *models.Notification) error {
+	fillNotification(n)
+	var data interface{}
+	if len(n.Data) > 0 {
+		encoded, err := json.Marshal(n.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode notification data: %w", err)
+		}
+		data = string(encoded)
+	}
+
+	query := `
+		INSERT INTO notifications (id, user_id, type, manga_id, message, read, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.Exec(query, n.ID, n.UserID, n.Type, n.MangaID, n.Message, n.Read, data,
+		n.CreatedAt.UTC().Format(database.TimestampFormat))
+	if err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// fillNotification sets the defaults shared by every NotificationStore
+func fillNotification(n *models.Notification) {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now()
+	}
+	if n.ID == "" {
+		n.ID = fmt.Sprintf("%s-%d", n.UserID, n.CreatedAt.UnixNano())
+	}
+}
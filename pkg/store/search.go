@@ -0,0 +1,25 @@
+package store
+
+import (
+	"strings"
+	"unicode"
+)
+
+// searchTerms splits a free-text query into lowercase word tokens.
+// Punctuation and FTS5 operators are dropped so user input can never
+// produce an invalid MATCH expression.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ftsMatchQuery builds an FTS5 MATCH expression that requires every term,
+// treating each one as a prefix so partial words still match
+func ftsMatchQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + term + `"*`
+	}
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,164 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+const sessionColumns = "id, user_id, refresh_hash, previous_hash, device, ip, created_at, last_used_at, expires_at, revoked_at"
+
+// SQLiteSessionStore is a SessionStore backed by SQLite
+type SQLiteSessionStore struct {
+	db *database.Database
+}
+
+// NewSQLiteSessionStore creates a SQLite session store
+func NewSQLiteSessionStore(db *database.Database) *SQLiteSessionStore {
+	return &SQLiteSessionStore{db: db}
+}
+
+// Create records a new session
+func (s *SQLiteSessionStore) Create(session *models.Session) error {
+	_, err := s.db.Exec(`INSERT INTO sessions (`+sessionColumns+`) VALUES (?, ?, ?, NULL, ?, ?, ?, ?, ?, NULL)`,
+		session.ID, session.UserID, session.RefreshHash, nullString(session.Device), nullString(session.IP),
+		timestamp(session.CreatedAt), timestamp(session.LastUsedAt), timestamp(session.ExpiresAt))
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+// Get retrieves a session by ID
+func (s *SQLiteSessionStore) Get(id string) (*models.Session, error) {
+	return s.getBy(`id = ?`, id)
+}
+
+// GetByRefreshHash retrieves the session whose current or previous refresh
+// token has the hash
+func (s *SQLiteSessionStore) GetByRefreshHash(hash string) (*models.Session, error) {
+	return s.getBy(`refresh_hash = ?1 OR previous_hash = ?1`, hash)
+}
+
+func (s *SQLiteSessionStore) getBy(where string, arg string) (*models.Session, error) {
+	session, err := scanSession(s.db.QueryRow(`SELECT `+sessionColumns+` FROM sessions WHERE `+where, arg))
+	if err == sql.ErrNoRows {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return session, nil
+}
+
+// Rotate replaces the refresh token hash of a live session, provided it is
+// still oldHash, and extends the session
+func (s *SQLiteSessionStore) Rotate(id, oldHash, newHash, ip string, usedAt, expiresAt time.Time) error {
+	res, err := s.db.Exec(`
+		UPDATE sessions
+		SET refresh_hash = ?, previous_hash = refresh_hash, ip = COALESCE(?, ip), last_used_at = ?, expires_at = ?
+		WHERE id = ? AND refresh_hash = ? AND revoked_at IS NULL`,
+		newHash, nullString(ip), timestamp(usedAt), timestamp(expiresAt), id, oldHash)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to rotate session: %w", err)
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// List lists a user's sessions that are neither revoked nor expired at now,
+// most recently used first
+func (s *SQLiteSessionStore) List(userID string, now time.Time) ([]models.Session, error) {
+	rows, err := s.db.Query(`
+		SELECT `+sessionColumns+` FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_used_at DESC`, userID, timestamp(now))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke ends a session
+func (s *SQLiteSessionStore) Revoke(id string, at time.Time) error {
+	res, err := s.db.Exec(`UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, timestamp(at), id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if n == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAll ends every live session of a user but exceptID, if set, and
+// returns how many it ended
+func (s *SQLiteSessionStore) RevokeAll(userID, exceptID string, at time.Time) (int, error) {
+	res, err := s.db.Exec(`UPDATE sessions SET revoked_at = ? WHERE user_id = ? AND id != ? AND revoked_at IS NULL`,
+		timestamp(at), userID, exceptID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return int(n), nil
+}
+
+// PurgeEnded deletes the sessions that expired or were revoked before the
+// cutoff
+func (s *SQLiteSessionStore) PurgeEnded(before time.Time) (int64, error) {
+	res, err := s.db.Exec(`DELETE FROM sessions WHERE expires_at < ?1 OR revoked_at < ?1`, timestamp(before))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+func scanSession(row rowScanner) (*models.Session, error) {
+	var session models.Session
+	var previousHash, device, ip sql.NullString
+	var revokedAt sql.NullTime
+	err := row.Scan(&session.ID, &session.UserID, &session.RefreshHash, &previousHash, &device, &ip,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt)
+	if err != nil {
+		return nil, err
+	}
+	session.PreviousHash = previousHash.String
+	session.Device = device.String
+	session.IP = ip.String
+	if revokedAt.Valid {
+		t := revokedAt.Time
+		session.RevokedAt = &t
+	}
+	return &session, nil
+}
+
+// timestamp stores a time as TimestampFormat text
+func timestamp(t time.Time) string {
+	return t.UTC().Format(database.TimestampFormat)
+}
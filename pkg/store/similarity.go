@@ -0,0 +1,189 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteSimilarityStore is a SimilarityStore backed by the manga_similarity
+// table
+type SQLiteSimilarityStore struct {
+	db *database.Database
+}
+
+// NewSQLiteSimilarityStore creates a SQLite similarity store
+func NewSQLiteSimilarityStore(db *database.Database) *SQLiteSimilarityStore {
+	return &SQLiteSimilarityStore{db: db}
+}
+
+// Signals loads the canonical genres of every manga outside the trash and
+// every library entry outside the trash
+func (s *SQLiteSimilarityStore) Signals() (*models.SimilaritySignals, error) {
+	signals := &models.SimilaritySignals{Genres: make(map[string][]string)}
+
+	rows, err := s.db.Query(`
+		SELECT m.id, COALESCE(g.name, '') FROM manga m
+		LEFT JOIN manga_genres mg ON mg.manga_id = m.id
+		LEFT JOIN genres g ON g.id = mg.genre_id
+		WHERE m.deleted_at IS NULL
+		ORDER BY m.id, g.name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manga genres: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, genre string
+		if err := rows.Scan(&id, &genre); err != nil {
+			return nil, fmt.Errorf("failed to scan manga genre: %w", err)
+		}
+		// Manga without genres still get a key, with no genres
+		genres := signals.Genres[id]
+		if genre != "" {
+			genres = append(genres, genre)
+		}
+		signals.Genres[id] = genres
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load manga genres: %w", err)
+	}
+
+	entries, err := s.db.Query(`
+		SELECT user_id, manga_id, COALESCE(status, ''), COALESCE(rating, 0) FROM user_progress
+		WHERE deleted_at IS NULL`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load library entries: %w", err)
+	}
+	defer entries.Close()
+	for entries.Next() {
+		var p models.Progress
+		if err := entries.Scan(&p.UserID, &p.MangaID, &p.Status, &p.Rating); err != nil {
+			return nil, fmt.Errorf("failed to scan library entry: %w", err)
+		}
+		signals.Entries = append(signals.Entries, p)
+	}
+	if err := entries.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load library entries: %w", err)
+	}
+	return signals, nil
+}
+
+// Replace swaps every stored score for the given ones in one transaction
+func (s *SQLiteSimilarityStore) Replace(scores []models.Similarity, computedAt time.Time) error {
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to store similarity: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM manga_similarity`); err != nil {
+		return fmt.Errorf("failed to store similarity: %w", err)
+	}
+	stmt, err := tx.Prepare(`
+		INSERT INTO manga_similarity (manga_id, similar_id, score, genre_score, coread_score, computed_at)
+		VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to store similarity: %w", err)
+	}
+	defer stmt.Close()
+
+	at := computedAt.UTC().Format(database.TimestampFormat)
+	for _, sim := range scores {
+		if _, err := stmt.Exec(sim.MangaID, sim.SimilarID, sim.Score, sim.GenreScore, sim.CoReadScore, at); err != nil {
+			return fmt.Errorf("failed to store similarity: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to store similarity: %w", err)
+	}
+	return nil
+}
+
+// Similar returns the stored similar manga of a manga, best first
+func (s *SQLiteSimilarityStore) Similar(mangaID string, limit int) ([]models.SimilarManga, error) {
+	var exists int
+	err := s.db.QueryRow(`SELECT 1 FROM manga WHERE id = ? AND deleted_at IS NULL`, mangaID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return nil, ErrMangaNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up manga: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT `+prefixColumns("m", mangaColumns)+`, s.score, s.genre_score, s.coread_score
+		FROM manga_similarity s
+		JOIN manga m ON m.id = s.similar_id
+		WHERE s.manga_id = ? AND m.deleted_at IS NULL
+		ORDER BY s.score DESC, m.title
+		LIMIT ?`, mangaID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load similar manga: %w", err)
+	}
+	defer rows.Close()
+
+	var similar []models.SimilarManga
+	for rows.Next() {
+		var sm models.SimilarManga
+		manga, err := scanManga(rows, &sm.Score, &sm.GenreScore, &sm.CoReadScore)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan similar manga: %w", err)
+		}
+		sm.Manga = *manga
+		similar = append(similar, sm)
+	}
+	return similar, rows.Err()
+}
+
+// Neighbours returns the stored scores from the given manga to similar
+// manga outside the trash
+func (s *SQLiteSimilarityStore) Neighbours(mangaIDs []string) ([]models.Similarity, error) {
+	if len(mangaIDs) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, len(mangaIDs))
+	for i, id := range mangaIDs {
+		args[i] = id
+	}
+
+	rows, err := s.db.Query(`
+		SELECT s.manga_id, s.similar_id, s.score, s.genre_score, s.coread_score
+		FROM manga_similarity s
+		JOIN manga m ON m.id = s.similar_id
+		WHERE m.deleted_at IS NULL
+			AND s.manga_id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(mangaIDs)), ", ")+`)`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load similarity: %w", err)
+	}
+	defer rows.Close()
+
+	var scores []models.Similarity
+	for rows.Next() {
+		var sim models.Similarity
+		if err := rows.Scan(&sim.MangaID, &sim.SimilarID, &sim.Score, &sim.GenreScore, &sim.CoReadScore); err != nil {
+			return nil, fmt.Errorf("failed to scan similarity: %w", err)
+		}
+		scores = append(scores, sim)
+	}
+	return scores, rows.Err()
+}
+
+// ComputedAt returns when the stored scores were computed
+func (s *SQLiteSimilarityStore) ComputedAt() (*time.Time, error) {
+	var at sql.NullString
+	if err := s.db.QueryRow(`SELECT MAX(computed_at) FROM manga_similarity`).Scan(&at); err != nil {
+		return nil, fmt.Errorf("failed to look up similarity refresh: %w", err)
+	}
+	if !at.Valid {
+		return nil, nil
+	}
+	t, err := time.Parse(database.TimestampFormat, at.String)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse similarity refresh time: %w", err)
+	}
+	return &t, nil
+}
@@ -0,0 +1,368 @@
+// Package store defines the repository interfaces used by the services and
+// servers, with a SQLite implementation backed by pkg/database and a
+// thread-safe in-memory implementation for tests and ephemeral setups.
+package store
+
+import (
+	"errors"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+var (
+	// ErrMangaNotFound is returned when a manga does not exist
+	ErrMangaNotFound = errors.New("manga not found")
+
+	// ErrUserNotFound is returned when a user does not exist
+	ErrUserNotFound = errors.New("user not found")
+
+	// ErrEntryNotFound is returned when a library entry does not exist
+	ErrEntryNotFound = errors.New("library entry not found")
+
+	// ErrChapterNotFound is returned when a chapter does not exist
+	ErrChapterNotFound = errors.New("chapter not found")
+
+	// ErrTitleNotFound is returned when a manga has no title with an ID
+	ErrTitleNotFound = errors.New("title not found")
+
+	// ErrExternalIDNotFound is returned when an external ID links to no manga
+	ErrExternalIDNotFound = errors.New("external ID not found")
+
+	// ErrPreferencesNotFound is returned when a user has no saved notification preferences
+	ErrPreferencesNotFound = errors.New("notification preferences not found")
+
+	// ErrSessionNotFound is returned when a session does not exist, or
+	// has already been revoked for the calls that need a live one
+	ErrSessionNotFound = errors.New("session not found")
+
+	// ErrAccessTokenNotFound is returned when a personal access token does
+	// not exist, belongs to another user or has already been revoked
+	ErrAccessTokenNotFound = errors.New("access token not found")
+
+	// ErrAlreadyExists is returned when creating a record whose key is taken
+	ErrAlreadyExists = errors.New("record already exists")
+)
+
+// MangaStore persists manga catalog entries. Delete moves a manga to the
+// trash; trashed manga are invisible to every other read until restored.
+// Create and Update resolve genres against the taxonomy, adding unknown
+// ones as tags, and store their canonical names.
+type MangaStore interface {
+	Create(manga *models.Manga) error
+	GetByID(id string) (*models.Manga, error)
+	List(limit, offset int) ([]models.Manga, error)
+	Search(filter *models.MangaFilter) ([]models.Manga, error)
+	// Facets counts every match of a search per genre and per status
+	Facets(filter *models.MangaFilter) (*models.SearchFacets, error)
+	// Fuzzy suggests manga whose title or author resembles the query
+	// despite typos, most similar first
+	Fuzzy(query string, limit int) ([]models.Suggestion, error)
+	// Autocomplete suggests manga whose title or one of its words starts
+	// with the prefix, whole-title matches and shorter titles first
+	Autocomplete(prefix string, limit int) ([]models.Suggestion, error)
+	// Update and Delete return ErrMangaNotFound when the manga does not
+	// exist or is in the trash
+	Update(manga *models.Manga) error
+	Delete(id string) error
+	// Restore returns ErrMangaNotFound when the manga is not in the trash
+	Restore(id string) error
+	// Trash lists trashed manga, most recently deleted first
+	Trash(limit, offset int) ([]models.Manga, error)
+	// PurgeTrash permanently deletes manga trashed before the cutoff
+	PurgeTrash(before time.Time) (int64, error)
+	// Rankings returns the unscored ranking figures of every manga with
+	// progress events since the cutoff or at least one rating, optionally
+	// within a genre, in no particular order
+	Rankings(since time.Time, genre string) ([]models.RankedManga, error)
+}
+
+// ChapterStore persists per-chapter catalog data. Every write keeps the
+// manga's TotalChapters at its highest chapter number.
+type ChapterStore interface {
+	// Create returns ErrMangaNotFound when the manga does not exist and
+	// ErrAlreadyExists when it already has the number in that language
+	Create(chapter *models.Chapter) error
+	Get(id int64) (*models.Chapter, error)
+	// List returns a manga's chapters ordered by number
+	List(filter models.ChapterFilter) ([]models.Chapter, error)
+	Update(chapter *models.Chapter) error
+	Delete(id int64) error
+}
+
+// TitleStore persists the alternate and localized titles of manga. Writing
+// a primary title demotes the previous primary title in that language.
+type TitleStore interface {
+	// List returns a manga's titles ordered by language, primary first, and
+	// ErrMangaNotFound when the manga does not exist
+	List(mangaID string) ([]models.MangaTitle, error)
+	// Add returns ErrMangaNotFound when the manga does not exist and
+	// ErrAlreadyExists when it already has the title in that language
+	Add(title *models.MangaTitle) error
+	// Replace swaps every title of a manga for the given ones, as importers
+	// do; duplicates and extra primary titles in a language are dropped
+	Replace(mangaID string, titles []models.MangaTitle) error
+	Delete(id int64) error
+	// InLanguage returns the titles in one language of the given manga,
+	// keyed by manga ID
+	InLanguage(mangaIDs []string, language string) (map[string][]models.MangaTitle, error)
+}
+
+// ExternalIDStore links manga to their IDs at outside catalogs such as
+// MangaDex, for importers to find what they imported before, and keeps what
+// each linked source last said about a manga for the catalog to merge
+type ExternalIDStore interface {
+	// Link links an external ID to a manga, moving it off any manga it
+	// linked to before, and returns ErrMangaNotFound when the manga does not
+	// exist
+	Link(id *models.ExternalID) error
+	// Find returns the ID of the manga an external ID links to, trashed or
+	// not, and ErrExternalIDNotFound when it links to none
+	Find(source, externalID string) (string, error)
+	// List returns the external IDs of a manga ordered by source
+	List(mangaID string) ([]models.ExternalID, error)
+	// SaveEntry links the entry's external ID to a manga like Link and
+	// keeps the entry as that source's latest view of it
+	SaveEntry(mangaID string, entry *models.CatalogEntry) error
+	// Entries returns the saved entries of every source linked to a manga
+	// ordered by source
+	Entries(mangaID string) ([]models.CatalogEntry, error)
+	// FieldSources returns the source that supplied each field of a manga,
+	// keyed by field
+	FieldSources(mangaID string) (map[string]string, error)
+	// SetFieldSources replaces the field sources of a manga
+	SetFieldSources(mangaID string, sources map[string]string) error
+}
+
+// SimilarityStore keeps the similar manga of each manga. Scores are
+// computed in batch from the Signals and stored with Replace, so reads stay
+// cheap; trashed manga are never returned.
+type SimilarityStore interface {
+	// Signals loads the inputs of a similarity refresh
+	Signals() (*models.SimilaritySignals, error)
+	// Replace swaps every stored score for the given ones
+	Replace(scores []models.Similarity, computedAt time.Time) error
+	// Similar returns the similar manga of a manga, best first, and
+	// ErrMangaNotFound when the manga does not exist
+	Similar(mangaID string, limit int) ([]models.SimilarManga, error)
+	// Neighbours returns the stored scores from the given manga
+	Neighbours(mangaIDs []string) ([]models.Similarity, error)
+	// ComputedAt returns when the scores were stored, nil before the first
+	// refresh
+	ComputedAt() (*time.Time, error)
+}
+
+// GenreStore reads the genre and tag taxonomy
+type GenreStore interface {
+	// List returns genres of one kind ("" for all) with their aliases and
+	// the number of manga outside the trash that carry them
+	List(kind string) ([]models.Genre, error)
+}
+
+// UserStore persists user accounts. Create returns ErrAlreadyExists when
+// the ID, username or email is taken. Update leaves the role alone; it is
+// only changed by SetRole, which returns ErrUserNotFound for unknown users.
+type UserStore interface {
+	Create(user *models.User) error
+	GetByID(id string) (*models.User, error)
+	GetByUsername(username string) (*models.User, error)
+	GetByEmail(email string) (*models.User, error)
+	ListByRole(role string) ([]models.User, error)
+	Update(user *models.User) error
+	UpdatePassword(userID, passwordHash string) error
+	SetRole(userID, role string) error
+	Delete(id string) error
+}
+
+// LibraryStore persists library entries and reading progress. Every write
+// also appends a progress event attributed to origin. Add returns
+// ErrAlreadyExists when the manga is already in the library. Remove moves
+// an entry to the trash, where Get and List no longer see it.
+type LibraryStore interface {
+	Add(progress *models.Progress, origin models.EventOrigin) error
+	Get(userID, mangaID string) (*models.Progress, error)
+	// List returns a user's entries, optionally filtered by status ("" for all)
+	List(userID, status string, limit, offset int) ([]models.Progress, error)
+	Update(progress *models.Progress, origin models.EventOrigin) error
+	Remove(userID, mangaID string, origin models.EventOrigin) error
+	// Restore returns ErrEntryNotFound when the entry is not in the trash
+	Restore(userID, mangaID string, origin models.EventOrigin) error
+	// Trash lists a user's trashed entries, most recently removed first
+	Trash(userID string, limit, offset int) ([]models.Progress, error)
+	// PurgeTrash permanently deletes entries trashed before the cutoff
+	PurgeTrash(before time.Time) (int64, error)
+	// Put writes an entry as given, keeping its timestamps, for copying
+	// entries between replicas of a library
+	Put(progress *models.Progress, origin models.EventOrigin) error
+	// SaveProgress records a synced chapter update, creating a "reading"
+	// entry when the manga is not in the library yet
+	SaveProgress(update *models.ProgressUpdate, origin models.EventOrigin) error
+	// Events returns progress events matching the filter, newest first
+	Events(filter models.ProgressEventFilter) ([]models.ProgressEvent, error)
+}
+
+// ChatStore persists chat messages
+type ChatStore interface {
+	SaveMessage(msg *models.ChatMessage) error
+	// History returns the newest messages of a room, newest first
+	History(roomID string, limit int) ([]models.ChatMessage, error)
+}
+
+// NotificationStore persists notification subscriptions and preferences
+type NotificationStore interface {
+	Subscribe(userID, mangaID string) error
+	Unsubscribe(userID, mangaID string) error
+	UnsubscribeAll(userID string) error
+	Subscriptions(userID string) ([]string, error)
+	GetPreferences(userID string) (*models.NotificationPreferences, error)
+	SavePreferences(prefs *models.NotificationPreferences) error
+	// SubscribedManga returns every manga at least one user is subscribed
+	// to, in ID order
+	SubscribedManga() ([]string, error)
+	// ReleaseSubscribers returns the users subscribed to a manga who want
+	// chapter release notifications; users who never saved preferences do
+	ReleaseSubscribers(mangaID string) ([]string, error)
+	// CreateNotification stores a notification, filling in its ID and
+	// CreatedAt when they are empty
+	CreateNotification(n *models.Notification) error
+}
+
+// MergeStore folds duplicate catalog manga into one. A merged-away ID
+// redirects to the manga it was merged into, so clients still holding it
+// find the manga that was kept.
+type MergeStore interface {
+	// Merge moves the library entries, subscriptions, chat room,
+	// notifications and progress history of drop over to keep, adds the
+	// chapters, titles and external IDs keep lacks, then deletes drop and
+	// leaves a redirect. It returns ErrMangaNotFound when either manga does
+	// not exist or is in the trash.
+	Merge(keepID, dropID string) (*models.MergeResult, error)
+	// Redirect returns the manga a merged-away ID redirects to and
+	// ErrMangaNotFound when the ID was never merged away
+	Redirect(id string) (string, error)
+}
+
+// SessionStore persists login sessions with the hashes of their refresh
+// tokens. Rotate and Revoke return ErrSessionNotFound when the session is
+// unknown or already revoked, and Rotate also when the hash has moved on.
+type SessionStore interface {
+	Create(session *models.Session) error
+	Get(id string) (*models.Session, error)
+	// GetByRefreshHash finds the session a refresh token belongs to, or
+	// belonged to before the session's last refresh
+	GetByRefreshHash(hash string) (*models.Session, error)
+	Rotate(id, oldHash, newHash, ip string, usedAt, expiresAt time.Time) error
+	List(userID string, now time.Time) ([]models.Session, error)
+	Revoke(id string, at time.Time) error
+	RevokeAll(userID, exceptID string, at time.Time) (int, error)
+	PurgeEnded(before time.Time) (int64, error)
+}
+
+// AccessTokenStore persists personal access tokens by the hash of the
+// token. Revoke returns ErrAccessTokenNotFound when the token is not the
+// user's or is already revoked.
+type AccessTokenStore interface {
+	Create(token *models.AccessToken) error
+	GetByHash(hash string) (*models.AccessToken, error)
+	// List lists a user's tokens that are neither revoked nor expired at
+	// now, newest first
+	List(userID string, now time.Time) ([]models.AccessToken, error)
+	Revoke(userID, id string, at time.Time) error
+	// Touch records that a token was used
+	Touch(id string, at time.Time) error
+	PurgeEnded(before time.Time) (int64, error)
+}
+
+// Stores bundles one implementation of every repository
+type Stores struct {
+	Manga         MangaStore
+	Chapters      ChapterStore
+	Titles        TitleStore
+	ExternalIDs   ExternalIDStore
+	Genres        GenreStore
+	Similarity    SimilarityStore
+	Users         UserStore
+	Library       LibraryStore
+	Chat          ChatStore
+	Notifications NotificationStore
+	Merges        MergeStore
+	Sessions      SessionStore
+	AccessTokens  AccessTokenStore
+}
+
+// NewSQLiteStores returns stores backed by the given database
+func NewSQLiteStores(db *database.Database) *Stores {
+	return &Stores{
+		Manga:         NewSQLiteMangaStore(db),
+		Chapters:      NewSQLiteChapterStore(db),
+		Titles:        NewSQLiteTitleStore(db),
+		ExternalIDs:   NewSQLiteExternalIDStore(db),
+		Genres:        NewSQLiteGenreStore(db),
+		Similarity:    NewSQLiteSimilarityStore(db),
+		Users:         NewSQLiteUserStore(db),
+		Library:       NewSQLiteLibraryStore(db),
+		Chat:          NewSQLiteChatStore(db),
+		Notifications: NewSQLiteNotificationStore(db),
+		Merges:        NewSQLiteMergeStore(db),
+		Sessions:      NewSQLiteSessionStore(db),
+		AccessTokens:  NewSQLiteAccessTokenStore(db),
+	}
+}
+
+// NewMemoryStores returns empty in-memory stores. The manga store reads
+// reader counts and ratings from the library store.
+func NewMemoryStores() *Stores {
+	manga := NewMemoryMangaStore()
+	library := NewMemoryLibraryStore()
+	manga.readerStats = library.mangaStats
+	manga.activityStats = library.activityStats
+	chapters := NewMemoryChapterStore(manga)
+	chat := NewMemoryChatStore()
+	notifications := NewMemoryNotificationStore()
+	return &Stores{
+		Manga:         manga,
+		Chapters:      chapters,
+		Titles:        NewMemoryTitleStore(manga),
+		ExternalIDs:   NewMemoryExternalIDStore(manga),
+		Genres:        NewMemoryGenreStore(manga),
+		Similarity:    NewMemorySimilarityStore(manga, library),
+		Users:         NewMemoryUserStore(),
+		Library:       library,
+		Chat:          chat,
+		Notifications: notifications,
+		Merges:        NewMemoryMergeStore(manga, chapters, library, notifications, chat),
+		Sessions:      NewMemorySessionStore(),
+		AccessTokens:  NewMemoryAccessTokenStore(),
+	}
+}
+
+// Compile-time checks that both implementations satisfy every interface
+var (
+	_ MangaStore        = (*SQLiteMangaStore)(nil)
+	_ MangaStore        = (*MemoryMangaStore)(nil)
+	_ ChapterStore      = (*SQLiteChapterStore)(nil)
+	_ ChapterStore      = (*MemoryChapterStore)(nil)
+	_ TitleStore        = (*SQLiteTitleStore)(nil)
+	_ TitleStore        = (*MemoryTitleStore)(nil)
+	_ ExternalIDStore   = (*SQLiteExternalIDStore)(nil)
+	_ ExternalIDStore   = (*MemoryExternalIDStore)(nil)
+	_ GenreStore        = (*SQLiteGenreStore)(nil)
+	_ GenreStore        = (*MemoryGenreStore)(nil)
+	_ SimilarityStore   = (*SQLiteSimilarityStore)(nil)
+	_ SimilarityStore   = (*MemorySimilarityStore)(nil)
+	_ UserStore         = (*SQLiteUserStore)(nil)
+	_ UserStore         = (*MemoryUserStore)(nil)
+	_ LibraryStore      = (*SQLiteLibraryStore)(nil)
+	_ LibraryStore      = (*MemoryLibraryStore)(nil)
+	_ ChatStore         = (*SQLiteChatStore)(nil)
+	_ ChatStore         = (*MemoryChatStore)(nil)
+	_ NotificationStore = (*SQLiteNotificationStore)(nil)
+	_ NotificationStore = (*MemoryNotificationStore)(nil)
+	_ MergeStore        = (*SQLiteMergeStore)(nil)
+	_ MergeStore        = (*MemoryMergeStore)(nil)
+	_ SessionStore      = (*SQLiteSessionStore)(nil)
+	_ SessionStore      = (*MemorySessionStore)(nil)
+	_ AccessTokenStore  = (*SQLiteAccessTokenStore)(nil)
+	_ AccessTokenStore  = (*MemoryAccessTokenStore)(nil)
+)
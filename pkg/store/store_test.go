@@ -0,0 +1,787 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// eachStores runs a contract test against the SQLite stores, on a fresh
+// database file, and against the in-memory stores, so both keep behaving
+// the same way
+func eachStores(t *testing.T, test func(t *testing.T, s *Stores)) {
+	t.Helper()
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := database.New(filepath.Join(t.TempDir(), "mangahub.db"))
+		if err != nil {
+			t.Fatalf("open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		if err := db.Init(); err != nil {
+			t.Fatalf("init database: %v", err)
+		}
+		test(t, NewSQLiteStores(db))
+	})
+	t.Run("memory", func(t *testing.T) {
+		test(t, NewMemoryStores())
+	})
+}
+
+func mustCreateUser(t *testing.T, s *Stores, id, username string) *models.User {
+	t.Helper()
+	user := &models.User{ID: id, Username: username, Email: username + "@example.com", PasswordHash: "hash"}
+	if err := s.Users.Create(user); err != nil {
+		t.Fatalf("create user %s: %v", username, err)
+	}
+	return user
+}
+
+func mustCreateManga(t *testing.T, s *Stores, id, title string) *models.Manga {
+	t.Helper()
+	manga := &models.Manga{ID: id, Title: title, Author: "Author", Status: "ongoing", Genres: []string{"Action"}}
+	if err := s.Manga.Create(manga); err != nil {
+		t.Fatalf("create manga %s: %v", id, err)
+	}
+	return manga
+}
+
+func wantErr(t *testing.T, what string, got, want error) {
+	t.Helper()
+	if !errors.Is(got, want) {
+		t.Fatalf("%s: got error %v, want %v", what, got, want)
+	}
+}
+
+func TestUserStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		user := mustCreateUser(t, s, "user_1", "alice")
+		if user.Role != models.RoleUser {
+			t.Errorf("new user role = %q, want %q", user.Role, models.RoleUser)
+		}
+
+		for name, get := range map[string]func() (*models.User, error){
+			"by ID":       func() (*models.User, error) { return s.Users.GetByID("user_1") },
+			"by username": func() (*models.User, error) { return s.Users.GetByUsername("alice") },
+			"by email":    func() (*models.User, error) { return s.Users.GetByEmail("alice@example.com") },
+		} {
+			got, err := get()
+			if err != nil {
+				t.Fatalf("get %s: %v", name, err)
+			}
+			if got.ID != "user_1" || got.Username != "alice" {
+				t.Errorf("get %s = %+v", name, got)
+			}
+		}
+
+		_, err := s.Users.GetByID("user_missing")
+		wantErr(t, "get unknown user", err, ErrUserNotFound)
+		_, err = s.Users.GetByUsername("nobody")
+		wantErr(t, "get unknown username", err, ErrUserNotFound)
+
+		dup := &models.User{ID: "user_2", Username: "alice", Email: "other@example.com", PasswordHash: "hash"}
+		wantErr(t, "create duplicate username", s.Users.Create(dup), ErrAlreadyExists)
+
+		wantErr(t, "set role of unknown user", s.Users.SetRole("user_missing", models.RoleAdmin), ErrUserNotFound)
+		if err := s.Users.SetRole("user_1", models.RoleModerator); err != nil {
+			t.Fatalf("set role: %v", err)
+		}
+		user.Email = "alice@example.org"
+		user.Role = models.RoleUser
+		if err := s.Users.Update(user); err != nil {
+			t.Fatalf("update user: %v", err)
+		}
+		got, err := s.Users.GetByID("user_1")
+		if err != nil {
+			t.Fatalf("get user: %v", err)
+		}
+		if got.Email != "alice@example.org" || got.Role != models.RoleModerator {
+			t.Errorf("after update: email %q role %q; Update must keep the role", got.Email, got.Role)
+		}
+
+		mods, err := s.Users.ListByRole(models.RoleModerator)
+		if err != nil {
+			t.Fatalf("list by role: %v", err)
+		}
+		if len(mods) != 1 || mods[0].ID != "user_1" {
+			t.Errorf("moderators = %+v", mods)
+		}
+	})
+}
+
+func TestMangaStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateManga(t, s, "one-piece", "One Piece")
+		mustCreateManga(t, s, "naruto", "Naruto")
+
+		dup := &models.Manga{ID: "naruto", Title: "Naruto again", Status: "ongoing"}
+		wantErr(t, "create duplicate manga", s.Manga.Create(dup), ErrAlreadyExists)
+
+		got, err := s.Manga.GetByID("one-piece")
+		if err != nil {
+			t.Fatalf("get manga: %v", err)
+		}
+		if got.Title != "One Piece" || len(got.Genres) != 1 {
+			t.Errorf("get manga = %+v", got)
+		}
+		_, err = s.Manga.GetByID("missing")
+		wantErr(t, "get unknown manga", err, ErrMangaNotFound)
+
+		got.Title = "One Piece (edited)"
+		if err := s.Manga.Update(got); err != nil {
+			t.Fatalf("update manga: %v", err)
+		}
+		wantErr(t, "update unknown manga", s.Manga.Update(&models.Manga{ID: "missing", Title: "Missing"}), ErrMangaNotFound)
+		wantErr(t, "delete unknown manga", s.Manga.Delete("missing"), ErrMangaNotFound)
+
+		if err := s.Manga.Delete("naruto"); err != nil {
+			t.Fatalf("delete manga: %v", err)
+		}
+		_, err = s.Manga.GetByID("naruto")
+		wantErr(t, "get trashed manga", err, ErrMangaNotFound)
+		wantErr(t, "delete trashed manga", s.Manga.Delete("naruto"), ErrMangaNotFound)
+		wantErr(t, "update trashed manga", s.Manga.Update(&models.Manga{ID: "naruto", Title: "Naruto edited"}), ErrMangaNotFound)
+		list, err := s.Manga.List(10, 0)
+		if err != nil {
+			t.Fatalf("list manga: %v", err)
+		}
+		if len(list) != 1 || list[0].ID != "one-piece" {
+			t.Errorf("list after delete = %v", mangaIDs(list))
+		}
+		trash, err := s.Manga.Trash(10, 0)
+		if err != nil {
+			t.Fatalf("list trash: %v", err)
+		}
+		if len(trash) != 1 || trash[0].ID != "naruto" || trash[0].DeletedAt == nil {
+			t.Errorf("trash = %v", mangaIDs(trash))
+		}
+
+		if err := s.Manga.Restore("naruto"); err != nil {
+			t.Fatalf("restore manga: %v", err)
+		}
+		wantErr(t, "restore manga outside the trash", s.Manga.Restore("naruto"), ErrMangaNotFound)
+		restored, err := s.Manga.GetByID("naruto")
+		if err != nil {
+			t.Fatalf("get restored manga: %v", err)
+		}
+		if restored.Title != "Naruto" {
+			t.Errorf("restored title = %q; the update in the trash must not apply", restored.Title)
+		}
+	})
+}
+
+func TestMangaAuthorFilterContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		for _, m := range []models.Manga{
+			{ID: "one-piece", Title: "One Piece", Author: "Eiichiro Oda", Status: "ongoing"},
+			{ID: "yotsuba", Title: "Yotsuba&!", Author: "Kiyohiko Azuma", Status: "ongoing"},
+			{ID: "percent", Title: "Percent", Author: "100% Studio", Status: "ongoing"},
+			{ID: "snake", Title: "Snake", Author: "snake_case", Status: "ongoing"},
+		} {
+			if err := s.Manga.Create(&m); err != nil {
+				t.Fatalf("create manga %s: %v", m.ID, err)
+			}
+		}
+
+		tests := []struct {
+			author string
+			want   []string
+		}{
+			{"oda", []string{"one-piece"}},
+			{"AZUMA", []string{"yotsuba"}},
+			{"%", []string{"percent"}},
+			{"0% s", []string{"percent"}},
+			{"_", []string{"snake"}},
+			{"e_c", []string{"snake"}},
+			{"o_a", nil},
+			{`\`, nil},
+		}
+		for _, tt := range tests {
+			got, err := s.Manga.Search(&models.MangaFilter{Author: tt.author, SortBy: "title", Order: "asc", Limit: 10})
+			if err != nil {
+				t.Fatalf("search by author %q: %v", tt.author, err)
+			}
+			if ids := mangaIDs(got); !slices.Equal(ids, tt.want) {
+				t.Errorf("author %q matched %v, want %v", tt.author, ids, tt.want)
+			}
+		}
+	})
+}
+
+// mustCreateSearchCatalog creates One Piece, matched by "piece" in its
+// title; Pirate Tales, matched only in its description; and Monster
+func mustCreateSearchCatalog(t *testing.T, s *Stores) {
+	t.Helper()
+	for _, m := range []models.Manga{
+		{ID: "one-piece", Title: "One Piece", Author: "Eiichiro Oda", Status: "ongoing",
+			Description: "Luffy sets sail to find the One Piece", Genres: []string{"Action", "Adventure"}},
+		{ID: "pirate-tales", Title: "Pirate Tales", Author: "Anne Bonny", Status: "completed",
+			Description: "Every island hides a piece of the map", Genres: []string{"Adventure"}},
+		{ID: "monster", Title: "Monster", Author: "Naoki Urasawa", Status: "completed",
+			Description: "A surgeon hunts a former patient", Genres: []string{"Mystery"}},
+	} {
+		if err := s.Manga.Create(&m); err != nil {
+			t.Fatalf("create manga %s: %v", m.ID, err)
+		}
+	}
+}
+
+func searchIDs(t *testing.T, s *Stores, query string) []string {
+	t.Helper()
+	got, err := s.Manga.Search(&models.MangaFilter{Query: query, SortBy: "relevance", Limit: 10})
+	if err != nil {
+		t.Fatalf("search %q: %v", query, err)
+	}
+	return mangaIDs(got)
+}
+
+func TestMangaSearchContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateSearchCatalog(t, s)
+
+		tests := []struct {
+			query string
+			want  []string
+		}{
+			{"piece", []string{"one-piece", "pirate-tales"}},
+			{"PIE", []string{"one-piece", "pirate-tales"}},
+			{"one piece", []string{"one-piece"}},
+			{"oda", []string{"one-piece"}},
+			{"surgeon", []string{"monster"}},
+			{`"piece"* (`, []string{"one-piece", "pirate-tales"}},
+			{"piece monster", nil},
+		}
+		for _, tt := range tests {
+			if ids := searchIDs(t, s, tt.query); !slices.Equal(ids, tt.want) {
+				t.Errorf("search %q = %v, want %v", tt.query, ids, tt.want)
+			}
+		}
+
+		got, err := s.Manga.Search(&models.MangaFilter{Query: "piece", SortBy: "relevance", Limit: 10})
+		if err != nil {
+			t.Fatalf("search: %v", err)
+		}
+		if got[0].Relevance <= got[1].Relevance {
+			t.Errorf("title match relevance %v is not above description match %v", got[0].Relevance, got[1].Relevance)
+		}
+		for _, m := range got {
+			if !strings.Contains(m.Snippet, "<mark>") {
+				t.Errorf("snippet of %s = %q, want the match highlighted", m.ID, m.Snippet)
+			}
+		}
+
+		// A title edit reaches the index
+		monster, err := s.Manga.GetByID("monster")
+		if err != nil {
+			t.Fatalf("get manga: %v", err)
+		}
+		monster.Title = "Pluto"
+		if err := s.Manga.Update(monster); err != nil {
+			t.Fatalf("update manga: %v", err)
+		}
+		if ids := searchIDs(t, s, "monster"); len(ids) != 0 {
+			t.Errorf("search by the old title = %v, want nothing", ids)
+		}
+		if ids := searchIDs(t, s, "pluto"); !slices.Equal(ids, []string{"monster"}) {
+			t.Errorf("search by the new title = %v, want monster", ids)
+		}
+
+		// Trashed manga never match and come back when restored
+		if err := s.Manga.Delete("one-piece"); err != nil {
+			t.Fatalf("delete manga: %v", err)
+		}
+		if ids := searchIDs(t, s, "piece"); !slices.Equal(ids, []string{"pirate-tales"}) {
+			t.Errorf("search with One Piece in the trash = %v", ids)
+		}
+		if err := s.Manga.Restore("one-piece"); err != nil {
+			t.Fatalf("restore manga: %v", err)
+		}
+		if ids := searchIDs(t, s, "piece"); !slices.Equal(ids, []string{"one-piece", "pirate-tales"}) {
+			t.Errorf("search after restoring One Piece = %v", ids)
+		}
+	})
+}
+
+func TestMangaFacetsContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateSearchCatalog(t, s)
+
+		facets, err := s.Manga.Facets(&models.MangaFilter{Query: "piece"})
+		if err != nil {
+			t.Fatalf("facets: %v", err)
+		}
+		wantGenres := []models.FacetCount{
+			{Value: "adventure", Name: "Adventure", Count: 2},
+			{Value: "action", Name: "Action", Count: 1},
+		}
+		if !slices.Equal(facets.Genres, wantGenres) {
+			t.Errorf("genre facets = %+v, want %+v", facets.Genres, wantGenres)
+		}
+		wantStatus := []models.FacetCount{{Value: "completed", Count: 1}, {Value: "ongoing", Count: 1}}
+		if !slices.Equal(facets.Status, wantStatus) {
+			t.Errorf("status facets = %+v, want %+v", facets.Status, wantStatus)
+		}
+
+		// Facets follow the other filters and leave out the trash
+		if err := s.Manga.Delete("pirate-tales"); err != nil {
+			t.Fatalf("delete manga: %v", err)
+		}
+		facets, err = s.Manga.Facets(&models.MangaFilter{Genres: []string{"adventure"}})
+		if err != nil {
+			t.Fatalf("facets: %v", err)
+		}
+		if want := []models.FacetCount{{Value: "ongoing", Count: 1}}; !slices.Equal(facets.Status, want) {
+			t.Errorf("status facets of adventure manga = %+v, want %+v", facets.Status, want)
+		}
+
+		facets, err = s.Manga.Facets(&models.MangaFilter{Query: "nothing matches this"})
+		if err != nil {
+			t.Fatalf("facets: %v", err)
+		}
+		if facets.Genres == nil || facets.Status == nil || len(facets.Genres)+len(facets.Status) != 0 {
+			t.Errorf("facets without matches = %+v, want empty lists", facets)
+		}
+	})
+}
+
+func TestMangaFuzzyContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateSearchCatalog(t, s)
+		if err := s.Titles.Add(&models.MangaTitle{MangaID: "monster", Language: "ja-ro", Title: "Monsutaa"}); err != nil {
+			t.Fatalf("add title: %v", err)
+		}
+
+		tests := []struct {
+			query string
+			id    string
+			field string
+		}{
+			{"one peice", "one-piece", models.SuggestionFieldTitle},
+			{"onepiece", "one-piece", models.SuggestionFieldTitle},
+			{"eiichiro odda", "one-piece", models.SuggestionFieldAuthor},
+			{"monsuta", "monster", models.SuggestionFieldAltTitle},
+		}
+		for _, tt := range tests {
+			got, err := s.Manga.Fuzzy(tt.query, 5)
+			if err != nil {
+				t.Fatalf("fuzzy %q: %v", tt.query, err)
+			}
+			if len(got) == 0 || got[0].MangaID != tt.id || got[0].Field != tt.field {
+				t.Errorf("fuzzy %q = %+v, want %s by its %s first", tt.query, got, tt.id, tt.field)
+			}
+		}
+
+		got, err := s.Manga.Fuzzy("zzzz qqqq", 5)
+		if err != nil || len(got) != 0 {
+			t.Errorf("fuzzy without a resemblance = %+v, %v; want nothing", got, err)
+		}
+
+		if err := s.Manga.Delete("one-piece"); err != nil {
+			t.Fatalf("delete manga: %v", err)
+		}
+		got, err = s.Manga.Fuzzy("one peice", 5)
+		if err != nil {
+			t.Fatalf("fuzzy: %v", err)
+		}
+		for _, sg := range got {
+			if sg.MangaID == "one-piece" {
+				t.Errorf("fuzzy suggested One Piece from the trash")
+			}
+		}
+	})
+}
+
+func TestMangaAutocompleteContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateSearchCatalog(t, s)
+		if err := s.Titles.Add(&models.MangaTitle{MangaID: "monster", Language: "fr", Title: "Pirates du Monstre"}); err != nil {
+			t.Fatalf("add title: %v", err)
+		}
+
+		tests := []struct {
+			prefix string
+			want   []string
+		}{
+			// Whole-title matches come before later words, shorter first
+			{"pi", []string{"pirate-tales", "monster", "one-piece"}},
+			{"PIECE", []string{"one-piece"}},
+			{"mon", []string{"monster"}},
+			{"iece", nil},
+			{"  ", nil},
+			{"%", nil},
+		}
+		for _, tt := range tests {
+			got, err := s.Manga.Autocomplete(tt.prefix, 10)
+			if err != nil {
+				t.Fatalf("autocomplete %q: %v", tt.prefix, err)
+			}
+			var ids []string
+			for _, sg := range got {
+				ids = append(ids, sg.MangaID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("autocomplete %q = %v, want %v", tt.prefix, ids, tt.want)
+			}
+		}
+
+		got, err := s.Manga.Autocomplete("pi", 1)
+		if err != nil {
+			t.Fatalf("autocomplete: %v", err)
+		}
+		if len(got) != 1 || got[0].Matched != "Pirate Tales" || got[0].Field != models.SuggestionFieldTitle || got[0].Score != 1 {
+			t.Errorf("autocomplete with a limit of 1 = %+v", got)
+		}
+
+		if err := s.Manga.Delete("pirate-tales"); err != nil {
+			t.Fatalf("delete manga: %v", err)
+		}
+		got, err = s.Manga.Autocomplete("pirate", 10)
+		if err != nil {
+			t.Fatalf("autocomplete: %v", err)
+		}
+		if len(got) != 1 || got[0].MangaID != "monster" || got[0].Matched != "Pirates du Monstre" {
+			t.Errorf("autocomplete with Pirate Tales in the trash = %+v, want only the alternate title of monster", got)
+		}
+	})
+}
+
+func mangaIDs(manga []models.Manga) []string {
+	ids := make([]string, len(manga))
+	for i, m := range manga {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+func TestChapterStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateManga(t, s, "one-piece", "One Piece")
+
+		wantErr(t, "create chapter of unknown manga",
+			s.Chapters.Create(&models.Chapter{MangaID: "missing", Number: 1}), ErrMangaNotFound)
+
+		for _, number := range []float64{1, 2, 10.5} {
+			if err := s.Chapters.Create(&models.Chapter{MangaID: "one-piece", Number: number}); err != nil {
+				t.Fatalf("create chapter %v: %v", number, err)
+			}
+		}
+		wantErr(t, "create duplicate chapter",
+			s.Chapters.Create(&models.Chapter{MangaID: "one-piece", Number: 2}), ErrAlreadyExists)
+
+		chapters, err := s.Chapters.List(models.ChapterFilter{MangaID: "one-piece"})
+		if err != nil {
+			t.Fatalf("list chapters: %v", err)
+		}
+		if len(chapters) != 3 || chapters[0].Number != 1 || chapters[2].Number != 10.5 {
+			t.Errorf("chapters = %+v", chapters)
+		}
+		if chapters[0].Language != models.DefaultChapterLanguage {
+			t.Errorf("chapter language = %q, want %q", chapters[0].Language, models.DefaultChapterLanguage)
+		}
+
+		manga, err := s.Manga.GetByID("one-piece")
+		if err != nil {
+			t.Fatalf("get manga: %v", err)
+		}
+		if manga.TotalChapters != 10 {
+			t.Errorf("total chapters = %d, want 10", manga.TotalChapters)
+		}
+
+		_, err = s.Chapters.Get(999)
+		wantErr(t, "get unknown chapter", err, ErrChapterNotFound)
+	})
+}
+
+func TestLibraryStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateUser(t, s, "user_1", "alice")
+		mustCreateManga(t, s, "one-piece", "One Piece")
+		mustCreateManga(t, s, "naruto", "Naruto")
+		origin := models.EventOrigin{Source: "api"}
+
+		for _, p := range []*models.Progress{
+			{UserID: "user_1", MangaID: "one-piece", Status: "reading", CurrentChapter: 5},
+			{UserID: "user_1", MangaID: "naruto", Status: "plan-to-read"},
+		} {
+			if err := s.Library.Add(p, origin); err != nil {
+				t.Fatalf("add %s: %v", p.MangaID, err)
+			}
+		}
+		dup := &models.Progress{UserID: "user_1", MangaID: "naruto", Status: "reading"}
+		wantErr(t, "add duplicate entry", s.Library.Add(dup, origin), ErrAlreadyExists)
+
+		_, err := s.Library.Get("user_1", "missing")
+		wantErr(t, "get unknown entry", err, ErrEntryNotFound)
+
+		entry, err := s.Library.Get("user_1", "one-piece")
+		if err != nil {
+			t.Fatalf("get entry: %v", err)
+		}
+		entry.CurrentChapter = 12
+		entry.Rating = 9
+		if err := s.Library.Update(entry, origin); err != nil {
+			t.Fatalf("update entry: %v", err)
+		}
+
+		reading, err := s.Library.List("user_1", "reading", 10, 0)
+		if err != nil {
+			t.Fatalf("list reading: %v", err)
+		}
+		if len(reading) != 1 || reading[0].CurrentChapter != 12 || reading[0].Rating != 9 {
+			t.Errorf("reading = %+v", reading)
+		}
+
+		if err := s.Library.Remove("user_1", "naruto", origin); err != nil {
+			t.Fatalf("remove entry: %v", err)
+		}
+		_, err = s.Library.Get("user_1", "naruto")
+		wantErr(t, "get removed entry", err, ErrEntryNotFound)
+		trash, err := s.Library.Trash("user_1", 10, 0)
+		if err != nil {
+			t.Fatalf("list trash: %v", err)
+		}
+		if len(trash) != 1 || trash[0].MangaID != "naruto" {
+			t.Errorf("trash = %+v", trash)
+		}
+		if err := s.Library.Restore("user_1", "naruto", origin); err != nil {
+			t.Fatalf("restore entry: %v", err)
+		}
+		wantErr(t, "restore entry outside the trash", s.Library.Restore("user_1", "naruto", origin), ErrEntryNotFound)
+
+		all, err := s.Library.List("user_1", "", 10, 0)
+		if err != nil {
+			t.Fatalf("list library: %v", err)
+		}
+		if len(all) != 2 {
+			t.Errorf("library has %d entries, want 2", len(all))
+		}
+
+		events, err := s.Library.Events(models.ProgressEventFilter{UserID: "user_1", MangaID: "naruto", Limit: 10})
+		if err != nil {
+			t.Fatalf("list events: %v", err)
+		}
+		var statuses []string
+		for _, e := range events {
+			statuses = append(statuses, e.ToStatus)
+		}
+		if len(events) != 3 || events[1].ToStatus != models.EventStatusRemoved {
+			t.Errorf("naruto event statuses (newest first) = %v, want restore, removal, add", statuses)
+		}
+	})
+}
+
+func TestNotificationStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateUser(t, s, "user_1", "alice")
+		mustCreateUser(t, s, "user_2", "bob")
+		mustCreateManga(t, s, "one-piece", "One Piece")
+
+		_, err := s.Notifications.GetPreferences("user_1")
+		wantErr(t, "get unsaved preferences", err, ErrPreferencesNotFound)
+
+		for _, userID := range []string{"user_1", "user_2"} {
+			if err := s.Notifications.Subscribe(userID, "one-piece"); err != nil {
+				t.Fatalf("subscribe %s: %v", userID, err)
+			}
+		}
+		if err := s.Notifications.SavePreferences(&models.NotificationPreferences{UserID: "user_2"}); err != nil {
+			t.Fatalf("save preferences: %v", err)
+		}
+
+		subscribers, err := s.Notifications.ReleaseSubscribers("one-piece")
+		if err != nil {
+			t.Fatalf("release subscribers: %v", err)
+		}
+		if len(subscribers) != 1 || subscribers[0] != "user_1" {
+			t.Errorf("release subscribers = %v, want [user_1]", subscribers)
+		}
+
+		if err := s.Notifications.Unsubscribe("user_1", "one-piece"); err != nil {
+			t.Fatalf("unsubscribe: %v", err)
+		}
+		subs, err := s.Notifications.Subscriptions("user_1")
+		if err != nil {
+			t.Fatalf("subscriptions: %v", err)
+		}
+		if len(subs) != 0 {
+			t.Errorf("subscriptions after unsubscribe = %v", subs)
+		}
+	})
+}
+
+func TestChatStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateUser(t, s, "user_1", "alice")
+		base := time.Now().Add(-time.Minute)
+		for i, text := range []string{"first", "second", "third"} {
+			msg := &models.ChatMessage{UserID: "user_1", Username: "alice", RoomID: "general", Message: text,
+				CreatedAt: base.Add(time.Duration(i) * time.Second)}
+			if err := s.Chat.SaveMessage(msg); err != nil {
+				t.Fatalf("save message: %v", err)
+			}
+			if msg.ID == "" || msg.Timestamp == 0 {
+				t.Errorf("saved message missing ID or timestamp: %+v", msg)
+			}
+		}
+
+		history, err := s.Chat.History("general", 2)
+		if err != nil {
+			t.Fatalf("history: %v", err)
+		}
+		if len(history) != 2 || history[0].Message != "third" || history[1].Message != "second" {
+			t.Errorf("history = %+v", history)
+		}
+	})
+}
+
+func TestSessionStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateUser(t, s, "user_1", "alice")
+		now := time.Now().Truncate(time.Second)
+		for _, id := range []string{"sess_a", "sess_b"} {
+			session := &models.Session{ID: id, UserID: "user_1", CreatedAt: now, LastUsedAt: now,
+				ExpiresAt: now.Add(time.Hour), RefreshHash: "hash_" + id}
+			if err := s.Sessions.Create(session); err != nil {
+				t.Fatalf("create session: %v", err)
+			}
+		}
+
+		_, err := s.Sessions.Get("sess_missing")
+		wantErr(t, "get unknown session", err, ErrSessionNotFound)
+
+		if err := s.Sessions.Rotate("sess_a", "hash_sess_a", "hash_2", "127.0.0.1", now, now.Add(2*time.Hour)); err != nil {
+			t.Fatalf("rotate: %v", err)
+		}
+		wantErr(t, "rotate with a stale hash",
+			s.Sessions.Rotate("sess_a", "hash_sess_a", "hash_3", "", now, now.Add(time.Hour)), ErrSessionNotFound)
+		previous, err := s.Sessions.GetByRefreshHash("hash_sess_a")
+		if err != nil || previous.ID != "sess_a" {
+			t.Fatalf("get by previous hash = %v, %v", previous, err)
+		}
+
+		revoked, err := s.Sessions.RevokeAll("user_1", "sess_a", now)
+		if err != nil || revoked != 1 {
+			t.Fatalf("revoke all = %d, %v; want 1", revoked, err)
+		}
+		wantErr(t, "revoke a revoked session", s.Sessions.Revoke("sess_b", now), ErrSessionNotFound)
+
+		live, err := s.Sessions.List("user_1", now)
+		if err != nil {
+			t.Fatalf("list sessions: %v", err)
+		}
+		if len(live) != 1 || live[0].ID != "sess_a" {
+			t.Errorf("live sessions = %+v", live)
+		}
+	})
+}
+
+func TestAccessTokenStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateUser(t, s, "user_1", "alice")
+		mustCreateUser(t, s, "user_2", "bob")
+		now := time.Now().Truncate(time.Second)
+		for i, id := range []string{"pat_a", "pat_b"} {
+			token := &models.AccessToken{ID: id, UserID: "user_1", Name: id, Scopes: []string{models.ScopeLibraryRead},
+				Hint: "mhp_" + id, TokenHash: "hash_" + id, CreatedAt: now.Add(time.Duration(i) * time.Second),
+				ExpiresAt: now.Add(time.Hour)}
+			if err := s.AccessTokens.Create(token); err != nil {
+				t.Fatalf("create token: %v", err)
+			}
+		}
+
+		got, err := s.AccessTokens.GetByHash("hash_pat_a")
+		if err != nil {
+			t.Fatalf("get by hash: %v", err)
+		}
+		if got.ID != "pat_a" || !got.HasScope(models.ScopeLibraryRead) || got.LastUsedAt != nil {
+			t.Errorf("token = %+v", got)
+		}
+		_, err = s.AccessTokens.GetByHash("hash_missing")
+		wantErr(t, "get unknown token", err, ErrAccessTokenNotFound)
+
+		if err := s.AccessTokens.Touch("pat_a", now); err != nil {
+			t.Fatalf("touch: %v", err)
+		}
+		wantErr(t, "revoke another user's token", s.AccessTokens.Revoke("user_2", "pat_b", now), ErrAccessTokenNotFound)
+		if err := s.AccessTokens.Revoke("user_1", "pat_b", now); err != nil {
+			t.Fatalf("revoke: %v", err)
+		}
+		wantErr(t, "revoke a revoked token", s.AccessTokens.Revoke("user_1", "pat_b", now), ErrAccessTokenNotFound)
+
+		tokens, err := s.AccessTokens.List("user_1", now)
+		if err != nil {
+			t.Fatalf("list tokens: %v", err)
+		}
+		if len(tokens) != 1 || tokens[0].ID != "pat_a" || tokens[0].LastUsedAt == nil {
+			t.Errorf("live tokens = %+v", tokens)
+		}
+	})
+}
+
+func TestExternalIDStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateManga(t, s, "one-piece", "One Piece")
+		mustCreateManga(t, s, "naruto", "Naruto")
+
+		wantErr(t, "link unknown manga",
+			s.ExternalIDs.Link(&models.ExternalID{MangaID: "missing", Source: "mangadex", ExternalID: "md-1"}), ErrMangaNotFound)
+		_, err := s.ExternalIDs.Find("mangadex", "md-1")
+		wantErr(t, "find unlinked ID", err, ErrExternalIDNotFound)
+
+		if err := s.ExternalIDs.Link(&models.ExternalID{MangaID: "one-piece", Source: "mangadex", ExternalID: "md-1"}); err != nil {
+			t.Fatalf("link: %v", err)
+		}
+		if err := s.ExternalIDs.Link(&models.ExternalID{MangaID: "naruto", Source: "mangadex", ExternalID: "md-1"}); err != nil {
+			t.Fatalf("relink: %v", err)
+		}
+		id, err := s.ExternalIDs.Find("mangadex", "md-1")
+		if err != nil || id != "naruto" {
+			t.Errorf("find after relink = %q, %v; want naruto", id, err)
+		}
+		ids, err := s.ExternalIDs.List("one-piece")
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		if len(ids) != 0 {
+			t.Errorf("one-piece keeps external IDs after relink: %+v", ids)
+		}
+	})
+}
+
+func TestTitleStoreContract(t *testing.T) {
+	eachStores(t, func(t *testing.T, s *Stores) {
+		mustCreateManga(t, s, "one-piece", "One Piece")
+
+		wantErr(t, "add title to unknown manga",
+			s.Titles.Add(&models.MangaTitle{MangaID: "missing", Language: "ja", Title: "ワンピース"}), ErrMangaNotFound)
+		_, err := s.Titles.List("missing")
+		wantErr(t, "list titles of unknown manga", err, ErrMangaNotFound)
+
+		if err := s.Titles.Add(&models.MangaTitle{MangaID: "one-piece", Language: "ja", Title: "ワンピース"}); err != nil {
+			t.Fatalf("add title: %v", err)
+		}
+		wantErr(t, "add duplicate title",
+			s.Titles.Add(&models.MangaTitle{MangaID: "one-piece", Language: "ja", Title: "ワンピース"}), ErrAlreadyExists)
+		if err := s.Titles.Add(&models.MangaTitle{MangaID: "one-piece", Language: "fr", Title: "One Piece FR"}); err != nil {
+			t.Fatalf("add title: %v", err)
+		}
+
+		titles, err := s.Titles.List("one-piece")
+		if err != nil {
+			t.Fatalf("list titles: %v", err)
+		}
+		var languages []string
+		for _, title := range titles {
+			languages = append(languages, title.Language)
+		}
+		if !sort.StringsAreSorted(languages) || len(languages) != 2 {
+			t.Errorf("title languages = %v, want 2 in language order", languages)
+		}
+	})
+}
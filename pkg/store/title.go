@@ -0,0 +1,195 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+// SQLiteTitleStore is a TitleStore backed by SQLite. The manga_titles
+// triggers keep the alt_titles column of manga_fts in step with the table.
+type SQLiteTitleStore struct {
+	db *database.Database
+}
+
+// NewSQLiteTitleStore creates a SQLite title store
+func NewSQLiteTitleStore(db *database.Database) *SQLiteTitleStore {
+	return &SQLiteTitleStore{db: db}
+}
+
+// List returns a manga's titles ordered by language, primary first
+func (s *SQLiteTitleStore) List(mangaID string) ([]models.MangaTitle, error) {
+	if err := s.mangaExists(s.db.QueryRow, mangaID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, manga_id, language, title, is_primary FROM manga_titles
+		WHERE manga_id = ? ORDER BY language, is_primary DESC, title`, mangaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list titles: %w", err)
+	}
+	defer rows.Close()
+	return scanTitles(rows)
+}
+
+// Add adds a title to a manga
+func (s *SQLiteTitleStore) Add(title *models.MangaTitle) error {
+	title.Language = models.NormalizeLanguage(title.Language)
+
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to add title: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.mangaExists(tx.QueryRow, title.MangaID); err != nil {
+		return err
+	}
+	if err := insertTitle(tx, title); err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to add title: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to add title: %w", err)
+	}
+	return nil
+}
+
+// Replace swaps every title of a manga for the given ones
+func (s *SQLiteTitleStore) Replace(mangaID string, titles []models.MangaTitle) error {
+	tx, err := s.db.BeginTx()
+	if err != nil {
+		return fmt.Errorf("failed to replace titles: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := s.mangaExists(tx.QueryRow, mangaID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM manga_titles WHERE manga_id = ?`, mangaID); err != nil {
+		return fmt.Errorf("failed to replace titles: %w", err)
+	}
+	for _, title := range dedupeTitles(mangaID, titles) {
+		if err := insertTitle(tx, &title); err != nil {
+			return fmt.Errorf("failed to replace titles: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to replace titles: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a title
+func (s *SQLiteTitleStore) Delete(id int64) error {
+	if _, err := s.db.Exec(`DELETE FROM manga_titles WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete title: %w", err)
+	}
+	return nil
+}
+
+// InLanguage returns the titles in one language of the given manga
+func (s *SQLiteTitleStore) InLanguage(mangaIDs []string, language string) (map[string][]models.MangaTitle, error) {
+	titles := make(map[string][]models.MangaTitle)
+	if len(mangaIDs) == 0 {
+		return titles, nil
+	}
+
+	args := []interface{}{models.NormalizeLanguage(language)}
+	for _, id := range mangaIDs {
+		args = append(args, id)
+	}
+	rows, err := s.db.Query(`
+		SELECT id, manga_id, language, title, is_primary FROM manga_titles
+		WHERE language = ? AND manga_id IN (`+strings.TrimSuffix(strings.Repeat("?, ", len(mangaIDs)), ", ")+`)
+		ORDER BY is_primary DESC, id`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load titles: %w", err)
+	}
+	defer rows.Close()
+
+	list, err := scanTitles(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range list {
+		titles[t.MangaID] = append(titles[t.MangaID], t)
+	}
+	return titles, nil
+}
+
+// mangaExists returns ErrMangaNotFound unless the manga is in the catalog
+// and not trashed
+func (s *SQLiteTitleStore) mangaExists(queryRow func(string, ...interface{}) *sql.Row, mangaID string) error {
+	var exists int
+	err := queryRow(`SELECT 1 FROM manga WHERE id = ? AND deleted_at IS NULL`, mangaID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return ErrMangaNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up manga: %w", err)
+	}
+	return nil
+}
+
+// insertTitle inserts a title, first demoting the primary title it replaces
+func insertTitle(tx *sql.Tx, title *models.MangaTitle) error {
+	if title.IsPrimary {
+		_, err := tx.Exec(`UPDATE manga_titles SET is_primary = 0 WHERE manga_id = ? AND language = ? AND is_primary = 1`,
+			title.MangaID, title.Language)
+		if err != nil {
+			return err
+		}
+	}
+	result, err := tx.Exec(`INSERT INTO manga_titles (manga_id, language, title, is_primary) VALUES (?, ?, ?, ?)`,
+		title.MangaID, title.Language, title.Title, title.IsPrimary)
+	if err != nil {
+		return err
+	}
+	title.ID, _ = result.LastInsertId()
+	return nil
+}
+
+func scanTitles(rows *sql.Rows) ([]models.MangaTitle, error) {
+	var titles []models.MangaTitle
+	for rows.Next() {
+		var t models.MangaTitle
+		if err := rows.Scan(&t.ID, &t.MangaID, &t.Language, &t.Title, &t.IsPrimary); err != nil {
+			return nil, fmt.Errorf("failed to scan title: %w", err)
+		}
+		titles = append(titles, t)
+	}
+	return titles, rows.Err()
+}
+
+// dedupeTitles normalizes the titles of one manga for Replace: blank and
+// repeated titles are dropped and only the first primary title of each
+// language stays primary
+func dedupeTitles(mangaID string, titles []models.MangaTitle) []models.MangaTitle {
+	seen := make(map[string]bool)
+	primary := make(map[string]bool)
+	var out []models.MangaTitle
+	for _, t := range titles {
+		t.ID = 0
+		t.MangaID = mangaID
+		t.Language = models.NormalizeLanguage(t.Language)
+		t.Title = strings.TrimSpace(t.Title)
+		key := t.Language + "\x00" + strings.ToLower(t.Title)
+		if t.Title == "" || t.Language == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		if t.IsPrimary {
+			t.IsPrimary = !primary[t.Language]
+			primary[t.Language] = true
+		}
+		out = append(out, t)
+	}
+	return out
+}
@@ -0,0 +1,154 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
+)
+
+const userColumns = "id, username, email, password_hash, created_at, updated_at, title_language, role"
+
+// SQLiteUserStore is a UserStore backed by SQLite
+type SQLiteUserStore struct {
+	db *database.Database
+}
+
+// NewSQLiteUserStore creates a SQLite user store
+func NewSQLiteUserStore(db *database.Database) *SQLiteUserStore {
+	return &SQLiteUserStore{db: db}
+}
+
+// Create creates a new user, as a plain user unless a role is set. It
+// returns ErrAlreadyExists when the ID, username or email is taken.
+func (s *SQLiteUserStore) Create(user *models.User) error {
+	query := `
+		INSERT INTO users (id, username, email, password_hash, created_at, updated_at, title_language, role)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+	now := time.Now()
+	_, err := s.db.Exec(query, user.ID, user.Username, user.Email, user.PasswordHash, now, now, nullString(user.TitleLanguage), user.Role)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return ErrAlreadyExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	user.CreatedAt, user.UpdatedAt = now, now
+	return nil
+}
+
+// GetByID retrieves a user by ID
+func (s *SQLiteUserStore) GetByID(id string) (*models.User, error) {
+	return s.getBy("id", id)
+}
+
+// GetByUsername retrieves a user by username
+func (s *SQLiteUserStore) GetByUsername(username string) (*models.User, error) {
+	return s.getBy("username", username)
+}
+
+// GetByEmail retrieves a user by email
+func (s *SQLiteUserStore) GetByEmail(email string) (*models.User, error) {
+	return s.getBy("email", email)
+}
+
+// getBy looks a user up by one of the unique columns
+func (s *SQLiteUserStore) getBy(column, value string) (*models.User, error) {
+	query := `SELECT ` + userColumns + ` FROM users WHERE ` + column + ` = ?`
+
+	user, err := scanUser(s.db.QueryRow(query, value))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// scanUser scans a row of userColumns
+func scanUser(row rowScanner) (*models.User, error) {
+	var user models.User
+	var titleLanguage sql.NullString
+	err := row.Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.CreatedAt, &user.UpdatedAt,
+		&titleLanguage, &user.Role)
+	if err != nil {
+		return nil, err
+	}
+	user.TitleLanguage = titleLanguage.String
+	return &user, nil
+}
+
+// ListByRole lists the users holding role, by username
+func (s *SQLiteUserStore) ListByRole(role string) ([]models.User, error) {
+	rows, err := s.db.Query(`SELECT `+userColumns+` FROM users WHERE role = ? ORDER BY username`, role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, *user)
+	}
+	return users, rows.Err()
+}
+
+// Update updates a user. The role is left alone; SetRole changes it.
+func (s *SQLiteUserStore) Update(user *models.User) error {
+	query := `
+		UPDATE users
+		SET username = ?, email = ?, password_hash = ?, title_language = ?, updated_at = ?
+		WHERE id = ?
+	`
+	_, err := s.db.Exec(query, user.Username, user.Email, user.PasswordHash, nullString(user.TitleLanguage), time.Now(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	return nil
+}
+
+// UpdatePassword updates a user's password
+func (s *SQLiteUserStore) UpdatePassword(userID, passwordHash string) error {
+	query := `UPDATE users SET password_hash = ?, updated_at = ? WHERE id = ?`
+	_, err := s.db.Exec(query, passwordHash, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	return nil
+}
+
+// SetRole changes a user's role
+func (s *SQLiteUserStore) SetRole(userID, role string) error {
+	res, err := s.db.Exec(`UPDATE users SET role = ?, updated_at = ? WHERE id = ?`, role, time.Now(), userID)
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to set role: %w", err)
+	}
+	if n == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Delete deletes a user
+func (s *SQLiteUserStore) Delete(id string) error {
+	_, err := s.db.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	return nil
+}
@@ -39,14 +39,23 @@ type MangaResponse struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	ID       string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Title    string   `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Author   string   `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
-	Genres   []string `protobuf:"bytes,4,rep,name=genres,proto3" json:"genres,omitempty"`
-	Chapters int32    `protobuf:"varint,5,opt,name=chapters,proto3" json:"chapters,omitempty"`
-	Status   string   `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
-	Synopsis string   `protobuf:"bytes,7,opt,name=synopsis,proto3" json:"synopsis,omitempty"`
-	Rating   float32  `protobuf:"fixed32,8,opt,name=rating,proto3" json:"rating,omitempty"`
+	ID        string   `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title     string   `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Author    string   `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	Genres    []string `protobuf:"bytes,4,rep,name=genres,proto3" json:"genres,omitempty"`
+	Chapters  int32    `protobuf:"varint,5,opt,name=chapters,proto3" json:"chapters,omitempty"`
+	Status    string   `protobuf:"bytes,6,opt,name=status,proto3" json:"status,omitempty"`
+	Synopsis  string   `protobuf:"bytes,7,opt,name=synopsis,proto3" json:"synopsis,omitempty"`
+	Rating    float32  `protobuf:"fixed32,8,opt,name=rating,proto3" json:"rating,omitempty"`
+	Snippet   string   `protobuf:"bytes,9,opt,name=snippet,proto3" json:"snippet,omitempty"`
+	Relevance float64  `protobuf:"fixed64,10,opt,name=relevance,proto3" json:"relevance,omitempty"`
+	Readers   int32    `protobuf:"varint,11,opt,name=readers,proto3" json:"readers,omitempty"`
+	Year      int32    `protobuf:"varint,12,opt,name=year,proto3" json:"year,omitempty"`
+
+	Rank        int32   `protobuf:"varint,13,opt,name=rank,proto3" json:"rank,omitempty"`
+	Score       float64 `protobuf:"fixed64,14,opt,name=score,proto3" json:"score,omitempty"`
+	Completions int32   `protobuf:"varint,15,opt,name=completions,proto3" json:"completions,omitempty"`
+	Activity    int32   `protobuf:"varint,16,opt,name=activity,proto3" json:"activity,omitempty"`
 }
 
 func (x *MangaResponse) Reset()         { *x = MangaResponse{} }
@@ -112,17 +121,80 @@ func (x *MangaResponse) GetRating() float32 {
 	return 0
 }
 
+func (x *MangaResponse) GetSnippet() string {
+	if x != nil {
+		return x.Snippet
+	}
+	return ""
+}
+
+func (x *MangaResponse) GetRelevance() float64 {
+	if x != nil {
+		return x.Relevance
+	}
+	return 0
+}
+
+func (x *MangaResponse) GetReaders() int32 {
+	if x != nil {
+		return x.Readers
+	}
+	return 0
+}
+
+func (x *MangaResponse) GetYear() int32 {
+	if x != nil {
+		return x.Year
+	}
+	return 0
+}
+
+func (x *MangaResponse) GetRank() int32 {
+	if x != nil {
+		return x.Rank
+	}
+	return 0
+}
+
+func (x *MangaResponse) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *MangaResponse) GetCompletions() int32 {
+	if x != nil {
+		return x.Completions
+	}
+	return 0
+}
+
+func (x *MangaResponse) GetActivity() int32 {
+	if x != nil {
+		return x.Activity
+	}
+	return 0
+}
+
 // SearchRequest represents a search request
 type SearchRequest struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Title  string   `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
-	Author string   `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
-	Genres []string `protobuf:"bytes,3,rep,name=genres,proto3" json:"genres,omitempty"`
-	Status string   `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
-	Limit  int32    `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Title       string   `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Author      string   `protobuf:"bytes,2,opt,name=author,proto3" json:"author,omitempty"`
+	Genres      []string `protobuf:"bytes,3,rep,name=genres,proto3" json:"genres,omitempty"`
+	Status      string   `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	Limit       int32    `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset      int32    `protobuf:"varint,6,opt,name=offset,proto3" json:"offset,omitempty"`
+	YearFrom    int32    `protobuf:"varint,7,opt,name=year_from,json=yearFrom,proto3" json:"year_from,omitempty"`
+	YearTo      int32    `protobuf:"varint,8,opt,name=year_to,json=yearTo,proto3" json:"year_to,omitempty"`
+	MinChapters int32    `protobuf:"varint,9,opt,name=min_chapters,json=minChapters,proto3" json:"min_chapters,omitempty"`
+	MinRating   float64  `protobuf:"fixed64,10,opt,name=min_rating,json=minRating,proto3" json:"min_rating,omitempty"`
+	SortBy      string   `protobuf:"bytes,11,opt,name=sort_by,json=sortBy,proto3" json:"sort_by,omitempty"`
+	Order       string   `protobuf:"bytes,12,opt,name=order,proto3" json:"order,omitempty"`
 }
 
 func (x *SearchRequest) Reset()         { *x = SearchRequest{} }
@@ -167,6 +239,55 @@ func (x *SearchRequest) GetLimit() int32 {
 	return 0
 }
 
+func (x *SearchRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetYearFrom() int32 {
+	if x != nil {
+		return x.YearFrom
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetYearTo() int32 {
+	if x != nil {
+		return x.YearTo
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetMinChapters() int32 {
+	if x != nil {
+		return x.MinChapters
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetMinRating() float64 {
+	if x != nil {
+		return x.MinRating
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetSortBy() string {
+	if x != nil {
+		return x.SortBy
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetOrder() string {
+	if x != nil {
+		return x.Order
+	}
+	return ""
+}
+
 // SearchResponse represents search results
 type SearchResponse struct {
 	state         protoimpl.MessageState
@@ -260,13 +381,56 @@ func (x *UpdateProgressResponse) GetMessage() string {
 	return ""
 }
 
+// RankingsRequest selects a ranking
+type RankingsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Window string `protobuf:"bytes,1,opt,name=window,proto3" json:"window,omitempty"`
+	Genre  string `protobuf:"bytes,2,opt,name=genre,proto3" json:"genre,omitempty"`
+	Limit  int32  `protobuf:"varint,3,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *RankingsRequest) Reset()         { *x = RankingsRequest{} }
+func (x *RankingsRequest) String() string { return x.Window }
+func (*RankingsRequest) ProtoMessage()    {}
+func (x *RankingsRequest) ProtoReflect() protoreflect.Message {
+	return nil
+}
+
+func (x *RankingsRequest) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *RankingsRequest) GetGenre() string {
+	if x != nil {
+		return x.Genre
+	}
+	return ""
+}
+
+func (x *RankingsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
 // Top10Response represents top 10 rankings
 type Top10Response struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Rankings []*MangaResponse `protobuf:"bytes,1,rep,name=rankings,proto3" json:"rankings,omitempty"`
+	Rankings    []*MangaResponse `protobuf:"bytes,1,rep,name=rankings,proto3" json:"rankings,omitempty"`
+	Window      string           `protobuf:"bytes,2,opt,name=window,proto3" json:"window,omitempty"`
+	Genre       string           `protobuf:"bytes,3,opt,name=genre,proto3" json:"genre,omitempty"`
+	GeneratedAt string           `protobuf:"bytes,4,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	Total       int32            `protobuf:"varint,5,opt,name=total,proto3" json:"total,omitempty"`
 }
 
 func (x *Top10Response) Reset()         { *x = Top10Response{} }
@@ -283,6 +447,261 @@ func (x *Top10Response) GetRankings() []*MangaResponse {
 	return nil
 }
 
+func (x *Top10Response) GetWindow() string {
+	if x != nil {
+		return x.Window
+	}
+	return ""
+}
+
+func (x *Top10Response) GetGenre() string {
+	if x != nil {
+		return x.Genre
+	}
+	return ""
+}
+
+func (x *Top10Response) GetGeneratedAt() string {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return ""
+}
+
+func (x *Top10Response) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// ListChaptersRequest selects the chapters of one manga
+type ListChaptersRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MangaID  string  `protobuf:"bytes,1,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Language string  `protobuf:"bytes,2,opt,name=language,proto3" json:"language,omitempty"`
+	After    float64 `protobuf:"fixed64,3,opt,name=after,proto3" json:"after,omitempty"`
+	Limit    int32   `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset   int32   `protobuf:"varint,5,opt,name=offset,proto3" json:"offset,omitempty"`
+}
+
+func (x *ListChaptersRequest) Reset()         { *x = ListChaptersRequest{} }
+func (x *ListChaptersRequest) String() string { return x.MangaID }
+func (*ListChaptersRequest) ProtoMessage()    {}
+func (x *ListChaptersRequest) ProtoReflect() protoreflect.Message {
+	return nil
+}
+
+func (x *ListChaptersRequest) GetMangaID() string {
+	if x != nil {
+		return x.MangaID
+	}
+	return ""
+}
+
+func (x *ListChaptersRequest) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ListChaptersRequest) GetAfter() float64 {
+	if x != nil {
+		return x.After
+	}
+	return 0
+}
+
+func (x *ListChaptersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListChaptersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+// ChapterResponse represents one chapter
+type ChapterResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID         int64   `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	MangaID    string  `protobuf:"bytes,2,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Number     float64 `protobuf:"fixed64,3,opt,name=number,proto3" json:"number,omitempty"`
+	Volume     int32   `protobuf:"varint,4,opt,name=volume,proto3" json:"volume,omitempty"`
+	Title      string  `protobuf:"bytes,5,opt,name=title,proto3" json:"title,omitempty"`
+	Language   string  `protobuf:"bytes,6,opt,name=language,proto3" json:"language,omitempty"`
+	Pages      int32   `protobuf:"varint,7,opt,name=pages,proto3" json:"pages,omitempty"`
+	ReleasedAt string  `protobuf:"bytes,8,opt,name=released_at,json=releasedAt,proto3" json:"released_at,omitempty"`
+}
+
+func (x *ChapterResponse) Reset()         { *x = ChapterResponse{} }
+func (x *ChapterResponse) String() string { return x.Title }
+func (*ChapterResponse) ProtoMessage()    {}
+func (x *ChapterResponse) ProtoReflect() protoreflect.Message {
+	return nil
+}
+
+func (x *ChapterResponse) GetID() int64 {
+	if x != nil {
+		return x.ID
+	}
+	return 0
+}
+
+func (x *ChapterResponse) GetMangaID() string {
+	if x != nil {
+		return x.MangaID
+	}
+	return ""
+}
+
+func (x *ChapterResponse) GetNumber() float64 {
+	if x != nil {
+		return x.Number
+	}
+	return 0
+}
+
+func (x *ChapterResponse) GetVolume() int32 {
+	if x != nil {
+		return x.Volume
+	}
+	return 0
+}
+
+func (x *ChapterResponse) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ChapterResponse) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *ChapterResponse) GetPages() int32 {
+	if x != nil {
+		return x.Pages
+	}
+	return 0
+}
+
+func (x *ChapterResponse) GetReleasedAt() string {
+	if x != nil {
+		return x.ReleasedAt
+	}
+	return ""
+}
+
+// ListChaptersResponse represents a page of chapters
+type ListChaptersResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Chapters []*ChapterResponse `protobuf:"bytes,1,rep,name=chapters,proto3" json:"chapters,omitempty"`
+}
+
+func (x *ListChaptersResponse) Reset()         { *x = ListChaptersResponse{} }
+func (x *ListChaptersResponse) String() string { return "ListChaptersResponse" }
+func (*ListChaptersResponse) ProtoMessage()    {}
+func (x *ListChaptersResponse) ProtoReflect() protoreflect.Message {
+	return nil
+}
+
+func (x *ListChaptersResponse) GetChapters() []*ChapterResponse {
+	if x != nil {
+		return x.Chapters
+	}
+	return nil
+}
+
+// SimilarRequest selects the similar manga of one manga
+type SimilarRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MangaID string `protobuf:"bytes,1,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Limit   int32  `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+}
+
+func (x *SimilarRequest) Reset()         { *x = SimilarRequest{} }
+func (x *SimilarRequest) String() string { return x.MangaID }
+func (*SimilarRequest) ProtoMessage()    {}
+func (x *SimilarRequest) ProtoReflect() protoreflect.Message {
+	return nil
+}
+
+func (x *SimilarRequest) GetMangaID() string {
+	if x != nil {
+		return x.MangaID
+	}
+	return ""
+}
+
+func (x *SimilarRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// SimilarResponse lists similar manga, most similar first
+type SimilarResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MangaID    string           `protobuf:"bytes,1,opt,name=manga_id,json=mangaId,proto3" json:"manga_id,omitempty"`
+	Similar    []*MangaResponse `protobuf:"bytes,2,rep,name=similar,proto3" json:"similar,omitempty"`
+	ComputedAt string           `protobuf:"bytes,3,opt,name=computed_at,json=computedAt,proto3" json:"computed_at,omitempty"`
+}
+
+func (x *SimilarResponse) Reset()         { *x = SimilarResponse{} }
+func (x *SimilarResponse) String() string { return "SimilarResponse" }
+func (*SimilarResponse) ProtoMessage()    {}
+func (x *SimilarResponse) ProtoReflect() protoreflect.Message {
+	return nil
+}
+
+func (x *SimilarResponse) GetMangaID() string {
+	if x != nil {
+		return x.MangaID
+	}
+	return ""
+}
+
+func (x *SimilarResponse) GetSimilar() []*MangaResponse {
+	if x != nil {
+		return x.Similar
+	}
+	return nil
+}
+
+func (x *SimilarResponse) GetComputedAt() string {
+	if x != nil {
+		return x.ComputedAt
+	}
+	return ""
+}
+
 // Empty message for requests with no parameters
 type Empty struct {
 	state         protoimpl.MessageState
@@ -303,6 +722,9 @@ type MangaServiceServer interface {
 	SearchManga(ctx context.Context, req *SearchRequest) (*SearchResponse, error)
 	UpdateProgress(ctx context.Context, req *UpdateProgressRequest) (*UpdateProgressResponse, error)
 	GetTop10Manga(ctx context.Context, req *Empty) (*Top10Response, error)
+	GetRankings(ctx context.Context, req *RankingsRequest) (*Top10Response, error)
+	ListChapters(ctx context.Context, req *ListChaptersRequest) (*ListChaptersResponse, error)
+	GetSimilarManga(ctx context.Context, req *SimilarRequest) (*SimilarResponse, error)
 }
 
 // MangaServiceClient defines manga service client methods
@@ -311,6 +733,9 @@ type MangaServiceClient interface {
 	SearchManga(ctx context.Context, req *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
 	UpdateProgress(ctx context.Context, req *UpdateProgressRequest, opts ...grpc.CallOption) (*UpdateProgressResponse, error)
 	GetTop10Manga(ctx context.Context, req *Empty, opts ...grpc.CallOption) (*Top10Response, error)
+	GetRankings(ctx context.Context, req *RankingsRequest, opts ...grpc.CallOption) (*Top10Response, error)
+	ListChapters(ctx context.Context, req *ListChaptersRequest, opts ...grpc.CallOption) (*ListChaptersResponse, error)
+	GetSimilarManga(ctx context.Context, req *SimilarRequest, opts ...grpc.CallOption) (*SimilarResponse, error)
 }
 
 // mangaServiceClient implements MangaServiceClient
@@ -359,6 +784,33 @@ func (c *mangaServiceClient) GetTop10Manga(ctx context.Context, req *Empty, opts
 	return out, nil
 }
 
+func (c *mangaServiceClient) GetRankings(ctx context.Context, req *RankingsRequest, opts ...grpc.CallOption) (*Top10Response, error) {
+	out := new(Top10Response)
+	err := c.cc.Invoke(ctx, "/manga.MangaService/GetRankings", req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mangaServiceClient) ListChapters(ctx context.Context, req *ListChaptersRequest, opts ...grpc.CallOption) (*ListChaptersResponse, error) {
+	out := new(ListChaptersResponse)
+	err := c.cc.Invoke(ctx, "/manga.MangaService/ListChapters", req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mangaServiceClient) GetSimilarManga(ctx context.Context, req *SimilarRequest, opts ...grpc.CallOption) (*SimilarResponse, error) {
+	out := new(SimilarResponse)
+	err := c.cc.Invoke(ctx, "/manga.MangaService/GetSimilarManga", req, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UnimplementedMangaServiceServer implements MangaServiceServer
 type UnimplementedMangaServiceServer struct{}
 
@@ -378,6 +830,18 @@ func (s *UnimplementedMangaServiceServer) GetTop10Manga(ctx context.Context, req
 	return nil, nil
 }
 
+func (s *UnimplementedMangaServiceServer) GetRankings(ctx context.Context, req *RankingsRequest) (*Top10Response, error) {
+	return nil, nil
+}
+
+func (s *UnimplementedMangaServiceServer) ListChapters(ctx context.Context, req *ListChaptersRequest) (*ListChaptersResponse, error) {
+	return nil, nil
+}
+
+func (s *UnimplementedMangaServiceServer) GetSimilarManga(ctx context.Context, req *SimilarRequest) (*SimilarResponse, error) {
+	return nil, nil
+}
+
 // MangaService_ServiceDesc is the service descriptor for MangaService
 var MangaService_ServiceDesc = grpc.ServiceDesc{
 	ServiceName: "manga.MangaService",
@@ -399,6 +863,18 @@ var MangaService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetTop10Manga",
 			Handler:    _MangaService_GetTop10Manga_Handler,
 		},
+		{
+			MethodName: "GetRankings",
+			Handler:    _MangaService_GetRankings_Handler,
+		},
+		{
+			MethodName: "ListChapters",
+			Handler:    _MangaService_ListChapters_Handler,
+		},
+		{
+			MethodName: "GetSimilarManga",
+			Handler:    _MangaService_GetSimilarManga_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "manga.proto",
@@ -476,6 +952,60 @@ func _MangaService_GetTop10Manga_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _MangaService_GetRankings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RankingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MangaServiceServer).GetRankings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/manga.MangaService/GetRankings",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MangaServiceServer).GetRankings(ctx, req.(*RankingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MangaService_ListChapters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListChaptersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MangaServiceServer).ListChapters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/manga.MangaService/ListChapters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MangaServiceServer).ListChapters(ctx, req.(*ListChaptersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MangaService_GetSimilarManga_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimilarRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MangaServiceServer).GetSimilarManga(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/manga.MangaService/GetSimilarManga",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MangaServiceServer).GetSimilarManga(ctx, req.(*SimilarRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RegisterMangaServiceServer registers the server implementation
 func RegisterMangaServiceServer(s grpc.ServiceRegistrar, srv MangaServiceServer) {
 	s.RegisterService(&MangaService_ServiceDesc, srv)
@@ -12,7 +12,9 @@ import (
 	"strings"
 	"time"
 
-	_ "modernc.org/sqlite"
+	"mangahub/pkg/covers"
+	"mangahub/pkg/database"
+	"mangahub/pkg/models"
 )
 
 // MangaData represents manga entry from JSON files
@@ -29,6 +31,13 @@ type MangaData struct {
 	Year        int      `json:"year"`
 	Rating      float64  `json:"rating"`
 	Source      string   `json:"source"`
+
+	// Titles are the alternate and localized titles, as saved by
+	// `mangahub manga dex`
+	Titles []models.MangaTitle `json:"titles,omitempty"`
+
+	// CoverURL is the remote cover art, downloaded into the cover store
+	CoverURL string `json:"cover_url,omitempty"`
 }
 
 // ScrapedQuote represents data from quotes.toscrape.com (educational practice)
@@ -118,6 +127,18 @@ func main() {
 	}
 	fmt.Printf("   Loaded %d manga entries to database\n", loaded)
 
+	// The loader writes genres as comma-separated text; resolve them
+	// against the genre taxonomy
+	if err := rebuildGenres(db); err != nil {
+		log.Fatal("Failed to index genres:", err)
+	}
+
+	// Store the covers locally so the API server serves them and their
+	// thumbnails
+	fmt.Println("🖼️  Downloading covers...")
+	stored := downloadCovers(db, covers.NewStore(dataDir, 0), allManga)
+	fmt.Printf("   Stored %d covers\n", stored)
+
 	// Print statistics
 	fmt.Println()
 	fmt.Println("=== Database Statistics ===")
@@ -248,102 +269,28 @@ func saveScrapedData(filepath string, data map[string]interface{}) error {
 	return encoder.Encode(data)
 }
 
+// initDatabase opens the database and brings it to the latest schema using
+// the same migrations as the servers, so loaded data always matches the
+// schema the services expect
 func initDatabase(dbPath string) (*sql.DB, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	db, err := database.New(dbPath)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create tables
-	schema := `
-	CREATE TABLE IF NOT EXISTS manga (
-		id TEXT PRIMARY KEY,
-		title TEXT NOT NULL,
-		author TEXT,
-		artist TEXT,
-		genres TEXT,
-		status TEXT,
-		chapters INTEGER,
-		volumes INTEGER,
-		description TEXT,
-		year INTEGER,
-		rating REAL,
-		source TEXT,
-		cover_url TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS users (
-		id TEXT PRIMARY KEY,
-		username TEXT UNIQUE NOT NULL,
-		email TEXT UNIQUE NOT NULL,
-		password_hash TEXT NOT NULL,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
-	);
-
-	CREATE TABLE IF NOT EXISTS user_progress (
-		user_id TEXT NOT NULL,
-		manga_id TEXT NOT NULL,
-		current_chapter INTEGER DEFAULT 0,
-		status TEXT DEFAULT 'plan-to-read',
-		rating INTEGER DEFAULT 0,
-		notes TEXT,
-		started_at TIMESTAMP,
-		completed_at TIMESTAMP,
-		updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		PRIMARY KEY (user_id, manga_id),
-		FOREIGN KEY (user_id) REFERENCES users(id),
-		FOREIGN KEY (manga_id) REFERENCES manga(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS chat_messages (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		username TEXT NOT NULL,
-		room_id TEXT NOT NULL,
-		message TEXT NOT NULL,
-		timestamp INTEGER,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	CREATE TABLE IF NOT EXISTS notifications (
-		id TEXT PRIMARY KEY,
-		user_id TEXT NOT NULL,
-		type TEXT NOT NULL,
-		manga_id TEXT,
-		message TEXT NOT NULL,
-		read BOOLEAN DEFAULT 0,
-		data TEXT,
-		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-		FOREIGN KEY (user_id) REFERENCES users(id)
-	);
-
-	CREATE INDEX IF NOT EXISTS idx_manga_title ON manga(title);
-	CREATE INDEX IF NOT EXISTS idx_manga_author ON manga(author);
-	CREATE INDEX IF NOT EXISTS idx_manga_status ON manga(status);
-	CREATE INDEX IF NOT EXISTS idx_manga_genres ON manga(genres);
-	CREATE INDEX IF NOT EXISTS idx_user_progress_user ON user_progress(user_id);
-	CREATE INDEX IF NOT EXISTS idx_user_progress_manga ON user_progress(manga_id);
-	CREATE INDEX IF NOT EXISTS idx_chat_room ON chat_messages(room_id);
-	CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id);
-	`
-
-	_, err = db.Exec(schema)
-	if err != nil {
+	if err := db.Init(); err != nil {
+		db.Close()
 		return nil, err
 	}
 
-	return db, nil
+	return db.DB, nil
 }
 
 func loadMangaToDatabase(db *sql.DB, manga []MangaData) (int, error) {
 	// Prepare statement
 	stmt, err := db.Prepare(`
-		INSERT OR REPLACE INTO manga (id, title, author, artist, genres, status, chapters, volumes, description, year, rating, source, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		INSERT OR REPLACE INTO manga (id, title, author, artist, genres, status, chapters, volumes, description, year, rating, source, cover_url, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
 	`)
 	if err != nil {
 		return 0, err
@@ -353,17 +300,76 @@ func loadMangaToDatabase(db *sql.DB, manga []MangaData) (int, error) {
 	count := 0
 	for _, m := range manga {
 		genres := strings.Join(m.Genres, ",")
-		_, err := stmt.Exec(m.ID, m.Title, m.Author, m.Artist, genres, m.Status, m.Chapters, m.Volumes, m.Description, m.Year, m.Rating, m.Source)
+		_, err := stmt.Exec(m.ID, m.Title, m.Author, m.Artist, genres, m.Status, m.Chapters, m.Volumes, m.Description, m.Year, m.Rating, m.Source, m.CoverURL)
 		if err != nil {
 			log.Printf("Warning: Failed to insert manga %s: %v", m.ID, err)
 			continue
 		}
+		if err := loadTitles(db, m); err != nil {
+			log.Printf("Warning: Failed to insert titles of manga %s: %v", m.ID, err)
+		}
 		count++
 	}
 
 	return count, nil
 }
 
+// loadTitles replaces the alternate titles of a manga. Repeated titles and
+// second primary titles in a language are skipped.
+func loadTitles(db *sql.DB, m MangaData) error {
+	if _, err := db.Exec(`DELETE FROM manga_titles WHERE manga_id = ?`, m.ID); err != nil {
+		return err
+	}
+	for _, t := range m.Titles {
+		lang := models.NormalizeLanguage(t.Language)
+		if lang == "" || strings.TrimSpace(t.Title) == "" {
+			continue
+		}
+		_, err := db.Exec(`INSERT OR IGNORE INTO manga_titles (manga_id, language, title, is_primary) VALUES (?, ?, ?, ?)`,
+			m.ID, lang, strings.TrimSpace(t.Title), t.IsPrimary)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// downloadCovers stores the remote cover of every manga that has one and
+// points its cover_url at the stored copy. Failed downloads keep the
+// remote URL.
+func downloadCovers(db *sql.DB, store *covers.Store, manga []MangaData) int {
+	count := 0
+	for _, m := range manga {
+		if !strings.HasPrefix(m.CoverURL, "http") {
+			continue
+		}
+		if err := store.Download(m.ID, m.CoverURL); err != nil {
+			log.Printf("Warning: Failed to store cover of manga %s: %v", m.ID, err)
+			continue
+		}
+		if _, err := db.Exec(`UPDATE manga SET cover_url = ? WHERE id = ?`, covers.URL(m.ID), m.ID); err != nil {
+			log.Printf("Warning: Failed to update cover of manga %s: %v", m.ID, err)
+			continue
+		}
+		count++
+	}
+	return count
+}
+
+// rebuildGenres re-derives manga_genres from the loaded genres column
+func rebuildGenres(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := database.RebuildMangaGenres(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 func printStatistics(db *sql.DB, manga []MangaData) {
 	// Count by genre
 	genreCounts := make(map[string]int)